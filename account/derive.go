@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package account
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// SLIP-0010 parameters for Ed25519, only hardened children exist
+const (
+	derivationCurveKey = "ed25519 seed"
+	hardenedOffset     = 0x80000000
+	pathRoot           = "m"
+	pathSeparator      = "/"
+	pathHardened       = "'"
+	pathHardenedAlt    = "H"
+)
+
+// DerivationPath - the path of the derived account with index
+func DerivationPath(index uint32) (string, error) {
+	if index >= hardenedOffset {
+		return "", fault.InvalidDerivationPath
+	}
+	return fmt.Sprintf("%s%s%d%s", pathRoot, pathSeparator, index, pathHardened), nil
+}
+
+// ParseDerivationPath - convert a path like m/0'/1' to child indexes
+//
+// every element must be hardened as Ed25519 has no public derivation
+func ParseDerivationPath(path string) ([]uint32, error) {
+	elements := strings.Split(path, pathSeparator)
+	if pathRoot != elements[0] {
+		return nil, fault.InvalidDerivationPath
+	}
+
+	indexes := make([]uint32, 0, len(elements)-1)
+	for _, e := range elements[1:] {
+		switch {
+		case strings.HasSuffix(e, pathHardened):
+			e = strings.TrimSuffix(e, pathHardened)
+		case strings.HasSuffix(e, pathHardenedAlt):
+			e = strings.TrimSuffix(e, pathHardenedAlt)
+		default:
+			return nil, fault.InvalidDerivationPath
+		}
+
+		n, err := strconv.ParseUint(e, 10, 31)
+		if nil != err {
+			return nil, fault.InvalidDerivationPath
+		}
+		indexes = append(indexes, uint32(n)+hardenedOffset)
+	}
+	return indexes, nil
+}
+
+// PrivateKeyFromBase58SeedPath - derive the private key at path from a
+// Base58 encoded V1 or V2 seed
+//
+// an empty path gives the same key as PrivateKeyFromBase58Seed so
+// existing identities are unchanged; any other path starts from the
+// ed25519 seed of that key and derives with SLIP-0010
+func PrivateKeyFromBase58SeedPath(seedBase58Encoded string, path string) (*PrivateKey, error) {
+	if "" == path {
+		return PrivateKeyFromBase58Seed(seedBase58Encoded)
+	}
+
+	sk, testnet, err := parseBase58Seed(seedBase58Encoded)
+	if nil != err {
+		return nil, err
+	}
+
+	ed25519Seed, err := ed25519SeedFromSecretKey(sk)
+	if nil != err {
+		return nil, err
+	}
+
+	return DeriveED25519PrivateKey(ed25519Seed[:ed25519.SeedSize], path, testnet)
+}
+
+// DeriveED25519PrivateKey - SLIP-0010 derivation of the private key at
+// path from a master seed
+func DeriveED25519PrivateKey(seed []byte, path string, testnet bool) (*PrivateKey, error) {
+	indexes, err := ParseDerivationPath(path)
+	if nil != err {
+		return nil, err
+	}
+
+	key, chainCode := hmacSplit([]byte(derivationCurveKey), seed)
+
+	for _, index := range indexes {
+		data := make([]byte, 1+len(key)+4)
+		copy(data[1:], key)
+		binary.BigEndian.PutUint32(data[1+len(key):], index)
+
+		key, chainCode = hmacSplit(chainCode, data)
+	}
+
+	privateKey := &PrivateKey{
+		PrivateKeyInterface: &ED25519PrivateKey{
+			Test:       testnet,
+			PrivateKey: ed25519.NewKeyFromSeed(key),
+		},
+	}
+	return privateKey, nil
+}
+
+// HMAC-SHA512 split into the key and the chain code
+func hmacSplit(key []byte, data []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
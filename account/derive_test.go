@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package account_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+type deriveTestItem struct {
+	path string
+	priv string // first 32 bytes, the ed25519 seed
+}
+
+// SLIP-0010 test vector 1 for ed25519
+var slip10Seed = "000102030405060708090a0b0c0d0e0f"
+var slip10TestItems = []deriveTestItem{
+	{"m", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+	{"m/0'", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+	{"m/0'/1'", "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+	{"m/0H/1H/2H", "92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9"},
+}
+
+func TestDeriveED25519PrivateKey(t *testing.T) {
+	seed := decodeHex(slip10Seed)
+	for _, item := range slip10TestItems {
+		k, err := account.DeriveED25519PrivateKey(seed, item.path, true)
+		if nil != err {
+			t.Fatalf("path: %s  error: %s", item.path, err)
+		}
+		expected := decodeHex(item.priv)
+		actual := k.PrivateKeyBytes()[:32]
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("path: %s  expected: %x  actual: %x", item.path, expected, actual)
+		}
+		if !k.IsTesting() {
+			t.Errorf("path: %s  derived key is not testing", item.path)
+		}
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	indexes, err := account.ParseDerivationPath("m/44'/0H/2147483647'")
+	if nil != err {
+		t.Fatalf("parse error: %s", err)
+	}
+	expected := []uint32{0x8000002c, 0x80000000, 0xffffffff}
+	if len(expected) != len(indexes) {
+		t.Fatalf("indexes: %v  expected: %v", indexes, expected)
+	}
+	for i := range expected {
+		if expected[i] != indexes[i] {
+			t.Errorf("%d: index: %x  expected: %x", i, indexes[i], expected[i])
+		}
+	}
+
+	invalidPaths := []string{
+		"",
+		"0'",
+		"m/0",
+		"m/x'",
+		"m/2147483648'",
+		"m/-1'",
+		"m//1'",
+	}
+	for _, path := range invalidPaths {
+		_, err := account.ParseDerivationPath(path)
+		if fault.InvalidDerivationPath != err {
+			t.Errorf("path: %q  error: %v  expected: %s", path, err, fault.InvalidDerivationPath)
+		}
+	}
+}
+
+func TestDerivationPath(t *testing.T) {
+	path, err := account.DerivationPath(5)
+	if nil != err {
+		t.Fatalf("derivation path error: %s", err)
+	}
+	if "m/5'" != path {
+		t.Errorf("path: %q  expected: %q", path, "m/5'")
+	}
+
+	_, err = account.DerivationPath(0x80000000)
+	if fault.InvalidDerivationPath != err {
+		t.Errorf("error: %v  expected: %s", err, fault.InvalidDerivationPath)
+	}
+}
+
+func TestPrivateKeyFromBase58SeedPath(t *testing.T) {
+	for _, item := range validSeedTestItems {
+
+		// empty path is the existing key
+		k, err := account.PrivateKeyFromBase58SeedPath(item.seed, "")
+		if nil != err {
+			t.Fatalf("seed error: %s", err)
+		}
+		if !bytes.Equal(decodeHex(item.priv), k.PrivateKeyBytes()) {
+			t.Errorf("empty path: expected: %s  actual: %x", item.priv, k.PrivateKeyBytes())
+		}
+
+		k0, err := account.PrivateKeyFromBase58SeedPath(item.seed, "m/0'")
+		if nil != err {
+			t.Fatalf("derive error: %s", err)
+		}
+		k1, err := account.PrivateKeyFromBase58SeedPath(item.seed, "m/1'")
+		if nil != err {
+			t.Fatalf("derive error: %s", err)
+		}
+		if item.isTest != k0.IsTesting() {
+			t.Errorf("network is testing: actual: %t, expected: %t", k0.IsTesting(), item.isTest)
+		}
+		if bytes.Equal(k.PrivateKeyBytes(), k0.PrivateKeyBytes()) ||
+			bytes.Equal(k0.PrivateKeyBytes(), k1.PrivateKeyBytes()) {
+			t.Errorf("seed: %s  derived keys are not distinct", item.seed)
+		}
+
+		again, err := account.PrivateKeyFromBase58SeedPath(item.seed, "m/1'")
+		if nil != err {
+			t.Fatalf("derive error: %s", err)
+		}
+		if !bytes.Equal(k1.PrivateKeyBytes(), again.PrivateKeyBytes()) {
+			t.Errorf("seed: %s  derivation is not repeatable", item.seed)
+		}
+	}
+
+	_, err := account.PrivateKeyFromBase58SeedPath(validSeedTestItems[0].seed, "m/1")
+	if fault.InvalidDerivationPath != err {
+		t.Errorf("error: %v  expected: %s", err, fault.InvalidDerivationPath)
+	}
+}
@@ -62,11 +62,33 @@ func PrivateKeyFromBase58Seed(seedBase58Encoded string) (*PrivateKey, error) {
 		return nil, err
 	}
 
-	skLength := len(sk)
+	ed25519Seed, err := ed25519SeedFromSecretKey(sk)
+	if nil != err {
+		return nil, err
+	}
+
+	// generate key pair from encrypted secret key
+	_, priv, err := ed25519.GenerateKey(bytes.NewBuffer(ed25519Seed))
+	if nil != err {
+		return nil, err
+	}
+
+	privateKey := &PrivateKey{
+		PrivateKeyInterface: &ED25519PrivateKey{
+			Test:       testnet,
+			PrivateKey: priv,
+		},
+	}
+	return privateKey, nil
+}
+
+// expand the secret key of a V1 or V2 seed to the ed25519 seed that
+// generates its key pair
+func ed25519SeedFromSecretKey(sk []byte) ([]byte, error) {
 
 	var ed25519Seed []byte // ed25519 seed to generate key pair
 
-	switch skLength {
+	switch len(sk) {
 	case secretKeyV1Length:
 		var skV1 [secretKeyV1Length]byte
 		copy(skV1[:], sk)
@@ -99,19 +121,7 @@ func PrivateKeyFromBase58Seed(seedBase58Encoded string) (*PrivateKey, error) {
 		return nil, fault.InvalidSeedHeader
 	}
 
-	// generate key pair from encrypted secret key
-	_, priv, err := ed25519.GenerateKey(bytes.NewBuffer(ed25519Seed))
-	if nil != err {
-		return nil, err
-	}
-
-	privateKey := &PrivateKey{
-		PrivateKeyInterface: &ED25519PrivateKey{
-			Test:       testnet,
-			PrivateKey: priv,
-		},
-	}
-	return privateKey, nil
+	return ed25519Seed, nil
 }
 
 // parse the base58 encoded seed
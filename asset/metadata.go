@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package asset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/mode"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// stops a cursor map at the end of the matching index entries
+var errEndOfRange = errors.New("end of range")
+
+// index key: key ⧺ 00 ⧺ value ⧺ 00 ⧺ asset id
+//
+// metadata keys and values never contain NUL so the prefix for a
+// key/value pair cannot match any other pair
+func metadataPrefix(key string, value string) []byte {
+	prefix := make([]byte, 0, len(key)+len(value)+2+transactionrecord.AssetIdentifierLength)
+	prefix = append(prefix, key...)
+	prefix = append(prefix, 0)
+	prefix = append(prefix, value...)
+	prefix = append(prefix, 0)
+	return prefix
+}
+
+// IndexMetadata - add every key/value pair of the metadata of an asset
+// to the index, data is the block number that set the metadata
+func IndexMetadata(trx storage.Transaction, assetId transactionrecord.AssetIdentifier, metadata string, blockNumberKey []byte) {
+	pairs, err := metadataPairs(metadata)
+	if nil != err {
+		// confirmed metadata was already checked
+		logger.Criticalf("asset: index metadata for: %v  error: %s", assetId, err)
+		return
+	}
+	for _, pair := range pairs {
+		key := append(metadataPrefix(pair[0], pair[1]), assetId[:]...)
+		trx.Put(storage.Pool.AssetMetadata, key, blockNumberKey, []byte{})
+	}
+}
+
+// UnindexMetadata - remove every key/value pair of the metadata of an
+// asset from the index
+func UnindexMetadata(trx storage.Transaction, assetId transactionrecord.AssetIdentifier, metadata string) {
+	pairs, err := metadataPairs(metadata)
+	if nil != err {
+		logger.Criticalf("asset: unindex metadata for: %v  error: %s", assetId, err)
+		return
+	}
+	for _, pair := range pairs {
+		key := append(metadataPrefix(pair[0], pair[1]), assetId[:]...)
+		trx.Delete(storage.Pool.AssetMetadata, key)
+	}
+}
+
+// VersionMetadata - the metadata and block number of one confirmed
+// version of an asset, version zero is the original registration
+func VersionMetadata(
+	trx storage.Transaction,
+	assetId transactionrecord.AssetIdentifier,
+	version uint64,
+	assetHandle storage.Handle,
+	versionHandle storage.Handle,
+) (string, uint64, error) {
+	var blockNumber uint64
+	var packed []byte
+	if 0 == version {
+		blockNumber, packed = trx.GetNB(assetHandle, assetId[:])
+	} else {
+		blockNumber, packed = trx.GetNB(versionHandle, VersionKey(assetId, version))
+	}
+	if nil == packed {
+		return "", 0, fault.AssetNotFound
+	}
+
+	tx, _, err := transactionrecord.Packed(packed).Unpack(mode.IsTesting())
+	if nil != err {
+		return "", 0, err
+	}
+	switch tx := tx.(type) {
+	case *transactionrecord.AssetData:
+		return tx.Metadata, blockNumber, nil
+	case *transactionrecord.AssetAmendment:
+		return tx.Metadata, blockNumber, nil
+	default:
+		return "", 0, fault.AssetNotFound
+	}
+}
+
+// RebuildMetadataIndex - index the latest metadata of every confirmed
+// asset
+func RebuildMetadataIndex(trx storage.Transaction) error {
+	return storage.Pool.Assets.NewFetchCursor().Map(func(key []byte, value []byte) error {
+		var assetId transactionrecord.AssetIdentifier
+		err := transactionrecord.AssetIdentifierFromBytes(&assetId, key)
+		if nil != err {
+			return err
+		}
+		if len(value) < 8 {
+			return fault.InvalidBuffer
+		}
+
+		tx, _, err := transactionrecord.Packed(value[8:]).Unpack(mode.IsTesting())
+		if nil != err {
+			return err
+		}
+		assetData, ok := tx.(*transactionrecord.AssetData)
+		if !ok {
+			return fault.TransactionIsNotAnAsset
+		}
+
+		metadata := assetData.Metadata
+		blockNumberKey := value[:8]
+
+		amendments, err := Amendments(assetId, storage.Pool.AssetVersions)
+		if nil != err {
+			return err
+		}
+		if n := len(amendments); n > 0 {
+			metadata = amendments[n-1].Metadata
+			blockNumberKey = make([]byte, 8)
+			binary.BigEndian.PutUint64(blockNumberKey, amendments[n-1].BlockNumber)
+		}
+
+		IndexMetadata(trx, assetId, metadata, blockNumberKey)
+		return nil
+	})
+}
+
+// QueryMetadata - the confirmed assets whose latest metadata contains
+// a key/value pair, in asset id order starting from start
+//
+// returns the asset id to start the next query from, or nil when
+// there are no more
+func QueryMetadata(
+	key string,
+	value string,
+	start *transactionrecord.AssetIdentifier,
+	count int,
+	indexHandle storage.Handle,
+) ([]transactionrecord.AssetIdentifier, *transactionrecord.AssetIdentifier, error) {
+	if nil == indexHandle {
+		return nil, nil, fault.NilPointer
+	}
+
+	prefix := metadataPrefix(key, value)
+	startKey := prefix
+	if nil != start {
+		startKey = append(append([]byte{}, prefix...), start[:]...)
+	}
+
+	assetIds := make([]transactionrecord.AssetIdentifier, 0, count)
+	var next *transactionrecord.AssetIdentifier
+
+	err := indexHandle.NewFetchCursor().Seek(startKey).Map(func(k []byte, _ []byte) error {
+		if !bytes.HasPrefix(k, prefix) {
+			return errEndOfRange
+		}
+
+		var assetId transactionrecord.AssetIdentifier
+		err := transactionrecord.AssetIdentifierFromBytes(&assetId, k[len(prefix):])
+		if nil != err {
+			return err
+		}
+
+		if len(assetIds) >= count {
+			next = &assetId
+			return errEndOfRange
+		}
+		assetIds = append(assetIds, assetId)
+		return nil
+	})
+	if nil != err && errEndOfRange != err {
+		return nil, nil, err
+	}
+
+	return assetIds, next, nil
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package asset_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+func setupStorage(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "asset-metadata")
+	if nil != err {
+		t.Fatalf("temp dir error: %s", err)
+	}
+
+	logging := logger.Configuration{
+		Directory: dir,
+		File:      "testing.log",
+		Size:      1048576,
+		Count:     10,
+		Console:   false,
+		Levels: map[string]string{
+			logger.DefaultTag: "critical",
+		},
+	}
+	if err := logger.Initialise(logging); nil != err {
+		t.Fatalf("logger setup failed: %s", err)
+	}
+
+	err = storage.Initialise(filepath.Join(dir, "test"), storage.ReadWrite)
+	if nil != err {
+		t.Fatalf("storage initialise error: %s", err)
+	}
+
+	return func() {
+		storage.Finalise()
+		logger.Finalise()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestQueryMetadata(t *testing.T) {
+	defer setupStorage(t)()
+
+	blockNumberKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumberKey, 2)
+
+	ids := make([]transactionrecord.AssetIdentifier, 3)
+	for i, fingerprint := range []string{"one", "two", "three"} {
+		ids[i] = transactionrecord.NewAssetIdentifier([]byte(fingerprint))
+	}
+
+	trx, err := storage.NewDBTransaction()
+	if nil != err {
+		t.Fatalf("transaction error: %s", err)
+	}
+	asset.IndexMetadata(trx, ids[0], "artist\x00me\x00year\x002020", blockNumberKey)
+	asset.IndexMetadata(trx, ids[1], "artist\x00me", blockNumberKey)
+	asset.IndexMetadata(trx, ids[2], "artist\x00mel\x00year\x002020", blockNumberKey)
+	err = trx.Commit()
+	if nil != err {
+		t.Fatalf("commit error: %s", err)
+	}
+
+	result, next, err := asset.QueryMetadata("artist", "me", nil, 10, storage.Pool.AssetMetadata)
+	if nil != err {
+		t.Fatalf("query error: %s", err)
+	}
+	if 2 != len(result) || nil != next {
+		t.Fatalf("query result: %v  next: %v", result, next)
+	}
+	for _, id := range result {
+		if id != ids[0] && id != ids[1] {
+			t.Errorf("unexpected asset: %v", id)
+		}
+	}
+
+	// page through one at a time
+	first, next, err := asset.QueryMetadata("year", "2020", nil, 1, storage.Pool.AssetMetadata)
+	if nil != err {
+		t.Fatalf("query error: %s", err)
+	}
+	if 1 != len(first) || nil == next {
+		t.Fatalf("first page: %v  next: %v", first, next)
+	}
+	second, next2, err := asset.QueryMetadata("year", "2020", next, 1, storage.Pool.AssetMetadata)
+	if nil != err {
+		t.Fatalf("query error: %s", err)
+	}
+	if 1 != len(second) || nil != next2 || second[0] != *next || first[0] == second[0] {
+		t.Fatalf("second page: %v  next: %v", second, next2)
+	}
+
+	// removing metadata drops it from the index
+	trx, err = storage.NewDBTransaction()
+	if nil != err {
+		t.Fatalf("transaction error: %s", err)
+	}
+	asset.UnindexMetadata(trx, ids[0], "artist\x00me\x00year\x002020")
+	err = trx.Commit()
+	if nil != err {
+		t.Fatalf("commit error: %s", err)
+	}
+
+	result, _, err = asset.QueryMetadata("artist", "me", nil, 10, storage.Pool.AssetMetadata)
+	if nil != err {
+		t.Fatalf("query error: %s", err)
+	}
+	if 1 != len(result) || ids[1] != result[0] {
+		t.Errorf("after unindex: %v", result)
+	}
+}
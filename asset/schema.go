@@ -0,0 +1,287 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package asset
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// SchemaKey - reserved metadata key whose value names the schema
+// that the rest of the metadata must satisfy
+const SchemaKey = "_schema"
+
+// property types that a schema can declare, metadata values are
+// always strings so these only restrict their format
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Schema - a JSON-schema-like description of asset metadata
+//
+// e.g.
+//
+//	{
+//	  "name": "artwork",
+//	  "required": ["artist"],
+//	  "additionalProperties": false,
+//	  "properties": {
+//	    "artist": {"type": "string", "maxLength": 64},
+//	    "year":   {"type": "integer", "minimum": 1000},
+//	    "medium": {"type": "string", "enum": ["oil", "ink"]}
+//	  }
+//	}
+type Schema struct {
+	Name                 string               `json:"name"`
+	Required             []string             `json:"required"`
+	AdditionalProperties *bool                `json:"additionalProperties"`
+	Properties           map[string]*Property `json:"properties"`
+}
+
+// Property - the constraints on a single metadata value
+type Property struct {
+	Type      string   `json:"type"`
+	Enum      []string `json:"enum"`
+	Pattern   string   `json:"pattern"`
+	MinLength *int     `json:"minLength"`
+	MaxLength *int     `json:"maxLength"`
+	Minimum   *float64 `json:"minimum"`
+	Maximum   *float64 `json:"maximum"`
+
+	pattern *regexp.Regexp
+}
+
+// registered schemas
+var schemas struct {
+	sync.RWMutex
+	items map[string]*Schema
+}
+
+// RegisterSchema - add or replace a schema from its JSON description
+func RegisterSchema(data []byte) (*Schema, error) {
+	schema := &Schema{}
+	err := json.Unmarshal(data, schema)
+	if nil != err {
+		return nil, fault.InvalidMetadataSchema
+	}
+
+	if "" == schema.Name || strings.ContainsRune(schema.Name, 0) {
+		return nil, fault.InvalidMetadataSchema
+	}
+
+	for key, p := range schema.Properties {
+		if "" == key || SchemaKey == key || nil == p {
+			return nil, fault.InvalidMetadataSchema
+		}
+		switch p.Type {
+		case "", TypeString, TypeInteger, TypeNumber, TypeBoolean:
+		default:
+			return nil, fault.InvalidMetadataSchema
+		}
+		if "" != p.Pattern {
+			p.pattern, err = regexp.Compile(p.Pattern)
+			if nil != err {
+				return nil, fault.InvalidMetadataSchema
+			}
+		}
+	}
+
+	// a required key must be declared
+	for _, key := range schema.Required {
+		if _, ok := schema.Properties[key]; !ok {
+			return nil, fault.InvalidMetadataSchema
+		}
+	}
+
+	schemas.Lock()
+	if nil == schemas.items {
+		schemas.items = make(map[string]*Schema)
+	}
+	schemas.items[schema.Name] = schema
+	schemas.Unlock()
+
+	return schema, nil
+}
+
+// LoadSchemas - register every "*.json" file in a directory
+func LoadSchemas(directory string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if nil != err {
+		return 0, err
+	}
+
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		if nil != err {
+			return 0, err
+		}
+		_, err = RegisterSchema(data)
+		if nil != err {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
+
+// ClearSchemas - remove all registered schemas
+func ClearSchemas() {
+	schemas.Lock()
+	schemas.items = nil
+	schemas.Unlock()
+}
+
+// ValidateMetadata - check metadata against the schema named by its
+// reserved key
+//
+// metadata without the reserved key is free-form and only has to be
+// a well formed key/value map
+func ValidateMetadata(metadata string) error {
+	pairs, err := metadataPairs(metadata)
+	if nil != err {
+		return err
+	}
+
+	duplicate := false
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if _, ok := values[pair[0]]; ok {
+			duplicate = true
+		}
+		values[pair[0]] = pair[1]
+	}
+
+	name, ok := values[SchemaKey]
+	if !ok {
+		return nil
+	}
+
+	// typed metadata must be an unambiguous map
+	if duplicate {
+		return fault.MetadataIsNotMap
+	}
+
+	schemas.RLock()
+	schema := schemas.items[name]
+	schemas.RUnlock()
+
+	if nil == schema {
+		return fault.MetadataSchemaNotFound
+	}
+
+	for _, key := range schema.Required {
+		if _, ok := values[key]; !ok {
+			return fault.MissingRequiredMetadata
+		}
+	}
+
+	for key, value := range values {
+		if SchemaKey == key {
+			continue
+		}
+		p, ok := schema.Properties[key]
+		if !ok {
+			if nil != schema.AdditionalProperties && !*schema.AdditionalProperties {
+				return fault.MetadataKeyNotInSchema
+			}
+			continue
+		}
+		if !p.valid(value) {
+			return fault.InvalidMetadataValue
+		}
+	}
+
+	return nil
+}
+
+// check a single value against its property
+func (p *Property) valid(value string) bool {
+
+	if len(p.Enum) > 0 {
+		found := false
+		for _, e := range p.Enum {
+			if e == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	length := utf8.RuneCountInString(value)
+	if nil != p.MinLength && length < *p.MinLength {
+		return false
+	}
+	if nil != p.MaxLength && length > *p.MaxLength {
+		return false
+	}
+
+	if nil != p.pattern && !p.pattern.MatchString(value) {
+		return false
+	}
+
+	var n float64
+	switch p.Type {
+	case TypeInteger:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if nil != err {
+			return false
+		}
+		n = float64(i)
+	case TypeNumber:
+		f, err := strconv.ParseFloat(value, 64)
+		if nil != err {
+			return false
+		}
+		n = f
+	case TypeBoolean:
+		return "true" == value || "false" == value
+	default:
+		return true
+	}
+
+	if nil != p.Minimum && n < *p.Minimum {
+		return false
+	}
+	if nil != p.Maximum && n > *p.Maximum {
+		return false
+	}
+	return true
+}
+
+// split metadata into its key/value pairs
+//
+// i.e.  key1 <NUL> value1 <NUL> key2 <NUL> value2 <NUL> … keyN <NUL> valueN
+func metadataPairs(metadata string) ([][2]string, error) {
+	if 0 == len(metadata) {
+		return nil, nil
+	}
+
+	items := strings.Split(metadata, "\u0000")
+	if 1 == len(items)%2 {
+		return nil, fault.MetadataIsNotMap
+	}
+	pairs := make([][2]string, 0, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		if 0 == len(items[i]) || 0 == len(items[i+1]) {
+			return nil, fault.MetadataIsNotMap
+		}
+		pairs = append(pairs, [2]string{items[i], items[i+1]})
+	}
+	return pairs, nil
+}
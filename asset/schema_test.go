@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package asset_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+const artworkSchema = `{
+  "name": "artwork",
+  "required": ["artist", "year"],
+  "additionalProperties": false,
+  "properties": {
+    "artist":  {"type": "string", "minLength": 2, "maxLength": 16},
+    "year":    {"type": "integer", "minimum": 1000, "maximum": 3000},
+    "price":   {"type": "number", "minimum": 0},
+    "framed":  {"type": "boolean"},
+    "medium":  {"type": "string", "enum": ["oil", "ink"]},
+    "edition": {"type": "string", "pattern": "^[0-9]+/[0-9]+$"}
+  }
+}`
+
+func TestRegisterSchema(t *testing.T) {
+	defer asset.ClearSchemas()
+
+	invalid := []string{
+		`not json`,
+		`{"properties": {}}`,
+		`{"name": "x", "properties": {"a": {"type": "date"}}}`,
+		`{"name": "x", "properties": {"a": {"pattern": "("}}}`,
+		`{"name": "x", "properties": {"_schema": {}}}`,
+		`{"name": "x", "required": ["a"], "properties": {}}`,
+	}
+	for i, data := range invalid {
+		_, err := asset.RegisterSchema([]byte(data))
+		if fault.InvalidMetadataSchema != err {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+	}
+
+	schema, err := asset.RegisterSchema([]byte(artworkSchema))
+	if nil != err {
+		t.Fatalf("register error: %s", err)
+	}
+	if "artwork" != schema.Name {
+		t.Errorf("schema name: %q", schema.Name)
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	defer asset.ClearSchemas()
+
+	_, err := asset.RegisterSchema([]byte(artworkSchema))
+	if nil != err {
+		t.Fatalf("register error: %s", err)
+	}
+
+	tests := []struct {
+		metadata string
+		err      error
+	}{
+		// free-form metadata is not checked against any schema
+		{"", nil},
+		{"owner\x00me", nil},
+		{"owner\x00me\x00owner\x00you", nil},
+		{"owner", fault.MetadataIsNotMap},
+		{"owner\x00\x00a\x00b", fault.MetadataIsNotMap},
+
+		// typed metadata
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020", nil},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00price\x0012.5\x00framed\x00true\x00medium\x00ink\x00edition\x001/10", nil},
+		{"_schema\x00sculpture\x00artist\x00me", fault.MetadataSchemaNotFound},
+		{"_schema\x00artwork\x00artist\x00me", fault.MissingRequiredMetadata},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00colour\x00red", fault.MetadataKeyNotInSchema},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x00twenty", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x00999", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00m\x00year\x002020", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00price\x00-1", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00framed\x00yes", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00medium\x00clay", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00edition\x00first", fault.InvalidMetadataValue},
+		{"_schema\x00artwork\x00artist\x00me\x00year\x002020\x00year\x002021", fault.MetadataIsNotMap},
+	}
+
+	for i, item := range tests {
+		err := asset.ValidateMetadata(item.metadata)
+		if item.err != err {
+			t.Errorf("%d: metadata: %q  error: %v  expected: %v", i, item.metadata, err, item.err)
+		}
+	}
+}
+
+func TestLoadSchemas(t *testing.T) {
+	defer asset.ClearSchemas()
+
+	directory, err := ioutil.TempDir("", "schemas")
+	if nil != err {
+		t.Fatalf("temporary directory error: %s", err)
+	}
+	defer os.RemoveAll(directory)
+
+	err = ioutil.WriteFile(filepath.Join(directory, "artwork.json"), []byte(artworkSchema), 0600)
+	if nil != err {
+		t.Fatalf("write error: %s", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(directory, "notes.txt"), []byte("ignored"), 0600)
+	if nil != err {
+		t.Fatalf("write error: %s", err)
+	}
+
+	n, err := asset.LoadSchemas(directory)
+	if nil != err {
+		t.Fatalf("load error: %s", err)
+	}
+	if 1 != n {
+		t.Errorf("loaded: %d schemas", n)
+	}
+
+	err = asset.ValidateMetadata("_schema\x00artwork\x00artist\x00me\x00year\x002020")
+	if nil != err {
+		t.Errorf("validate error: %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package asset
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/mode"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// Version - one version of the metadata of a confirmed asset
+//
+// version zero is the metadata of the original registration
+type Version struct {
+	Version     uint64        `json:"version,string"`
+	BlockNumber uint64        `json:"blockNumber,string"`
+	TxId        merkle.Digest `json:"txId"`
+	Metadata    string        `json:"metadata"`
+}
+
+// VersionKey - storage key of an amendment: asset id ⧺ version
+func VersionKey(assetId transactionrecord.AssetIdentifier, version uint64) []byte {
+	key := make([]byte, len(assetId)+8)
+	copy(key, assetId[:])
+	binary.BigEndian.PutUint64(key[len(assetId):], version)
+	return key
+}
+
+// CheckAmendment - check that an amendment is signed by the registrant
+// of a confirmed asset and is the next version, returns the block
+// number of the original registration
+func CheckAmendment(
+	trx storage.Transaction,
+	amendment *transactionrecord.AssetAmendment,
+	assetHandle storage.Handle,
+	versionHandle storage.Handle,
+) (uint64, error) {
+	if nil == assetHandle || nil == versionHandle {
+		return 0, fault.NilPointer
+	}
+
+	var blockNumber uint64
+	var packedAsset []byte
+	if nil == trx {
+		blockNumber, packedAsset = assetHandle.GetNB(amendment.AssetId[:])
+	} else {
+		blockNumber, packedAsset = trx.GetNB(assetHandle, amendment.AssetId[:])
+	}
+	if nil == packedAsset {
+		return 0, fault.AssetNotFound
+	}
+
+	assetTx, _, err := transactionrecord.Packed(packedAsset).Unpack(mode.IsTesting())
+	if nil != err {
+		return 0, err
+	}
+	assetData, ok := assetTx.(*transactionrecord.AssetData)
+	if !ok {
+		return 0, fault.AssetNotFound
+	}
+
+	// only the original registrant can amend
+	if nil == amendment.Registrant || !bytes.Equal(amendment.Registrant.Bytes(), assetData.Registrant.Bytes()) {
+		return 0, fault.InvalidOwnerOrRegistrant
+	}
+
+	if 0 == amendment.Version {
+		return 0, fault.InvalidAssetVersion
+	}
+
+	has := versionHandle.Has
+	if nil != trx {
+		has = func(key []byte) bool {
+			return trx.Has(versionHandle, key)
+		}
+	}
+
+	// must follow directly on from the latest confirmed version
+	if has(VersionKey(amendment.AssetId, amendment.Version)) {
+		return 0, fault.AssetVersionOutOfSequence
+	}
+	if amendment.Version > 1 && !has(VersionKey(amendment.AssetId, amendment.Version-1)) {
+		return 0, fault.AssetVersionOutOfSequence
+	}
+
+	return blockNumber, nil
+}
+
+// Amendments - the confirmed metadata amendments of an asset, oldest
+// first
+//
+// versions are always consecutive so stop at the first missing one
+func Amendments(assetId transactionrecord.AssetIdentifier, versionHandle storage.Handle) ([]Version, error) {
+	if nil == versionHandle {
+		return nil, fault.NilPointer
+	}
+
+	versions := []Version{}
+	for version := uint64(1); ; version += 1 {
+		blockNumber, packed := versionHandle.GetNB(VersionKey(assetId, version))
+		if nil == packed {
+			break
+		}
+
+		tx, _, err := transactionrecord.Packed(packed).Unpack(mode.IsTesting())
+		if nil != err {
+			return nil, err
+		}
+		amendment, ok := tx.(*transactionrecord.AssetAmendment)
+		if !ok || amendment.Version != version {
+			return nil, fault.InvalidAssetVersion
+		}
+
+		versions = append(versions, Version{
+			Version:     version,
+			BlockNumber: blockNumber,
+			TxId:        transactionrecord.Packed(packed).MakeLink(),
+			Metadata:    amendment.Metadata,
+		})
+	}
+
+	return versions, nil
+}
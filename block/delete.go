@@ -104,6 +104,7 @@ outer_loop:
 			case *transactionrecord.AssetData:
 				assetId := tx.AssetId()
 				trx.Delete(storage.Pool.Assets, assetId[:])
+				asset.UnindexMetadata(trx, assetId, tx.Metadata)
 				asset.Delete(assetId)
 
 			case *transactionrecord.BitmarkIssue:
@@ -251,9 +252,8 @@ outer_loop:
 
 				shareId := shareData.IssueTxId()
 
-				fKey := append(linkOwner.Bytes(), shareId[:]...)
 				trx.Delete(storage.Pool.Shares, shareId[:])
-				trx.Delete(storage.Pool.ShareQuantity, fKey)
+				ownership.SetShareBalance(trx, linkOwner, shareId, 0)
 
 				ownership.Transfer(trx, txId, tx.Link, blockNumber, linkOwner, linkOwner)
 
@@ -283,12 +283,8 @@ outer_loop:
 				oAccountBalance += tx.Quantity
 
 				// update balances
-				if 0 == rAccountBalance {
-					trx.Delete(storage.Pool.ShareQuantity, rKey)
-				} else {
-					trx.PutN(storage.Pool.ShareQuantity, rKey, rAccountBalance)
-				}
-				trx.PutN(storage.Pool.ShareQuantity, oKey, oAccountBalance)
+				ownership.SetShareBalance(trx, tx.Recipient, tx.ShareId, rAccountBalance)
+				ownership.SetShareBalance(trx, tx.Owner, tx.ShareId, oAccountBalance)
 
 			case *transactionrecord.ShareSwap:
 
@@ -329,20 +325,92 @@ outer_loop:
 				ownerTwoShareTwoAccountBalance += tx.QuantityTwo
 
 				// update database share one
-				if 0 == ownerTwoShareOneAccountBalance {
-					trx.Delete(storage.Pool.ShareQuantity, ownerTwoShareOneKey)
-				} else {
-					trx.PutN(storage.Pool.ShareQuantity, ownerTwoShareOneKey, ownerTwoShareOneAccountBalance)
-				}
-				trx.PutN(storage.Pool.ShareQuantity, ownerOneShareOneKey, ownerOneShareOneAccountBalance)
+				ownership.SetShareBalance(trx, tx.OwnerTwo, tx.ShareIdOne, ownerTwoShareOneAccountBalance)
+				ownership.SetShareBalance(trx, tx.OwnerOne, tx.ShareIdOne, ownerOneShareOneAccountBalance)
 
 				// update database share two
-				if 0 == ownerOneShareTwoAccountBalance {
-					trx.Delete(storage.Pool.ShareQuantity, ownerOneShareTwoKey)
-				} else {
-					trx.PutN(storage.Pool.ShareQuantity, ownerOneShareTwoKey, ownerOneShareTwoAccountBalance)
+				ownership.SetShareBalance(trx, tx.OwnerOne, tx.ShareIdTwo, ownerOneShareTwoAccountBalance)
+				ownership.SetShareBalance(trx, tx.OwnerTwo, tx.ShareIdTwo, ownerTwoShareTwoAccountBalance)
+
+			case *transactionrecord.ShareRedemption:
+
+				txId := packedTransaction.MakeLink()
+				shareBlockNumber, shareOwner := ownership.OwnerOf(trx, tx.Link)
+				if nil == shareOwner {
+					trx.Abort()
+					log.Criticalf("missing transaction record for: %v", tx.Link)
+					logger.Panic("Transactions database is corrupt")
+				}
+
+				trx.Delete(storage.Pool.Transactions, txId[:])
+				reservoir.DeleteByTxId(txId)
+
+				// recreate the share with every unit held by the redeeming owner
+				ownership.Unredeem(trx, txId, tx.Link, shareBlockNumber, tx.Owner, shareOwner, tx.Quantity)
+
+			case *transactionrecord.AtomicSwap:
+
+				txId := packedTransaction.MakeLink()
+
+				trx.Delete(storage.Pool.Transactions, txId[:])
+				reservoir.DeleteByTxId(txId)
+
+				// return each bitmark to its sender
+				for j, leg := range tx.Bitmarks {
+					legTxId := transactionrecord.SwapLegTxId(txId, j)
+					trx.Delete(storage.Pool.Transactions, legTxId[:])
+
+					blockNumber, linkOwner := ownership.OwnerOf(trx, leg.Link)
+					if nil == linkOwner {
+						trx.Abort()
+						log.Criticalf("missing transaction record for: %v", leg.Link)
+						logger.Panic("Transactions database is corrupt")
+					}
+					ownership.Transfer(trx, legTxId, leg.Link, blockNumber, leg.Recipient, linkOwner)
+				}
+
+				// return each share grant to its sender
+				for _, leg := range tx.Shares {
+					oKey := append(leg.Owner.Bytes(), leg.ShareId[:]...)
+					rKey := append(leg.Recipient.Bytes(), leg.ShareId[:]...)
+
+					// this could be zero
+					oAccountBalance, _ := trx.GetN(storage.Pool.ShareQuantity, oKey)
+
+					// this cannot be zero
+					rAccountBalance, ok := trx.GetN(storage.Pool.ShareQuantity, rKey)
+					if !ok {
+						trx.Abort()
+						log.Criticalf("missing balance record for: %v share id: %x", leg.Recipient, leg.ShareId)
+						logger.Panic("ShareQuantity database is corrupt")
+					}
+
+					rAccountBalance -= leg.Quantity
+					oAccountBalance += leg.Quantity
+
+					ownership.SetShareBalance(trx, leg.Recipient, leg.ShareId, rAccountBalance)
+					ownership.SetShareBalance(trx, leg.Owner, leg.ShareId, oAccountBalance)
+				}
+
+			case *transactionrecord.AssetAmendment:
+
+				txId := packedTransaction.MakeLink()
+
+				trx.Delete(storage.Pool.Transactions, txId[:])
+				trx.Delete(storage.Pool.AssetVersions, asset.VersionKey(tx.AssetId, tx.Version))
+				reservoir.DeleteByTxId(txId)
+
+				// restore the index to the previous version
+				previous, previousBlockNumber, err := asset.VersionMetadata(trx, tx.AssetId, tx.Version-1, storage.Pool.Assets, storage.Pool.AssetVersions)
+				if nil != err {
+					trx.Abort()
+					logger.Criticalf("missing asset version: %d for: %v", tx.Version-1, tx.AssetId)
+					logger.Panic("AssetVersions database is corrupt")
 				}
-				trx.PutN(storage.Pool.ShareQuantity, ownerTwoShareTwoKey, ownerTwoShareTwoAccountBalance)
+				previousBlockNumberKey := make([]byte, 8)
+				binary.BigEndian.PutUint64(previousBlockNumberKey, previousBlockNumber)
+				asset.UnindexMetadata(trx, tx.AssetId, tx.Metadata)
+				asset.IndexMetadata(trx, tx.AssetId, previous, previousBlockNumberKey)
 
 			default:
 				trx.Abort()
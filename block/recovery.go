@@ -8,7 +8,9 @@ package block
 import (
 	"encoding/binary"
 
+	"github.com/bitmark-inc/bitmarkd/asset"
 	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/ownership"
 	"github.com/bitmark-inc/bitmarkd/storage"
 )
 
@@ -47,3 +49,35 @@ func recoverBlockHeaderHash(blockNumberBytes []byte, packedBlock []byte) error {
 
 	return nil
 }
+
+// rebuild the share holder index, which did not exist before
+// database version 2
+func doShareHolders() error {
+	trx, err := storage.NewDBTransaction()
+	if nil != err {
+		return err
+	}
+
+	err = ownership.RebuildShareHolders(trx)
+	if nil != err {
+		trx.Abort()
+		return err
+	}
+	return trx.Commit()
+}
+
+// rebuild the asset metadata index, which did not exist before
+// database version 3
+func doMetadataIndex() error {
+	trx, err := storage.NewDBTransaction()
+	if nil != err {
+		return err
+	}
+
+	err = asset.RebuildMetadataIndex(trx)
+	if nil != err {
+		trx.Abort()
+		return err
+	}
+	return trx.Commit()
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 
 	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/asset"
 	"github.com/bitmark-inc/bitmarkd/background"
 	"github.com/bitmark-inc/bitmarkd/blockdigest"
 	"github.com/bitmark-inc/bitmarkd/blockheader"
@@ -192,6 +193,12 @@ func validateTransactionData(header *blockrecord.Header, digest blockdigest.Dige
 				globalData.log.Error("asset is not indexed")
 				return fault.AssetIsNotIndexed
 			}
+		case *transactionrecord.AssetAmendment:
+			globalData.log.Debugf("validate whether the asset amendment indexed. txId: %s", txId)
+			if !storage.Pool.AssetVersions.Has(asset.VersionKey(tx.AssetId, tx.Version)) {
+				globalData.log.Error("asset amendment is not indexed")
+				return fault.AssetIsNotIndexed
+			}
 		case *transactionrecord.BitmarkIssue:
 			globalData.log.Debugf("validate whether the issue transaction indexed. txId: %s", txId)
 			if !storage.Pool.Transactions.Has(txId[:]) {
@@ -230,7 +237,7 @@ func validateTransactionData(header *blockrecord.Header, digest blockdigest.Dige
 			priorTxOwnerTxs[tx.GetLink().String()] = struct{}{}
 
 		//nolint:ignore SA4020 XXX: unreachable case clause here
-		case *transactionrecord.BitmarkShare, *transactionrecord.ShareGrant, *transactionrecord.ShareSwap:
+		case *transactionrecord.BitmarkShare, *transactionrecord.ShareGrant, *transactionrecord.ShareSwap, *transactionrecord.ShareRedemption, *transactionrecord.AtomicSwap:
 			globalData.log.Debugf("validate whether the share transaction indexed. txId: %s", txId)
 			if !storage.Pool.Transactions.Has(txId[:]) {
 				globalData.log.Error("tx is not indexed")
@@ -316,16 +323,45 @@ func Initialise(blockHandle storage.Handle) error {
 	}
 
 	if migrate {
-		log.Info("start block migration…")
-		globalData.rebuild = true
-		globalData.Unlock()
-		err := doBlockHeaderHash()
-		globalData.Lock()
+		// only apply migrations added after the version on file
+		if storage.IsMigrationNeedFor(storage.BlockHeaderHashVersion) {
+			log.Info("start block migration…")
+			globalData.rebuild = true
+			globalData.Unlock()
+			err := doBlockHeaderHash()
+			globalData.Lock()
+			if nil != err {
+				log.Criticalf("blocks migration error: %s", err)
+				return err
+			}
+			log.Info("block migration completed")
+		}
+
+		if storage.IsMigrationNeedFor(storage.ShareHoldersVersion) {
+			log.Info("start share holder migration…")
+			err := doShareHolders()
+			if nil != err {
+				log.Criticalf("share holder migration error: %s", err)
+				return err
+			}
+			log.Info("share holder migration completed")
+		}
+
+		if storage.IsMigrationNeedFor(storage.MetadataIndexVersion) {
+			log.Info("start asset metadata migration…")
+			err := doMetadataIndex()
+			if nil != err {
+				log.Criticalf("asset metadata migration error: %s", err)
+				return err
+			}
+			log.Info("asset metadata migration completed")
+		}
+
+		err := storage.MigrationCompleted()
 		if nil != err {
-			log.Criticalf("blocks migration error: %s", err)
+			log.Criticalf("database version update error: %s", err)
 			return err
 		}
-		log.Info("block migration completed")
 	}
 
 	// ensure not in rebuild mode
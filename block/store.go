@@ -157,6 +157,19 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 		txIds := make([]merkle.Digest, header.TransactionCount)
 
 		localAssets := make(map[transactionrecord.AssetIdentifier]struct{})
+		localAmendments := make(map[transactionrecord.AssetIdentifier]struct{})
+
+		// each link can only be spent once per block, by a transfer,
+		// share, redemption or swap leg, as every one of them is
+		// checked against the ownership before the block
+		localLinks := make(map[merkle.Digest]struct{})
+		spendLink := func(link merkle.Digest) error {
+			if _, ok := localLinks[link]; ok {
+				return fault.DoubleTransferAttempt
+			}
+			localLinks[link] = struct{}{}
+			return nil
+		}
 
 		// check all transactions are valid
 		for i := uint16(0); i < header.TransactionCount; i++ {
@@ -166,6 +179,11 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 			}
 			txId := merkle.NewDigest(data[:n])
 
+			// newer record types need a block version that allows them
+			if transactionrecord.IsExtendedRecord(transaction) && !blockrecord.IsExtendedRecordsAllowedVersion(header.Version) {
+				return fault.RecordNotAllowedInBlockVersion
+			}
+
 			// repack records to check signature is valid
 			switch tx := transaction.(type) {
 
@@ -215,6 +233,10 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 				if !ownership.CurrentlyOwns(nil, linkOwner, link, storage.Pool.OwnerTxIndex) {
 					return fault.DoubleTransferAttempt
 				}
+				err = spendLink(link)
+				if nil != err {
+					return err
+				}
 
 				ownerData, err := ownership.GetOwnerData(nil, link, storage.Pool.OwnerData)
 				if nil != err {
@@ -277,6 +299,10 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 				if !ok {
 					return fault.CanOnlyConvertAssetsToShares
 				}
+				err = spendLink(link)
+				if nil != err {
+					return err
+				}
 
 				txs[i].linkOwner = linkOwner
 
@@ -300,6 +326,64 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 					return err
 				}
 
+			case *transactionrecord.ShareRedemption:
+				_, err := tx.Pack(tx.Owner)
+				if nil != err {
+					return err
+				}
+				_, err = reservoir.CheckRedemptionBalance(nil, tx, storage.Pool.ShareQuantity, storage.Pool.Shares, storage.Pool.OwnerData)
+				if nil != err {
+					return err
+				}
+
+				err = spendLink(tx.Link)
+				if nil != err {
+					return err
+				}
+
+				_, shareOwner := ownership.OwnerOf(nil, tx.Link)
+				if nil == shareOwner {
+					return fault.LinkToInvalidOrUnconfirmedTransaction
+				}
+
+				txs[i].linkOwner = shareOwner
+
+			case *transactionrecord.AtomicSwap:
+				_, err := tx.Pack(tx.OwnerOne)
+				if nil != err {
+					return err
+				}
+				_, err = reservoir.CheckAtomicSwap(nil, tx, storage.Pool.ShareQuantity, storage.Pool.OwnerTxIndex, storage.Pool.OwnerData)
+				if nil != err {
+					return err
+				}
+
+				for _, leg := range tx.Bitmarks {
+					err = spendLink(leg.Link)
+					if nil != err {
+						return err
+					}
+				}
+
+			case *transactionrecord.AssetAmendment:
+				_, err := tx.Pack(tx.Registrant)
+				if nil != err {
+					return err
+				}
+				if !suppressDuplicateRecordChecks && storage.Pool.Transactions.Has(txId[:]) {
+					return fault.TransactionAlreadyExists
+				}
+				_, err = asset.CheckAmendment(nil, tx, storage.Pool.Assets, storage.Pool.AssetVersions)
+				if nil != err {
+					return err
+				}
+
+				// only one version of an asset per block
+				if _, ok := localAmendments[tx.AssetId]; ok {
+					return fault.AssetVersionOutOfSequence
+				}
+				localAmendments[tx.AssetId] = struct{}{}
+
 			default:
 				// occurs if the above code is not in sync with transactionrecord/unpack.go
 				// i.e. one or more case blocks are missing
@@ -410,6 +494,7 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 			assets := storage.Pool.Assets
 			if !trx.Has(assets, assetId[:]) {
 				trx.Put(assets, assetId[:], thisBlockNumberKey, item.packed)
+				asset.IndexMetadata(trx, assetId, tx.Metadata, thisBlockNumberKey)
 			}
 
 		case *transactionrecord.BitmarkIssue:
@@ -524,15 +609,9 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 			oAccountBalance -= tx.Quantity
 			rAccountBalance += tx.Quantity
 
-			share := storage.Pool.ShareQuantity
-
 			// update balances
-			if 0 == oAccountBalance {
-				trx.Delete(share, oKey)
-			} else {
-				trx.PutN(share, oKey, oAccountBalance)
-			}
-			trx.PutN(share, rKey, rAccountBalance)
+			ownership.SetShareBalance(trx, tx.Owner, tx.ShareId, oAccountBalance)
+			ownership.SetShareBalance(trx, tx.Recipient, tx.ShareId, rAccountBalance)
 
 			trx.Put(
 				storage.Pool.Transactions,
@@ -582,22 +661,84 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 			ownerTwoShareTwoAccountBalance -= tx.QuantityTwo
 			ownerOneShareTwoAccountBalance += tx.QuantityTwo
 
-			share := storage.Pool.ShareQuantity
 			// update database share one
-			if 0 == ownerOneShareOneAccountBalance {
-				trx.Delete(share, ownerOneShareOneKey)
-			} else {
-				trx.PutN(share, ownerOneShareOneKey, ownerOneShareOneAccountBalance)
-			}
-			trx.PutN(share, ownerTwoShareOneKey, ownerTwoShareOneAccountBalance)
+			ownership.SetShareBalance(trx, tx.OwnerOne, tx.ShareIdOne, ownerOneShareOneAccountBalance)
+			ownership.SetShareBalance(trx, tx.OwnerTwo, tx.ShareIdOne, ownerTwoShareOneAccountBalance)
 
 			// update database share two
-			if 0 == ownerTwoShareTwoAccountBalance {
-				trx.Delete(share, ownerTwoShareTwoKey)
-			} else {
-				trx.PutN(share, ownerTwoShareTwoKey, ownerTwoShareTwoAccountBalance)
+			ownership.SetShareBalance(trx, tx.OwnerTwo, tx.ShareIdTwo, ownerTwoShareTwoAccountBalance)
+			ownership.SetShareBalance(trx, tx.OwnerOne, tx.ShareIdTwo, ownerOneShareTwoAccountBalance)
+			trx.Put(
+				storage.Pool.Transactions,
+				item.txId[:],
+				thisBlockNumberKey,
+				item.packed,
+			)
+
+		case *transactionrecord.ShareRedemption:
+
+			reservoir.DeleteByTxId(item.txId)
+
+			trx.Put(
+				storage.Pool.Transactions,
+				item.txId[:],
+				thisBlockNumberKey,
+				item.packed,
+			)
+
+			// burn the shares and return the bitmark to the redeeming owner
+			ownership.Redeem(trx, tx.Link, item.txId, header.Number, item.linkOwner, tx.Owner)
+
+		case *transactionrecord.AtomicSwap:
+
+			reservoir.DeleteByTxId(item.txId)
+
+			trx.Put(
+				storage.Pool.Transactions,
+				item.txId[:],
+				thisBlockNumberKey,
+				item.packed,
+			)
+
+			// each bitmark leg is owned under its own leg id
+			for j, leg := range tx.Bitmarks {
+				reservoir.DeleteByLink(leg.Link)
+
+				legTxId := transactionrecord.SwapLegTxId(item.txId, j)
+				trx.Put(
+					storage.Pool.Transactions,
+					legTxId[:],
+					thisBlockNumberKey,
+					item.packed,
+				)
+				ownership.Transfer(trx, leg.Link, legTxId, header.Number, leg.Owner, leg.Recipient)
 			}
-			trx.PutN(share, ownerOneShareTwoKey, ownerOneShareTwoAccountBalance)
+
+			for _, leg := range tx.Shares {
+				oKey := append(leg.Owner.Bytes(), leg.ShareId[:]...)
+				rKey := append(leg.Recipient.Bytes(), leg.ShareId[:]...)
+
+				oAccountBalance, ok := trx.GetN(storage.Pool.ShareQuantity, oKey)
+				if !ok {
+					trx.Abort()
+					// check was earlier
+					logger.Panic("read swap owner balance should not fail")
+				}
+
+				// if record does not exists the balance is zero
+				rAccountBalance, _ := trx.GetN(storage.Pool.ShareQuantity, rKey)
+
+				oAccountBalance -= leg.Quantity
+				rAccountBalance += leg.Quantity
+
+				ownership.SetShareBalance(trx, leg.Owner, leg.ShareId, oAccountBalance)
+				ownership.SetShareBalance(trx, leg.Recipient, leg.ShareId, rAccountBalance)
+			}
+
+		case *transactionrecord.AssetAmendment:
+
+			reservoir.DeleteByTxId(item.txId)
+
 			trx.Put(
 				storage.Pool.Transactions,
 				item.txId[:],
@@ -605,6 +746,24 @@ func StoreIncoming(packedBlock, packedNextBlock []byte, performRescan rescanType
 				item.packed,
 			)
 
+			// the original asset record is never changed
+			trx.Put(
+				storage.Pool.AssetVersions,
+				asset.VersionKey(tx.AssetId, tx.Version),
+				thisBlockNumberKey,
+				item.packed,
+			)
+
+			// only the latest metadata is indexed
+			previous, _, err := asset.VersionMetadata(trx, tx.AssetId, tx.Version-1, storage.Pool.Assets, storage.Pool.AssetVersions)
+			if nil != err {
+				trx.Abort()
+				// check was earlier
+				logger.Panicf("read previous asset version should not fail: %s", err)
+			}
+			asset.UnindexMetadata(trx, tx.AssetId, previous)
+			asset.IndexMetadata(trx, tx.AssetId, tx.Metadata, thisBlockNumberKey)
+
 		default:
 			trx.Abort()
 			globalData.log.Criticalf("unhandled transaction: %v", tx)
@@ -29,6 +29,9 @@ const (
 	digestVersion     = argon2.Version13
 )
 
+// Memory - argon2 memory usage in KiB for a block digest
+const Memory = digestMemory
+
 // Digest - type for a digest
 // stored as little endian byte array
 // represented as big endian hex value for print
@@ -37,10 +40,18 @@ type Digest [Length]byte
 
 // NewDigest - create a digest from a byte slice
 func NewDigest(record []byte) Digest {
+	return NewDigestWithMemory(record, digestMemory)
+}
+
+// NewDigestWithMemory - create a digest using a different argon2
+// memory size in KiB
+//
+// only for benchmarking, block digests must use NewDigest
+func NewDigestWithMemory(record []byte, memory int) Digest {
 
 	context := &argon2.Context{
 		Iterations:  digestIterations,
-		Memory:      digestMemory,
+		Memory:      memory,
 		Parallelism: digestParallelism,
 		HashLen:     Length,
 		Mode:        digestMode,
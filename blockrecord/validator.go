@@ -19,6 +19,7 @@ const (
 	initialVersion             = 1
 	modifiedTimeSpacingVersion = 2
 	difficultyAppliedVersion   = 5
+	extendedRecordsVersion     = 6
 )
 
 // ValidBlockTimeSpacingAtVersion - valid block time spacing based on different version
@@ -55,6 +56,12 @@ func IsDifficultyAppliedVersion(version uint16) bool {
 	return version >= difficultyAppliedVersion
 }
 
+// IsExtendedRecordsAllowedVersion - are the record types added after
+// the original set allowed at header version
+func IsExtendedRecordsAllowedVersion(version uint16) bool {
+	return version >= extendedRecordsVersion
+}
+
 // IsBlockToAdjustDifficulty - is block the one to adjust difficulty
 func IsBlockToAdjustDifficulty(height uint64, version uint16) bool {
 	if !IsDifficultyAppliedVersion(version) {
@@ -117,6 +117,16 @@ func TestIsDifficultyAppliedVersionWhenNotApplied(t *testing.T) {
 	assert.Equal(t, false, ok, "difficulty not applied version")
 }
 
+func TestIsExtendedRecordsAllowedVersionWhenAllowed(t *testing.T) {
+	ok := blockrecord.IsExtendedRecordsAllowedVersion(6)
+	assert.Equal(t, true, ok, "extended records allowed version")
+}
+
+func TestIsExtendedRecordsAllowedVersionWhenNotAllowed(t *testing.T) {
+	ok := blockrecord.IsExtendedRecordsAllowedVersion(5)
+	assert.Equal(t, false, ok, "extended records not allowed version")
+}
+
 func TestValidHeaderVersionWhenTooSmall(t *testing.T) {
 	err := blockrecord.ValidHeaderVersion(uint16(10), uint16(0))
 	assert.Equal(t, fault.InvalidBlockHeaderVersion, err, "header version small")
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package batch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/batch"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := "op,asset,fingerprint,quantity,receiver,txid\n" +
+		"\n" +
+		"issue, first, 01ab, 1,,\n" +
+		"transfer,,,,bob,\"0123\"\n"
+
+	ops, err := batch.ReadCSV(strings.NewReader(data))
+	if nil != err {
+		t.Fatalf("read error: %s", err)
+	}
+	if 2 != len(ops) {
+		t.Fatalf("operations: %d  expected: 2", len(ops))
+	}
+	if 3 != ops[0].Line || batch.OpIssue != ops[0].Op || "first" != ops[0].Asset || 1 != ops[0].Quantity {
+		t.Errorf("issue: %+v", ops[0])
+	}
+	if 4 != ops[1].Line || batch.OpTransfer != ops[1].Op || "bob" != ops[1].Receiver || "0123" != ops[1].TxId {
+		t.Errorf("transfer: %+v", ops[1])
+	}
+
+	_, err = batch.ReadCSV(strings.NewReader("op,colour\n"))
+	if nil == err {
+		t.Error("unknown column accepted")
+	}
+	_, err = batch.ReadCSV(strings.NewReader("op,quantity\nissue,many\n"))
+	if nil == err {
+		t.Error("bad quantity accepted")
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	data := `{"op":"grant","shareId":"00ff","quantity":5,"receiver":"carol","beforeBlock":100}` + "\n" +
+		"\n" +
+		`{"op":"issue","asset":"a","fingerprint":"01","quantity":2}` + "\n"
+
+	ops, err := batch.ReadJSONL(strings.NewReader(data))
+	if nil != err {
+		t.Fatalf("read error: %s", err)
+	}
+	if 2 != len(ops) {
+		t.Fatalf("operations: %d  expected: 2", len(ops))
+	}
+	if 1 != ops[0].Line || 100 != ops[0].BeforeBlock || 5 != ops[0].Quantity {
+		t.Errorf("grant: %+v", ops[0])
+	}
+	if 3 != ops[1].Line || 2 != ops[1].Quantity {
+		t.Errorf("issue: %+v", ops[1])
+	}
+
+	_, err = batch.ReadJSONL(strings.NewReader("{\n"))
+	if nil == err {
+		t.Error("bad JSON accepted")
+	}
+}
+
+func issue(line int, fingerprint string, quantity uint64) *batch.Operation {
+	return &batch.Operation{
+		Line:        line,
+		Op:          batch.OpIssue,
+		Fingerprint: fingerprint,
+		Quantity:    quantity,
+	}
+}
+
+func TestGroupIssues(t *testing.T) {
+	ops := []*batch.Operation{
+		issue(1, "a", 1), // free
+		issue(2, "b", 1), // free
+		issue(3, "a", 1), // paid, second single of a
+		issue(4, "a", 3), // paid
+		{Line: 5, Op: batch.OpTransfer},
+		issue(6, "b", 2), // paid
+		issue(7, "c", 1), // free
+		issue(8, "d", 1), // free, but overflows first free group
+	}
+	batch.MarkFree(ops)
+
+	free := map[int]bool{1: true, 2: true, 7: true, 8: true}
+	for _, op := range ops {
+		if free[op.Line] != op.IsFree() {
+			t.Errorf("line: %d  free: %t", op.Line, op.IsFree())
+		}
+	}
+
+	groups := batch.GroupIssues(ops, 3)
+
+	expected := [][]int{{1, 2, 7}, {3}, {4}, {6}, {8}}
+	if len(expected) != len(groups) {
+		t.Fatalf("groups: %d  expected: %d", len(groups), len(expected))
+	}
+	for i, g := range groups {
+		if g.Issues() > 3 {
+			t.Errorf("group: %d  issues: %d", i, g.Issues())
+		}
+		if len(expected[i]) != len(g.Operations) {
+			t.Errorf("group: %d  operations: %d  expected: %d", i, len(g.Operations), len(expected[i]))
+			continue
+		}
+		for j, op := range g.Operations {
+			if expected[i][j] != op.Line {
+				t.Errorf("group: %d  line: %d  expected: %d", i, op.Line, expected[i][j])
+			}
+		}
+	}
+	if !groups[0].Free || groups[1].Free || !groups[4].Free {
+		t.Errorf("free flags: %t %t %t", groups[0].Free, groups[1].Free, groups[4].Free)
+	}
+}
+
+func TestIssueNonce(t *testing.T) {
+	digest := []byte{1, 2, 3}
+	ops := []*batch.Operation{issue(1, "a", 1), issue(2, "a", 2)}
+	batch.MarkFree(ops)
+
+	if 0 != batch.IssueNonce(digest, ops[0], 0) {
+		t.Error("free issue has a nonce")
+	}
+
+	n0 := batch.IssueNonce(digest, ops[1], 0)
+	n1 := batch.IssueNonce(digest, ops[1], 1)
+	if 0 == n0 || 0 == n1 || n0 == n1 {
+		t.Errorf("nonces: %d %d", n0, n1)
+	}
+	if n0 != batch.IssueNonce(digest, ops[1], 0) {
+		t.Error("nonce is not repeatable")
+	}
+	if n0 == batch.IssueNonce([]byte{4, 5, 6}, ops[1], 0) {
+		t.Error("nonce does not depend on batch")
+	}
+}
+
+func TestResultsResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	if nil != err {
+		t.Fatalf("temp dir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "ops.result")
+	digest := []byte{0xaa, 0xbb}
+	ops := []*batch.Operation{issue(1, "a", 1), issue(2, "b", 1), issue(3, "c", 1)}
+
+	r, err := batch.OpenResults(fileName, digest)
+	if nil != err {
+		t.Fatalf("open error: %s", err)
+	}
+	err = r.Write(
+		&batch.Result{Line: 1, Op: batch.OpIssue, TxIds: []string{"01"}},
+		&batch.Result{Line: 2, Op: batch.OpIssue, Error: "failed"},
+	)
+	if nil != err {
+		t.Fatalf("write error: %s", err)
+	}
+	r.Close()
+
+	// simulate a crash in the middle of a line
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY, 0600)
+	if nil != err {
+		t.Fatalf("append error: %s", err)
+	}
+	f.WriteString(`{"line":3,"op":"iss`)
+	f.Close()
+
+	r, err = batch.OpenResults(fileName, digest)
+	if nil != err {
+		t.Fatalf("reopen error: %s", err)
+	}
+	pending := r.Pending(ops)
+	if 2 != len(pending) || 2 != pending[0].Line || 3 != pending[1].Line {
+		t.Errorf("pending: %d", len(pending))
+	}
+	err = r.Write(&batch.Result{Line: 2, Op: batch.OpIssue, TxIds: []string{"02"}})
+	if nil != err {
+		t.Fatalf("write error: %s", err)
+	}
+	r.Close()
+
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		t.Fatalf("read error: %s", err)
+	}
+	if 4 != strings.Count(string(data), "\n") {
+		t.Errorf("lines: %d  expected: 4", strings.Count(string(data), "\n"))
+	}
+
+	r, err = batch.OpenResults(fileName, digest)
+	if nil != err {
+		t.Fatalf("reopen error: %s", err)
+	}
+	if !r.Done(1) || !r.Done(2) || r.Done(3) {
+		t.Errorf("done: %t %t %t", r.Done(1), r.Done(2), r.Done(3))
+	}
+	r.Close()
+
+	_, err = batch.OpenResults(fileName, []byte{0x01})
+	if nil == err {
+		t.Error("results of another batch accepted")
+	}
+}
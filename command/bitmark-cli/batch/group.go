@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package batch
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Group - issue operations sent in a single Bitmarks.Create call
+type Group struct {
+	Free       bool
+	Operations []*Operation
+}
+
+// Issues - total number of issues in the group
+func (g *Group) Issues() int {
+	n := 0
+	for _, op := range g.Operations {
+		n += int(op.Quantity)
+	}
+	return n
+}
+
+// MarkFree - flag the free issues of a batch
+//
+// a quantity of one is free (zero nonce) if it is the first such issue
+// of its asset in the batch; later ones are paid as a second free
+// issue would duplicate the first.  This must be called with every
+// operation, including those already done, so a resumed batch makes
+// the same choice.
+func MarkFree(ops []*Operation) {
+	seen := make(map[string]struct{})
+	for _, op := range ops {
+		op.free = false
+		if OpIssue != op.Op || 1 != op.Quantity {
+			continue
+		}
+		if _, ok := seen[op.Fingerprint]; ok {
+			continue
+		}
+		seen[op.Fingerprint] = struct{}{}
+		op.free = true
+	}
+}
+
+// IsFree - true if the operation is a free issue
+func (op *Operation) IsFree() bool {
+	return op.free
+}
+
+// GroupIssues - pack issue operations into as few Create calls as
+// possible while keeping each call acceptable to bitmarkd:
+//
+//   - no more than maximum issues in a call
+//   - free issues of any asset may share a call
+//   - paid issues in a call must all be of one asset
+//
+// file order is kept within each group
+func GroupIssues(ops []*Operation, maximum int) []*Group {
+	groups := []*Group{}
+
+	var free *Group
+	paid := make(map[string]*Group)
+
+	for _, op := range ops {
+		if OpIssue != op.Op {
+			continue
+		}
+		n := int(op.Quantity)
+
+		if op.free {
+			if nil == free || free.Issues()+n > maximum {
+				free = &Group{Free: true}
+				groups = append(groups, free)
+			}
+			free.Operations = append(free.Operations, op)
+			continue
+		}
+
+		g := paid[op.Fingerprint]
+		if nil == g || g.Issues()+n > maximum {
+			g = &Group{}
+			paid[op.Fingerprint] = g
+			groups = append(groups, g)
+		}
+		g.Operations = append(g.Operations, op)
+	}
+	return groups
+}
+
+// IssueNonce - the nonce of the index'th issue of an operation
+//
+// free issues must use zero; paid issues use a value derived from the
+// batch so that re-running after a crash makes identical records that
+// bitmarkd recognises as duplicates instead of new issues
+func IssueNonce(digest []byte, op *Operation, index int) uint64 {
+	if op.free {
+		return 0
+	}
+
+	buffer := make([]byte, len(digest)+16)
+	copy(buffer, digest)
+	binary.BigEndian.PutUint64(buffer[len(digest):], uint64(op.Line))
+	binary.BigEndian.PutUint64(buffer[len(digest)+8:], uint64(index))
+	hash := sha3.Sum256(buffer)
+
+	nonce := binary.BigEndian.Uint64(hash[:8])
+	if 0 == nonce {
+		nonce = 1
+	}
+	return nonce
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// operation names
+const (
+	OpIssue    = "issue"
+	OpTransfer = "transfer"
+	OpGrant    = "grant"
+)
+
+// Operation - one line of the batch file
+type Operation struct {
+	Line        int    `json:"-"`
+	Op          string `json:"op"`
+	Asset       string `json:"asset"`
+	Fingerprint string `json:"fingerprint"`
+	Metadata    string `json:"metadata"`
+	Quantity    uint64 `json:"quantity"`
+	TxId        string `json:"txId"`
+	Receiver    string `json:"receiver"`
+	ShareId     string `json:"shareId"`
+	BeforeBlock uint64 `json:"beforeBlock"`
+
+	free bool // set by MarkFree
+}
+
+// CSV column names, the header row may list them in any order
+var csvColumns = map[string]func(op *Operation, value string) error{
+	"op":           func(op *Operation, v string) error { op.Op = v; return nil },
+	"asset":        func(op *Operation, v string) error { op.Asset = v; return nil },
+	"fingerprint":  func(op *Operation, v string) error { op.Fingerprint = v; return nil },
+	"metadata":     func(op *Operation, v string) error { op.Metadata = v; return nil },
+	"quantity":     func(op *Operation, v string) error { return parseNumber(v, &op.Quantity) },
+	"txid":         func(op *Operation, v string) error { op.TxId = v; return nil },
+	"receiver":     func(op *Operation, v string) error { op.Receiver = v; return nil },
+	"share_id":     func(op *Operation, v string) error { op.ShareId = v; return nil },
+	"before_block": func(op *Operation, v string) error { return parseNumber(v, &op.BeforeBlock) },
+}
+
+func parseNumber(s string, n *uint64) error {
+	if "" == s {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if nil != err {
+		return err
+	}
+	*n = v
+	return nil
+}
+
+// Read - load a batch file, ".csv" files are CSV with a header row
+// and anything else is one JSON object per line
+//
+// also returns the digest of the file so results can be matched to it
+func Read(fileName string) ([]*Operation, []byte, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		return nil, nil, err
+	}
+	digest := sha3.Sum256(data)
+
+	var ops []*Operation
+	if ".csv" == strings.ToLower(filepath.Ext(fileName)) {
+		ops, err = ReadCSV(bytes.NewReader(data))
+	} else {
+		ops, err = ReadJSONL(bytes.NewReader(data))
+	}
+	if nil != err {
+		return nil, nil, err
+	}
+	return ops, digest[:], nil
+}
+
+// ReadCSV - decode CSV operations, one per line; blank lines are skipped
+func ReadCSV(r io.Reader) ([]*Operation, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 65536), 1<<20)
+
+	var header []string
+	ops := []*Operation{}
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		text := strings.TrimSpace(scanner.Text())
+		if "" == text {
+			continue
+		}
+
+		reader := csv.NewReader(strings.NewReader(text))
+		reader.TrimLeadingSpace = true
+		record, err := reader.Read()
+		if nil != err {
+			return nil, fmt.Errorf("line: %d  error: %s", line, err)
+		}
+
+		if nil == header {
+			header = record
+			for i, h := range header {
+				header[i] = strings.ToLower(strings.TrimSpace(h))
+				if _, ok := csvColumns[header[i]]; !ok {
+					return nil, fmt.Errorf("line: %d  unknown column: %q", line, h)
+				}
+			}
+			continue
+		}
+
+		op := &Operation{
+			Line: line,
+		}
+		for i, value := range record {
+			if i >= len(header) {
+				return nil, fmt.Errorf("line: %d  too many fields", line)
+			}
+			err := csvColumns[header[i]](op, strings.TrimSpace(value))
+			if nil != err {
+				return nil, fmt.Errorf("line: %d  column: %s  error: %s", line, header[i], err)
+			}
+		}
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// ReadJSONL - decode one JSON operation per line, blank lines are skipped
+func ReadJSONL(r io.Reader) ([]*Operation, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 65536), 1<<20)
+
+	ops := []*Operation{}
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		text := bytes.TrimSpace(scanner.Bytes())
+		if 0 == len(text) {
+			continue
+		}
+
+		op := &Operation{}
+		err := json.Unmarshal(text, op)
+		if nil != err {
+			return nil, fmt.Errorf("line: %d  error: %s", line, err)
+		}
+		op.Line = line
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, err
+	}
+	return ops, nil
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package batch
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// first line of a result file
+type header struct {
+	Batch string `json:"batch"`
+}
+
+// Result - the outcome of one operation, written as one JSON line
+type Result struct {
+	Line        int               `json:"line"`
+	Op          string            `json:"op"`
+	TxIds       []string          `json:"txIds,omitempty"`
+	AssetId     string            `json:"assetId,omitempty"`
+	PayId       string            `json:"payId,omitempty"`
+	ProofStatus string            `json:"proofStatus,omitempty"`
+	Commands    map[string]string `json:"commands,omitempty"`
+	Countersign string            `json:"countersign,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// Results - append only result file
+//
+// an existing file is read first: lines that succeeded are done and
+// are skipped by a resumed run, failed lines are tried again and the
+// newest result for a line is the one that counts
+type Results struct {
+	file *os.File
+	done map[int]struct{}
+}
+
+// OpenResults - open or create the result file of a batch
+func OpenResults(fileName string, digest []byte) (*Results, error) {
+	batch := hex.EncodeToString(digest)
+
+	r := &Results{
+		done: make(map[int]struct{}),
+	}
+
+	offset, err := r.load(fileName, batch)
+	if nil != err {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY, 0600)
+	if nil != err {
+		return nil, err
+	}
+
+	// drop a partial line left by a crash
+	err = f.Truncate(offset)
+	if nil == err {
+		_, err = f.Seek(offset, 0)
+	}
+	if nil != err {
+		f.Close()
+		return nil, err
+	}
+	r.file = f
+
+	if 0 == offset {
+		err = r.write(header{Batch: batch})
+		if nil != err {
+			f.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// read an existing file and return the size of its complete lines
+func (r *Results) load(fileName string, batch string) (int64, error) {
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if nil != err {
+		return 0, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	offset := int64(0)
+	first := true
+	for {
+		line, err := reader.ReadBytes('\n')
+		if nil != err {
+			// EOF, possibly after a partial line
+			break
+		}
+
+		if first {
+			var h header
+			err := json.Unmarshal(line, &h)
+			if nil != err || batch != h.Batch {
+				return 0, fmt.Errorf("result file: %q is not for this batch", fileName)
+			}
+			first = false
+		} else {
+			var result Result
+			err := json.Unmarshal(line, &result)
+			if nil != err {
+				break
+			}
+			if "" == result.Error {
+				r.done[result.Line] = struct{}{}
+			} else {
+				delete(r.done, result.Line)
+			}
+		}
+		offset += int64(len(line))
+	}
+	return offset, nil
+}
+
+// Done - true if the line already succeeded
+func (r *Results) Done(line int) bool {
+	_, ok := r.done[line]
+	return ok
+}
+
+// Pending - operations that have not yet succeeded
+func (r *Results) Pending(ops []*Operation) []*Operation {
+	pending := []*Operation{}
+	for _, op := range ops {
+		if !r.Done(op.Line) {
+			pending = append(pending, op)
+		}
+	}
+	return pending
+}
+
+// Write - append results and flush them to disk
+func (r *Results) Write(results ...*Result) error {
+	for _, result := range results {
+		err := r.write(result)
+		if nil != err {
+			return err
+		}
+		if "" == result.Error {
+			r.done[result.Line] = struct{}{}
+		}
+	}
+	return r.file.Sync()
+}
+
+func (r *Results) write(item interface{}) error {
+	data, err := json.Marshal(item)
+	if nil != err {
+		return err
+	}
+	_, err = r.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close - close the result file
+func (r *Results) Close() error {
+	return r.file.Close()
+}
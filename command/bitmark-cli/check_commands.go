@@ -151,6 +151,19 @@ func checkSeed(seed string, new bool, testnet bool) (string, error) {
 	return seed, nil
 }
 
+// blank or a derivation index converted to its path
+func checkDerivation(index string) (string, error) {
+	if "" == index {
+		return "", nil
+	}
+
+	n, err := strconv.ParseUint(index, 10, 31)
+	if nil != err {
+		return "", fault.InvalidDerivationPath
+	}
+	return account.DerivationPath(uint32(n))
+}
+
 // get decrypted identity - prompts for password or uses agent
 // only use owner to sign things
 func checkOwnerWithPasswordPrompt(name string, config *configuration.Configuration, c *cli.Context) (string, *configuration.Private, error) {
@@ -158,7 +171,22 @@ func checkOwnerWithPasswordPrompt(name string, config *configuration.Configurati
 		name = config.DefaultIdentity
 	}
 
-	var err error
+	// keys held by an external signer need no password
+	id, err := config.Identity(name)
+	if nil != err {
+		return "", nil, err
+	}
+	if id.HasSigner() {
+		acc, err := account.AccountFromBase58(id.Account)
+		if nil != err {
+			return "", nil, err
+		}
+		owner := &configuration.Private{
+			Description: id.Description,
+			Signer:      newProgramSigner(id.Signer, name, acc, c.Command.Name),
+		}
+		return name, owner, nil
+	}
 
 	// get global password items
 	agent := c.GlobalString("use-agent")
@@ -185,6 +213,20 @@ func checkOwnerWithPasswordPrompt(name string, config *configuration.Configurati
 	return name, owner, nil
 }
 
+// get the account of an identity without decrypting it, this also
+// works for read-only identities
+func checkOwnerAccount(name string, config *configuration.Configuration) (string, *account.Account, error) {
+	if "" == name {
+		name = config.DefaultIdentity
+	}
+
+	owner, err := config.Account(name)
+	if nil != err {
+		return "", nil, err
+	}
+	return name, owner, nil
+}
+
 // recipient is required field convert to an account
 // used for any non-signing account process (e.g. provenance listing)
 func checkRecipient(c *cli.Context, name string, config *configuration.Configuration) (string, *account.Account, error) {
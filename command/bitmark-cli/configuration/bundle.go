@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"encoding/json"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// constants for the bundle header
+const (
+	BundleFormat  = "bitmark-identities"
+	BundleVersion = 1
+)
+
+// Bundle - identities encrypted with a single password for moving
+// between machines
+//
+// the data is the JSON list of BundleIdentity encrypted in the same
+// way as a current version identity, with the format as the
+// additional data
+type Bundle struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	TestNet bool   `json:"testnet"`
+	Salt    string `json:"salt"`
+	Data    string `json:"data"`
+}
+
+// BundleIdentity - one identity in a bundle, the seed is blank for
+// read-only and external signer identities
+type BundleIdentity struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Account     string `json:"account"`
+	Path        string `json:"path,omitempty"`
+	Signer      string `json:"signer,omitempty"`
+	Seed        string `json:"seed,omitempty"`
+}
+
+// BundleEntry - the bundle form of a configured identity, private
+// must be the decrypted identity if it has a secret key
+func (config *Configuration) BundleEntry(name string, private *Private) (*BundleIdentity, error) {
+	id, err := config.Identity(name)
+	if nil != err {
+		return nil, err
+	}
+
+	entry := &BundleIdentity{
+		Name:        name,
+		Description: id.Description,
+		Account:     id.Account,
+		Path:        id.Path,
+		Signer:      id.Signer,
+	}
+	if "" != id.Data {
+		if nil == private || "" == private.Seed {
+			return nil, fault.NotPrivateKey
+		}
+		entry.Seed = private.Seed
+	}
+	return entry, nil
+}
+
+// MakeBundle - encrypt identities with a password
+func (config *Configuration) MakeBundle(entries []*BundleIdentity, password string) (*Bundle, error) {
+	data, err := json.Marshal(entries)
+	if nil != err {
+		return nil, err
+	}
+
+	salt, secretKey, err := hashPasswordV2(password)
+	if nil != err {
+		return nil, err
+	}
+
+	encrypted, err := encryptDataV2(string(data), secretKey, []byte(BundleFormat))
+	if nil != err {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		Format:  BundleFormat,
+		Version: BundleVersion,
+		TestNet: config.TestNet,
+		Salt:    salt.String(),
+		Data:    encrypted,
+	}
+	return bundle, nil
+}
+
+// Open - decrypt the identities of a bundle
+func (bundle *Bundle) Open(password string) ([]*BundleIdentity, error) {
+	if BundleFormat != bundle.Format || BundleVersion != bundle.Version {
+		return nil, fault.InvalidIdentityBundle
+	}
+
+	salt := new(Salt)
+	err := salt.UnmarshalText([]byte(bundle.Salt))
+	if nil != err {
+		return nil, fault.InvalidIdentityBundle
+	}
+
+	secretKey, err := generateKeyV2(password, salt)
+	if nil != err {
+		return nil, err
+	}
+
+	data, err := decryptDataV2(bundle.Data, secretKey, []byte(BundleFormat))
+	if nil != err {
+		return nil, fault.WrongPassword
+	}
+
+	entries := []*BundleIdentity{}
+	err = json.Unmarshal([]byte(data), &entries)
+	if nil != err {
+		return nil, fault.InvalidIdentityBundle
+	}
+	return entries, nil
+}
+
+// Import - add the identities from a bundle, secret keys are encrypted
+// with password
+//
+// nothing is added if any name already exists
+func (config *Configuration) Import(bundle *Bundle, entries []*BundleIdentity, password string) error {
+	if bundle.TestNet != config.TestNet {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	for _, entry := range entries {
+		if _, ok := config.Identities[entry.Name]; ok {
+			return fault.IdentityNameAlreadyExists
+		}
+	}
+
+	added := []string{}
+	for _, entry := range entries {
+		var err error
+		switch {
+		case "" != entry.Seed:
+			err = config.AddIdentity(entry.Name, entry.Description, entry.Seed, entry.Path, password)
+			if nil == err && config.Identities[entry.Name].Account != entry.Account {
+				err = fault.InvalidIdentityBundle
+			}
+		case "" != entry.Signer:
+			err = config.AddSignerIdentity(entry.Name, entry.Description, entry.Account, entry.Signer)
+		default:
+			err = config.AddReceiveOnlyIdentity(entry.Name, entry.Description, entry.Account)
+		}
+
+		// leave the configuration as it was
+		if nil != err {
+			delete(config.Identities, entry.Name)
+			for _, name := range added {
+				delete(config.Identities, name)
+			}
+			return err
+		}
+		added = append(added, entry.Name)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+func TestBundleExportImport(t *testing.T) {
+
+	seed := "9J877LVjhr3Xxd2nGzRVRVNUZpSKJF4TH"
+
+	source := &Configuration{
+		TestNet:    true,
+		Identities: map[string]Identity{},
+	}
+	err := source.AddIdentity("main", "main key", seed, "m/3'", "source password")
+	if nil != err {
+		t.Fatalf("add error: %s", err)
+	}
+	err = source.AddReceiveOnlyIdentity("watch", "watch only", "fXXHGtCdFPuQvNhJ4nDPKCdwPxH7aSZ4842n2katZi319NsaCs")
+	if nil != err {
+		t.Fatalf("add receive only error: %s", err)
+	}
+
+	private, err := source.Private("source password", "main")
+	if nil != err {
+		t.Fatalf("private error: %s", err)
+	}
+
+	_, err = source.BundleEntry("main", nil)
+	if fault.NotPrivateKey != err {
+		t.Errorf("entry without private error: %v  expected: %s", err, fault.NotPrivateKey)
+	}
+	main, err := source.BundleEntry("main", private)
+	if nil != err {
+		t.Fatalf("entry error: %s", err)
+	}
+	watch, err := source.BundleEntry("watch", nil)
+	if nil != err {
+		t.Fatalf("entry error: %s", err)
+	}
+
+	bundle, err := source.MakeBundle([]*BundleIdentity{main, watch}, "bundle password")
+	if nil != err {
+		t.Fatalf("make bundle error: %s", err)
+	}
+
+	_, err = bundle.Open("wrong password")
+	if fault.WrongPassword != err {
+		t.Errorf("open error: %v  expected: %s", err, fault.WrongPassword)
+	}
+	entries, err := bundle.Open("bundle password")
+	if nil != err {
+		t.Fatalf("open error: %s", err)
+	}
+
+	live := &Configuration{
+		TestNet:    false,
+		Identities: map[string]Identity{},
+	}
+	err = live.Import(bundle, entries, "target password")
+	if fault.WrongNetworkForPublicKey != err {
+		t.Errorf("live import error: %v  expected: %s", err, fault.WrongNetworkForPublicKey)
+	}
+
+	target := &Configuration{
+		TestNet: true,
+		Identities: map[string]Identity{
+			"watch": {Account: "fXXHGtCdFPuQvNhJ4nDPKCdwPxH7aSZ4842n2katZi319NsaCs"},
+		},
+	}
+	err = target.Import(bundle, entries, "target password")
+	if fault.IdentityNameAlreadyExists != err {
+		t.Errorf("conflict import error: %v  expected: %s", err, fault.IdentityNameAlreadyExists)
+	}
+	if 1 != len(target.Identities) {
+		t.Errorf("conflict import added identities: %d", len(target.Identities))
+	}
+
+	delete(target.Identities, "watch")
+	err = target.Import(bundle, entries, "target password")
+	if nil != err {
+		t.Fatalf("import error: %s", err)
+	}
+
+	imported, err := target.Private("target password", "main")
+	if nil != err {
+		t.Fatalf("imported private error: %s", err)
+	}
+	if private.Account().String() != imported.Account().String() || "m/3'" != imported.Path {
+		t.Errorf("imported account: %s  path: %q", imported.Account(), imported.Path)
+	}
+	if "" != target.Identities["watch"].Data {
+		t.Error("watch only identity has secret data")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 
+	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/nacl/secretbox"
 
 	"github.com/bitmark-inc/bitmarkd/account"
@@ -16,10 +17,26 @@ import (
 	"github.com/bitmark-inc/go-argon2"
 )
 
+// identity encryption formats, a missing version is version 1
+const (
+	identityVersion1 = 1 // argon2i key and secretbox
+	identityVersion2 = 2 // argon2id key and XChaCha20-Poly1305
+
+	// CurrentIdentityVersion - format used for new or re-encrypted identities
+	CurrentIdentityVersion = identityVersion2
+)
+
+// Poly1305 authenticator size
+const aeadOverhead = 16
+
+// Private - the decrypted identity, or one whose key is held by an
+// external signer
 type Private struct {
 	PrivateKey  *account.PrivateKey `json:"privateKey"`
 	Seed        string              `json:"seed"`
+	Path        string              `json:"path,omitempty"`
 	Description string              `json:"description"`
+	Signer      Signer              `json:"-"`
 }
 
 // decryptIdentity - check if password unlocks data in the configuration file
@@ -31,17 +48,33 @@ func decryptIdentity(password string, identity *Identity) (*Private, error) {
 		return nil, fault.NotPrivateKey
 	}
 
-	key, err := generateKey(password, salt)
-	if nil != err {
-		return nil, err
-	}
+	var seed string
+	switch identity.EncryptionVersion() {
+	case identityVersion1:
+		key, err := generateKey(password, salt)
+		if nil != err {
+			return nil, err
+		}
+		seed, err = decryptData(identity.Data, key)
+		if nil != err {
+			return nil, fault.WrongPassword
+		}
 
-	seed, err := decryptData(identity.Data, key)
-	if nil != err {
-		return nil, fault.WrongPassword
+	case identityVersion2:
+		key, err := generateKeyV2(password, salt)
+		if nil != err {
+			return nil, err
+		}
+		seed, err = decryptDataV2(identity.Data, key, []byte(identity.Account))
+		if nil != err {
+			return nil, fault.WrongPassword
+		}
+
+	default:
+		return nil, fault.InvalidIdentityVersion
 	}
 
-	privateKey, err := account.PrivateKeyFromBase58Seed(seed)
+	privateKey, err := account.PrivateKeyFromBase58SeedPath(seed, identity.Path)
 	if nil != err {
 		return nil, err
 	}
@@ -49,11 +82,28 @@ func decryptIdentity(password string, identity *Identity) (*Private, error) {
 	r := Private{
 		PrivateKey:  privateKey,
 		Seed:        seed,
+		Path:        identity.Path,
 		Description: identity.Description,
 	}
 	return &r, nil
 }
 
+// encrypt a seed in the current format, the account is bound to the
+// ciphertext so the entry cannot be moved to a different account
+func encryptIdentity(seed string, acc string, password string) (string, string, error) {
+	salt, secretKey, err := hashPasswordV2(password)
+	if nil != err {
+		return "", "", err
+	}
+
+	encrypted, err := encryptDataV2(seed, secretKey, []byte(acc))
+	if nil != err {
+		return "", "", err
+	}
+
+	return encrypted, salt.String(), nil
+}
+
 func hashPassword(password string) (*Salt, *[32]byte, error) {
 	salt, err := MakeSalt()
 	if nil != err {
@@ -68,6 +118,20 @@ func hashPassword(password string) (*Salt, *[32]byte, error) {
 	return salt, cipher, nil
 }
 
+func hashPasswordV2(password string) (*Salt, *[32]byte, error) {
+	salt, err := MakeSalt()
+	if nil != err {
+		return nil, nil, err
+	}
+
+	cipher, err := generateKeyV2(password, salt)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	return salt, cipher, nil
+}
+
 func generateKey(password string, salt *Salt) (*[32]byte, error) {
 
 	saltBytes := salt.Bytes()
@@ -92,6 +156,29 @@ func generateKey(password string, salt *Salt) (*[32]byte, error) {
 	return &secretKey, nil
 }
 
+// version 2 key derivation
+func generateKeyV2(password string, salt *Salt) (*[32]byte, error) {
+
+	ctx := &argon2.Context{
+		Iterations:  3,
+		Memory:      1 << 16,
+		Parallelism: 4,
+		HashLen:     32,
+		Mode:        argon2.ModeArgon2id,
+		Version:     argon2.Version13,
+	}
+
+	hash, err := argon2.Hash(ctx, []byte(password), salt.Bytes())
+	if nil != err {
+		return nil, err
+	}
+
+	var secretKey [32]byte
+	copy(secretKey[:], hash)
+
+	return &secretKey, nil
+}
+
 // encrypt a string and convert to hex
 func encryptData(data string, secretKey *[32]byte) (string, error) {
 
@@ -144,3 +231,52 @@ func decryptData(ciphertext string, secretKey *[32]byte) (string, error) {
 
 	return string(decrypted), nil
 }
+
+// encrypt a string with authenticated additional data and convert to
+// hex, the random nonce is stored as a prefix
+func encryptDataV2(data string, secretKey *[32]byte, additionalData []byte) (string, error) {
+
+	// ensure data not too small or too large
+	if len(data) < 32 || len(data) >= 16384 {
+		return "", fault.CryptoFailed
+	}
+
+	aead, err := chacha20poly1305.NewX(secretKey[:])
+	if nil != err {
+		return "", err
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fault.CryptoFailed
+	}
+
+	ciphertext := aead.Seal(nonce, nonce, []byte(data), additionalData)
+
+	return hex.EncodeToString(ciphertext), nil
+}
+
+// decrypt a hex string produced by encryptDataV2
+func decryptDataV2(ciphertext string, secretKey *[32]byte, additionalData []byte) (string, error) {
+
+	encrypted, err := hex.DecodeString(ciphertext)
+	if nil != err {
+		return "", err
+	}
+	if len(encrypted) <= chacha20poly1305.NonceSizeX+aeadOverhead {
+		return "", fault.CryptoFailed
+	}
+
+	aead, err := chacha20poly1305.NewX(secretKey[:])
+	if nil != err {
+		return "", err
+	}
+
+	nonce := encrypted[:chacha20poly1305.NonceSizeX]
+	decrypted, err := aead.Open(nil, nonce, encrypted[chacha20poly1305.NonceSizeX:], additionalData)
+	if nil != err {
+		return "", fault.CryptoFailed
+	}
+
+	return string(decrypted), nil
+}
@@ -26,6 +26,9 @@ type Configuration struct {
 type Identity struct {
 	Description string `json:"description"`
 	Account     string `json:"account"`
+	Path        string `json:"path,omitempty"`
+	Signer      string `json:"signer,omitempty"`
+	Version     int    `json:"version,omitempty"`
 	Data        string `json:"data"`
 	Salt        string `json:"salt"`
 }
@@ -113,34 +116,72 @@ func (config *Configuration) Private(password string, name string) (*Private, er
 }
 
 // AddIdentity - store encrypted identity
-func (config *Configuration) AddIdentity(name string, description string, seed string, password string) error {
+//
+// path is blank for the seed's own key, otherwise the derivation path
+// of the account within the seed
+func (config *Configuration) AddIdentity(name string, description string, seed string, path string, password string) error {
 
 	if _, ok := config.Identities[name]; ok {
 		return fault.IdentityNameAlreadyExists
 	}
 
-	salt, secretKey, err := hashPassword(password)
+	private, err := account.PrivateKeyFromBase58SeedPath(seed, path)
 	if nil != err {
 		return err
 	}
+	acc := private.Account().String()
 
-	encrypted, err := encryptData(seed, secretKey)
+	encrypted, salt, err := encryptIdentity(seed, acc, password)
 	if nil != err {
 		return err
 	}
 
-	private, err := account.PrivateKeyFromBase58Seed(seed)
+	config.Identities[name] = Identity{
+		Description: description,
+		Account:     acc,
+		Path:        path,
+		Version:     CurrentIdentityVersion,
+		Data:        encrypted,
+		Salt:        salt,
+	}
+
+	return nil
+}
+
+// EncryptionVersion - format of the encrypted data
+func (identity Identity) EncryptionVersion() int {
+	if 0 == identity.Version {
+		return identityVersion1
+	}
+	return identity.Version
+}
+
+// NeedsUpgrade - true if the encrypted data is in an older format
+func (identity Identity) NeedsUpgrade() bool {
+	return "" != identity.Data && identity.EncryptionVersion() < CurrentIdentityVersion
+}
+
+// ChangePassword - re-encrypt a decrypted identity with a new password,
+// older formats are migrated to the current version
+func (config *Configuration) ChangePassword(name string, private *Private, password string) error {
+	id, err := config.Identity(name)
 	if nil != err {
 		return err
 	}
+	if "" == id.Data || "" == private.Seed {
+		return fault.NotPrivateKey
+	}
 
-	config.Identities[name] = Identity{
-		Description: description,
-		Account:     private.Account().String(),
-		Data:        encrypted,
-		Salt:        salt.String(),
+	encrypted, salt, err := encryptIdentity(private.Seed, id.Account, password)
+	if nil != err {
+		return err
 	}
 
+	id.Version = CurrentIdentityVersion
+	id.Data = encrypted
+	id.Salt = salt
+	config.Identities[name] = *id
+
 	return nil
 }
 
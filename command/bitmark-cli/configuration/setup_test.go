@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// derived identities share a seed but have distinct accounts
+func TestAddDerivedIdentity(t *testing.T) {
+
+	seed := "9J877LVjhr3Xxd2nGzRVRVNUZpSKJF4TH"
+	password := "derived password"
+
+	config := &Configuration{
+		Identities: map[string]Identity{},
+	}
+
+	err := config.AddIdentity("root", "root key", seed, "", password)
+	if nil != err {
+		t.Fatalf("add root error: %s", err)
+	}
+	err = config.AddIdentity("derived", "derived key", seed, "m/1'", password)
+	if nil != err {
+		t.Fatalf("add derived error: %s", err)
+	}
+
+	err = config.AddIdentity("derived", "again", seed, "m/2'", password)
+	if fault.IdentityNameAlreadyExists != err {
+		t.Errorf("duplicate error: %v  expected: %s", err, fault.IdentityNameAlreadyExists)
+	}
+
+	root, err := config.Private(password, "root")
+	if nil != err {
+		t.Fatalf("root private error: %s", err)
+	}
+	derived, err := config.Private(password, "derived")
+	if nil != err {
+		t.Fatalf("derived private error: %s", err)
+	}
+
+	if "m/1'" != derived.Path {
+		t.Errorf("derived path: %q", derived.Path)
+	}
+	if seed != derived.Seed {
+		t.Errorf("derived seed: %q  expected: %q", derived.Seed, seed)
+	}
+
+	expected, err := account.PrivateKeyFromBase58SeedPath(seed, "m/1'")
+	if nil != err {
+		t.Fatalf("derive error: %s", err)
+	}
+	if expected.Account().String() != derived.PrivateKey.Account().String() {
+		t.Errorf("derived account: %s  expected: %s", derived.PrivateKey.Account(), expected.Account())
+	}
+	if config.Identities["derived"].Account != expected.Account().String() {
+		t.Errorf("stored account: %s  expected: %s", config.Identities["derived"].Account, expected.Account())
+	}
+	if root.PrivateKey.Account().String() == derived.PrivateKey.Account().String() {
+		t.Error("derived account is the same as root account")
+	}
+}
+
+// a version 1 entry as written by earlier releases
+func makeVersion1Identity(t *testing.T, seed string, password string) Identity {
+	salt, secretKey, err := hashPassword(password)
+	if nil != err {
+		t.Fatalf("hash error: %s", err)
+	}
+	encrypted, err := encryptData(seed, secretKey)
+	if nil != err {
+		t.Fatalf("encrypt error: %s", err)
+	}
+	private, err := account.PrivateKeyFromBase58Seed(seed)
+	if nil != err {
+		t.Fatalf("seed error: %s", err)
+	}
+	return Identity{
+		Description: "old identity",
+		Account:     private.Account().String(),
+		Data:        encrypted,
+		Salt:        salt.String(),
+	}
+}
+
+func TestChangePasswordMigrates(t *testing.T) {
+
+	seed := "9J877LVjhr3Xxd2nGzRVRVNUZpSKJF4TH"
+
+	config := &Configuration{
+		Identities: map[string]Identity{
+			"old": makeVersion1Identity(t, seed, "old password"),
+		},
+	}
+
+	if !config.Identities["old"].NeedsUpgrade() {
+		t.Fatal("version 1 identity does not need upgrade")
+	}
+
+	private, err := config.Private("old password", "old")
+	if nil != err {
+		t.Fatalf("version 1 private error: %s", err)
+	}
+
+	err = config.ChangePassword("old", private, "new password")
+	if nil != err {
+		t.Fatalf("change password error: %s", err)
+	}
+
+	id := config.Identities["old"]
+	if CurrentIdentityVersion != id.EncryptionVersion() || id.NeedsUpgrade() {
+		t.Errorf("version: %d  expected: %d", id.EncryptionVersion(), CurrentIdentityVersion)
+	}
+
+	_, err = config.Private("old password", "old")
+	if fault.WrongPassword != err {
+		t.Errorf("old password error: %v  expected: %s", err, fault.WrongPassword)
+	}
+	migrated, err := config.Private("new password", "old")
+	if nil != err {
+		t.Fatalf("new password error: %s", err)
+	}
+	if seed != migrated.Seed || private.Account().String() != migrated.Account().String() {
+		t.Errorf("migrated identity does not match: %s", migrated.Account())
+	}
+
+	// ciphertext is bound to the account
+	id.Account = "fXXHGtCdFPuQvNhJ4nDPKCdwPxH7aSZ4842n2katZi319NsaCs"
+	config.Identities["old"] = id
+	_, err = config.Private("new password", "old")
+	if fault.WrongPassword != err {
+		t.Errorf("moved account error: %v  expected: %s", err, fault.WrongPassword)
+	}
+}
+
+func TestUnknownIdentityVersion(t *testing.T) {
+	config := &Configuration{
+		Identities: map[string]Identity{},
+	}
+	err := config.AddIdentity("id", "future", "9J877LVjhr3Xxd2nGzRVRVNUZpSKJF4TH", "", "password")
+	if nil != err {
+		t.Fatalf("add error: %s", err)
+	}
+	id := config.Identities["id"]
+	id.Version = CurrentIdentityVersion + 1
+	config.Identities["id"] = id
+
+	_, err = config.Private("password", "id")
+	if fault.InvalidIdentityVersion != err {
+		t.Errorf("error: %v  expected: %s", err, fault.InvalidIdentityVersion)
+	}
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// Signer - produces signatures for an identity whose private key is
+// not in the configuration file, e.g. a hardware wallet
+type Signer interface {
+	Account() *account.Account
+	Sign(message []byte) ([]byte, error)
+}
+
+// Account - the account that signs for this identity
+func (private *Private) Account() *account.Account {
+	if nil != private.Signer {
+		return private.Signer.Account()
+	}
+	return private.PrivateKey.Account()
+}
+
+// Sign - sign a message with the private key or the external signer
+func (private *Private) Sign(message []byte) ([]byte, error) {
+	if nil != private.Signer {
+		return private.Signer.Sign(message)
+	}
+	if nil == private.PrivateKey {
+		return nil, fault.NotPrivateKey
+	}
+	return ed25519.Sign(private.PrivateKey.PrivateKeyBytes(), message), nil
+}
+
+// HasSigner - true if the identity signs with an external program
+func (identity Identity) HasSigner() bool {
+	return "" != identity.Signer
+}
+
+// AddSignerIdentity - store an identity whose key is held by an
+// external signer program
+func (config *Configuration) AddSignerIdentity(name string, description string, acc string, signer string) error {
+
+	if _, ok := config.Identities[name]; ok {
+		return fault.IdentityNameAlreadyExists
+	}
+
+	a, err := account.AccountFromBase58(acc)
+	if nil != err {
+		return err
+	}
+	if a.IsTesting() != config.TestNet {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	config.Identities[name] = Identity{
+		Description: description,
+		Account:     acc,
+		Signer:      signer,
+		Data:        "",
+		Salt:        "",
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+func TestAddSignerIdentityNetwork(t *testing.T) {
+	publicKey := make([]byte, 32)
+	publicKey[0] = 1
+
+	testAccount := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: publicKey,
+		},
+	}
+	liveAccount := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      false,
+			PublicKey: publicKey,
+		},
+	}
+
+	config := &Configuration{
+		TestNet:    true,
+		Identities: map[string]Identity{},
+	}
+
+	err := config.AddSignerIdentity("live", "live key", liveAccount.String(), "signer")
+	if fault.WrongNetworkForPublicKey != err {
+		t.Errorf("add live account error: %v  expected: %s", err, fault.WrongNetworkForPublicKey)
+	}
+	if _, ok := config.Identities["live"]; ok {
+		t.Error("live account was added")
+	}
+
+	err = config.AddSignerIdentity("test", "test key", testAccount.String(), "signer")
+	if nil != err {
+		t.Fatalf("add test account error: %s", err)
+	}
+	if !config.Identities["test"].HasSigner() {
+		t.Error("identity has no signer")
+	}
+}
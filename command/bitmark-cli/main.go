@@ -20,6 +20,7 @@ type metadata struct {
 	file             string
 	config           *configuration.Configuration
 	connectionOffset int
+	quorum           int
 	save             bool
 	testnet          bool
 	verbose          bool
@@ -60,6 +61,11 @@ func main() {
 			Value: 0,
 			Usage: " connection offset `N` [0]",
 		},
+		cli.IntFlag{
+			Name:  "quorum",
+			Value: 0,
+			Usage: " read provenance, owned and balance from `N` nodes and report any disagreement",
+		},
 		cli.StringFlag{
 			Name:  "identity, i",
 			Value: "",
@@ -132,6 +138,16 @@ func main() {
 					Value: "",
 					Usage: "+add read-only `ACCOUNT`",
 				},
+				cli.StringFlag{
+					Name:  "derive, D",
+					Value: "",
+					Usage: " use the hardened derived account `INDEX` of the seed",
+				},
+				cli.StringFlag{
+					Name:  "signer, S",
+					Value: "",
+					Usage: "+external signer `PROGRAM` that holds the private key",
+				},
 			},
 			Action: runAdd,
 		},
@@ -164,6 +180,15 @@ func main() {
 					Value: 1,
 					Usage: " quantity to create `COUNT`",
 				},
+				cli.BoolFlag{
+					Name:  "watch, w",
+					Usage: " follow the issues until confirmed",
+				},
+				cli.DurationFlag{
+					Name:  "timeout, T",
+					Value: defaultWatchTimeout,
+					Usage: " stop watching after `DURATION`",
+				},
 			},
 			Action: runCreate,
 		},
@@ -186,6 +211,15 @@ func main() {
 					Name:  "unratified, u",
 					Usage: " perform an unratified transfer (default is output single signed hex)",
 				},
+				cli.BoolFlag{
+					Name:  "watch, w",
+					Usage: " follow an unratified transfer until confirmed",
+				},
+				cli.DurationFlag{
+					Name:  "timeout, T",
+					Value: defaultWatchTimeout,
+					Usage: " stop watching after `DURATION`",
+				},
 			},
 			Action: runTransfer,
 		},
@@ -261,6 +295,63 @@ func main() {
 			},
 			Action: runFullProvenance,
 		},
+		{
+			Name:      "export-provenance",
+			Usage:     "export a provenance chain with the block headers and merkle paths proving it",
+			ArgsUsage: "\n   (* = required, + = select one)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "txid, t",
+					Value: "",
+					Usage: "+transaction id to export provenance back from `TXID`",
+				},
+				cli.StringFlag{
+					Name:  "bitmarkid, b",
+					Value: "",
+					Usage: "+bitmark id to export full provenance `BITMARKID`",
+				},
+				cli.BoolFlag{
+					Name:  "sign, s",
+					Usage: " sign the bundle with the current identity",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Value: "",
+					Usage: " write the bundle to `FILE` default is standard output",
+				},
+			},
+			Action: runExportProvenance,
+		},
+		{
+			Name:      "verify-provenance",
+			Usage:     "verify an exported provenance bundle without connecting to a node",
+			ArgsUsage: "\n   (* = required, + = at least one)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Value: "",
+					Usage: "*provenance bundle `FILE`",
+				},
+				cli.StringSliceFlag{
+					Name:  "block, b",
+					Usage: "+trusted block hash, one of the bundle's blocks must match (repeatable) `DIGEST`",
+				},
+				cli.StringSliceFlag{
+					Name:  "signer, s",
+					Usage: "+trusted exporter, the bundle must be signed by one of them (repeatable) `ACCOUNT`",
+				},
+				cli.Float64Flag{
+					Name:  "min-difficulty, d",
+					Value: 1.0,
+					Usage: " lowest difficulty accepted for any block header `N`",
+				},
+				cli.BoolFlag{
+					Name:  "allow-local",
+					Usage: " accept a bundle from the local chain, its blocks have no proof of work",
+				},
+			},
+			Action: runVerifyProvenance,
+		},
 		{
 			Name:      "owned",
 			Usage:     "list bitmarks owned",
@@ -302,6 +393,24 @@ func main() {
 			},
 			Action: runShare,
 		},
+		{
+			Name:      "redeem",
+			Usage:     "convert every share of a bitmark back into the bitmark",
+			ArgsUsage: "\n   (* = required)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "txid, t",
+					Value: "",
+					Usage: "*transaction id of the share record `TXID`",
+				},
+				cli.Uint64Flag{
+					Name:  "quantity, q",
+					Value: 0,
+					Usage: "*total quantity of the share `NUMBER`",
+				},
+			},
+			Action: runRedeem,
+		},
 		{
 			Name:      "grant",
 			Usage:     "grant some shares of a bitmark to a receiver",
@@ -368,6 +477,310 @@ func main() {
 			},
 			Action: runSwap,
 		},
+		{
+			Name:      "atomicswap",
+			Usage:     "exchange bitmarks and shares with a receiver in one record",
+			ArgsUsage: "\n   (* = required)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "receiver, r",
+					Value: "",
+					Usage: "*identity name to swap with `ACCOUNT`",
+				},
+				cli.StringSliceFlag{
+					Name:  "give-bitmark",
+					Usage: " bitmark to give to the receiver (repeatable) `TXID`",
+				},
+				cli.StringSliceFlag{
+					Name:  "take-bitmark",
+					Usage: " bitmark to take from the receiver (repeatable) `TXID`",
+				},
+				cli.StringSliceFlag{
+					Name:  "give-share",
+					Usage: " shares to give to the receiver (repeatable) `SHAREID:QUANTITY`",
+				},
+				cli.StringSliceFlag{
+					Name:  "take-share",
+					Usage: " shares to take from the receiver (repeatable) `SHAREID:QUANTITY`",
+				},
+				cli.Uint64Flag{
+					Name:  "before-block, b",
+					Value: 0,
+					Usage: " must confirm before this block `NUMBER`",
+				},
+			},
+			Action: runAtomicSwap,
+		},
+		{
+			Name:  "build",
+			Usage: "build unsigned transactions into a file for offline signing",
+			Subcommands: []cli.Command{
+				{
+					Name:      "create",
+					Usage:     "build an asset and issues for the current identity",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "asset, a",
+							Value: "",
+							Usage: "*asset name `STRING`",
+						},
+						cli.StringFlag{
+							Name:  "metadata, m",
+							Value: "",
+							Usage: "*asset metadata `META`",
+						},
+						cli.StringFlag{
+							Name:  "fingerprint, f",
+							Value: "",
+							Usage: "*asset fingerprint `STRING`",
+						},
+						cli.IntFlag{
+							Name:  "quantity, q",
+							Value: 1,
+							Usage: " quantity to create `COUNT`",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildCreate,
+				},
+				{
+					Name:      "transfer",
+					Usage:     "build a transfer from the current identity",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "txid, t",
+							Value: "",
+							Usage: "*transaction id to transfer `TXID`",
+						},
+						cli.StringFlag{
+							Name:  "receiver, r",
+							Value: "",
+							Usage: "*identity name to receive the bitmark `ACCOUNT`",
+						},
+						cli.BoolFlag{
+							Name:  "unratified, u",
+							Usage: " build an unratified transfer (default is countersigned)",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildTransfer,
+				},
+				{
+					Name:      "share",
+					Usage:     "build a conversion of a bitmark into a share",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "txid, t",
+							Value: "",
+							Usage: "*transaction id to convert `TXID`",
+						},
+						cli.IntFlag{
+							Name:  "quantity, q",
+							Value: 0,
+							Usage: "*quantity to create `NUMBER`",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildShare,
+				},
+				{
+					Name:      "redeem",
+					Usage:     "build a redemption of every share of a bitmark",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "txid, t",
+							Value: "",
+							Usage: "*transaction id of the share record `TXID`",
+						},
+						cli.Uint64Flag{
+							Name:  "quantity, q",
+							Value: 0,
+							Usage: "*total quantity of the share `NUMBER`",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildRedeem,
+				},
+				{
+					Name:      "grant",
+					Usage:     "build a grant of some shares to a receiver",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "receiver, r",
+							Value: "",
+							Usage: "*identity name to receive the shares `ACCOUNT`",
+						},
+						cli.StringFlag{
+							Name:  "share-id, s",
+							Value: "",
+							Usage: "*transaction id of share `SHAREID`",
+						},
+						cli.Uint64Flag{
+							Name:  "quantity, q",
+							Value: 1,
+							Usage: " quantity to grant `NUMBER`",
+						},
+						cli.Uint64Flag{
+							Name:  "before-block, b",
+							Value: 0,
+							Usage: " must confirm before this block `NUMBER` (default is current height plus 1000)",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildGrant,
+				},
+				{
+					Name:      "swap",
+					Usage:     "build a swap of some shares with a receiver",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "receiver, r",
+							Value: "",
+							Usage: "*identity name to swap with `ACCOUNT`",
+						},
+						cli.StringFlag{
+							Name:  "share-id-one, s",
+							Value: "",
+							Usage: "*transaction id of share one `SHAREID`",
+						},
+						cli.Uint64Flag{
+							Name:  "quantity-one, q",
+							Value: 1,
+							Usage: " quantity of share one `NUMBER`",
+						},
+						cli.StringFlag{
+							Name:  "share-id-two, S",
+							Value: "",
+							Usage: "*transaction id of share two `SHAREID`",
+						},
+						cli.Uint64Flag{
+							Name:  "quantity-two, Q",
+							Value: 1,
+							Usage: " quantity of share two `NUMBER`",
+						},
+						cli.Uint64Flag{
+							Name:  "before-block, b",
+							Value: 0,
+							Usage: " must confirm before this block `NUMBER` (default is current height plus 1000)",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildSwap,
+				},
+				{
+					Name:      "blocktransfer",
+					Usage:     "build a transfer of block ownership",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "txid, t",
+							Value: "",
+							Usage: "*transaction id to transfer `TXID`",
+						},
+						cli.StringFlag{
+							Name:  "receiver, r",
+							Value: "",
+							Usage: "*identity name to receive the block `ACCOUNT`",
+						},
+						cli.StringFlag{
+							Name:  "bitcoin, b",
+							Value: "",
+							Usage: "*address receive the bitcoin payment `ADDRESS`",
+						},
+						cli.StringFlag{
+							Name:  "litecoin, l",
+							Value: "",
+							Usage: "*address to receive the litecoin payment `ADDRESS`",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "*transaction file to create or append to `FILE`",
+						},
+					},
+					Action: runBuildBlockTransfer,
+				},
+			},
+		},
+		{
+			Name:      "signtx",
+			Usage:     "sign the transactions in a file that are waiting for the current identity",
+			ArgsUsage: "\n   (* = required)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Value: "",
+					Usage: "*transaction file to sign `FILE`",
+				},
+				cli.StringFlag{
+					Name:  "output, o",
+					Value: "",
+					Usage: " file for the signed transactions `FILE` default is to overwrite the input",
+				},
+			},
+			Action: runSignTx,
+		},
+		{
+			Name:      "submit",
+			Usage:     "submit a file of fully signed transactions",
+			ArgsUsage: "\n   (* = required)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Value: "",
+					Usage: "*transaction file to submit `FILE`",
+				},
+			},
+			Action: runSubmit,
+		},
+		{
+			Name:      "batch",
+			Usage:     "issue, transfer and grant from a CSV or JSON lines file",
+			ArgsUsage: "\n   (* = required)",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Value: "",
+					Usage: "*operations file, \".csv\" or one JSON object per line `FILE`",
+				},
+				cli.StringFlag{
+					Name:  "results, r",
+					Value: "",
+					Usage: " per line results, a rerun skips lines that succeeded `FILE` default is FILE.result",
+				},
+			},
+			Action: runBatch,
+		},
 		{
 			Name:      "balance",
 			Usage:     "display balance of some shares",
@@ -401,6 +814,15 @@ func main() {
 					Value: "",
 					Usage: "*transaction id to check status `TXID`",
 				},
+				cli.BoolFlag{
+					Name:  "watch, w",
+					Usage: " follow the transaction until confirmed: exit 2 on timeout, 3 if dropped",
+				},
+				cli.DurationFlag{
+					Name:  "timeout, T",
+					Value: defaultWatchTimeout,
+					Usage: " stop watching after `DURATION`",
+				},
 			},
 			Action: runTransactionStatus,
 		},
@@ -419,6 +841,17 @@ func main() {
 			},
 			Action: runList,
 		},
+		{
+			Name:  "discover",
+			Usage: "list RPC nodes announced to the connected bitmarkd",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "add, a",
+					Usage: " add new nodes to the configured connections",
+				},
+			},
+			Action: runDiscover,
+		},
 		{
 			Name:   "bitmarkd",
 			Usage:  "display bitmarkd information",
@@ -475,6 +908,42 @@ func main() {
 			Usage:  "change an identity's password",
 			Action: runChangePassword,
 		},
+		{
+			Name:  "identity",
+			Usage: "move identities between machines in an encrypted bundle",
+			Subcommands: []cli.Command{
+				{
+					Name:      "export",
+					Usage:     "write the current identity to a new bundle file",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "file, f",
+							Value: "",
+							Usage: "*bundle `FILE` to create",
+						},
+						cli.BoolFlag{
+							Name:  "all, a",
+							Usage: " export all identities",
+						},
+					},
+					Action: runIdentityExport,
+				},
+				{
+					Name:      "import",
+					Usage:     "add the identities from a bundle file",
+					ArgsUsage: "\n   (* = required)",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "file, f",
+							Value: "",
+							Usage: "*bundle `FILE` to read",
+						},
+					},
+					Action: runIdentityImport,
+				},
+			},
+		},
 		{
 			Name:      "fingerprint",
 			Usage:     "fingerprint a file (version 01 SHA3-512 algorithm)",
@@ -556,7 +1025,7 @@ func main() {
 		// to suppress reading config params for certain commands
 		command := c.Args().Get(0)
 		switch command {
-		case "help", "h", "version":
+		case "help", "h", "version", "verify-provenance":
 			return nil
 		}
 
@@ -614,11 +1083,16 @@ func main() {
 			if connectionOffset < 0 || connectionOffset >= len(configuration.Connections) {
 				return fmt.Errorf("connection: %d outside: %d to %d", connectionOffset, 0, len(configuration.Connections)-1)
 			}
+			quorum := c.GlobalInt("quorum")
+			if quorum < 0 || quorum > len(configuration.Connections) {
+				return fmt.Errorf("quorum: %d outside: %d to %d", quorum, 0, len(configuration.Connections))
+			}
 
 			c.App.Metadata["config"] = &metadata{
 				file:             file,
 				config:           configuration,
 				connectionOffset: connectionOffset,
+				quorum:           quorum,
 				testnet:          configuration.TestNet,
 				save:             false,
 				verbose:          verbose,
@@ -652,6 +1126,9 @@ func main() {
 	err := app.Run(os.Args)
 	if nil != err {
 		fmt.Fprintf(app.ErrWriter, "terminated with error: %s\n", err)
+		if e, ok := err.(*watchError); ok {
+			os.Exit(e.code)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package offline - transaction files for signing on a host without
+// network access
+//
+// the workflow is:
+//  1. build: on a networked host create the unsigned records
+//  2. sign:  on the offline host add the signature of the identity
+//     (a countersigned record is signed a second time by the
+//     receiver, either offline or online)
+//  3. submit: from any host send the completed records to bitmarkd
+//
+// the file is JSON:
+//
+//	{
+//	  "format": "bitmark-transactions",
+//	  "version": 1,
+//	  "network": "testing",
+//	  "transactions": [
+//	    {
+//	      "type": "BitmarkTransferUnratified",
+//	      "signer": "<base58 account that makes the first signature>",
+//	      "packed": "<hex>"
+//	    }
+//	  ]
+//	}
+//
+// "packed" is a transactionrecord.Packed in hex.  Each missing
+// signature is replaced by the placeholder bytes 01 00 (a one byte
+// signature of zero) so the record still unpacks; the placeholder can
+// never be a valid Ed25519 signature.  "type" is informational, the
+// record type is always taken from the packed data.
+package offline
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package offline
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// constants for the file header
+const (
+	FileFormat  = "bitmark-transactions"
+	FileVersion = 1
+
+	networkLive    = "bitmark"
+	networkTesting = "testing"
+)
+
+// File - transactions moved between hosts
+type File struct {
+	Format       string  `json:"format"`
+	Version      int     `json:"version"`
+	Network      string  `json:"network"`
+	Transactions []Entry `json:"transactions"`
+}
+
+// Entry - one transaction in a file
+type Entry struct {
+	Type   string `json:"type"`
+	Signer string `json:"signer"`
+	Packed string `json:"packed"`
+}
+
+// New - create an empty file for a network
+func New(testnet bool) *File {
+	network := networkLive
+	if testnet {
+		network = networkTesting
+	}
+	return &File{
+		Format:       FileFormat,
+		Version:      FileVersion,
+		Network:      network,
+		Transactions: []Entry{},
+	}
+}
+
+// Read - load and check a transaction file
+func Read(fileName string) (*File, error) {
+	f, err := os.Open(fileName)
+	if nil != err {
+		return nil, err
+	}
+	defer f.Close()
+
+	file := &File{}
+	err = json.NewDecoder(f).Decode(file)
+	if nil != err {
+		return nil, err
+	}
+
+	if FileFormat != file.Format || FileVersion != file.Version {
+		return nil, fault.InvalidTransactionFile
+	}
+	if networkLive != file.Network && networkTesting != file.Network {
+		return nil, fault.InvalidTransactionFile
+	}
+
+	return file, nil
+}
+
+// Write - save the file, replacing any existing file of the same name
+func (file *File) Write(fileName string) error {
+	tempFile := fileName + ".new"
+
+	f, err := os.OpenFile(tempFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if nil != err {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(file)
+	if nil != err {
+		f.Close()
+		os.Remove(tempFile)
+		return err
+	}
+
+	err = f.Close()
+	if nil != err {
+		os.Remove(tempFile)
+		return err
+	}
+
+	return os.Rename(tempFile, fileName)
+}
+
+// IsTesting - true for files of the test network
+func (file *File) IsTesting() bool {
+	return networkTesting == file.Network
+}
+
+// Add - append a record, signed or not, to the file
+//
+// signer is the account that makes the first signature; it is only
+// needed for records where it is not part of the record itself
+func (file *File) Add(tx transactionrecord.Transaction, signer *account.Account) error {
+	record := &Record{
+		Transaction: tx,
+		Signer:      signer,
+	}
+	signer = record.signer()
+	if nil == signer {
+		return fault.InvalidOwnerOrRegistrant
+	}
+	if signer.IsTesting() != file.IsTesting() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	packed, err := record.Pack()
+	if nil != err {
+		return err
+	}
+
+	name, _ := transactionrecord.RecordName(tx)
+	file.Transactions = append(file.Transactions, Entry{
+		Type:   name,
+		Signer: signer.String(),
+		Packed: hex.EncodeToString(packed),
+	})
+	return nil
+}
+
+// Records - decode all the records of the file
+func (file *File) Records() ([]*Record, error) {
+	records := make([]*Record, len(file.Transactions))
+	for i, entry := range file.Transactions {
+		r, err := decode(entry, file.IsTesting())
+		if nil != err {
+			return nil, err
+		}
+		records[i] = r
+	}
+	return records, nil
+}
+
+// Update - replace the entries with the current state of the records
+func (file *File) Update(records []*Record) error {
+	entries := make([]Entry, len(records))
+	for i, r := range records {
+		packed, err := r.Pack()
+		if nil != err {
+			return err
+		}
+		name, _ := transactionrecord.RecordName(r.Transaction)
+		entries[i] = Entry{
+			Type:   name,
+			Signer: r.signer().String(),
+			Packed: hex.EncodeToString(packed),
+		}
+	}
+	file.Transactions = entries
+	return nil
+}
+
+// decode a single entry
+func decode(entry Entry, testnet bool) (*Record, error) {
+	packed, err := hex.DecodeString(entry.Packed)
+	if nil != err {
+		return nil, err
+	}
+
+	tx, n, err := transactionrecord.Packed(packed).Unpack(testnet)
+	if nil != err {
+		return nil, err
+	}
+	if n != len(packed) {
+		return nil, fault.InvalidTransactionFile
+	}
+
+	signer, err := account.AccountFromBase58(entry.Signer)
+	if nil != err {
+		return nil, err
+	}
+
+	r := &Record{
+		Transaction: tx,
+		Signer:      signer,
+	}
+	err = r.removePlaceholders()
+	if nil != err {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package offline_test
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/offline"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+func newKey(t *testing.T) *configuration.Private {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if nil != err {
+		t.Fatalf("generate key error: %s", err)
+	}
+	return &configuration.Private{
+		PrivateKey: &account.PrivateKey{
+			PrivateKeyInterface: &account.ED25519PrivateKey{
+				Test:       true,
+				PrivateKey: private,
+			},
+		},
+	}
+}
+
+func link() merkle.Digest {
+	return merkle.NewDigest([]byte("offline test link"))
+}
+
+// reload a file through its JSON encoding
+func roundTrip(t *testing.T, file *offline.File) (*offline.File, []*offline.Record) {
+	dir, err := ioutil.TempDir("", "offline")
+	if nil != err {
+		t.Fatalf("temp dir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "tx.json")
+	err = file.Write(fileName)
+	if nil != err {
+		t.Fatalf("write error: %s", err)
+	}
+
+	file, err = offline.Read(fileName)
+	if nil != err {
+		t.Fatalf("read error: %s", err)
+	}
+	records, err := file.Records()
+	if nil != err {
+		t.Fatalf("records error: %s", err)
+	}
+	return file, records
+}
+
+func TestUnratifiedTransfer(t *testing.T) {
+	owner := newKey(t)
+	receiver := newKey(t)
+
+	file := offline.New(true)
+	err := file.Add(&transactionrecord.BitmarkTransferUnratified{
+		Link:  link(),
+		Owner: receiver.Account(),
+	}, owner.Account())
+	if nil != err {
+		t.Fatalf("add error: %s", err)
+	}
+
+	file, records := roundTrip(t, file)
+	if 1 != len(records) {
+		t.Fatalf("records: %d  expected: 1", len(records))
+	}
+	r := records[0]
+	if r.IsComplete() {
+		t.Fatal("unsigned record is complete")
+	}
+	if r.NextSigner().String() != owner.Account().String() {
+		t.Errorf("next signer: %s  expected: %s", r.NextSigner(), owner.Account())
+	}
+
+	err = r.Sign(receiver)
+	if fault.WrongSigningIdentity != err {
+		t.Errorf("sign by receiver error: %v  expected: %s", err, fault.WrongSigningIdentity)
+	}
+
+	err = r.Sign(owner)
+	if nil != err {
+		t.Fatalf("sign error: %s", err)
+	}
+	if !r.IsComplete() {
+		t.Fatal("signed record is not complete")
+	}
+
+	err = r.Sign(owner)
+	if fault.TransactionAlreadySigned != err {
+		t.Errorf("second sign error: %v  expected: %s", err, fault.TransactionAlreadySigned)
+	}
+
+	err = file.Update(records)
+	if nil != err {
+		t.Fatalf("update error: %s", err)
+	}
+	_, records = roundTrip(t, file)
+	if !records[0].IsComplete() {
+		t.Fatal("reloaded record is not complete")
+	}
+
+	// the completed record must be acceptable to bitmarkd
+	_, err = records[0].Transaction.Pack(owner.Account())
+	if nil != err {
+		t.Errorf("pack error: %s", err)
+	}
+}
+
+func TestCountersignedTransfer(t *testing.T) {
+	owner := newKey(t)
+	receiver := newKey(t)
+
+	file := offline.New(true)
+	err := file.Add(&transactionrecord.BitmarkTransferCountersigned{
+		Link:  link(),
+		Owner: receiver.Account(),
+	}, owner.Account())
+	if nil != err {
+		t.Fatalf("add error: %s", err)
+	}
+
+	file, records := roundTrip(t, file)
+	err = records[0].Sign(owner)
+	if nil != err {
+		t.Fatalf("owner sign error: %s", err)
+	}
+	if records[0].IsComplete() {
+		t.Fatal("single signed record is complete")
+	}
+
+	err = file.Update(records)
+	if nil != err {
+		t.Fatalf("update error: %s", err)
+	}
+	file, records = roundTrip(t, file)
+
+	r := records[0]
+	if r.NextSigner().String() != receiver.Account().String() {
+		t.Errorf("next signer: %s  expected: %s", r.NextSigner(), receiver.Account())
+	}
+	err = r.Sign(owner)
+	if fault.WrongSigningIdentity != err {
+		t.Errorf("sign by owner error: %v  expected: %s", err, fault.WrongSigningIdentity)
+	}
+	err = r.Sign(receiver)
+	if nil != err {
+		t.Fatalf("receiver sign error: %s", err)
+	}
+	if !r.IsComplete() {
+		t.Fatal("countersigned record is not complete")
+	}
+
+	_, err = r.Transaction.Pack(owner.Account())
+	if nil != err {
+		t.Errorf("pack error: %s", err)
+	}
+}
+
+func TestAssetAndIssue(t *testing.T) {
+	registrant := newKey(t)
+
+	asset := &transactionrecord.AssetData{
+		Name:        "offline asset",
+		Fingerprint: "01deadbeef",
+		Metadata:    "k\u0000v",
+		Registrant:  registrant.Account(),
+	}
+	issue := &transactionrecord.BitmarkIssue{
+		AssetId: asset.AssetId(),
+		Owner:   registrant.Account(),
+		Nonce:   0,
+	}
+
+	file := offline.New(true)
+	for _, tx := range []transactionrecord.Transaction{asset, issue} {
+		err := file.Add(tx, nil)
+		if nil != err {
+			t.Fatalf("add error: %s", err)
+		}
+	}
+
+	_, records := roundTrip(t, file)
+	for i, r := range records {
+		err := r.Sign(registrant)
+		if nil != err {
+			t.Fatalf("%d: sign error: %s", i, err)
+		}
+		if !r.IsComplete() {
+			t.Errorf("%d: record is not complete", i)
+		}
+	}
+}
+
+func TestWrongNetwork(t *testing.T) {
+	owner := newKey(t)
+
+	file := offline.New(false)
+	err := file.Add(&transactionrecord.BitmarkTransferUnratified{
+		Link:  link(),
+		Owner: owner.Account(),
+	}, owner.Account())
+	if fault.WrongNetworkForPublicKey != err {
+		t.Errorf("add error: %v  expected: %s", err, fault.WrongNetworkForPublicKey)
+	}
+}
+
+func TestInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offline")
+	if nil != err {
+		t.Fatalf("temp dir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "bad.json")
+	err = ioutil.WriteFile(fileName, []byte(`{"format":"something-else","version":1,"network":"testing"}`), 0600)
+	if nil != err {
+		t.Fatalf("write error: %s", err)
+	}
+
+	_, err = offline.Read(fileName)
+	if fault.InvalidTransactionFile != err {
+		t.Errorf("read error: %v  expected: %s", err, fault.InvalidTransactionFile)
+	}
+}
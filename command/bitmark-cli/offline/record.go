@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package offline
+
+import (
+	"bytes"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// stands in for a missing signature: varint length 1, one zero byte
+var placeholder = []byte{0x01, 0x00}
+
+// Record - a transaction and the account that makes its first signature
+type Record struct {
+	Transaction transactionrecord.Transaction
+	Signer      *account.Account
+}
+
+// the signature fields of a record
+type signatures struct {
+	signature        *account.Signature
+	countersignature *account.Signature // nil if not countersigned
+	countersigner    *account.Account
+}
+
+func signaturesOf(tx transactionrecord.Transaction) (signatures, error) {
+	switch r := tx.(type) {
+	case *transactionrecord.AssetData:
+		return signatures{signature: &r.Signature}, nil
+	case *transactionrecord.BitmarkIssue:
+		return signatures{signature: &r.Signature}, nil
+	case *transactionrecord.BitmarkTransferUnratified:
+		return signatures{signature: &r.Signature}, nil
+	case *transactionrecord.BitmarkShare:
+		return signatures{signature: &r.Signature}, nil
+	case *transactionrecord.ShareRedemption:
+		return signatures{signature: &r.Signature}, nil
+	case *transactionrecord.BitmarkTransferCountersigned:
+		return signatures{&r.Signature, &r.Countersignature, r.Owner}, nil
+	case *transactionrecord.BlockOwnerTransfer:
+		return signatures{&r.Signature, &r.Countersignature, r.Owner}, nil
+	case *transactionrecord.ShareGrant:
+		return signatures{&r.Signature, &r.Countersignature, r.Recipient}, nil
+	case *transactionrecord.ShareSwap:
+		return signatures{&r.Signature, &r.Countersignature, r.OwnerTwo}, nil
+	case *transactionrecord.AtomicSwap:
+		return signatures{&r.Signature, &r.Countersignature, r.OwnerTwo}, nil
+	default:
+		return signatures{}, fault.UnexpectedTransactionRecord
+	}
+}
+
+// the account for the first signature, taken from the record where
+// possible; grant, swap and redemption must use the record's own account pointer
+// for packing
+func (r *Record) signer() *account.Account {
+	switch tx := r.Transaction.(type) {
+	case *transactionrecord.AssetData:
+		return tx.Registrant
+	case *transactionrecord.BitmarkIssue:
+		return tx.Owner
+	case *transactionrecord.ShareGrant:
+		return tx.Owner
+	case *transactionrecord.ShareSwap:
+		return tx.OwnerOne
+	case *transactionrecord.AtomicSwap:
+		return tx.OwnerOne
+	case *transactionrecord.ShareRedemption:
+		return tx.Owner
+	default:
+		return r.Signer
+	}
+}
+
+// NextSigner - the account that must sign next, nil when complete
+func (r *Record) NextSigner() *account.Account {
+	s, err := signaturesOf(r.Transaction)
+	if nil != err {
+		return nil
+	}
+	if 0 == len(*s.signature) {
+		return r.signer()
+	}
+	if nil != s.countersignature && 0 == len(*s.countersignature) {
+		return s.countersigner
+	}
+	return nil
+}
+
+// IsComplete - true if all signatures are present
+func (r *Record) IsComplete() bool {
+	return nil == r.NextSigner()
+}
+
+// Pack - encode the record with placeholders for missing signatures
+func (r *Record) Pack() (transactionrecord.Packed, error) {
+	s, err := signaturesOf(r.Transaction)
+	if nil != err {
+		return nil, err
+	}
+	signer := r.signer()
+	if nil == signer {
+		return nil, fault.InvalidOwnerOrRegistrant
+	}
+
+	packed, err := r.Transaction.Pack(signer)
+	if nil == err {
+		return packed, nil
+	}
+	if fault.InvalidSignature != err {
+		return nil, err
+	}
+
+	// no signatures yet
+	if 0 == len(*s.signature) {
+		packed = append(packed, placeholder...)
+		if nil != s.countersignature {
+			if 0 != len(*s.countersignature) {
+				return nil, fault.InvalidSignature
+			}
+			packed = append(packed, placeholder...)
+		}
+		return packed, nil
+	}
+
+	// a signature is present so it must be the countersignature
+	// that is missing and the signature must be valid
+	if nil == s.countersignature || 0 != len(*s.countersignature) {
+		return nil, fault.InvalidSignature
+	}
+
+	signature := *s.signature
+	*s.signature = nil
+	message, _ := r.Transaction.Pack(signer)
+	*s.signature = signature
+
+	err = signer.CheckSignature(message, signature)
+	if nil != err {
+		return nil, err
+	}
+
+	return append(packed, placeholder...), nil
+}
+
+// convert placeholders back to missing signatures and check the signer
+// matches any account in the record
+func (r *Record) removePlaceholders() error {
+	s, err := signaturesOf(r.Transaction)
+	if nil != err {
+		return err
+	}
+
+	if isPlaceholder(*s.signature) {
+		*s.signature = nil
+	}
+	if nil != s.countersignature && isPlaceholder(*s.countersignature) {
+		*s.countersignature = nil
+	}
+
+	signer := r.signer()
+	if nil == signer || nil == r.Signer || !bytes.Equal(signer.Bytes(), r.Signer.Bytes()) {
+		return fault.InvalidTransactionFile
+	}
+
+	// ensure the signatures that are present are valid
+	_, err = r.Pack()
+	return err
+}
+
+func isPlaceholder(signature account.Signature) bool {
+	return 1 == len(signature) && 0 == signature[0]
+}
+
+// Signer - anything that can sign for an account
+type Signer interface {
+	Account() *account.Account
+	Sign(message []byte) ([]byte, error)
+}
+
+// Sign - add the next missing signature
+//
+// the signer must be the next signer of the record
+func (r *Record) Sign(signer Signer) error {
+	s, err := signaturesOf(r.Transaction)
+	if nil != err {
+		return err
+	}
+
+	next := r.NextSigner()
+	if nil == next {
+		return fault.TransactionAlreadySigned
+	}
+	if !bytes.Equal(next.Bytes(), signer.Account().Bytes()) {
+		return fault.WrongSigningIdentity
+	}
+
+	// the message is the packed record up to the missing signature
+	message, err := r.Transaction.Pack(r.signer())
+	if fault.InvalidSignature != err {
+		if nil == err {
+			return fault.TransactionAlreadySigned
+		}
+		return err
+	}
+
+	signature, err := signer.Sign(message)
+	if nil != err {
+		return err
+	}
+	if 0 == len(*s.signature) {
+		*s.signature = signature
+	} else {
+		*s.countersignature = signature
+	}
+
+	_, err = r.Pack()
+	return err
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// perform a read on one node, or on several if --quorum was given
+//
+// with a quorum the most common answer is returned; if any node
+// disagreed or failed the report is written to the error stream and
+// the answer is returned together with fault.QuorumNotReached
+func quorumRead(m *metadata, read func(*rpccalls.Client) (interface{}, error)) (interface{}, error) {
+
+	if m.quorum < 2 {
+		client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+		if nil != err {
+			return nil, err
+		}
+		defer client.Close()
+
+		return read(client)
+	}
+
+	answer, report, err := rpccalls.Quorum(m.testnet, m.config.Connections, m.connectionOffset, m.quorum, m.verbose, m.e, read)
+
+	if m.verbose || !report.Agreed {
+		fmt.Fprintf(m.e, "quorum: %d of %d nodes agree\n", report.Votes, len(report.Nodes))
+		printJson(m.e, report)
+	}
+	if nil != err {
+		return nil, err
+	}
+	if !report.Agreed {
+		return answer, fault.QuorumNotReached
+	}
+	return answer, nil
+}
@@ -8,8 +8,6 @@ package rpccalls
 import (
 	"fmt"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/rpc/assets"
@@ -44,7 +42,7 @@ func (client *Client) MakeAsset(assetConfig *AssetData) (*AssetResult, error) {
 	client.printJson("Asset Get Request", getArgs)
 
 	var getReply assets.GetReply
-	if err := client.client.Call("Assets.Get", &getArgs, &getReply); nil != err {
+	if err := client.call("Assets.Get", &getArgs, &getReply); nil != err {
 		return nil, err
 	}
 
@@ -87,7 +85,33 @@ func (client *Client) MakeAsset(assetConfig *AssetData) (*AssetResult, error) {
 
 	client.printJson("Asset Get Reply", getReply)
 
-	registrant := assetConfig.Registrant.PrivateKey.Account()
+	r, err := SignAsset(assetConfig)
+	if nil != err {
+		return nil, err
+	}
+
+	client.printJson("Asset Request", r)
+
+	args := bitmarks.CreateArguments{
+		Assets: []*transactionrecord.AssetData{r},
+		Issues: nil,
+	}
+
+	var reply bitmarks.CreateReply
+	if err := client.call("Bitmarks.Create", &args, &reply); nil != err {
+		return nil, err
+	}
+
+	client.printJson("Asset Reply", reply)
+
+	result.AssetId = reply.Assets[0].AssetId
+	return result, nil
+}
+
+// SignAsset - build a properly signed asset record
+func SignAsset(assetConfig *AssetData) (*transactionrecord.AssetData, error) {
+
+	registrant := assetConfig.Registrant.Account()
 	r := transactionrecord.AssetData{
 		Name:        assetConfig.Name,
 		Fingerprint: assetConfig.Fingerprint,
@@ -103,28 +127,15 @@ func (client *Client) MakeAsset(assetConfig *AssetData) (*AssetResult, error) {
 	}
 
 	// manually sign the record and attach signature
-	signature := ed25519.Sign(assetConfig.Registrant.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := assetConfig.Registrant.Sign(packed)
+	if nil != err {
+		return nil, err
+	}
 	r.Signature = signature[:]
 
 	// check that signature is correct by packing again
 	if _, err = r.Pack(registrant); nil != err {
 		return nil, err
 	}
-
-	client.printJson("Asset Request", r)
-
-	args := bitmarks.CreateArguments{
-		Assets: []*transactionrecord.AssetData{&r},
-		Issues: nil,
-	}
-
-	var reply bitmarks.CreateReply
-	if err := client.client.Call("Bitmarks.Create", &args, &reply); nil != err {
-		return nil, err
-	}
-
-	client.printJson("Asset Reply", reply)
-
-	result.AssetId = reply.Assets[0].AssetId
-	return result, nil
+	return &r, nil
 }
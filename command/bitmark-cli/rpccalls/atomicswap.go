@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpccalls
+
+import (
+	"encoding/hex"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/rpc/share"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// AtomicSwapShare - quantity of a share moved by one leg
+type AtomicSwapShare struct {
+	ShareId  string
+	Quantity uint64
+}
+
+// AtomicSwapData - data for an atomic swap request, give legs go from
+// the first owner to the second and take legs come back
+type AtomicSwapData struct {
+	GiveBitmarks []string
+	TakeBitmarks []string
+	GiveShares   []AtomicSwapShare
+	TakeShares   []AtomicSwapShare
+	OwnerOne     *configuration.Private
+	OwnerTwo     *account.Account
+	BeforeBlock  uint64
+}
+
+// AtomicSwap - make the first signature of an atomic swap request
+func (client *Client) AtomicSwap(swapConfig *AtomicSwapData) (*SwapSingleSignedReply, error) {
+
+	ownerOne := swapConfig.OwnerOne.Account()
+	ownerTwo := swapConfig.OwnerTwo
+
+	r := transactionrecord.AtomicSwap{
+		OwnerOne: ownerOne,
+		OwnerTwo: ownerTwo,
+	}
+
+	addBitmarks := func(links []string, owner *account.Account, recipient *account.Account) error {
+		for _, l := range links {
+			var link merkle.Digest
+			err := link.UnmarshalText([]byte(l))
+			if nil != err {
+				return err
+			}
+			r.Bitmarks = append(r.Bitmarks, transactionrecord.SwapBitmarkLeg{
+				Link:      link,
+				Owner:     owner,
+				Recipient: recipient,
+			})
+		}
+		return nil
+	}
+	addShares := func(shares []AtomicSwapShare, owner *account.Account, recipient *account.Account) error {
+		for _, s := range shares {
+			var shareId merkle.Digest
+			err := shareId.UnmarshalText([]byte(s.ShareId))
+			if nil != err {
+				return err
+			}
+			r.Shares = append(r.Shares, transactionrecord.SwapShareLeg{
+				ShareId:   shareId,
+				Quantity:  s.Quantity,
+				Owner:     owner,
+				Recipient: recipient,
+			})
+		}
+		return nil
+	}
+
+	if err := addBitmarks(swapConfig.GiveBitmarks, ownerOne, ownerTwo); nil != err {
+		return nil, err
+	}
+	if err := addBitmarks(swapConfig.TakeBitmarks, ownerTwo, ownerOne); nil != err {
+		return nil, err
+	}
+	if err := addShares(swapConfig.GiveShares, ownerOne, ownerTwo); nil != err {
+		return nil, err
+	}
+	if err := addShares(swapConfig.TakeShares, ownerTwo, ownerOne); nil != err {
+		return nil, err
+	}
+
+	if 0 == swapConfig.BeforeBlock {
+		info, err := client.GetBitmarkInfo()
+		if nil != err {
+			return nil, err
+		}
+		swapConfig.BeforeBlock = info.Block.Height + 100 // allow plenty of time to mine
+	}
+	r.BeforeBlock = swapConfig.BeforeBlock
+
+	packed, err := makeAtomicSwapOneSignature(&r, swapConfig.OwnerOne)
+	if nil != err {
+		return nil, err
+	}
+
+	client.printJson("Atomic Swap Request", r)
+
+	response := SwapSingleSignedReply{
+		Identity: ownerOne.String(),
+		Swap:     hex.EncodeToString(packed),
+	}
+
+	return &response, nil
+}
+
+// CountersignAtomicSwap - submit a countersigned atomic swap
+func (client *Client) CountersignAtomicSwap(swap *transactionrecord.AtomicSwap) (*SwapReply, error) {
+
+	client.printJson("Atomic Swap Request", swap)
+
+	var reply share.AtomicSwapReply
+	err := client.call("Share.AtomicSwap", swap, &reply)
+	if nil != err {
+		return nil, err
+	}
+
+	tpid, err := reply.PayId.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+
+	commands := make(map[string]string)
+	for _, payment := range reply.Payments {
+		currency := payment[0].Currency
+		commands[currency.String()] = paymentCommand(client.testnet, currency, string(tpid), payment)
+	}
+
+	client.printJson("Atomic Swap Reply", reply)
+
+	// make response
+	response := SwapReply{
+		SwapId:   reply.TxId,
+		PayId:    reply.PayId,
+		Payments: reply.Payments,
+		Commands: commands,
+	}
+
+	return &response, nil
+}
+
+func makeAtomicSwapOneSignature(r *transactionrecord.AtomicSwap, ownerOne *configuration.Private) ([]byte, error) {
+
+	// pack without signature
+	packed, err := r.Pack(r.OwnerOne)
+	if nil == err {
+		return nil, fault.MakeSwapFailed
+	} else if fault.InvalidSignature != err {
+		return nil, err
+	}
+
+	// attach signature
+	signature, err := ownerOne.Sign(packed)
+	if nil != err {
+		return nil, err
+	}
+	r.Signature = signature[:]
+
+	// include first signature by packing again
+	packed, err = r.Pack(r.OwnerOne)
+	if nil == err {
+		return nil, fault.MakeSwapFailed
+	} else if fault.InvalidSignature != err {
+		return nil, err
+	}
+	return packed, nil
+}
@@ -37,7 +37,7 @@ func (client *Client) GetBalance(balanceConfig *BalanceData) (*share.BalanceRepl
 	client.printJson("Balance Request", balanceArgs)
 
 	reply := &share.BalanceReply{}
-	err := client.client.Call("Share.Balance", balanceArgs, reply)
+	err := client.call("Share.Balance", balanceArgs, reply)
 	if nil != err {
 		return nil, err
 	}
@@ -24,7 +24,7 @@ func (client *Client) DecodeBlock(blockDecodeConfig *BlockDecodeData) (*node.Blo
 	client.printJson("BlockDecode Request", blockDecodeArgs)
 
 	reply := &node.BlockDecodeReply{}
-	err := client.client.Call("Node.BlockDecode", blockDecodeArgs, reply)
+	err := client.call("Node.BlockDecode", blockDecodeArgs, reply)
 	if nil != err {
 		return nil, err
 	}
@@ -28,7 +28,7 @@ func (client *Client) GetBlocks(blockDumpConfig *BlockDumpData) (*node.BlockDump
 	client.printJson("BlockDump Request", blockDumpArgs)
 
 	reply := &node.BlockDumpRangeReply{}
-	err := client.client.Call("Node.BlockDumpRange", blockDumpArgs, reply)
+	err := client.call("Node.BlockDumpRange", blockDumpArgs, reply)
 	if nil != err {
 		return nil, err
 	}
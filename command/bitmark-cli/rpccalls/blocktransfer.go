@@ -8,14 +8,13 @@ package rpccalls
 import (
 	"encoding/hex"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/currency"
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
 	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/blockowner"
 	"github.com/bitmark-inc/bitmarkd/transactionrecord"
 )
@@ -33,6 +32,7 @@ type BlockTransferReply struct {
 	BlockTransferId merkle.Digest                                   `json:"blockTransferId"`
 	PayId           pay.PayId                                       `json:"payId"`
 	Payments        map[string]transactionrecord.PaymentAlternative `json:"payments"`
+	Escrow          *reservoir.EscrowInfo                           `json:"escrow,omitempty"`
 	Commands        map[string]string                               `json:"commands,omitempty"`
 }
 
@@ -73,7 +73,7 @@ func (client *Client) SingleSignedBlockTransfer(blockTransferConfig *BlockTransf
 func (client *Client) CountersignBlockTransfer(blockTransfer *transactionrecord.BlockOwnerTransfer) (*BlockTransferReply, error) {
 
 	var reply blockowner.TransferReply
-	err := client.client.Call("BlockOwner.Transfer", blockTransfer, &reply)
+	err := client.call("BlockOwner.Transfer", blockTransfer, &reply)
 	if nil != err {
 		return nil, err
 	}
@@ -96,6 +96,7 @@ func (client *Client) CountersignBlockTransfer(blockTransfer *transactionrecord.
 		BlockTransferId: reply.TxId,
 		PayId:           reply.PayId,
 		Payments:        reply.Payments,
+		Escrow:          reply.Escrow,
 		Commands:        commands,
 	}
 
@@ -113,7 +114,7 @@ func makeBlockTransferOneSignature(testnet bool, link merkle.Digest, payments cu
 		Countersignature: nil,
 	}
 
-	ownerAccount := owner.PrivateKey.Account()
+	ownerAccount := owner.Account()
 
 	// pack without signature
 	packed, err := r.Pack(ownerAccount)
@@ -124,7 +125,10 @@ func makeBlockTransferOneSignature(testnet bool, link merkle.Digest, payments cu
 	}
 
 	// attach signature
-	signature := ed25519.Sign(owner.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := owner.Sign(packed)
+	if nil != err {
+		return nil, nil, err
+	}
 	r.Signature = signature[:]
 
 	// include first signature by packing again
@@ -8,8 +8,6 @@ package rpccalls
 import (
 	"encoding/hex"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/transactionrecord"
@@ -36,7 +34,10 @@ func (client *Client) Countersign(countersignConfig *CountersignData) (interface
 	}
 
 	// attach signature
-	signature := ed25519.Sign(countersignConfig.NewOwner.PrivateKey.PrivateKeyBytes(), b)
+	signature, err := countersignConfig.NewOwner.Sign(b)
+	if nil != err {
+		return nil, err
+	}
 
 	switch tx := r.(type) {
 	case *transactionrecord.BitmarkTransferCountersigned:
@@ -55,6 +56,10 @@ func (client *Client) Countersign(countersignConfig *CountersignData) (interface
 		tx.Countersignature = signature[:]
 		return client.CountersignSwap(tx)
 
+	case *transactionrecord.AtomicSwap:
+		tx.Countersignature = signature[:]
+		return client.CountersignAtomicSwap(tx)
+
 	default:
 		return nil, fault.NotACountersignableRecord
 	}
@@ -42,7 +42,7 @@ func (client *Client) GetFullProvenance(fullProvenanceConfig *FullProvenanceData
 	client.printJson("Full Provenance Request", fullProvenanceArgs)
 
 	var reply bitmark.FullProvenanceReply
-	err := client.client.Call("Bitmark.FullProvenance", fullProvenanceArgs, &reply)
+	err := client.call("Bitmark.FullProvenance", fullProvenanceArgs, &reply)
 	if nil != err {
 		return nil, err
 	}
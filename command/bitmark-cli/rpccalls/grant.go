@@ -8,8 +8,6 @@ package rpccalls
 import (
 	"encoding/hex"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/fault"
@@ -89,7 +87,7 @@ func (client *Client) CountersignGrant(grant *transactionrecord.ShareGrant) (*Gr
 	client.printJson("Grant Request", grant)
 
 	var reply share.GrantReply
-	err := client.client.Call("Share.Grant", grant, &reply)
+	err := client.call("Share.Grant", grant, &reply)
 	if nil != err {
 		return nil, err
 	}
@@ -120,7 +118,7 @@ func (client *Client) CountersignGrant(grant *transactionrecord.ShareGrant) (*Gr
 
 func makeGrantOneSignature(testnet bool, shareId merkle.Digest, quantity uint64, owner *configuration.Private, recipient *account.Account, beforeBlock uint64) ([]byte, *transactionrecord.ShareGrant, error) {
 
-	ownerAccount := owner.PrivateKey.Account()
+	ownerAccount := owner.Account()
 
 	r := transactionrecord.ShareGrant{
 		ShareId:          shareId,
@@ -141,7 +139,10 @@ func makeGrantOneSignature(testnet bool, shareId merkle.Digest, quantity uint64,
 	}
 
 	// attach signature
-	signature := ed25519.Sign(owner.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := owner.Sign(packed)
+	if nil != err {
+		return nil, nil, err
+	}
 	r.Signature = signature[:]
 
 	// include first signature by packing again
@@ -12,7 +12,6 @@ import (
 	"io"
 	"time"
 
-	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/sha3"
 
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
@@ -69,15 +68,22 @@ func (client *Client) Issue(issueConfig *IssueData) (*IssueReply, error) {
 		issues[i] = issue
 	}
 
+	return client.SubmitCreate(nil, issues)
+}
+
+// SubmitCreate - send already signed assets and issues then either
+// return the payment commands or run the proof for free issues
+func (client *Client) SubmitCreate(assets []*transactionrecord.AssetData, issues []*transactionrecord.BitmarkIssue) (*IssueReply, error) {
+
 	client.printJson("Issue Request", issues)
 
 	issuesArgs := bitmarks.CreateArguments{
-		Assets: nil,
+		Assets: assets,
 		Issues: issues,
 	}
 
 	var issuesReply bitmarks.CreateReply
-	if err := client.client.Call("Bitmarks.Create", issuesArgs, &issuesReply); err != nil {
+	if err := client.call("Bitmarks.Create", issuesArgs, &issuesReply); err != nil {
 		return nil, err
 	}
 
@@ -85,8 +91,7 @@ func (client *Client) Issue(issueConfig *IssueData) (*IssueReply, error) {
 
 	// make response
 	response := IssueReply{
-		AssetId:        issues[0].AssetId, // Note: all issues are for the same asset
-		IssueIds:       make([]merkle.Digest, len(issues)),
+		IssueIds:       make([]merkle.Digest, len(issuesReply.Issues)),
 		PayId:          issuesReply.PayId,
 		PayNonce:       issuesReply.PayNonce,
 		Difficulty:     issuesReply.Difficulty,
@@ -94,7 +99,16 @@ func (client *Client) Issue(issueConfig *IssueData) (*IssueReply, error) {
 		SubmittedNonce: "",
 	}
 
-	if nil != issuesReply.Payments && len(issuesReply.Payments) > 0 {
+	if 0 != len(issues) {
+		response.AssetId = issues[0].AssetId // Note: all issues are for the same asset
+	} else if 0 != len(issuesReply.Assets) && nil != issuesReply.Assets[0].AssetId {
+		response.AssetId = *issuesReply.Assets[0].AssetId
+	}
+
+	if 0 == len(issues) {
+		// assets only, nothing to pay or prove
+
+	} else if nil != issuesReply.Payments && len(issuesReply.Payments) > 0 {
 
 		tpid, err := issuesReply.PayId.MarshalText()
 		if nil != err {
@@ -120,7 +134,7 @@ func (client *Client) Issue(issueConfig *IssueData) (*IssueReply, error) {
 		client.printJson("Proof Request", proofArgs)
 
 		var proofReply bitmarks.ProofReply
-		if err := client.client.Call("Bitmarks.Proof", &proofArgs, &proofReply); err != nil {
+		if err := client.call("Bitmarks.Proof", &proofArgs, &proofReply); err != nil {
 			return nil, err
 		}
 
@@ -138,6 +152,16 @@ func (client *Client) Issue(issueConfig *IssueData) (*IssueReply, error) {
 	return &response, nil
 }
 
+// SignIssue - build a properly signed issue with a given nonce
+func SignIssue(issuer *configuration.Private, assetId transactionrecord.AssetIdentifier, nonce uint64) (*transactionrecord.BitmarkIssue, error) {
+	issueConfig := &IssueData{
+		Issuer:  issuer,
+		AssetId: &assetId,
+	}
+	_, issue, err := internalMakeIssue(false, issueConfig, nonce, false)
+	return issue, err
+}
+
 // build a properly signed issues
 func makeIssue(testnet bool, issueConfig *IssueData, nonce uint64) (*transactionrecord.BitmarkIssue, error) {
 	_, issue, err := internalMakeIssue(testnet, issueConfig, nonce, false)
@@ -146,7 +170,7 @@ func makeIssue(testnet bool, issueConfig *IssueData, nonce uint64) (*transaction
 
 func internalMakeIssue(testnet bool, issueConfig *IssueData, nonce uint64, generateDigest bool) (*merkle.Digest, *transactionrecord.BitmarkIssue, error) {
 
-	issuerAccount := issueConfig.Issuer.PrivateKey.Account()
+	issuerAccount := issueConfig.Issuer.Account()
 
 	r := transactionrecord.BitmarkIssue{
 		AssetId:   *issueConfig.AssetId,
@@ -164,7 +188,10 @@ func internalMakeIssue(testnet bool, issueConfig *IssueData, nonce uint64, gener
 	}
 
 	// manually sign the record and attach signature
-	signature := ed25519.Sign(issueConfig.Issuer.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := issueConfig.Issuer.Sign(packed)
+	if nil != err {
+		return nil, nil, err
+	}
 	r.Signature = signature[:]
 
 	// check that signature is correct by packing again
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpccalls
+
+import (
+	"github.com/bitmark-inc/bitmarkd/rpc/node"
+)
+
+// limit for a single list request
+const maximumNodeList = 100
+
+// NodeEntry - a node announcing an RPC service
+type NodeEntry struct {
+	Fingerprint string   `json:"fingerprint"`
+	Connections []string `json:"connections"`
+}
+
+// nodeListReply - decodable form of node.Reply
+type nodeListReply struct {
+	Nodes     []NodeEntry `json:"nodes"`
+	NextStart uint64      `json:"nextStart,string"`
+}
+
+// ListNodes - fetch every RPC node announced to the connected node
+func (client *Client) ListNodes() ([]NodeEntry, error) {
+
+	nodes := []NodeEntry{}
+	start := uint64(0)
+	for {
+		listArgs := node.Arguments{
+			Start: start,
+			Count: maximumNodeList,
+		}
+
+		client.printJson("Node List Request", listArgs)
+
+		var reply nodeListReply
+		err := client.call("Node.List", listArgs, &reply)
+		if nil != err {
+			return nil, err
+		}
+
+		client.printJson("Node List Reply", reply)
+
+		nodes = append(nodes, reply.Nodes...)
+
+		// a short page, or no progress, is the end of the list
+		if len(reply.Nodes) < maximumNodeList || reply.NextStart <= start {
+			return nodes, nil
+		}
+		start = reply.NextStart
+	}
+}
@@ -29,7 +29,7 @@ func (client *Client) GetOwned(ownedConfig *OwnedData) (*owner.BitmarksReply, er
 	client.printJson("Owned Request", ownedArgs)
 
 	reply := &owner.BitmarksReply{}
-	err := client.client.Call("Owner.Bitmarks", ownedArgs, reply)
+	err := client.call("Owner.Bitmarks", ownedArgs, reply)
 	if nil != err {
 		return nil, err
 	}
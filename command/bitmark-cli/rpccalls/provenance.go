@@ -44,7 +44,7 @@ func (client *Client) GetProvenance(provenanceConfig *ProvenanceData) (*Provenan
 	client.printJson("Provenance Request", provenanceArgs)
 
 	var reply bitmark.ProvenanceReply
-	err := client.client.Call("Bitmark.Provenance", provenanceArgs, &reply)
+	err := client.call("Bitmark.Provenance", provenanceArgs, &reply)
 	if nil != err {
 		return nil, err
 	}
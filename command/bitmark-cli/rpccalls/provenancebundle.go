@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpccalls
+
+import (
+	"fmt"
+
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/provenance"
+	"github.com/bitmark-inc/bitmarkd/rpc/bitmark"
+	"github.com/bitmark-inc/bitmarkd/rpc/node"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// largest page returned by Bitmark.Provenance
+const maximumProvenanceCount = 100
+
+// ProvenanceBundleData - data for a provenance export
+// only one of TxId or BitmarkId is used
+type ProvenanceBundleData struct {
+	Chain     string
+	TxId      string
+	BitmarkId string
+}
+
+// the parts of a provenance record needed to locate it in a block
+type provenanceLocation struct {
+	record  string
+	txId    interface{}
+	assetId interface{}
+	inBlock uint64
+}
+
+// GetProvenanceBundle - collect every record of a provenance chain with
+// the block header and merkle path proving its inclusion
+func (client *Client) GetProvenanceBundle(bundleConfig *ProvenanceBundleData) (*provenance.Bundle, error) {
+
+	var locations []provenanceLocation
+	var err error
+	if "" != bundleConfig.BitmarkId {
+		locations, err = client.fullProvenanceLocations(bundleConfig.BitmarkId)
+	} else {
+		locations, err = client.provenanceLocations(bundleConfig.TxId)
+	}
+	if nil != err {
+		return nil, err
+	}
+
+	bundle := &provenance.Bundle{
+		Version: provenance.Version,
+		Chain:   bundleConfig.Chain,
+		Records: make([]provenance.Record, 0, len(locations)),
+	}
+
+	blocks := make(map[uint64][]byte)
+	for _, l := range locations {
+		match, err := matcher(l)
+		if nil != err {
+			return nil, err
+		}
+
+		packedBlock, ok := blocks[l.inBlock]
+		if !ok {
+			packedBlock, err = client.getPackedBlock(l.inBlock)
+			if nil != err {
+				return nil, err
+			}
+			blocks[l.inBlock] = packedBlock
+		}
+
+		r, err := provenance.NewRecord(packedBlock, client.testnet, match)
+		if nil != err {
+			return nil, fmt.Errorf("%s in block: %d: %s", l.record, l.inBlock, err)
+		}
+		bundle.Records = append(bundle.Records, *r)
+	}
+
+	return bundle, nil
+}
+
+// select the record in its block
+func matcher(l provenanceLocation) (provenance.Matcher, error) {
+	switch l.record {
+	case "AssetData":
+		var assetId transactionrecord.AssetIdentifier
+		s, _ := l.assetId.(string)
+		if err := assetId.UnmarshalText([]byte(s)); nil != err {
+			return nil, err
+		}
+		return provenance.MatchAsset(assetId), nil
+
+	case "BlockFoundation", "BaseData":
+		return provenance.MatchFoundation(), nil
+
+	case "AtomicSwap":
+		// reported under the id of the leg that moved the bitmark
+		var legId merkle.Digest
+		s, _ := l.txId.(string)
+		if err := legId.UnmarshalText([]byte(s)); nil != err {
+			return nil, err
+		}
+		return provenance.MatchSwapLeg(legId), nil
+
+	default:
+		var txId merkle.Digest
+		s, _ := l.txId.(string)
+		if err := txId.UnmarshalText([]byte(s)); nil != err {
+			return nil, err
+		}
+		return provenance.MatchTxId(txId), nil
+	}
+}
+
+func (client *Client) fullProvenanceLocations(id string) ([]provenanceLocation, error) {
+
+	var bitmarkId merkle.Digest
+	if err := bitmarkId.UnmarshalText([]byte(id)); nil != err {
+		return nil, err
+	}
+
+	fullProvenanceArgs := bitmark.FullProvenanceArguments{
+		BitmarkId: bitmarkId,
+	}
+
+	client.printJson("Full Provenance Request", fullProvenanceArgs)
+
+	var reply bitmark.FullProvenanceReply
+	err := client.call("Bitmark.FullProvenance", fullProvenanceArgs, &reply)
+	if nil != err {
+		return nil, err
+	}
+
+	client.printJson("Full Provenance Reply", reply)
+
+	locations := make([]provenanceLocation, len(reply.Data))
+	for i, d := range reply.Data {
+		locations[i] = provenanceLocation{
+			record:  d.Record,
+			txId:    d.TxId,
+			assetId: d.AssetId,
+			inBlock: d.InBlock,
+		}
+	}
+	return locations, nil
+}
+
+// follow Bitmark.Provenance a page at a time until the chain ends
+func (client *Client) provenanceLocations(id string) ([]provenanceLocation, error) {
+
+	var txId merkle.Digest
+	if err := txId.UnmarshalText([]byte(id)); nil != err {
+		return nil, err
+	}
+
+	locations := make([]provenanceLocation, 0, maximumProvenanceCount)
+	for {
+		provenanceArgs := bitmark.ProvenanceArguments{
+			TxId:  txId,
+			Count: maximumProvenanceCount,
+		}
+
+		client.printJson("Provenance Request", provenanceArgs)
+
+		var reply bitmark.ProvenanceReply
+		err := client.call("Bitmark.Provenance", provenanceArgs, &reply)
+		if nil != err {
+			return nil, err
+		}
+
+		client.printJson("Provenance Reply", reply)
+
+		data := reply.Data
+		if len(locations) > 0 && len(data) > 0 {
+			data = data[1:] // repeat of the last record of the previous page
+		}
+		for _, d := range data {
+			locations = append(locations, provenanceLocation{
+				record:  d.Record,
+				txId:    d.TxId,
+				assetId: d.AssetId,
+				inBlock: d.InBlock,
+			})
+		}
+
+		if len(reply.Data) < maximumProvenanceCount || 0 == len(data) {
+			return locations, nil
+		}
+		last := locations[len(locations)-1]
+		switch last.record {
+		case "AssetData", "BlockFoundation", "BaseData":
+			return locations, nil
+		}
+		s, _ := last.txId.(string)
+		if err := txId.UnmarshalText([]byte(s)); nil != err {
+			return nil, err
+		}
+	}
+}
+
+type blockDumpReply struct {
+	Block struct {
+		Packed []byte `json:"binary"`
+	} `json:"block"`
+}
+
+// fetch the complete packed block
+func (client *Client) getPackedBlock(height uint64) ([]byte, error) {
+
+	blockDumpArgs := node.BlockDumpArguments{
+		Height: height,
+		Binary: false, // false leaves the packed block in the reply
+	}
+
+	client.printJson("BlockDump Request", blockDumpArgs)
+
+	var reply blockDumpReply
+	err := client.call("Node.BlockDump", blockDumpArgs, &reply)
+	if nil != err {
+		return nil, err
+	}
+
+	return reply.Block.Packed, nil
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpccalls
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// QuorumVote - the answer of one node
+type QuorumVote struct {
+	Connection string `json:"connection"`
+	Digest     string `json:"digest,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// QuorumReport - how the nodes answered a quorum read
+type QuorumReport struct {
+	Agreed bool         `json:"agreed"` // every node gave the same answer
+	Votes  int          `json:"votes"`  // nodes that gave the chosen answer
+	Nodes  []QuorumVote `json:"nodes"`
+}
+
+// Quorum - perform the same read on count nodes starting from offset
+// and return the most common answer
+//
+// answers are compared by the digest of their JSON, so the read must
+// not add anything that depends on the node; any node that fails or
+// answers differently clears the Agreed flag of the report
+func Quorum(testnet bool, connections []string, offset int, count int, verbose bool, handle io.Writer, read func(*Client) (interface{}, error)) (interface{}, *QuorumReport, error) {
+
+	if count > len(connections) {
+		count = len(connections)
+	}
+
+	report := &QuorumReport{
+		Nodes: make([]QuorumVote, count),
+	}
+	answers := make(map[string]interface{})
+	var firstErr error
+
+	for i := 0; i < count; i += 1 {
+		connect := connections[(offset+i)%len(connections)]
+		report.Nodes[i].Connection = connect
+
+		answer, err := quorumRead(testnet, connect, verbose, handle, read)
+		if nil == err {
+			var data []byte
+			data, err = json.Marshal(answer)
+			if nil == err {
+				digest := sha3.Sum256(data)
+				d := hex.EncodeToString(digest[:])
+				report.Nodes[i].Digest = d
+				if _, ok := answers[d]; !ok {
+					answers[d] = answer
+				}
+			}
+		}
+		if nil != err {
+			report.Nodes[i].Error = err.Error()
+			if nil == firstErr {
+				firstErr = err
+			}
+		}
+	}
+
+	chosen, votes := tally(report.Nodes)
+	report.Votes = votes
+	report.Agreed = 0 != votes && votes == count
+
+	if 0 == votes {
+		return nil, report, firstErr
+	}
+	return answers[chosen], report, nil
+}
+
+// read from a single node
+func quorumRead(testnet bool, connect string, verbose bool, handle io.Writer, read func(*Client) (interface{}, error)) (interface{}, error) {
+	client, err := NewClient(testnet, []string{connect}, 0, verbose, handle)
+	if nil != err {
+		return nil, err
+	}
+	defer client.Close()
+
+	return read(client)
+}
+
+// the digest with most votes, the earliest wins a tie
+func tally(nodes []QuorumVote) (string, int) {
+	counts := make(map[string]int)
+	chosen := ""
+	votes := 0
+	for _, n := range nodes {
+		if "" == n.Digest {
+			continue
+		}
+		counts[n.Digest] += 1
+		if counts[n.Digest] > votes {
+			chosen = n.Digest
+			votes = counts[n.Digest]
+		}
+	}
+	return chosen, votes
+}
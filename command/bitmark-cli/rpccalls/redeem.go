@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpccalls
+
+import (
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/rpc/share"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// RedeemData - data for a redeem request
+type RedeemData struct {
+	Owner    *configuration.Private
+	TxId     string
+	Quantity uint64
+}
+
+// RedeemReply - JSON data to output after redeem completes
+type RedeemReply struct {
+	TxId     merkle.Digest                                   `json:"txId"`
+	ShareId  merkle.Digest                                   `json:"shareId"`
+	PayId    pay.PayId                                       `json:"payId"`
+	Payments map[string]transactionrecord.PaymentAlternative `json:"payments"`
+	Commands map[string]string                               `json:"commands,omitempty"`
+}
+
+// Redeem - perform a redeem request
+func (client *Client) Redeem(redeemConfig *RedeemData) (*RedeemReply, error) {
+
+	var link merkle.Digest
+	err := link.UnmarshalText([]byte(redeemConfig.TxId))
+	if nil != err {
+		return nil, err
+	}
+
+	r, err := makeRedemption(client.testnet, link, redeemConfig.Quantity, redeemConfig.Owner)
+	if nil != err {
+		return nil, err
+	}
+	if nil == r {
+		return nil, fault.MakeRedemptionFailed
+	}
+
+	return client.SubmitRedemption(r)
+}
+
+// SubmitRedemption - send an already signed redemption
+func (client *Client) SubmitRedemption(r *transactionrecord.ShareRedemption) (*RedeemReply, error) {
+
+	client.printJson("Redeem Request", r)
+
+	var reply share.RedeemReply
+	err := client.call("Share.Redeem", r, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	tpid, err := reply.PayId.MarshalText()
+	if nil != err {
+		return nil, err
+	}
+
+	commands := make(map[string]string)
+	for _, payment := range reply.Payments {
+		currency := payment[0].Currency
+		commands[currency.String()] = paymentCommand(client.testnet, currency, string(tpid), payment)
+	}
+
+	client.printJson("Redeem Reply", reply)
+
+	// make response
+	response := RedeemReply{
+		TxId:     reply.TxId,
+		ShareId:  reply.ShareId,
+		PayId:    reply.PayId,
+		Payments: reply.Payments,
+		Commands: commands,
+	}
+
+	return &response, nil
+}
+
+func makeRedemption(testnet bool, link merkle.Digest, quantity uint64, owner *configuration.Private) (*transactionrecord.ShareRedemption, error) {
+
+	ownerAccount := owner.Account()
+
+	r := transactionrecord.ShareRedemption{
+		Link:      link,
+		Quantity:  quantity,
+		Owner:     ownerAccount,
+		Signature: nil,
+	}
+
+	// pack without signature
+	packed, err := r.Pack(ownerAccount)
+	if nil == err {
+		return nil, fault.MakeRedemptionFailed
+	} else if fault.InvalidSignature != err {
+		return nil, err
+	}
+
+	// attach signature
+	signature, err := owner.Sign(packed)
+	if nil != err {
+		return nil, err
+	}
+	r.Signature = signature[:]
+
+	// check that signature is correct by packing again
+	_, err = r.Pack(ownerAccount)
+	if nil != err {
+		return nil, err
+	}
+	return &r, nil
+}
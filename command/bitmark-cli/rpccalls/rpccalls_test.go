@@ -4,3 +4,261 @@
 // license that can be found in the LICENSE file.
 
 package rpccalls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
+	"github.com/bitmark-inc/bitmarkd/rpc/node"
+)
+
+const fixturesDir = "../../../rpc/fixtures"
+
+// a Node service announcing a fixed list
+type testNode struct {
+	nodes []NodeEntry
+}
+
+// ListReply - same JSON as node.Reply, exported for net/rpc
+type ListReply nodeListReply
+
+func (n *testNode) List(arguments *node.Arguments, reply *ListReply) error {
+	start := int(arguments.Start)
+	if start >= len(n.nodes) {
+		return nil
+	}
+	end := start + arguments.Count
+	if end > len(n.nodes) {
+		end = len(n.nodes)
+	}
+	reply.Nodes = n.nodes[start:end]
+	reply.NextStart = uint64(end)
+	return nil
+}
+
+// start a TLS JSON RPC server on a random local port
+func startServer(t *testing.T, nodes []NodeEntry) (string, func()) {
+	certificate, err := tls.X509KeyPair([]byte(fixtures.Certificate(fixturesDir)), []byte(fixtures.Key(fixturesDir)))
+	if nil != err {
+		t.Fatalf("certificate error: %s", err)
+	}
+
+	server := rpc.NewServer()
+	err = server.RegisterName("Node", &testNode{nodes: nodes})
+	if nil != err {
+		t.Fatalf("register error: %s", err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if nil != err {
+		t.Fatalf("listen error: %s", err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if nil != err {
+				return
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	return listener.Addr().String(), func() { listener.Close() }
+}
+
+// an address with nothing listening
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatalf("listen error: %s", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func makeNodes(n int) []NodeEntry {
+	nodes := make([]NodeEntry, n)
+	for i := range nodes {
+		nodes[i] = NodeEntry{
+			Fingerprint: fmt.Sprintf("%064x", i),
+			Connections: []string{fmt.Sprintf("10.0.0.%d:2130", i%250)},
+		}
+	}
+	return nodes
+}
+
+func TestFailover(t *testing.T) {
+	address, stop := startServer(t, makeNodes(1))
+	defer stop()
+
+	down := closedAddress(t)
+
+	client, err := NewClient(true, []string{down, address}, 0, false, ioutil.Discard)
+	if nil != err {
+		t.Fatalf("connect error: %s", err)
+	}
+	defer client.Close()
+
+	if address != client.Connection() {
+		t.Errorf("connection: %s  expected: %s", client.Connection(), address)
+	}
+
+	_, err = NewClient(true, []string{down}, 0, false, ioutil.Discard)
+	if nil == err {
+		t.Error("connected to a closed port")
+	}
+}
+
+func TestFailoverDuringCall(t *testing.T) {
+	first, stopFirst := startServer(t, makeNodes(1))
+	second, stopSecond := startServer(t, makeNodes(2))
+	defer stopSecond()
+
+	client, err := NewClient(true, []string{first, second}, 0, false, ioutil.Discard)
+	if nil != err {
+		t.Fatalf("connect error: %s", err)
+	}
+	defer client.Close()
+
+	// break the established connection
+	stopFirst()
+	client.conn.Close()
+
+	nodes, err := client.ListNodes()
+	if nil != err {
+		t.Fatalf("list error: %s", err)
+	}
+	if 2 != len(nodes) || second != client.Connection() {
+		t.Errorf("nodes: %d  connection: %s", len(nodes), client.Connection())
+	}
+}
+
+func TestFailoverWhenAllDown(t *testing.T) {
+	last, stop := startServer(t, makeNodes(1))
+
+	connections := []string{closedAddress(t), closedAddress(t), last}
+	client, err := NewClient(true, connections, 0, false, ioutil.Discard)
+	if nil != err {
+		t.Fatalf("connect error: %s", err)
+	}
+	defer client.Close()
+
+	if last != client.Connection() {
+		t.Fatalf("connection: %s  expected: %s", client.Connection(), last)
+	}
+
+	// every node is now down
+	stop()
+	client.conn.Close()
+
+	_, err = client.ListNodes()
+	if nil == err {
+		t.Fatal("list with all nodes down")
+	}
+
+	found := false
+	for _, c := range connections {
+		if c == client.Connection() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("connection: %s  not one of: %v", client.Connection(), connections)
+	}
+
+	// a second call must not move past the last node either
+	_, err = client.ListNodes()
+	if nil == err {
+		t.Fatal("second list with all nodes down")
+	}
+	_ = client.Connection()
+}
+
+func TestListNodes(t *testing.T) {
+	expected := makeNodes(maximumNodeList + 7)
+	address, stop := startServer(t, expected)
+	defer stop()
+
+	client, err := NewClient(true, []string{address}, 0, false, ioutil.Discard)
+	if nil != err {
+		t.Fatalf("connect error: %s", err)
+	}
+	defer client.Close()
+
+	nodes, err := client.ListNodes()
+	if nil != err {
+		t.Fatalf("list error: %s", err)
+	}
+	if len(expected) != len(nodes) {
+		t.Fatalf("nodes: %d  expected: %d", len(nodes), len(expected))
+	}
+	for i := range nodes {
+		if expected[i].Fingerprint != nodes[i].Fingerprint || expected[i].Connections[0] != nodes[i].Connections[0] {
+			t.Errorf("%d: node: %+v  expected: %+v", i, nodes[i], expected[i])
+		}
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	a, stopA := startServer(t, makeNodes(3))
+	defer stopA()
+	b, stopB := startServer(t, makeNodes(3))
+	defer stopB()
+	c, stopC := startServer(t, makeNodes(2))
+	defer stopC()
+
+	read := func(client *Client) (interface{}, error) {
+		return client.ListNodes()
+	}
+
+	answer, report, err := Quorum(true, []string{a, b}, 0, 2, false, ioutil.Discard, read)
+	if nil != err {
+		t.Fatalf("quorum error: %s", err)
+	}
+	if !report.Agreed || 2 != report.Votes || 3 != len(answer.([]NodeEntry)) {
+		t.Errorf("report: %+v", report)
+	}
+
+	// one node disagrees, the majority answer is returned
+	answer, report, err = Quorum(true, []string{c, a, b}, 0, 3, false, ioutil.Discard, read)
+	if nil != err {
+		t.Fatalf("quorum error: %s", err)
+	}
+	if report.Agreed || 2 != report.Votes || 3 != len(answer.([]NodeEntry)) {
+		t.Errorf("report: %+v", report)
+	}
+	if report.Nodes[0].Digest == report.Nodes[1].Digest || report.Nodes[1].Digest != report.Nodes[2].Digest {
+		t.Errorf("digests: %+v", report.Nodes)
+	}
+
+	// a node that is down does not agree
+	down := closedAddress(t)
+	_, report, err = Quorum(true, []string{a, down}, 0, 2, false, ioutil.Discard, read)
+	if nil != err {
+		t.Fatalf("quorum error: %s", err)
+	}
+	if report.Agreed || 1 != report.Votes || "" == report.Nodes[1].Error {
+		t.Errorf("report: %+v", report)
+	}
+
+	_, _, err = Quorum(true, []string{down}, 0, 1, false, ioutil.Discard, read)
+	if nil == err {
+		t.Error("quorum with no answers succeeded")
+	}
+}
+
+func TestNewClientNoConnections(t *testing.T) {
+	_, err := NewClient(true, nil, 0, false, ioutil.Discard)
+	if fault.MissingParameters != err {
+		t.Errorf("error: %v  expected: %s", err, fault.MissingParameters)
+	}
+}
@@ -7,45 +7,134 @@ package rpccalls
 
 import (
 	"crypto/tls"
+	"fmt"
 	"io"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
 )
 
+const dialTimeout = 10 * time.Second
+
 // Client - to hold RPC connections streams
 type Client struct {
-	conn    net.Conn
-	client  *rpc.Client
-	testnet bool
-	verbose bool
-	handle  io.Writer // if verbose is set output items here
+	conn        net.Conn
+	client      *rpc.Client
+	connections []string // all nodes that may be used
+	current     int      // index of the connected node
+	testnet     bool
+	verbose     bool
+	handle      io.Writer // if verbose is set output items here
 }
 
 // NewClient - create a RPC connection to a bitmarkd
-func NewClient(testnet bool, connect string, verbose bool, handle io.Writer) (*Client, error) {
+//
+// the connections are tried in turn starting from offset and the first
+// node to accept is used; if that node later drops the connection the
+// remaining nodes are tried before a call fails
+func NewClient(testnet bool, connections []string, offset int, verbose bool, handle io.Writer) (*Client, error) {
 
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
+	if 0 == len(connections) {
+		return nil, fault.MissingParameters
 	}
 
-	conn, err := tls.Dial("tcp", connect, tlsConfig)
+	r := &Client{
+		connections: connections,
+		testnet:     testnet,
+		verbose:     verbose,
+		handle:      handle,
+	}
+
+	err := r.dial(offset, len(connections))
 	if nil != err {
 		return nil, err
 	}
+	return r, nil
+}
 
-	r := &Client{
-		conn:    conn,
-		client:  jsonrpc.NewClient(conn),
-		testnet: testnet,
-		verbose: verbose,
-		handle:  handle,
+// connect to the first of count nodes from offset that accepts
+func (c *Client) dial(offset int, count int) error {
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
 	}
-	return r, nil
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+	}
+
+	var err error
+	for i := 0; i < count; i += 1 {
+		n := (offset + i) % len(c.connections)
+		connect := c.connections[n]
+
+		conn, e := tls.DialWithDialer(dialer, "tcp", connect, tlsConfig)
+		if nil != e {
+			if c.verbose {
+				fmt.Fprintf(c.handle, "connect: %s  error: %s\n", connect, e)
+			}
+			err = e
+			continue
+		}
+		if c.verbose && 0 != i {
+			fmt.Fprintf(c.handle, "failed over to: %s\n", connect)
+		}
+
+		c.conn = conn
+		c.client = jsonrpc.NewClient(conn)
+		c.current = n
+		return nil
+	}
+	return err
+}
+
+// call an RPC, moving to the next node if the connection breaks
+//
+// errors returned by a node are final, only transport failures are
+// retried, so a resubmitted transaction is at worst reported as a
+// duplicate by the next node
+func (c *Client) call(method string, args interface{}, reply interface{}) error {
+	err := c.client.Call(method, args, reply)
+
+	for tried := 1; tried < len(c.connections) && isConnectionError(err); tried += 1 {
+		c.Close()
+		if e := c.dial(c.current+1, 1); nil != e {
+			c.current = (c.current + 1) % len(c.connections)
+			continue
+		}
+		err = c.client.Call(method, args, reply)
+	}
+	return err
+}
+
+// distinguish a broken connection from an error sent by the node
+func isConnectionError(err error) bool {
+	if nil == err {
+		return false
+	}
+	if _, ok := err.(rpc.ServerError); ok {
+		return false
+	}
+	if rpc.ErrShutdown == err || io.EOF == err || io.ErrUnexpectedEOF == err {
+		return true
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
+
+// Connection - the node currently in use
+func (c *Client) Connection() string {
+	return c.connections[c.current]
 }
 
 // Close - shutdown the bitmarkd connection
 func (c *Client) Close() {
-	c.client.Close()
-	c.conn.Close()
+	if nil != c.client {
+		c.client.Close()
+	}
+	if nil != c.conn {
+		c.conn.Close()
+	}
 }
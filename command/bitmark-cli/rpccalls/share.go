@@ -6,8 +6,6 @@
 package rpccalls
 
 import (
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
@@ -50,10 +48,16 @@ func (client *Client) Share(shareConfig *ShareData) (*ShareReply, error) {
 		return nil, fault.MakeShareFailed
 	}
 
+	return client.SubmitShare(sh)
+}
+
+// SubmitShare - send an already signed share
+func (client *Client) SubmitShare(sh *transactionrecord.BitmarkShare) (*ShareReply, error) {
+
 	client.printJson("Share Request", sh)
 
 	var reply share.CreateReply
-	err = client.client.Call("Share.Create", sh, &reply)
+	err := client.call("Share.Create", sh, &reply)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +95,7 @@ func makeShare(testnet bool, link merkle.Digest, quantity uint64, owner *configu
 		Signature: nil,
 	}
 
-	ownerAccount := owner.PrivateKey.Account()
+	ownerAccount := owner.Account()
 
 	// pack without signature
 	packed, err := r.Pack(ownerAccount)
@@ -102,7 +106,10 @@ func makeShare(testnet bool, link merkle.Digest, quantity uint64, owner *configu
 	}
 
 	// attach signature
-	signature := ed25519.Sign(owner.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := owner.Sign(packed)
+	if nil != err {
+		return nil, err
+	}
 	r.Signature = signature[:]
 
 	// check that signature is correct by packing again
@@ -6,6 +6,10 @@
 package rpccalls
 
 import (
+	"strings"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
 	"github.com/bitmark-inc/bitmarkd/rpc/transaction"
 )
@@ -31,7 +35,7 @@ func (client *Client) GetTransactionStatus(statusConfig *TransactionStatusData)
 	client.printJson("Status Request", statusArgs)
 
 	var reply transaction.StatusReply
-	err = client.client.Call("Transaction.Status", statusArgs, &reply)
+	err = client.call("Transaction.Status", statusArgs, &reply)
 	if err != nil {
 		return nil, err
 	}
@@ -40,3 +44,73 @@ func (client *Client) GetTransactionStatus(statusConfig *TransactionStatusData)
 
 	return &reply, nil
 }
+
+// WatchReply - a change of transaction status
+type WatchReply struct {
+	Status      string            `json:"status"`
+	PayId       string            `json:"payId,omitempty"`
+	Commands    map[string]string `json:"commands,omitempty"`
+	BlockNumber uint64            `json:"blockNumber,omitempty"`
+}
+
+// WatchTransactionStatus - wait up to timeout for the status to
+// differ from the one given
+//
+// fault.WatchNotSupported is returned if the node does not have the
+// watch RPC, then the caller must poll with GetTransactionStatus
+func (client *Client) WatchTransactionStatus(statusConfig *TransactionStatusData, status string, timeout time.Duration) (*WatchReply, error) {
+
+	var txId merkle.Digest
+	err := txId.UnmarshalText([]byte(statusConfig.TxId))
+	if nil != err {
+		return nil, err
+	}
+
+	// round up so a short wait is not taken as the server default
+	seconds := uint64((timeout + time.Second - 1) / time.Second)
+	if 0 == seconds {
+		seconds = 1
+	}
+
+	watchArgs := transaction.WatchArguments{
+		TxId:    txId,
+		Status:  status,
+		Timeout: seconds,
+	}
+
+	client.printJson("Watch Request", watchArgs)
+
+	var reply transaction.WatchReply
+	err = client.call("Transaction.Watch", watchArgs, &reply)
+	if nil != err {
+		if strings.Contains(err.Error(), "can't find method") {
+			return nil, fault.WatchNotSupported
+		}
+		return nil, err
+	}
+
+	client.printJson("Watch Reply", reply)
+
+	response := &WatchReply{
+		Status:      reply.Status,
+		BlockNumber: reply.BlockNumber,
+	}
+
+	if nil != reply.PayId {
+		tpid, err := reply.PayId.MarshalText()
+		if nil != err {
+			return nil, err
+		}
+		response.PayId = string(tpid)
+
+		if 0 != len(reply.Payments) {
+			response.Commands = make(map[string]string)
+			for _, payment := range reply.Payments {
+				currency := payment[0].Currency
+				response.Commands[currency.String()] = paymentCommand(client.testnet, currency, response.PayId, payment)
+			}
+		}
+	}
+
+	return response, nil
+}
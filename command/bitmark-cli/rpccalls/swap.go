@@ -8,8 +8,6 @@ package rpccalls
 import (
 	"encoding/hex"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/fault"
@@ -97,7 +95,7 @@ func (client *Client) CountersignSwap(swap *transactionrecord.ShareSwap) (*SwapR
 	client.printJson("Swap Request", swap)
 
 	var reply share.SwapReply
-	err := client.client.Call("Share.Swap", swap, &reply)
+	err := client.call("Share.Swap", swap, &reply)
 	if nil != err {
 		return nil, err
 	}
@@ -128,7 +126,7 @@ func (client *Client) CountersignSwap(swap *transactionrecord.ShareSwap) (*SwapR
 
 func makeSwapOneSignature(testnet bool, shareIdOne merkle.Digest, quantityOne uint64, ownerOne *configuration.Private, shareIdTwo merkle.Digest, quantityTwo uint64, ownerTwo *account.Account, beforeBlock uint64) ([]byte, *transactionrecord.ShareSwap, error) {
 
-	ownerOneAccount := ownerOne.PrivateKey.Account()
+	ownerOneAccount := ownerOne.Account()
 
 	r := transactionrecord.ShareSwap{
 		ShareIdOne:       shareIdOne,
@@ -151,7 +149,10 @@ func makeSwapOneSignature(testnet bool, shareIdOne merkle.Digest, quantityOne ui
 	}
 
 	// attach signature
-	signature := ed25519.Sign(ownerOne.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := ownerOne.Sign(packed)
+	if nil != err {
+		return nil, nil, err
+	}
 	r.Signature = signature[:]
 
 	// include first signature by packing again
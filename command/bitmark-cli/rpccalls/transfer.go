@@ -8,13 +8,12 @@ package rpccalls
 import (
 	"encoding/hex"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
 	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/bitmark"
 	"github.com/bitmark-inc/bitmarkd/transactionrecord"
 )
@@ -38,6 +37,7 @@ type TransferReply struct {
 	BitmarkId  merkle.Digest                                   `json:"bitmarkId"`
 	PayId      pay.PayId                                       `json:"payId"`
 	Payments   map[string]transactionrecord.PaymentAlternative `json:"payments"`
+	Escrow     *reservoir.EscrowInfo                           `json:"escrow,omitempty"`
 	Commands   map[string]string                               `json:"commands,omitempty"`
 }
 
@@ -67,7 +67,7 @@ func (client *Client) Transfer(transferConfig *TransferData) (*TransferReply, er
 	client.printJson("Transfer Request", transfer)
 
 	var reply bitmark.TransferReply
-	err = client.client.Call("Bitmark.Transfer", transfer, &reply)
+	err = client.call("Bitmark.Transfer", transfer, &reply)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +91,7 @@ func (client *Client) Transfer(transferConfig *TransferData) (*TransferReply, er
 		BitmarkId:  reply.BitmarkId,
 		PayId:      reply.PayId,
 		Payments:   reply.Payments,
+		Escrow:     reply.Escrow,
 		Commands:   commands,
 	}
 
@@ -126,11 +127,16 @@ func (client *Client) SingleSignedTransfer(transferConfig *TransferData) (*Trans
 
 // CountersignTransfer - perform as countersigned transfer
 func (client *Client) CountersignTransfer(transfer *transactionrecord.BitmarkTransferCountersigned) (*TransferReply, error) {
+	return client.SubmitTransfer(transfer)
+}
+
+// SubmitTransfer - send an already signed transfer of either kind
+func (client *Client) SubmitTransfer(transfer transactionrecord.BitmarkTransfer) (*TransferReply, error) {
 
 	client.printJson("Transfer Request", transfer)
 
 	var reply bitmark.TransferReply
-	err := client.client.Call("Bitmark.Transfer", transfer, &reply)
+	err := client.call("Bitmark.Transfer", transfer, &reply)
 	if nil != err {
 		return nil, err
 	}
@@ -154,6 +160,7 @@ func (client *Client) CountersignTransfer(transfer *transactionrecord.BitmarkTra
 		BitmarkId:  reply.BitmarkId,
 		PayId:      reply.PayId,
 		Payments:   reply.Payments,
+		Escrow:     reply.Escrow,
 		Commands:   commands,
 	}
 
@@ -168,7 +175,7 @@ func makeTransferUnratified(testnet bool, link merkle.Digest, owner *configurati
 		Signature: nil,
 	}
 
-	ownerAccount := owner.PrivateKey.Account()
+	ownerAccount := owner.Account()
 
 	// pack without signature
 	packed, err := r.Pack(ownerAccount)
@@ -179,7 +186,10 @@ func makeTransferUnratified(testnet bool, link merkle.Digest, owner *configurati
 	}
 
 	// attach signature
-	signature := ed25519.Sign(owner.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := owner.Sign(packed)
+	if nil != err {
+		return nil, err
+	}
 	r.Signature = signature[:]
 
 	// check that signature is correct by packing again
@@ -199,7 +209,7 @@ func makeTransferOneSignature(testnet bool, link merkle.Digest, owner *configura
 		Countersignature: nil,
 	}
 
-	ownerAccount := owner.PrivateKey.Account()
+	ownerAccount := owner.Account()
 
 	// pack without signature
 	packed, err := r.Pack(ownerAccount)
@@ -210,7 +220,10 @@ func makeTransferOneSignature(testnet bool, link merkle.Digest, owner *configura
 	}
 
 	// attach signature
-	signature := ed25519.Sign(owner.PrivateKey.PrivateKeyBytes(), packed)
+	signature, err := owner.Sign(packed)
+	if nil != err {
+		return nil, nil, err
+	}
 	r.Signature = signature[:]
 
 	// include first signature by packing again
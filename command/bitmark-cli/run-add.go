@@ -31,16 +31,43 @@ func runAdd(c *cli.Context) error {
 	seed := c.String("seed")
 	new := c.Bool("new")
 	acc := c.String("account")
+	signer := c.String("signer")
+
+	path, err := checkDerivation(c.String("derive"))
+	if nil != err {
+		return err
+	}
 
 	if m.verbose {
 		fmt.Fprintf(m.e, "identity: %s\n", name)
 		fmt.Fprintf(m.e, "description: %s\n", description)
 		fmt.Fprintf(m.e, "seed: %s\n", seed)
 		fmt.Fprintf(m.e, "account: %s\n", acc)
+		fmt.Fprintf(m.e, "path: %s\n", path)
+		fmt.Fprintf(m.e, "signer: %s\n", signer)
 		fmt.Fprintf(m.e, "new: %t\n", new)
 	}
 
-	if "" == acc {
+	if "" != signer {
+		if "" != seed || new || "" != path {
+			return fault.IncompatibleOptions
+		}
+
+		// ask the signer for its account unless it was given
+		if "" == acc {
+			a, err := accountFromSigner(signer, name, m.testnet)
+			if nil != err {
+				return err
+			}
+			acc = a.String()
+		}
+
+		err = m.config.AddSignerIdentity(name, description, acc, signer)
+		if nil != err {
+			return err
+		}
+
+	} else if "" == acc {
 		seed, err = checkSeed(seed, new, m.testnet)
 		if nil != err {
 			return err
@@ -54,12 +81,12 @@ func runAdd(c *cli.Context) error {
 			}
 		}
 
-		err = m.config.AddIdentity(name, description, seed, password)
+		err = m.config.AddIdentity(name, description, seed, path, password)
 		if nil != err {
 			return err
 		}
 
-	} else if "" == seed && "" != acc && !new {
+	} else if "" == seed && "" != acc && !new && "" == path {
 		err = m.config.AddReceiveOnlyIdentity(name, description, acc)
 		if nil != err {
 			return err
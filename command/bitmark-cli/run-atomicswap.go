@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+)
+
+func runAtomicSwap(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	to, recipient, err := checkRecipient(c, "receiver", m.config)
+	if nil != err {
+		return err
+	}
+
+	giveBitmarks, err := checkTxIds(c.StringSlice("give-bitmark"))
+	if nil != err {
+		return err
+	}
+	takeBitmarks, err := checkTxIds(c.StringSlice("take-bitmark"))
+	if nil != err {
+		return err
+	}
+	giveShares, err := checkSwapShares(c.StringSlice("give-share"))
+	if nil != err {
+		return err
+	}
+	takeShares, err := checkSwapShares(c.StringSlice("take-share"))
+	if nil != err {
+		return err
+	}
+
+	if 0 == len(giveBitmarks)+len(giveShares) || 0 == len(takeBitmarks)+len(takeShares) {
+		return fmt.Errorf("both sides of a swap must give something")
+	}
+
+	beforeBlock := c.Uint64("before-block")
+
+	from, owner, err := checkOwnerWithPasswordPrompt(c.GlobalString("identity"), m.config, c)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "giveBitmarks: %v\n", giveBitmarks)
+		fmt.Fprintf(m.e, "giveShares: %v\n", giveShares)
+		fmt.Fprintf(m.e, "ownerOne: %s\n", from)
+		fmt.Fprintf(m.e, "takeBitmarks: %v\n", takeBitmarks)
+		fmt.Fprintf(m.e, "takeShares: %v\n", takeShares)
+		fmt.Fprintf(m.e, "ownerTwo: %s\n", to)
+		fmt.Fprintf(m.e, "beforeBlock: %d\n", beforeBlock)
+	}
+
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+	if nil != err {
+		return err
+	}
+	defer client.Close()
+
+	swapConfig := &rpccalls.AtomicSwapData{
+		GiveBitmarks: giveBitmarks,
+		TakeBitmarks: takeBitmarks,
+		GiveShares:   giveShares,
+		TakeShares:   takeShares,
+		OwnerOne:     owner,
+		OwnerTwo:     recipient,
+		BeforeBlock:  beforeBlock,
+	}
+
+	response, err := client.AtomicSwap(swapConfig)
+	if nil != err {
+		return err
+	}
+
+	printJson(m.w, response)
+
+	return nil
+}
+
+// check a list of bitmark tx ids
+func checkTxIds(txIds []string) ([]string, error) {
+	for _, txId := range txIds {
+		if _, err := checkTxId(txId); nil != err {
+			return nil, err
+		}
+	}
+	return txIds, nil
+}
+
+// check a list of SHAREID:QUANTITY share legs
+func checkSwapShares(items []string) ([]rpccalls.AtomicSwapShare, error) {
+	shares := make([]rpccalls.AtomicSwapShare, 0, len(items))
+	for _, item := range items {
+		s := strings.SplitN(item, ":", 2)
+		if 2 != len(s) {
+			return nil, fmt.Errorf("invalid share: %q expected SHAREID:QUANTITY", item)
+		}
+		shareId, err := checkTxId(s[0])
+		if nil != err {
+			return nil, err
+		}
+		quantity, err := strconv.ParseUint(s[1], 10, 64)
+		if nil != err || 0 == quantity {
+			return nil, fmt.Errorf("invalid share quantity: %q", s[1])
+		}
+		shares = append(shares, rpccalls.AtomicSwapShare{
+			ShareId:  shareId,
+			Quantity: quantity,
+		})
+	}
+	return shares, nil
+}
@@ -42,24 +42,18 @@ func runBalance(c *cli.Context) error {
 		fmt.Fprintf(m.e, "count: %d\n", count)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
-	if nil != err {
-		return err
-	}
-	defer client.Close()
-
 	balanceConfig := &rpccalls.BalanceData{
 		Owner:   owner,
 		ShareId: shareId,
 		Count:   count,
 	}
 
-	response, err := client.GetBalance(balanceConfig)
-	if nil != err {
-		return err
+	response, err := quorumRead(m, func(client *rpccalls.Client) (interface{}, error) {
+		return client.GetBalance(balanceConfig)
+	})
+	if nil != response {
+		printJson(m.w, response)
 	}
 
-	printJson(m.w, response)
-
-	return nil
+	return err
 }
@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/batch"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// suffix of the default result file
+const batchResultSuffix = ".result"
+
+// totals printed when the batch finishes
+type batchSummary struct {
+	Results   string `json:"results"`
+	Total     int    `json:"total"`
+	Skipped   int    `json:"skipped"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+}
+
+func runBatch(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	fileName, err := checkFileName(c.String("file"))
+	if nil != err {
+		return err
+	}
+
+	resultName := c.String("results")
+	if "" == resultName {
+		resultName = fileName + batchResultSuffix
+	}
+
+	ops, digest, err := batch.Read(fileName)
+	if nil != err {
+		return err
+	}
+	batch.MarkFree(ops)
+
+	results, err := batch.OpenResults(resultName, digest)
+	if nil != err {
+		return err
+	}
+	defer results.Close()
+
+	pending := results.Pending(ops)
+	summary := batchSummary{
+		Results: resultName,
+		Total:   len(ops),
+		Skipped: len(ops) - len(pending),
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "file: %q  operations: %d  pending: %d\n", fileName, len(ops), len(pending))
+		fmt.Fprintf(m.e, "results: %q\n", resultName)
+	}
+
+	if 0 == len(pending) {
+		printJson(m.w, summary)
+		return nil
+	}
+
+	_, owner, err := checkOwnerWithPasswordPrompt(c.GlobalString("identity"), m.config, c)
+	if nil != err {
+		return err
+	}
+
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+	if nil != err {
+		return err
+	}
+	defer client.Close()
+
+	b := &batchRun{
+		m:       m,
+		client:  client,
+		owner:   owner,
+		digest:  digest,
+		results: results,
+		summary: &summary,
+	}
+
+	// reject invalid lines before sending anything
+	valid := make([]*batch.Operation, 0, len(pending))
+	for _, op := range pending {
+		err := b.check(op)
+		if nil != err {
+			err = b.record(&batch.Result{Line: op.Line, Op: op.Op, Error: err.Error()})
+			if nil != err {
+				return err
+			}
+			continue
+		}
+		valid = append(valid, op)
+	}
+
+	for _, group := range batch.GroupIssues(valid, reservoir.MaximumIssues) {
+		err := b.create(group)
+		if nil != err {
+			return err
+		}
+	}
+
+	for _, op := range valid {
+		var result *batch.Result
+		switch op.Op {
+		case batch.OpTransfer:
+			result = b.transfer(op)
+		case batch.OpGrant:
+			result = b.grant(op)
+		default:
+			continue
+		}
+		err := b.record(result)
+		if nil != err {
+			return err
+		}
+	}
+
+	printJson(m.w, summary)
+	return nil
+}
+
+// state shared by the steps of a batch
+type batchRun struct {
+	m           *metadata
+	client      *rpccalls.Client
+	owner       *configuration.Private
+	digest      []byte
+	results     *batch.Results
+	summary     *batchSummary
+	beforeBlock uint64
+	assets      map[string]*batch.Operation
+}
+
+// validate and normalise one operation
+func (b *batchRun) check(op *batch.Operation) error {
+	var err error
+
+	switch op.Op {
+	case batch.OpIssue:
+		op.Fingerprint, err = checkAssetFingerprint(op.Fingerprint)
+		if nil != err {
+			return err
+		}
+		op.Metadata, err = checkAssetMetadata(op.Metadata)
+		if nil != err {
+			return err
+		}
+		if 0 == op.Quantity || op.Quantity > reservoir.MaximumIssues {
+			return fmt.Errorf("invalid quantity: %d  must be 1 to %d", op.Quantity, reservoir.MaximumIssues)
+		}
+
+		// all lines of an asset must describe the same asset
+		if nil == b.assets {
+			b.assets = make(map[string]*batch.Operation)
+		}
+		if first, ok := b.assets[op.Fingerprint]; !ok {
+			b.assets[op.Fingerprint] = op
+		} else if first.Asset != op.Asset || first.Metadata != op.Metadata {
+			return fmt.Errorf("asset differs from line: %d", first.Line)
+		}
+
+	case batch.OpTransfer:
+		_, err = checkTxId(op.TxId)
+		if nil != err {
+			return err
+		}
+		_, err = b.m.config.Account(op.Receiver)
+
+	case batch.OpGrant:
+		_, err = checkTxId(op.ShareId)
+		if nil != err {
+			return err
+		}
+		if 0 == op.Quantity {
+			return fmt.Errorf("invalid quantity: %d", op.Quantity)
+		}
+		_, err = b.m.config.Account(op.Receiver)
+
+	default:
+		err = fmt.Errorf("unknown operation: %q", op.Op)
+	}
+	return err
+}
+
+// write a result and count it
+func (b *batchRun) record(results ...*batch.Result) error {
+	for _, result := range results {
+		if "" == result.Error {
+			b.summary.Succeeded += 1
+		} else {
+			b.summary.Failed += 1
+		}
+		if b.m.verbose {
+			fmt.Fprintf(b.m.e, "line: %d  op: %s  error: %q\n", result.Line, result.Op, result.Error)
+		}
+	}
+	return b.results.Write(results...)
+}
+
+// submit a group of issues with their assets as one Bitmarks.Create
+func (b *batchRun) create(group *batch.Group) error {
+	fail := func(err error) error {
+		results := make([]*batch.Result, len(group.Operations))
+		for i, op := range group.Operations {
+			results[i] = &batch.Result{Line: op.Line, Op: op.Op, Error: err.Error()}
+		}
+		return b.record(results...)
+	}
+
+	assets := []*transactionrecord.AssetData{}
+	issues := []*transactionrecord.BitmarkIssue{}
+	assetIds := make([]transactionrecord.AssetIdentifier, len(group.Operations))
+	registered := make(map[string]struct{})
+
+	for i, op := range group.Operations {
+		asset, err := rpccalls.SignAsset(&rpccalls.AssetData{
+			Name:        op.Asset,
+			Fingerprint: op.Fingerprint,
+			Metadata:    op.Metadata,
+			Registrant:  b.owner,
+		})
+		if nil != err {
+			return fail(err)
+		}
+		assetIds[i] = asset.AssetId()
+
+		// registering again is harmless, an existing asset is a duplicate
+		if _, ok := registered[op.Fingerprint]; !ok {
+			registered[op.Fingerprint] = struct{}{}
+			assets = append(assets, asset)
+		}
+
+		for j := 0; j < int(op.Quantity); j += 1 {
+			issue, err := rpccalls.SignIssue(b.owner, assetIds[i], batch.IssueNonce(b.digest, op, j))
+			if nil != err {
+				return fail(err)
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	if b.m.verbose {
+		fmt.Fprintf(b.m.e, "create: free: %t  assets: %d  issues: %d\n", group.Free, len(assets), len(issues))
+	}
+
+	reply, err := b.client.SubmitCreate(assets, issues)
+	if nil != err && !group.Free && 0 != len(assets) && strings.Contains(err.Error(), fault.AssetNotFound.Error()) {
+		// paid issues need a confirmed asset, so register the asset
+		// now and leave the issues as failed for a later run
+		_, err = b.client.SubmitCreate(assets, nil)
+		if nil != err {
+			return fail(err)
+		}
+		return fail(fmt.Errorf("asset: %s is not confirmed yet: paid issues need a confirmed asset, re-run the batch once it is confirmed", assetIds[0]))
+	}
+	if nil != err {
+		return fail(err)
+	}
+	if len(reply.IssueIds) != len(issues) {
+		return fail(fmt.Errorf("issues sent: %d  returned: %d", len(issues), len(reply.IssueIds)))
+	}
+
+	payId := ""
+	if 0 != len(reply.Commands) {
+		payId = reply.PayId.String()
+	}
+	proofStatus := ""
+	if group.Free {
+		proofStatus = reply.ProofStatus.String()
+	}
+
+	results := make([]*batch.Result, len(group.Operations))
+	n := 0
+	for i, op := range group.Operations {
+		txIds := make([]string, op.Quantity)
+		for j := range txIds {
+			txIds[j] = reply.IssueIds[n].String()
+			n += 1
+		}
+		results[i] = &batch.Result{
+			Line:        op.Line,
+			Op:          op.Op,
+			TxIds:       txIds,
+			AssetId:     assetIds[i].String(),
+			PayId:       payId,
+			ProofStatus: proofStatus,
+			Commands:    reply.Commands,
+		}
+	}
+	return b.record(results...)
+}
+
+// unratified transfer signed by the batch identity
+func (b *batchRun) transfer(op *batch.Operation) *batch.Result {
+	result := &batch.Result{Line: op.Line, Op: op.Op}
+
+	newOwner, err := b.m.config.Account(op.Receiver)
+	if nil != err {
+		result.Error = err.Error()
+		return result
+	}
+
+	reply, err := b.client.Transfer(&rpccalls.TransferData{
+		Owner:    b.owner,
+		NewOwner: newOwner,
+		TxId:     op.TxId,
+	})
+	if nil != err {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.TxIds = []string{reply.TransferId.String()}
+	if 0 != len(reply.Commands) {
+		result.PayId = reply.PayId.String()
+		result.Commands = reply.Commands
+	}
+	return result
+}
+
+// grant signed by the batch identity, the recipient must countersign
+func (b *batchRun) grant(op *batch.Operation) *batch.Result {
+	result := &batch.Result{Line: op.Line, Op: op.Op}
+
+	recipient, err := b.m.config.Account(op.Receiver)
+	if nil != err {
+		result.Error = err.Error()
+		return result
+	}
+
+	// one expiry for all grants that do not give their own
+	beforeBlock := op.BeforeBlock
+	if 0 == beforeBlock {
+		if 0 == b.beforeBlock {
+			info, err := b.client.GetBitmarkInfo()
+			if nil != err {
+				result.Error = err.Error()
+				return result
+			}
+			b.beforeBlock = info.Block.Height + offlineBeforeBlockMargin
+		}
+		beforeBlock = b.beforeBlock
+	}
+
+	reply, err := b.client.Grant(&rpccalls.GrantData{
+		ShareId:     op.ShareId,
+		Quantity:    op.Quantity,
+		Owner:       b.owner,
+		Recipient:   recipient,
+		BeforeBlock: beforeBlock,
+	})
+	if nil != err {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Countersign = reply.Grant
+	return result
+}
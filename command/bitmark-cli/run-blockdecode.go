@@ -31,7 +31,7 @@ func runBlockDecode(c *cli.Context) error {
 		fmt.Fprintf(m.e, "packed: %s\n", packed)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
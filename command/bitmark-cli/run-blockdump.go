@@ -33,7 +33,7 @@ func runBlockDump(c *cli.Context) error {
 		fmt.Fprintf(m.e, "decode txs: %t\n", txs)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
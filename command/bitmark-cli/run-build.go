@@ -0,0 +1,439 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/offline"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// blocks allowed for a grant or swap to be countersigned and mined
+const offlineBeforeBlockMargin = 1000
+
+// add unsigned records to the output file, an existing file is
+// appended to so several records can be signed in one pass
+func buildOutput(c *cli.Context, m *metadata, records []transactionrecord.Transaction, signer *account.Account) error {
+	fileName, err := checkFileName(c.String("output"))
+	if nil != err {
+		return err
+	}
+
+	file := offline.New(m.testnet)
+	if _, err := os.Stat(fileName); nil == err {
+		file, err = offline.Read(fileName)
+		if nil != err {
+			return err
+		}
+		if file.IsTesting() != m.testnet {
+			return fmt.Errorf("file: %q is for network: %s", fileName, file.Network)
+		}
+	}
+
+	for _, r := range records {
+		err := file.Add(r, signer)
+		if nil != err {
+			return err
+		}
+	}
+
+	err = file.Write(fileName)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "file: %q  added: %d  total: %d\n", fileName, len(records), len(file.Transactions))
+	}
+
+	printJson(m.w, file)
+	return nil
+}
+
+// current height plus a margin for the offline signing round trip
+func offlineBeforeBlock(m *metadata, beforeBlock uint64) (uint64, error) {
+	if 0 != beforeBlock {
+		return beforeBlock, nil
+	}
+
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+	if nil != err {
+		return 0, err
+	}
+	defer client.Close()
+
+	info, err := client.GetBitmarkInfo()
+	if nil != err {
+		return 0, err
+	}
+	return info.Block.Height + offlineBeforeBlockMargin, nil
+}
+
+func runBuildCreate(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	assetName := c.String("asset")
+
+	fingerprint, err := checkAssetFingerprint(c.String("fingerprint"))
+	if nil != err {
+		return err
+	}
+
+	metadata, err := checkAssetMetadata(c.String("metadata"))
+	if nil != err {
+		return err
+	}
+
+	quantity := c.Int("quantity")
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	name, registrant, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "issuer: %s\n", name)
+		fmt.Fprintf(m.e, "assetName: %q\n", assetName)
+		fmt.Fprintf(m.e, "fingerprint: %q\n", fingerprint)
+		fmt.Fprintf(m.e, "quantity: %d\n", quantity)
+	}
+
+	asset := &transactionrecord.AssetData{
+		Name:        assetName,
+		Fingerprint: fingerprint,
+		Metadata:    metadata,
+		Registrant:  registrant,
+	}
+	records := []transactionrecord.Transaction{asset}
+
+	// same nonce choice as create: a single issue is a free issue
+	nonce := uint64(time.Now().UTC().Unix() * 1000)
+	if 1 == quantity {
+		nonce = 0
+	}
+	for i := 0; i < quantity; i += 1 {
+		records = append(records, &transactionrecord.BitmarkIssue{
+			AssetId: asset.AssetId(),
+			Owner:   registrant,
+			Nonce:   nonce + uint64(i),
+		})
+	}
+
+	return buildOutput(c, m, records, registrant)
+}
+
+func runBuildTransfer(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	txId, err := checkTxId(c.String("txid"))
+	if nil != err {
+		return err
+	}
+	var link merkle.Digest
+	err = link.UnmarshalText([]byte(txId))
+	if nil != err {
+		return err
+	}
+
+	to, recipient, err := checkRecipient(c, "receiver", m.config)
+	if nil != err {
+		return err
+	}
+
+	from, owner, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "txid: %s\n", txId)
+		fmt.Fprintf(m.e, "receiver: %s\n", to)
+		fmt.Fprintf(m.e, "sender: %s\n", from)
+	}
+
+	var transfer transactionrecord.Transaction
+	if c.Bool("unratified") {
+		transfer = &transactionrecord.BitmarkTransferUnratified{
+			Link:  link,
+			Owner: recipient,
+		}
+	} else {
+		transfer = &transactionrecord.BitmarkTransferCountersigned{
+			Link:  link,
+			Owner: recipient,
+		}
+	}
+
+	return buildOutput(c, m, []transactionrecord.Transaction{transfer}, owner)
+}
+
+func runBuildShare(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	txId, err := checkTxId(c.String("txid"))
+	if nil != err {
+		return err
+	}
+	var link merkle.Digest
+	err = link.UnmarshalText([]byte(txId))
+	if nil != err {
+		return err
+	}
+
+	quantity := c.Int("quantity")
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	from, owner, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "from: %s\n", from)
+		fmt.Fprintf(m.e, "txid: %s\n", txId)
+		fmt.Fprintf(m.e, "quantity: %d\n", quantity)
+	}
+
+	share := &transactionrecord.BitmarkShare{
+		Link:     link,
+		Quantity: uint64(quantity),
+	}
+
+	return buildOutput(c, m, []transactionrecord.Transaction{share}, owner)
+}
+
+func runBuildRedeem(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	txId, err := checkTxId(c.String("txid"))
+	if nil != err {
+		return err
+	}
+	var link merkle.Digest
+	err = link.UnmarshalText([]byte(txId))
+	if nil != err {
+		return err
+	}
+
+	quantity := c.Uint64("quantity")
+	if 0 == quantity {
+		return fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	from, owner, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "from: %s\n", from)
+		fmt.Fprintf(m.e, "txid: %s\n", txId)
+		fmt.Fprintf(m.e, "quantity: %d\n", quantity)
+	}
+
+	redemption := &transactionrecord.ShareRedemption{
+		Link:     link,
+		Quantity: quantity,
+		Owner:    owner,
+	}
+
+	return buildOutput(c, m, []transactionrecord.Transaction{redemption}, owner)
+}
+
+func runBuildGrant(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	to, recipient, err := checkRecipient(c, "receiver", m.config)
+	if nil != err {
+		return err
+	}
+
+	shareId, err := checkTxId(c.String("share-id"))
+	if nil != err {
+		return err
+	}
+	var id merkle.Digest
+	err = id.UnmarshalText([]byte(shareId))
+	if nil != err {
+		return err
+	}
+
+	quantity := c.Uint64("quantity")
+	if quantity == 0 {
+		return fmt.Errorf("invalid quantity: %d", quantity)
+	}
+
+	from, owner, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	beforeBlock, err := offlineBeforeBlock(m, c.Uint64("before-block"))
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "shareId: %s\n", shareId)
+		fmt.Fprintf(m.e, "quantity: %d\n", quantity)
+		fmt.Fprintf(m.e, "sender: %s\n", from)
+		fmt.Fprintf(m.e, "receiver: %s\n", to)
+		fmt.Fprintf(m.e, "beforeBlock: %d\n", beforeBlock)
+	}
+
+	grant := &transactionrecord.ShareGrant{
+		ShareId:     id,
+		Quantity:    quantity,
+		Owner:       owner,
+		Recipient:   recipient,
+		BeforeBlock: beforeBlock,
+	}
+
+	return buildOutput(c, m, []transactionrecord.Transaction{grant}, owner)
+}
+
+func runBuildSwap(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	to, recipient, err := checkRecipient(c, "receiver", m.config)
+	if nil != err {
+		return err
+	}
+
+	shareIdOne, err := checkTxId(c.String("share-id-one"))
+	if nil != err {
+		return err
+	}
+	var idOne merkle.Digest
+	err = idOne.UnmarshalText([]byte(shareIdOne))
+	if nil != err {
+		return err
+	}
+
+	quantityOne := c.Uint64("quantity-one")
+	if quantityOne == 0 {
+		return fmt.Errorf("invalid quantity-one: %d", quantityOne)
+	}
+
+	shareIdTwo, err := checkTxId(c.String("share-id-two"))
+	if nil != err {
+		return err
+	}
+	var idTwo merkle.Digest
+	err = idTwo.UnmarshalText([]byte(shareIdTwo))
+	if nil != err {
+		return err
+	}
+
+	quantityTwo := c.Uint64("quantity-two")
+	if quantityTwo == 0 {
+		return fmt.Errorf("invalid quantity-two: %d", quantityTwo)
+	}
+
+	from, owner, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	beforeBlock, err := offlineBeforeBlock(m, c.Uint64("before-block"))
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "shareIdOne: %s\n", shareIdOne)
+		fmt.Fprintf(m.e, "quantityOne: %d\n", quantityOne)
+		fmt.Fprintf(m.e, "ownerOne: %s\n", from)
+		fmt.Fprintf(m.e, "shareIdTwo: %s\n", shareIdTwo)
+		fmt.Fprintf(m.e, "quantityTwo: %d\n", quantityTwo)
+		fmt.Fprintf(m.e, "ownerTwo: %s\n", to)
+		fmt.Fprintf(m.e, "beforeBlock: %d\n", beforeBlock)
+	}
+
+	swap := &transactionrecord.ShareSwap{
+		ShareIdOne:  idOne,
+		QuantityOne: quantityOne,
+		OwnerOne:    owner,
+		ShareIdTwo:  idTwo,
+		QuantityTwo: quantityTwo,
+		OwnerTwo:    recipient,
+		BeforeBlock: beforeBlock,
+	}
+
+	return buildOutput(c, m, []transactionrecord.Transaction{swap}, owner)
+}
+
+func runBuildBlockTransfer(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	txId, err := checkTxId(c.String("txid"))
+	if nil != err {
+		return err
+	}
+	var link merkle.Digest
+	err = link.UnmarshalText([]byte(txId))
+	if nil != err {
+		return err
+	}
+
+	to, newOwner, err := checkRecipient(c, "receiver", m.config)
+	if nil != err {
+		return err
+	}
+
+	bitcoinAddress, err := checkCoinAddress(currency.Bitcoin, c.String("bitcoin"), m.testnet)
+	if nil != err {
+		return err
+	}
+	litecoinAddress, err := checkCoinAddress(currency.Litecoin, c.String("litecoin"), m.testnet)
+	if nil != err {
+		return err
+	}
+
+	from, owner, err := checkOwnerAccount(c.GlobalString("identity"), m.config)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "txid: %s\n", txId)
+		fmt.Fprintf(m.e, "receiver: %s\n", to)
+		fmt.Fprintf(m.e, "sender: %s\n", from)
+	}
+
+	transfer := &transactionrecord.BlockOwnerTransfer{
+		Link:    link,
+		Version: 1,
+		Payments: currency.Map{
+			currency.Bitcoin:  bitcoinAddress,
+			currency.Litecoin: litecoinAddress,
+		},
+		Owner: newOwner,
+	}
+
+	return buildOutput(c, m, []transactionrecord.Transaction{transfer}, owner)
+}
@@ -6,7 +6,12 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+	"github.com/bitmark-inc/bitmarkd/fault"
 )
 
 func runChangePassword(c *cli.Context) error {
@@ -19,13 +24,22 @@ func runChangePassword(c *cli.Context) error {
 		return err
 	}
 
+	// no password to change for an external signer
+	if nil != owner.Signer {
+		return fault.NotPrivateKey
+	}
+
 	// prompt new password and confirm
 	newPassword, err := promptNewPassword()
 	if nil != err {
 		return err
 	}
 
-	err = m.config.AddIdentity(name, owner.Description, owner.Seed, newPassword)
+	// also upgrades the identity to the current encryption format
+	if m.verbose && m.config.Identities[name].NeedsUpgrade() {
+		fmt.Fprintf(m.e, "upgrade: %s  from version: %d  to version: %d\n", name, m.config.Identities[name].EncryptionVersion(), configuration.CurrentIdentityVersion)
+	}
+	err = m.config.ChangePassword(name, owner, newPassword)
 	if nil != err {
 		return err
 	}
@@ -34,7 +34,7 @@ func runCountersign(c *cli.Context) error {
 		fmt.Fprintf(m.e, "receiver: %s\n", to)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
 )
 
 func runCreate(c *cli.Context) error {
@@ -57,7 +58,7 @@ func runCreate(c *cli.Context) error {
 		fmt.Fprintf(m.e, "quantity: %d\n", quantity)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
@@ -99,5 +100,17 @@ func runCreate(c *cli.Context) error {
 	}
 
 	printJson(m.w, response)
-	return nil
+
+	if !c.Bool("watch") || 0 == len(response.IssueIds) {
+		return nil
+	}
+	if reservoir.TrackingInvalid == response.ProofStatus {
+		return &watchError{
+			code:    exitWatchInvalid,
+			message: "free issue proof was rejected",
+		}
+	}
+
+	// all issues share one pay id, so follow the first
+	return watchTransaction(m, client, response.IssueIds[0].String(), response.Commands, c.Duration("timeout"))
 }
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+)
+
+func runDiscover(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+	if nil != err {
+		return err
+	}
+	defer client.Close()
+
+	nodes, err := client.ListNodes()
+	if nil != err {
+		return err
+	}
+
+	known := make(map[string]struct{})
+	for _, connect := range m.config.Connections {
+		known[connect] = struct{}{}
+	}
+
+	added := []string{}
+	for _, n := range nodes {
+		for _, connect := range n.Connections {
+			if _, ok := known[connect]; ok {
+				continue
+			}
+			known[connect] = struct{}{}
+			added = append(added, connect)
+		}
+	}
+
+	if c.Bool("add") && 0 != len(added) {
+		if m.verbose {
+			fmt.Fprintf(m.e, "adding %d connections\n", len(added))
+		}
+		m.config.Connections = append(m.config.Connections, added...)
+		m.save = true
+	}
+
+	type discovered struct {
+		Source string               `json:"source"`
+		Nodes  []rpccalls.NodeEntry `json:"nodes"`
+		New    []string             `json:"new"`
+		Added  bool                 `json:"added"`
+	}
+
+	printJson(m.w, discovered{
+		Source: client.Connection(),
+		Nodes:  nodes,
+		New:    added,
+		Added:  m.save,
+	})
+
+	return nil
+}
@@ -25,7 +25,7 @@ func runFullProvenance(c *cli.Context) error {
 		fmt.Fprintf(m.e, "bitmark id: %s\n", bitmarkId)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
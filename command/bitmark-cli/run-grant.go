@@ -47,7 +47,7 @@ func runGrant(c *cli.Context) error {
 		fmt.Fprintf(m.e, "beforeBlock: %d\n", beforeBlock)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+)
+
+// write identities to an encrypted bundle
+func runIdentityExport(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	fileName, err := checkFileName(c.String("file"))
+	if nil != err {
+		return err
+	}
+	if _, err := os.Stat(fileName); nil == err {
+		return fmt.Errorf("file: %q already exists", fileName)
+	}
+
+	names := []string{}
+	if c.Bool("all") {
+		for name := range m.config.Identities {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	} else {
+		name := c.GlobalString("identity")
+		if "" == name {
+			name = m.config.DefaultIdentity
+		}
+		names = append(names, name)
+	}
+
+	entries := make([]*configuration.BundleIdentity, 0, len(names))
+	for _, name := range names {
+		id, err := m.config.Identity(name)
+		if nil != err {
+			return err
+		}
+
+		// only identities with a secret key need their password
+		var owner *configuration.Private
+		if "" != id.Data {
+			_, owner, err = checkOwnerWithPasswordPrompt(name, m.config, c)
+			if nil != err {
+				return err
+			}
+		}
+
+		entry, err := m.config.BundleEntry(name, owner)
+		if nil != err {
+			return err
+		}
+		entries = append(entries, entry)
+
+		if m.verbose {
+			fmt.Fprintf(m.e, "export: %s  account: %s\n", name, entry.Account)
+		}
+	}
+
+	// the bundle has its own password
+	password, err := promptNewPassword()
+	if nil != err {
+		return err
+	}
+
+	bundle, err := m.config.MakeBundle(entries, password)
+	if nil != err {
+		return err
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if nil != err {
+		return err
+	}
+	err = ioutil.WriteFile(fileName, append(data, '\n'), 0600)
+	if nil != err {
+		return err
+	}
+
+	printJson(m.w, names)
+	return nil
+}
+
+// add identities from an encrypted bundle
+func runIdentityImport(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	fileName, err := checkFileName(c.String("file"))
+	if nil != err {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		return err
+	}
+	bundle := &configuration.Bundle{}
+	err = json.Unmarshal(data, bundle)
+	if nil != err {
+		return err
+	}
+
+	bundlePassword, err := promptPassword(fileName)
+	if nil != err {
+		return err
+	}
+
+	entries, err := bundle.Open(bundlePassword)
+	if nil != err {
+		return err
+	}
+
+	// secret keys are stored with a new local password
+	password := c.GlobalString("password")
+	if "" == password {
+		password, err = promptNewPassword()
+		if nil != err {
+			return err
+		}
+	}
+
+	err = m.config.Import(bundle, entries, password)
+	if nil != err {
+		return err
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+		if m.verbose {
+			fmt.Fprintf(m.e, "import: %s  account: %s\n", entry.Name, entry.Account)
+		}
+	}
+
+	// require configuration update
+	m.save = true
+
+	printJson(m.w, names)
+	return nil
+}
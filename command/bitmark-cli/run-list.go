@@ -38,6 +38,9 @@ func runList(c *cli.Context) error {
 			Name        string `json:"name"`
 			Account     string `json:"account"`
 			Description string `json:"description"`
+			Path        string `json:"path,omitempty"`
+			Signer      string `json:"signer,omitempty"`
+			Version     int    `json:"version,omitempty"`
 		}
 		jsonData := make([]item, len(names))
 
@@ -46,6 +49,11 @@ func runList(c *cli.Context) error {
 			jsonData[i].Name = name
 			jsonData[i].Account = identities[name].Account
 			jsonData[i].Description = identities[name].Description
+			jsonData[i].Path = identities[name].Path
+			jsonData[i].Signer = identities[name].Signer
+			if jsonData[i].HasSecret {
+				jsonData[i].Version = identities[name].EncryptionVersion()
+			}
 		}
 
 		printJson(m.w, jsonData)
@@ -55,6 +63,8 @@ func runList(c *cli.Context) error {
 			flag := "--"
 			if len(identities[name].Salt) > 0 {
 				flag = "SK"
+			} else if identities[name].HasSigner() {
+				flag = "XS"
 			}
 			fmt.Fprintf(m.w, "%s %-20s  %s  %q\n", flag, name, identities[name].Account, identities[name].Description)
 		}
@@ -43,24 +43,18 @@ func runOwned(c *cli.Context) error {
 		return err
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
-	if nil != err {
-		return err
-	}
-	defer client.Close()
-
 	ownedConfig := &rpccalls.OwnedData{
 		Owner: owner,
 		Start: start,
 		Count: count,
 	}
 
-	response, err := client.GetOwned(ownedConfig)
-	if nil != err {
-		return err
+	response, err := quorumRead(m, func(client *rpccalls.Client) (interface{}, error) {
+		return client.GetOwned(ownedConfig)
+	})
+	if nil != response {
+		printJson(m.w, response)
 	}
 
-	printJson(m.w, response)
-
-	return nil
+	return err
 }
@@ -32,12 +32,6 @@ func runProvenance(c *cli.Context) error {
 		fmt.Fprintf(m.e, "count: %d\n", count)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
-	if nil != err {
-		return err
-	}
-	defer client.Close()
-
 	// map for adding identity name to provenance records
 	ids := make(map[string]string)
 	for name, id := range m.config.Identities {
@@ -50,12 +44,12 @@ func runProvenance(c *cli.Context) error {
 		Identities: ids,
 	}
 
-	response, err := client.GetProvenance(provenanceConfig)
-	if nil != err {
-		return err
+	response, err := quorumRead(m, func(client *rpccalls.Client) (interface{}, error) {
+		return client.GetProvenance(provenanceConfig)
+	})
+	if nil != response {
+		printJson(m.w, response)
 	}
 
-	printJson(m.w, response)
-
-	return nil
+	return err
 }
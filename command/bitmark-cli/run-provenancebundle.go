@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/provenance"
+	"github.com/urfave/cli"
+)
+
+func runExportProvenance(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	txId := c.String("txid")
+	bitmarkId := c.String("bitmarkid")
+	if ("" == txId) == ("" == bitmarkId) {
+		return fmt.Errorf("only one of txid or bitmarkid must be given")
+	}
+
+	var err error
+	if "" != txId {
+		txId, err = checkTxId(txId)
+	} else {
+		bitmarkId, err = checkTxId(bitmarkId)
+	}
+	if nil != err {
+		return err
+	}
+
+	output := c.String("output")
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "txid: %s\n", txId)
+		fmt.Fprintf(m.e, "bitmark id: %s\n", bitmarkId)
+		fmt.Fprintf(m.e, "output: %s\n", output)
+	}
+
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+	if nil != err {
+		return err
+	}
+	defer client.Close()
+
+	bundleConfig := &rpccalls.ProvenanceBundleData{
+		Chain:     strings.ToLower(c.GlobalString("network")),
+		TxId:      txId,
+		BitmarkId: bitmarkId,
+	}
+
+	bundle, err := client.GetProvenanceBundle(bundleConfig)
+	if nil != err {
+		return err
+	}
+
+	if c.Bool("sign") {
+		from, owner, err := checkOwnerWithPasswordPrompt(c.GlobalString("identity"), m.config, c)
+		if nil != err {
+			return err
+		}
+		if m.verbose {
+			fmt.Fprintf(m.e, "signer: %s\n", from)
+		}
+		err = bundle.Sign(owner.Account(), owner.Sign)
+		if nil != err {
+			return err
+		}
+	}
+
+	if "" == output {
+		printJson(m.w, bundle)
+		return nil
+	}
+
+	buffer, err := json.MarshalIndent(bundle, "", "  ")
+	if nil != err {
+		return err
+	}
+	return ioutil.WriteFile(output, append(buffer, '\n'), 0644)
+}
+
+// does not need a configuration or a node
+func runVerifyProvenance(c *cli.Context) error {
+
+	network := strings.ToLower(c.GlobalString("network"))
+	if !chain.Valid(network) {
+		return fmt.Errorf("network: %q can only be bitmark/testing/local", network)
+	}
+
+	fileName, err := checkFileName(c.String("file"))
+	if nil != err {
+		return err
+	}
+
+	options := &provenance.Options{
+		Chain:             network,
+		AllowLocal:        c.Bool("allow-local"),
+		MinimumDifficulty: c.Float64("min-difficulty"),
+	}
+
+	for _, s := range c.StringSlice("block") {
+		var digest blockdigest.Digest
+		if err := digest.UnmarshalText([]byte(s)); nil != err {
+			return fmt.Errorf("block: %q is not a valid block hash", s)
+		}
+		options.TrustedBlocks = append(options.TrustedBlocks, digest)
+	}
+	for _, s := range c.StringSlice("signer") {
+		signer, err := account.AccountFromBase58(s)
+		if nil != err {
+			return fmt.Errorf("signer: %q is not a valid account: %s", s, err)
+		}
+		options.TrustedSigners = append(options.TrustedSigners, signer)
+	}
+	if 0 == len(options.TrustedBlocks) && 0 == len(options.TrustedSigners) {
+		return fmt.Errorf("at least one trusted block or signer must be given")
+	}
+
+	buffer, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		return err
+	}
+
+	bundle := &provenance.Bundle{}
+	err = json.Unmarshal(buffer, bundle)
+	if nil != err {
+		return fault.InvalidProvenanceBundle
+	}
+
+	result, err := provenance.Verify(bundle, options)
+	if nil != err {
+		return err
+	}
+
+	printJson(c.App.Writer, result)
+	return nil
+}
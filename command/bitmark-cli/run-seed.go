@@ -13,6 +13,7 @@ import (
 
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/configuration"
+	"github.com/bitmark-inc/bitmarkd/fault"
 )
 
 // merge the account string to private data
@@ -33,6 +34,11 @@ func runSeed(c *cli.Context) error {
 		return err
 	}
 
+	// the secret is held by the external signer
+	if nil != owner.Signer {
+		return fault.NotPrivateKey
+	}
+
 	phrase, err := account.Base58EncodedSeedToPhrase(owner.Seed)
 	if nil != err {
 		return err
@@ -46,7 +52,7 @@ func runSeed(c *cli.Context) error {
 	result := seedResult{
 		Private: owner,
 		Name:    name,
-		Account: owner.PrivateKey.Account().String(),
+		Account: owner.Account().String(),
 		Phrase:  strings.Join(phrase, " "),
 	}
 
@@ -75,7 +75,7 @@ func runSetup(c *cli.Context) error {
 		}
 	}
 
-	err = config.AddIdentity(name, description, seed, password)
+	err = config.AddIdentity(name, description, seed, "", password)
 	if nil != err {
 		return err
 	}
@@ -38,7 +38,7 @@ func runShare(c *cli.Context) error {
 		fmt.Fprintf(m.e, "quantity: %d\n", quantity)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
@@ -44,7 +44,10 @@ func runSign(c *cli.Context) error {
 		return err
 	}
 
-	signature := ed25519.Sign(owner.PrivateKey.PrivateKeyBytes(), data)
+	signature, err := owner.Sign(data)
+	if nil != err {
+		return err
+	}
 	s := hex.EncodeToString(signature)
 
 	if m.verbose {
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/offline"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// summary of one record for display before and after signing
+type offlineRecordStatus struct {
+	Type       string                        `json:"type"`
+	NextSigner string                        `json:"nextSigner,omitempty"`
+	Complete   bool                          `json:"complete"`
+	Record     transactionrecord.Transaction `json:"record"`
+}
+
+func offlineStatus(records []*offline.Record) []offlineRecordStatus {
+	status := make([]offlineRecordStatus, len(records))
+	for i, r := range records {
+		name, _ := transactionrecord.RecordName(r.Transaction)
+		status[i] = offlineRecordStatus{
+			Type:     name,
+			Complete: r.IsComplete(),
+			Record:   r.Transaction,
+		}
+		if next := r.NextSigner(); nil != next {
+			status[i].NextSigner = next.String()
+		}
+	}
+	return status
+}
+
+// read a transaction file for the current network
+func readOfflineFile(m *metadata, fileName string) (*offline.File, []*offline.Record, error) {
+	file, err := offline.Read(fileName)
+	if nil != err {
+		return nil, nil, err
+	}
+	if file.IsTesting() != m.testnet {
+		return nil, nil, fmt.Errorf("file: %q is for network: %s", fileName, file.Network)
+	}
+
+	records, err := file.Records()
+	if nil != err {
+		return nil, nil, err
+	}
+	return file, records, nil
+}
+
+func runSignTx(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	fileName, err := checkFileName(c.String("file"))
+	if nil != err {
+		return err
+	}
+
+	output := c.String("output")
+	if "" == output {
+		output = fileName
+	}
+
+	file, records, err := readOfflineFile(m, fileName)
+	if nil != err {
+		return err
+	}
+
+	name, owner, err := checkOwnerWithPasswordPrompt(c.GlobalString("identity"), m.config, c)
+	if nil != err {
+		return err
+	}
+	signer := owner.Account()
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "signer: %s\n", name)
+		fmt.Fprintf(m.e, "file: %q  records: %d\n", fileName, len(records))
+	}
+
+	signed := 0
+	for i, r := range records {
+		next := r.NextSigner()
+		if nil == next || !bytes.Equal(next.Bytes(), signer.Bytes()) {
+			continue
+		}
+		err := r.Sign(owner)
+		if nil != err {
+			return fmt.Errorf("record: %d  error: %s", i, err)
+		}
+		signed += 1
+	}
+
+	if 0 == signed {
+		return fmt.Errorf("no records to be signed by: %s", name)
+	}
+
+	err = file.Update(records)
+	if nil != err {
+		return err
+	}
+	err = file.Write(output)
+	if nil != err {
+		return err
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "signed: %d  output: %q\n", signed, output)
+	}
+
+	printJson(m.w, offlineStatus(records))
+	return nil
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+func runSubmit(c *cli.Context) error {
+
+	m := c.App.Metadata["config"].(*metadata)
+
+	fileName, err := checkFileName(c.String("file"))
+	if nil != err {
+		return err
+	}
+
+	_, records, err := readOfflineFile(m, fileName)
+	if nil != err {
+		return err
+	}
+
+	for i, r := range records {
+		if !r.IsComplete() {
+			return fmt.Errorf("record: %d  next signer: %s  error: %s", i, r.NextSigner(), fault.TransactionIsNotFullySigned)
+		}
+	}
+
+	if m.verbose {
+		fmt.Fprintf(m.e, "file: %q  records: %d\n", fileName, len(records))
+	}
+
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
+	if nil != err {
+		return err
+	}
+	defer client.Close()
+
+	// assets and issues are sent together, everything else in file order
+	assets := []*transactionrecord.AssetData{}
+	issues := []*transactionrecord.BitmarkIssue{}
+	responses := []interface{}{}
+
+	for i, r := range records {
+		var response interface{}
+
+		switch tx := r.Transaction.(type) {
+		case *transactionrecord.AssetData:
+			assets = append(assets, tx)
+			continue
+		case *transactionrecord.BitmarkIssue:
+			issues = append(issues, tx)
+			continue
+		case *transactionrecord.BitmarkTransferUnratified:
+			response, err = client.SubmitTransfer(tx)
+		case *transactionrecord.BitmarkTransferCountersigned:
+			response, err = client.SubmitTransfer(tx)
+		case *transactionrecord.BitmarkShare:
+			response, err = client.SubmitShare(tx)
+		case *transactionrecord.ShareRedemption:
+			response, err = client.SubmitRedemption(tx)
+		case *transactionrecord.ShareGrant:
+			response, err = client.CountersignGrant(tx)
+		case *transactionrecord.ShareSwap:
+			response, err = client.CountersignSwap(tx)
+		case *transactionrecord.AtomicSwap:
+			response, err = client.CountersignAtomicSwap(tx)
+		case *transactionrecord.BlockOwnerTransfer:
+			response, err = client.CountersignBlockTransfer(tx)
+		default:
+			err = fault.UnexpectedTransactionRecord
+		}
+
+		if nil != err {
+			printJson(m.w, responses)
+			return fmt.Errorf("record: %d  error: %s", i, err)
+		}
+		responses = append(responses, response)
+	}
+
+	if 0 != len(assets) || 0 != len(issues) {
+		response, err := client.SubmitCreate(assets, issues)
+		if nil != err {
+			printJson(m.w, responses)
+			return err
+		}
+		responses = append(responses, response)
+	}
+
+	printJson(m.w, responses)
+	return nil
+}
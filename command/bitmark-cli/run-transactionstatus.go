@@ -26,12 +26,16 @@ func runTransactionStatus(c *cli.Context) error {
 		fmt.Fprintf(m.e, "txid: %s\n", txId)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
 	defer client.Close()
 
+	if c.Bool("watch") {
+		return watchTransaction(m, client, txId, nil, c.Duration("timeout"))
+	}
+
 	statusConfig := &rpccalls.TransactionStatusData{
 		TxId: txId,
 	}
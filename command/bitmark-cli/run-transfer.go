@@ -38,7 +38,7 @@ func runTransfer(c *cli.Context) error {
 		fmt.Fprintf(m.e, "sender: %s\n", from)
 	}
 
-	client, err := rpccalls.NewClient(m.testnet, m.config.Connections[m.connectionOffset], m.verbose, m.e)
+	client, err := rpccalls.NewClient(m.testnet, m.config.Connections, m.connectionOffset, m.verbose, m.e)
 	if nil != err {
 		return err
 	}
@@ -59,7 +59,15 @@ func runTransfer(c *cli.Context) error {
 
 		printJson(m.w, response)
 
+		if c.Bool("watch") {
+			return watchTransaction(m, client, response.TransferId.String(), response.Commands, c.Duration("timeout"))
+		}
+
 	} else {
+		if c.Bool("watch") {
+			return fmt.Errorf("only an unratified transfer can be watched")
+		}
+
 		response, err := client.SingleSignedTransfer(transferConfig)
 		if nil != err {
 			return err
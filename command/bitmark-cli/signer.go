@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+const (
+	signerProtocolVersion = 1
+
+	signerRequestAccount = "account"
+	signerRequestSign    = "sign"
+)
+
+// the signer program is executed once per request, it reads a single
+// JSON request from stdin and writes a single JSON reply to stdout;
+// stderr is passed through so a device can prompt the user
+//
+//	request: {"version":1,"request":"sign","identity":"<name>","account":"<base58>",
+//	          "testnet":true,"operation":"<command>","message":"<hex>"}
+//	reply:   {"signature":"<hex>"} or {"error":"<text>"}
+//
+// the "account" request omits account and message and its reply is
+// {"account":"<base58>"}; it is only used when adding an identity
+type signerRequest struct {
+	Version   int    `json:"version"`
+	Request   string `json:"request"`
+	Identity  string `json:"identity"`
+	Account   string `json:"account,omitempty"`
+	Testnet   bool   `json:"testnet"`
+	Operation string `json:"operation,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+type signerReply struct {
+	Account   string `json:"account"`
+	Signature string `json:"signature"`
+	Error     string `json:"error"`
+}
+
+// an identity whose key is held by an external program
+type programSigner struct {
+	program   string
+	name      string
+	account   *account.Account
+	operation string
+}
+
+func newProgramSigner(program string, name string, acc *account.Account, operation string) *programSigner {
+	return &programSigner{
+		program:   program,
+		name:      name,
+		account:   acc,
+		operation: operation,
+	}
+}
+
+// Account - the account the program signs for
+func (signer *programSigner) Account() *account.Account {
+	return signer.account
+}
+
+// Sign - ask the program for a signature and verify it
+func (signer *programSigner) Sign(message []byte) ([]byte, error) {
+	reply, err := runSignerProgram(signer.program, &signerRequest{
+		Version:   signerProtocolVersion,
+		Request:   signerRequestSign,
+		Identity:  signer.name,
+		Account:   signer.account.String(),
+		Testnet:   signer.account.IsTesting(),
+		Operation: signer.operation,
+		Message:   hex.EncodeToString(message),
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	signature, err := hex.DecodeString(reply.Signature)
+	if nil != err {
+		return nil, err
+	}
+
+	err = signer.account.CheckSignature(message, signature)
+	if nil != err {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// accountFromSigner - ask the program which account it holds
+func accountFromSigner(program string, name string, testnet bool) (*account.Account, error) {
+	reply, err := runSignerProgram(program, &signerRequest{
+		Version:  signerProtocolVersion,
+		Request:  signerRequestAccount,
+		Identity: name,
+		Testnet:  testnet,
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	acc, err := account.AccountFromBase58(reply.Account)
+	if nil != err {
+		return nil, err
+	}
+	if acc.IsTesting() != testnet {
+		return nil, fault.WrongNetworkForPublicKey
+	}
+	return acc, nil
+}
+
+func runSignerProgram(program string, request *signerRequest) (*signerReply, error) {
+	input, err := json.Marshal(request)
+	if nil != err {
+		return nil, err
+	}
+
+	cmd := exec.Command(program)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if nil != err {
+		return nil, fmt.Errorf("signer: %q  error: %s", program, err)
+	}
+
+	var reply signerReply
+	err = json.Unmarshal(out, &reply)
+	if nil != err {
+		return nil, fmt.Errorf("signer: %q  invalid reply: %s", program, err)
+	}
+	if "" != reply.Error {
+		return nil, fmt.Errorf("signer: %q  refused: %s", program, reply.Error)
+	}
+	return &reply, nil
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+const (
+	helperSignerEnv = "BITMARK_CLI_TEST_SIGNER"
+	helperKeyEnv    = "BITMARK_CLI_TEST_SIGNER_KEY"
+)
+
+// acts as the signer program when run from the wrapper script
+func TestHelperSigner(t *testing.T) {
+	mode := os.Getenv(helperSignerEnv)
+	if "" == mode {
+		return
+	}
+
+	private, _ := hex.DecodeString(os.Getenv(helperKeyEnv))
+	acc := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: private[ed25519.SeedSize:],
+		},
+	}
+
+	var request signerRequest
+	json.NewDecoder(os.Stdin).Decode(&request)
+
+	reply := signerReply{}
+	switch {
+	case "refuse" == mode:
+		reply.Error = "user declined"
+	case signerRequestAccount == request.Request:
+		reply.Account = acc.String()
+	case signerRequestSign == request.Request && acc.String() == request.Account:
+		message, _ := hex.DecodeString(request.Message)
+		if "wrong" == mode {
+			message = append(message, 0x00)
+		}
+		reply.Signature = hex.EncodeToString(ed25519.Sign(private, message))
+	default:
+		reply.Error = "unknown request"
+	}
+	json.NewEncoder(os.Stdout).Encode(reply)
+	os.Exit(0)
+}
+
+// create a program that runs this test binary as a signer
+func makeSignerProgram(t *testing.T, dir string, mode string, private ed25519.PrivateKey) string {
+	program := filepath.Join(dir, "signer-"+mode)
+	script := fmt.Sprintf("#!/bin/sh\n%s=%s %s=%x exec %q -test.run=TestHelperSigner\n",
+		helperSignerEnv, mode, helperKeyEnv, []byte(private), os.Args[0])
+	err := ioutil.WriteFile(program, []byte(script), 0700)
+	if nil != err {
+		t.Fatalf("write signer program error: %s", err)
+	}
+	return program
+}
+
+func TestProgramSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer")
+	if nil != err {
+		t.Fatalf("temp dir error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, private, err := ed25519.GenerateKey(nil)
+	if nil != err {
+		t.Fatalf("generate key error: %s", err)
+	}
+
+	program := makeSignerProgram(t, dir, "sign", private)
+
+	acc, err := accountFromSigner(program, "hw", true)
+	if nil != err {
+		t.Fatalf("account from signer error: %s", err)
+	}
+	if !bytes.Equal(private[ed25519.SeedSize:], acc.PublicKeyBytes()) {
+		t.Fatalf("account: %s  does not match key", acc)
+	}
+
+	_, err = accountFromSigner(program, "hw", false)
+	if fault.WrongNetworkForPublicKey != err {
+		t.Errorf("live account error: %v  expected: %s", err, fault.WrongNetworkForPublicKey)
+	}
+
+	message := []byte("message to be signed")
+	signer := newProgramSigner(program, "hw", acc, "transfer")
+	signature, err := signer.Sign(message)
+	if nil != err {
+		t.Fatalf("sign error: %s", err)
+	}
+	if !bytes.Equal(ed25519.Sign(private, message), signature) {
+		t.Errorf("signature: %x  is not correct", signature)
+	}
+
+	// a signature for some other message must be rejected
+	signer = newProgramSigner(makeSignerProgram(t, dir, "wrong", private), "hw", acc, "transfer")
+	_, err = signer.Sign(message)
+	if fault.InvalidSignature != err {
+		t.Errorf("wrong signature error: %v  expected: %s", err, fault.InvalidSignature)
+	}
+
+	signer = newProgramSigner(makeSignerProgram(t, dir, "refuse", private), "hw", acc, "transfer")
+	_, err = signer.Sign(message)
+	if nil == err {
+		t.Error("refused signature did not error")
+	}
+}
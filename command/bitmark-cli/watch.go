@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/command/bitmark-cli/rpccalls"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+)
+
+// exit codes when watching, any other error exits with 1
+const (
+	exitWatchTimeout = 2
+	exitWatchInvalid = 3
+)
+
+const (
+	defaultWatchTimeout = time.Hour
+	watchPushInterval   = 60 * time.Second // longest single wait on the node
+	watchPollInterval   = 15 * time.Second // if the node cannot push
+)
+
+// watchError - terminates the program with a specific exit code
+type watchError struct {
+	code    int
+	message string
+}
+
+func (e *watchError) Error() string {
+	return e.message
+}
+
+// watchEvent - printed each time the status changes
+type watchEvent struct {
+	TxId        string            `json:"txId"`
+	Status      string            `json:"status"`
+	PayId       string            `json:"payId,omitempty"`
+	Commands    map[string]string `json:"commands,omitempty"`
+	BlockNumber uint64            `json:"blockNumber,omitempty"`
+}
+
+// watchTransaction - follow a transaction until it is confirmed
+//
+// each change of status is printed; payment commands are shown while
+// pending, either those returned when the transaction was submitted
+// or those provided by the node.  Exits with exitWatchTimeout if not
+// confirmed in time and exitWatchInvalid if the transaction leaves the
+// reservoir without being confirmed.
+func watchTransaction(m *metadata, client *rpccalls.Client, txId string, commands map[string]string, timeout time.Duration) error {
+
+	deadline := time.Now().Add(timeout)
+	statusConfig := &rpccalls.TransactionStatusData{
+		TxId: txId,
+	}
+
+	current, err := client.GetTransactionStatus(statusConfig)
+	if nil != err {
+		return err
+	}
+	reply := &rpccalls.WatchReply{
+		Status: current.Status,
+	}
+
+	status := ""
+	seen := false
+	push := true
+
+	for {
+		if reply.Status != status {
+			status = reply.Status
+
+			event := &watchEvent{
+				TxId:   txId,
+				Status: status,
+			}
+
+			switch status {
+			case reservoir.StatePending.String():
+				seen = true
+				event.PayId = reply.PayId
+				event.Commands = reply.Commands
+				if 0 == len(event.Commands) {
+					event.Commands = commands
+				}
+
+			case reservoir.StateVerified.String():
+				seen = true
+
+			case reservoir.StateConfirmed.String():
+				event.BlockNumber = reply.BlockNumber
+				if 0 == event.BlockNumber {
+					event.BlockNumber = confirmedBlock(client, txId)
+				}
+				printJson(m.w, event)
+				return nil
+
+			default:
+				if seen {
+					printJson(m.w, event)
+					return &watchError{
+						code:    exitWatchInvalid,
+						message: fmt.Sprintf("transaction: %s  was dropped without being confirmed", txId),
+					}
+				}
+			}
+			printJson(m.w, event)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return &watchError{
+				code:    exitWatchTimeout,
+				message: fmt.Sprintf("timeout watching transaction: %s  status: %s", txId, status),
+			}
+		}
+
+		if push {
+			wait := remaining
+			if wait > watchPushInterval {
+				wait = watchPushInterval
+			}
+			reply, err = client.WatchTransactionStatus(statusConfig, status, wait)
+			if fault.WatchNotSupported == err {
+				if m.verbose {
+					fmt.Fprintf(m.e, "node cannot push status, polling every: %s\n", watchPollInterval)
+				}
+				push = false
+				reply = &rpccalls.WatchReply{Status: status}
+				continue
+			}
+		} else {
+			wait := remaining
+			if wait > watchPollInterval {
+				wait = watchPollInterval
+			}
+			time.Sleep(wait)
+			current, err = client.GetTransactionStatus(statusConfig)
+			if nil == err {
+				reply = &rpccalls.WatchReply{Status: current.Status}
+			}
+		}
+		if nil != err {
+			return err
+		}
+	}
+}
+
+// find the block of a confirmed transaction when the node did not
+// supply it; only bitmark transactions have a provenance
+func confirmedBlock(client *rpccalls.Client, txId string) uint64 {
+	provenance, err := client.GetProvenance(&rpccalls.ProvenanceData{
+		TxId:  txId,
+		Count: 1,
+	})
+	if nil != err || 0 == len(provenance.Data) {
+		return 0
+	}
+	return provenance.Data[0].InBlock
+}
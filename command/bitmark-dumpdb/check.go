@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// Problem - an inconsistency found by the check
+type Problem struct {
+	Pool    string `json:"pool"`
+	Key     string `json:"key"`
+	Message string `json:"problem"`
+}
+
+// pools used by the check
+type checkPools struct {
+	transactions      storage.Handle
+	ownerNextCount    storage.Handle
+	ownerList         storage.Handle
+	ownerTxIndex      storage.Handle
+	ownerData         storage.Handle
+	blockOwnerTxIndex storage.Handle
+	shares            storage.Handle
+	shareQuantity     storage.Handle
+	shareHolders      storage.Handle
+}
+
+type checker struct {
+	pools    checkPools
+	testnet  bool
+	report   func(*Problem)
+	records  int
+	problems int
+	owned    map[merkle.Digest]struct{} // txIds on some owner's list
+}
+
+// consistencyCheck - cross-validate the ownership indexes against the
+// transactions pool, each problem is passed to report and the counts
+// of records examined and problems found are returned
+func consistencyCheck(pools checkPools, testnet bool, report func(*Problem)) (int, int, error) {
+	c := &checker{
+		pools:   pools,
+		testnet: testnet,
+		report:  report,
+		owned:   make(map[merkle.Digest]struct{}),
+	}
+
+	steps := []struct {
+		pool storage.Handle
+		f    func(key []byte, value []byte)
+	}{
+		{pools.ownerList, c.checkOwnerList},
+		{pools.ownerTxIndex, c.checkOwnerTxIndex},
+		{pools.ownerData, c.checkOwnerData},
+		{pools.blockOwnerTxIndex, c.checkBlockOwnerTxIndex},
+		{pools.shares, c.checkShare},
+		{pools.shareQuantity, c.checkShareQuantity},
+		{pools.shareHolders, c.checkShareHolder},
+	}
+	for _, step := range steps {
+		f := step.f
+		err := step.pool.NewFetchCursor().Map(func(key []byte, value []byte) error {
+			c.records += 1
+			f(key, value)
+			return nil
+		})
+		if nil != err {
+			return c.records, c.problems, err
+		}
+	}
+	return c.records, c.problems, nil
+}
+
+func (c *checker) problem(pool string, key []byte, format string, arguments ...interface{}) {
+	c.problems += 1
+	c.report(&Problem{
+		Pool:    pool,
+		Key:     hex.EncodeToString(key),
+		Message: fmt.Sprintf(format, arguments...),
+	})
+}
+
+// L ⧺ owner ⧺ count → txId
+func (c *checker) checkOwnerList(key []byte, value []byte) {
+	l, err := decodeOwnerList(key, value, c.testnet)
+	if nil != err {
+		c.problem("L", key, "invalid record: %s", err)
+		return
+	}
+	ol := l.(*OwnerList)
+	c.owned[ol.TxId] = struct{}{}
+
+	if !c.pools.ownerData.Has(ol.TxId[:]) {
+		c.problem("L", key, "txId: %s has no owner data", ol.TxId)
+	}
+
+	dKey := append(ol.Owner.Bytes(), ol.TxId[:]...)
+	count := c.pools.ownerTxIndex.Get(dKey)
+	if nil == count {
+		c.problem("L", key, "txId: %s missing from owner tx index", ol.TxId)
+	} else if !bytes.Equal(count, key[len(key)-uint64ByteSize:]) {
+		c.problem("L", key, "owner tx index count: %x does not match", count)
+	}
+
+	next, ok := c.pools.ownerNextCount.GetN(ol.Owner.Bytes())
+	if !ok || ol.Count >= next {
+		c.problem("L", key, "count: %d is not below next count: %d", ol.Count, next)
+	}
+
+	owner, err := c.ownerOf(ol.TxId)
+	if nil != err {
+		c.problem("L", key, "txId: %s owner: %s", ol.TxId, err)
+	} else if !bytes.Equal(owner.Bytes(), ol.Owner.Bytes()) {
+		c.problem("L", key, "txId: %s is owned by: %s not: %s", ol.TxId, owner, ol.Owner)
+	}
+}
+
+// D ⧺ owner ⧺ txId → count
+func (c *checker) checkOwnerTxIndex(key []byte, value []byte) {
+	d, err := decodeOwnerTxIndex(key, value, c.testnet)
+	if nil != err {
+		c.problem("D", key, "invalid record: %s", err)
+		return
+	}
+	od := d.(*OwnerTxIndex)
+
+	oKey := append(od.Owner.Bytes(), value...)
+	txId := c.pools.ownerList.Get(oKey)
+	if nil == txId {
+		c.problem("D", key, "count: %d missing from owner list", od.Count)
+	} else if !bytes.Equal(txId, od.TxId[:]) {
+		c.problem("D", key, "owner list has txId: %x for count: %d", txId, od.Count)
+	}
+}
+
+// O ⧺ txId → owner data
+func (c *checker) checkOwnerData(key []byte, value []byte) {
+	o, err := decodeOwnerData(key, value, c.testnet)
+	if nil != err {
+		c.problem("O", key, "invalid record: %s", err)
+		return
+	}
+	od := o.(*OwnerData)
+
+	if _, ok := c.owned[od.TxId]; !ok {
+		c.problem("O", key, "txId: %s is not on any owner list", od.TxId)
+	}
+
+	blockNumber, packed := c.pools.transactions.GetNB(key)
+	if nil == packed {
+		c.problem("O", key, "txId: %s missing from transactions", od.TxId)
+	} else if blockNumber != od.TransferBlockNumber {
+		c.problem("O", key, "transfer block: %d but transaction is in block: %d", od.TransferBlockNumber, blockNumber)
+	}
+
+	if !c.pools.transactions.Has(od.IssueTxId[:]) {
+		c.problem("O", key, "issue txId: %s missing from transactions", od.IssueTxId)
+	}
+
+	switch od.Item {
+	case ownership.OwnedBlock:
+		n, ok := c.pools.blockOwnerTxIndex.GetN(key)
+		if !ok || n != od.IssueBlockNumber {
+			c.problem("O", key, "block: %d missing from block owner tx index", od.IssueBlockNumber)
+		}
+	case ownership.OwnedShare:
+		if !c.pools.shares.Has(od.IssueTxId[:]) {
+			c.problem("O", key, "share: %s missing from shares", od.IssueTxId)
+		}
+	}
+}
+
+// I ⧺ txId → owned BN
+func (c *checker) checkBlockOwnerTxIndex(key []byte, value []byte) {
+	if !c.pools.transactions.Has(key) {
+		c.problem("I", key, "txId missing from transactions")
+	}
+}
+
+// F ⧺ share id → quantity ⧺ txId
+func (c *checker) checkShare(key []byte, value []byte) {
+	s, err := decodeShare(key, value, c.testnet)
+	if nil != err {
+		c.problem("F", key, "invalid record: %s", err)
+		return
+	}
+	share := s.(*Share)
+
+	if !c.pools.transactions.Has(share.ShareId[:]) {
+		c.problem("F", key, "share id: %s missing from transactions", share.ShareId)
+	}
+	if !c.pools.transactions.Has(share.TxId[:]) {
+		c.problem("F", key, "txId: %s missing from transactions", share.TxId)
+	}
+}
+
+// Q ⧺ owner ⧺ share id → balance
+func (c *checker) checkShareQuantity(key []byte, value []byte) {
+	q, err := decodeShareQuantity(key, value, c.testnet)
+	if nil != err {
+		c.problem("Q", key, "invalid record: %s", err)
+		return
+	}
+	sq := q.(*ShareQuantity)
+
+	if !c.pools.shares.Has(sq.ShareId[:]) {
+		c.problem("Q", key, "share id: %s missing from shares", sq.ShareId)
+	}
+	if 0 == sq.Quantity {
+		c.problem("Q", key, "zero balance was not deleted")
+	}
+
+	sKey := append(sq.ShareId[:], sq.Owner.Bytes()...)
+	balance, ok := c.pools.shareHolders.GetN(sKey)
+	if !ok {
+		c.problem("Q", key, "share id: %s missing from share holders", sq.ShareId)
+	} else if balance != sq.Quantity {
+		c.problem("Q", key, "balance: %d does not match share holders: %d", sq.Quantity, balance)
+	}
+}
+
+// S ⧺ share id ⧺ owner → balance
+func (c *checker) checkShareHolder(key []byte, value []byte) {
+	h, err := decodeShareHolder(key, value, c.testnet)
+	if nil != err {
+		c.problem("S", key, "invalid record: %s", err)
+		return
+	}
+	sh := h.(*ShareHolder)
+
+	if !c.pools.shares.Has(sh.ShareId[:]) {
+		c.problem("S", key, "share id: %s missing from shares", sh.ShareId)
+	}
+	qKey := append(sh.Owner.Bytes(), sh.ShareId[:]...)
+	if !c.pools.shareQuantity.Has(qKey) {
+		c.problem("S", key, "share id: %s missing from share quantity", sh.ShareId)
+	}
+}
+
+// owner of a confirmed transaction, a share conversion belongs to the
+// owner of the bitmark it converted
+func (c *checker) ownerOf(txId merkle.Digest) (*account.Account, error) {
+	for {
+		_, packed := c.pools.transactions.GetNB(txId[:])
+		if nil == packed {
+			return nil, fault.LinkToInvalidOrUnconfirmedTransaction
+		}
+		transaction, _, err := transactionrecord.Packed(packed).Unpack(c.testnet)
+		if nil != err {
+			return nil, err
+		}
+
+		switch tx := transaction.(type) {
+		case *transactionrecord.BitmarkIssue:
+			return tx.Owner, nil
+		case *transactionrecord.BitmarkTransferUnratified:
+			return tx.Owner, nil
+		case *transactionrecord.BitmarkTransferCountersigned:
+			return tx.Owner, nil
+		case *transactionrecord.BlockFoundation:
+			return tx.Owner, nil
+		case *transactionrecord.BlockOwnerTransfer:
+			return tx.Owner, nil
+		case *transactionrecord.BitmarkShare:
+			txId = tx.Link
+		case *transactionrecord.ShareRedemption:
+			return tx.Owner, nil
+		case *transactionrecord.AtomicSwap:
+			leg, ok := tx.BitmarkLeg(transactionrecord.Packed(packed).MakeLink(), txId)
+			if !ok {
+				return nil, fault.NotOwnedItem
+			}
+			return leg.Recipient, nil
+		default:
+			return nil, fault.NotOwnedItem
+		}
+	}
+}
@@ -0,0 +1,506 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+const (
+	uint64ByteSize = 8
+	txIdSize       = merkle.DigestLength
+)
+
+// decoder - convert a raw key/value pair into a typed structure
+type decoder func(key []byte, value []byte, testnet bool) (interface{}, error)
+
+// decoders for each pool prefix tag, see storage/doc.go for the layouts
+var decoders = map[string]decoder{
+	"B": decodeBlock,
+	"2": decodeBlockHeaderHash,
+	"H": decodeBlockOwnerPayment,
+	"I": decodeBlockOwnerTxIndex,
+	"A": decodeAsset,
+	"V": decodeAssetVersion,
+	"M": decodeAssetMetadata,
+	"T": decodeTransaction,
+	"N": decodeOwnerNextCount,
+	"L": decodeOwnerList,
+	"D": decodeOwnerTxIndex,
+	"O": decodeOwnerData,
+	"F": decodeShare,
+	"Q": decodeShareQuantity,
+	"S": decodeShareHolder,
+}
+
+// Record - one decoded element as output in JSON mode
+type Record struct {
+	Pool  string      `json:"pool"`
+	Key   string      `json:"key"`
+	Value interface{} `json:"value,omitempty"`
+	Raw   string      `json:"raw,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// decodeRecord - decode an element, falling back to hex if the pool
+// has no decoder or the data is invalid
+func decodeRecord(tag string, key []byte, value []byte, testnet bool) *Record {
+	r := &Record{
+		Pool: tag,
+		Key:  hex.EncodeToString(key),
+	}
+
+	d, ok := decoders[tag]
+	if !ok {
+		r.Raw = hex.EncodeToString(value)
+		return r
+	}
+
+	v, err := d(key, value, testnet)
+	if nil != err {
+		r.Raw = hex.EncodeToString(value)
+		r.Error = err.Error()
+		return r
+	}
+	r.Value = v
+	return r
+}
+
+// BlockTransaction - a transaction within a block
+type BlockTransaction struct {
+	TxId   merkle.Digest `json:"txId"`
+	Type   string        `json:"type"`
+	Record interface{}   `json:"record"`
+}
+
+// Block - B ⧺ BN → header ⧺ transactions
+type Block struct {
+	BlockNumber  uint64              `json:"blockNumber,string"`
+	Header       *blockrecord.Header `json:"header"`
+	Transactions []BlockTransaction  `json:"transactions"`
+}
+
+func decodeBlock(key []byte, value []byte, testnet bool) (interface{}, error) {
+	blockNumber, err := blockNumberKey(key)
+	if nil != err {
+		return nil, err
+	}
+
+	packedHeader := blockrecord.PackedHeader{}
+	if len(value) < len(packedHeader) {
+		return nil, fault.InvalidBlockHeaderSize
+	}
+	copy(packedHeader[:], value)
+	header, err := packedHeader.Unpack()
+	if nil != err {
+		return nil, err
+	}
+
+	b := &Block{
+		BlockNumber:  blockNumber,
+		Header:       header,
+		Transactions: make([]BlockTransaction, 0, header.TransactionCount),
+	}
+
+	data := value[len(packedHeader):]
+	for len(data) > 0 {
+		tx, n, err := transactionrecord.Packed(data).Unpack(testnet)
+		if nil != err {
+			return nil, err
+		}
+		name, _ := transactionrecord.RecordName(tx)
+		b.Transactions = append(b.Transactions, BlockTransaction{
+			TxId:   transactionrecord.Packed(data[:n]).MakeLink(),
+			Type:   name,
+			Record: tx,
+		})
+		data = data[n:]
+	}
+	return b, nil
+}
+
+// BlockHeaderHash - 2 ⧺ BN → header digest
+type BlockHeaderHash struct {
+	BlockNumber uint64             `json:"blockNumber,string"`
+	Digest      blockdigest.Digest `json:"digest"`
+}
+
+func decodeBlockHeaderHash(key []byte, value []byte, testnet bool) (interface{}, error) {
+	blockNumber, err := blockNumberKey(key)
+	if nil != err {
+		return nil, err
+	}
+	h := &BlockHeaderHash{
+		BlockNumber: blockNumber,
+	}
+	err = blockdigest.DigestFromBytes(&h.Digest, value)
+	if nil != err {
+		return nil, err
+	}
+	return h, nil
+}
+
+// BlockOwnerPayment - H ⧺ BN → currency addresses for payment
+type BlockOwnerPayment struct {
+	BlockNumber uint64       `json:"blockNumber,string"`
+	Payments    currency.Map `json:"payments"`
+}
+
+func decodeBlockOwnerPayment(key []byte, value []byte, testnet bool) (interface{}, error) {
+	blockNumber, err := blockNumberKey(key)
+	if nil != err {
+		return nil, err
+	}
+	payments, _, err := currency.UnpackMap(value, testnet)
+	if nil != err {
+		return nil, err
+	}
+	return &BlockOwnerPayment{
+		BlockNumber: blockNumber,
+		Payments:    payments,
+	}, nil
+}
+
+// BlockOwnerTxIndex - I ⧺ txId → owned BN
+type BlockOwnerTxIndex struct {
+	TxId        merkle.Digest `json:"txId"`
+	BlockNumber uint64        `json:"blockNumber,string"`
+}
+
+func decodeBlockOwnerTxIndex(key []byte, value []byte, testnet bool) (interface{}, error) {
+	i := &BlockOwnerTxIndex{}
+	err := merkle.DigestFromBytes(&i.TxId, key)
+	if nil != err {
+		return nil, err
+	}
+	i.BlockNumber, err = blockNumberKey(value)
+	if nil != err {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Asset - A ⧺ asset id → BN ⧺ asset data
+type Asset struct {
+	AssetId     transactionrecord.AssetIdentifier `json:"assetId"`
+	BlockNumber uint64                            `json:"blockNumber,string"`
+	Record      interface{}                       `json:"record"`
+}
+
+func decodeAsset(key []byte, value []byte, testnet bool) (interface{}, error) {
+	a := &Asset{}
+	err := transactionrecord.AssetIdentifierFromBytes(&a.AssetId, key)
+	if nil != err {
+		return nil, err
+	}
+	a.BlockNumber, a.Record, err = unpackNB(value, testnet)
+	if nil != err {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AssetVersion - V ⧺ asset id ⧺ version → BN ⧺ amendment
+type AssetVersion struct {
+	AssetId     transactionrecord.AssetIdentifier `json:"assetId"`
+	Version     uint64                            `json:"version,string"`
+	BlockNumber uint64                            `json:"blockNumber,string"`
+	Record      interface{}                       `json:"record"`
+}
+
+func decodeAssetVersion(key []byte, value []byte, testnet bool) (interface{}, error) {
+	if len(key) != transactionrecord.AssetIdentifierLength+uint64ByteSize {
+		return nil, fault.InvalidBuffer
+	}
+	v := &AssetVersion{}
+	err := transactionrecord.AssetIdentifierFromBytes(&v.AssetId, key[:transactionrecord.AssetIdentifierLength])
+	if nil != err {
+		return nil, err
+	}
+	v.Version, err = blockNumberKey(key[transactionrecord.AssetIdentifierLength:])
+	if nil != err {
+		return nil, err
+	}
+	v.BlockNumber, v.Record, err = unpackNB(value, testnet)
+	if nil != err {
+		return nil, err
+	}
+	return v, nil
+}
+
+// AssetMetadata - M ⧺ key ⧺ 00 ⧺ value ⧺ 00 ⧺ asset id → BN
+type AssetMetadata struct {
+	Key         string                            `json:"key"`
+	Value       string                            `json:"value"`
+	AssetId     transactionrecord.AssetIdentifier `json:"assetId"`
+	BlockNumber uint64                            `json:"blockNumber,string"`
+}
+
+func decodeAssetMetadata(key []byte, value []byte, testnet bool) (interface{}, error) {
+	n := len(key) - transactionrecord.AssetIdentifierLength
+	if n < 4 || 0 != key[n-1] {
+		return nil, fault.InvalidBuffer
+	}
+	i := bytes.IndexByte(key[:n-1], 0)
+	if i <= 0 {
+		return nil, fault.InvalidBuffer
+	}
+	m := &AssetMetadata{
+		Key:   string(key[:i]),
+		Value: string(key[i+1 : n-1]),
+	}
+	err := transactionrecord.AssetIdentifierFromBytes(&m.AssetId, key[n:])
+	if nil != err {
+		return nil, err
+	}
+	m.BlockNumber, err = blockNumberKey(value)
+	if nil != err {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Transaction - T ⧺ txId → BN ⧺ transaction
+type Transaction struct {
+	TxId        merkle.Digest `json:"txId"`
+	BlockNumber uint64        `json:"blockNumber,string"`
+	Type        string        `json:"type"`
+	Record      interface{}   `json:"record"`
+}
+
+func decodeTransaction(key []byte, value []byte, testnet bool) (interface{}, error) {
+	t := &Transaction{}
+	err := merkle.DigestFromBytes(&t.TxId, key)
+	if nil != err {
+		return nil, err
+	}
+	t.BlockNumber, t.Record, err = unpackNB(value, testnet)
+	if nil != err {
+		return nil, err
+	}
+	t.Type, _ = transactionrecord.RecordName(t.Record)
+	return t, nil
+}
+
+// OwnerNextCount - N ⧺ owner → next count
+type OwnerNextCount struct {
+	Owner *account.Account `json:"owner"`
+	Count uint64           `json:"count,string"`
+}
+
+func decodeOwnerNextCount(key []byte, value []byte, testnet bool) (interface{}, error) {
+	owner, err := account.AccountFromBytes(key)
+	if nil != err {
+		return nil, err
+	}
+	count, err := blockNumberKey(value)
+	if nil != err {
+		return nil, err
+	}
+	return &OwnerNextCount{
+		Owner: owner,
+		Count: count,
+	}, nil
+}
+
+// OwnerList - L ⧺ owner ⧺ count → txId
+type OwnerList struct {
+	Owner *account.Account `json:"owner"`
+	Count uint64           `json:"count,string"`
+	TxId  merkle.Digest    `json:"txId"`
+}
+
+func decodeOwnerList(key []byte, value []byte, testnet bool) (interface{}, error) {
+	owner, count, err := splitOwnerKey(key, uint64ByteSize)
+	if nil != err {
+		return nil, err
+	}
+	l := &OwnerList{
+		Owner: owner,
+		Count: binary.BigEndian.Uint64(count),
+	}
+	err = merkle.DigestFromBytes(&l.TxId, value)
+	if nil != err {
+		return nil, err
+	}
+	return l, nil
+}
+
+// OwnerTxIndex - D ⧺ owner ⧺ txId → count
+type OwnerTxIndex struct {
+	Owner *account.Account `json:"owner"`
+	TxId  merkle.Digest    `json:"txId"`
+	Count uint64           `json:"count,string"`
+}
+
+func decodeOwnerTxIndex(key []byte, value []byte, testnet bool) (interface{}, error) {
+	owner, txId, err := splitOwnerKey(key, txIdSize)
+	if nil != err {
+		return nil, err
+	}
+	d := &OwnerTxIndex{
+		Owner: owner,
+	}
+	merkle.DigestFromBytes(&d.TxId, txId)
+	d.Count, err = blockNumberKey(value)
+	if nil != err {
+		return nil, err
+	}
+	return d, nil
+}
+
+// OwnerData - O ⧺ txId → ownership details of the bitmark or block
+type OwnerData struct {
+	TxId                merkle.Digest                      `json:"txId"`
+	Item                ownership.OwnedItem                `json:"item"`
+	TransferBlockNumber uint64                             `json:"transferBlockNumber,string"`
+	IssueTxId           merkle.Digest                      `json:"issueTxId"`
+	IssueBlockNumber    uint64                             `json:"issueBlockNumber,string"`
+	AssetId             *transactionrecord.AssetIdentifier `json:"assetId,omitempty"`
+}
+
+func decodeOwnerData(key []byte, value []byte, testnet bool) (interface{}, error) {
+	o := &OwnerData{}
+	err := merkle.DigestFromBytes(&o.TxId, key)
+	if nil != err {
+		return nil, err
+	}
+	ownerData, err := ownership.PackedOwnerData(value).Unpack()
+	if nil != err {
+		return nil, err
+	}
+	o.TransferBlockNumber = ownerData.TransferBlockNumber()
+	o.IssueTxId = ownerData.IssueTxId()
+	o.IssueBlockNumber = ownerData.IssueBlockNumber()
+
+	switch od := ownerData.(type) {
+	case *ownership.AssetOwnerData:
+		o.Item = ownership.OwnedAsset
+		assetId := od.AssetId()
+		o.AssetId = &assetId
+	case *ownership.BlockOwnerData:
+		o.Item = ownership.OwnedBlock
+	case *ownership.ShareOwnerData:
+		o.Item = ownership.OwnedShare
+		assetId := od.AssetId()
+		o.AssetId = &assetId
+	}
+	return o, nil
+}
+
+// Share - F ⧺ share id → total quantity ⧺ txId that created the share
+type Share struct {
+	ShareId  merkle.Digest `json:"shareId"`
+	Quantity uint64        `json:"quantity,string"`
+	TxId     merkle.Digest `json:"txId"`
+}
+
+func decodeShare(key []byte, value []byte, testnet bool) (interface{}, error) {
+	s := &Share{}
+	err := merkle.DigestFromBytes(&s.ShareId, key)
+	if nil != err {
+		return nil, err
+	}
+	if uint64ByteSize+txIdSize != len(value) {
+		return nil, fault.InvalidBuffer
+	}
+	s.Quantity = binary.BigEndian.Uint64(value[:uint64ByteSize])
+	merkle.DigestFromBytes(&s.TxId, value[uint64ByteSize:])
+	return s, nil
+}
+
+// ShareQuantity - Q ⧺ owner ⧺ share id → balance
+type ShareQuantity struct {
+	Owner    *account.Account `json:"owner"`
+	ShareId  merkle.Digest    `json:"shareId"`
+	Quantity uint64           `json:"quantity,string"`
+}
+
+func decodeShareQuantity(key []byte, value []byte, testnet bool) (interface{}, error) {
+	owner, shareId, err := splitOwnerKey(key, txIdSize)
+	if nil != err {
+		return nil, err
+	}
+	q := &ShareQuantity{
+		Owner: owner,
+	}
+	merkle.DigestFromBytes(&q.ShareId, shareId)
+	q.Quantity, err = blockNumberKey(value)
+	if nil != err {
+		return nil, err
+	}
+	return q, nil
+}
+
+// ShareHolder - S ⧺ share id ⧺ owner → balance
+type ShareHolder struct {
+	ShareId  merkle.Digest    `json:"shareId"`
+	Owner    *account.Account `json:"owner"`
+	Quantity uint64           `json:"quantity,string"`
+}
+
+func decodeShareHolder(key []byte, value []byte, testnet bool) (interface{}, error) {
+	if len(key) <= txIdSize {
+		return nil, fault.InvalidBuffer
+	}
+	owner, err := account.AccountFromBytes(key[txIdSize:])
+	if nil != err {
+		return nil, err
+	}
+	h := &ShareHolder{
+		Owner: owner,
+	}
+	merkle.DigestFromBytes(&h.ShareId, key[:txIdSize])
+	h.Quantity, err = blockNumberKey(value)
+	if nil != err {
+		return nil, err
+	}
+	return h, nil
+}
+
+// an 8 byte big endian number
+func blockNumberKey(buffer []byte) (uint64, error) {
+	if uint64ByteSize != len(buffer) {
+		return 0, fault.InvalidBuffer
+	}
+	return binary.BigEndian.Uint64(buffer), nil
+}
+
+// split owner ⧺ suffix where the suffix has a fixed size
+func splitOwnerKey(key []byte, suffixSize int) (*account.Account, []byte, error) {
+	split := len(key) - suffixSize
+	if split <= 0 {
+		return nil, nil, fault.InvalidBuffer
+	}
+	owner, err := account.AccountFromBytes(key[:split])
+	if nil != err {
+		return nil, nil, err
+	}
+	return owner, key[split:], nil
+}
+
+// BN ⧺ packed transaction as stored in the PoolNB pools
+func unpackNB(value []byte, testnet bool) (uint64, transactionrecord.Transaction, error) {
+	if len(value) <= uint64ByteSize {
+		return 0, nil, fault.InvalidBuffer
+	}
+	tx, _, err := transactionrecord.Packed(value[uint64ByteSize:]).Unpack(testnet)
+	if nil != err {
+		return 0, nil, err
+	}
+	return binary.BigEndian.Uint64(value[:uint64ByteSize]), tx, nil
+}
@@ -4,4 +4,14 @@
 // license that can be found in the LICENSE file.
 
 // Bitmark bitmark-dumpdb
+//
+// dump the records of one database pool selected by its prefix tag
+// (see --list) starting from an optional hex key prefix, either as hex
+// or with --json as one decoded record per line.  --key selects a
+// single record and --until ends a range before the given key; a zero
+// --count reads to the end of the pool.
+//
+// --check cross-validates the ownership indexes against the
+// transactions pool, printing each problem found and exiting with a
+// non-zero status if there were any.
 package main
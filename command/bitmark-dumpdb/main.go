@@ -8,6 +8,8 @@ package main
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -53,6 +55,11 @@ func main() {
 		{Long: "ascii", HasArg: getoptions.NO_ARGUMENT, Short: 'a'},
 		{Long: "file", HasArg: getoptions.REQUIRED_ARGUMENT, Short: 'f'},
 		{Long: "count", HasArg: getoptions.REQUIRED_ARGUMENT, Short: 'c'},
+		{Long: "json", HasArg: getoptions.NO_ARGUMENT, Short: 'j'},
+		{Long: "key", HasArg: getoptions.REQUIRED_ARGUMENT, Short: 'k'},
+		{Long: "until", HasArg: getoptions.REQUIRED_ARGUMENT, Short: 'u'},
+		{Long: "testnet", HasArg: getoptions.NO_ARGUMENT, Short: 't'},
+		{Long: "check", HasArg: getoptions.NO_ARGUMENT, Short: 'C'},
 	}
 
 	program, options, arguments, err := getoptions.GetOS(flags)
@@ -79,8 +86,11 @@ func main() {
 		return
 	}
 
-	if len(options["help"]) > 0 || 0 == len(arguments) || 1 != len(options["file"]) {
-		exitwithstatus.Message("usage: %s [--help] [--verbose] [--quiet] [--count=N] --file=FILE tag [--list] [key-prefix]", program)
+	check := len(options["check"]) > 0
+
+	if len(options["help"]) > 0 || (0 == len(arguments) && !check) || 1 != len(options["file"]) {
+		exitwithstatus.Message("usage: %s [--help] [--verbose] [--quiet] [--count=N] [--json] [--testnet] [--key=HEX | --until=HEX] --file=FILE tag [--list] [key-prefix]\n"+
+			"       %s [--json] [--testnet] --file=FILE --check", program, program)
 	}
 
 	// stop if prefix no longer matches
@@ -90,6 +100,12 @@ func main() {
 	ascii := len(options["ascii"]) > 0
 	delete := len(options["delete"]) > 0
 	verbose := len(options["verbose"]) > 0
+	jsonOutput := len(options["json"]) > 0
+	testnet := len(options["testnet"]) > 0
+
+	if delete && jsonOutput {
+		exitwithstatus.Message("%s: delete is interactive and cannot be used with JSON output", program)
+	}
 
 	count := 10
 	if len(options["count"]) > 0 {
@@ -97,22 +113,42 @@ func main() {
 		if nil != err {
 			exitwithstatus.Message("%s: convert count error: %s", program, err)
 		}
-		if count < 1 {
+		if count < 0 {
 			exitwithstatus.Message("%s: invalid count: %d", program, count)
 		}
 	}
 
 	filename := options["file"][0]
-	tag := arguments[0]
-	if verbose {
-		fmt.Printf("read tag: %s from file: %q\n", tag, filename)
-	}
 
+	tag := ""
 	prefix := []byte(nil)
-	if len(arguments) > 1 {
-		prefix, err = hex.DecodeString(arguments[1])
+	if !check {
+		tag = arguments[0]
+		if verbose {
+			fmt.Printf("read tag: %s from file: %q\n", tag, filename)
+		}
+
+		if len(arguments) > 1 {
+			prefix, err = hex.DecodeString(arguments[1])
+			if nil != err {
+				exitwithstatus.Message("%s: convert prefix error: %s", program, err)
+			}
+		}
+	}
+
+	key := []byte(nil)
+	if len(options["key"]) > 0 {
+		key, err = hex.DecodeString(options["key"][0])
+		if nil != err {
+			exitwithstatus.Message("%s: convert key error: %s", program, err)
+		}
+	}
+
+	until := []byte(nil)
+	if len(options["until"]) > 0 {
+		until, err = hex.DecodeString(options["until"][0])
 		if nil != err {
-			exitwithstatus.Message("%s: convert prefix error: %s", program, err)
+			exitwithstatus.Message("%s: convert until error: %s", program, err)
 		}
 	}
 
@@ -141,6 +177,11 @@ func main() {
 
 	defer storage.Finalise()
 
+	if check {
+		runCheck(program, jsonOutput, testnet)
+		return
+	}
+
 	// this will be a struct type
 	poolType := reflect.TypeOf(storage.Pool)
 
@@ -182,15 +223,20 @@ tag_scan:
 	cValue := reflect.ValueOf(&cursor).Elem()
 	cValue.Set(cf.Call(nil)[0])
 
-	if len(prefix) > 0 {
+	if nil != key {
+		cursor.Seek(key)
+		data, err := cursor.Fetch(1)
+		if nil != err {
+			exitwithstatus.Message("%s: error on Fetch: %s", program, err)
+		}
+		if 0 == len(data) || !bytes.Equal(key, data[0].Key) {
+			exitwithstatus.Message("%s: key: %x not found in: %q", program, key, tag)
+		}
+		count = 1
+	} else if len(prefix) > 0 {
 		cursor.Seek(prefix)
 	}
 
-	data, err := cursor.Fetch(count)
-	if nil != err {
-		exitwithstatus.Message("%s: error on Fetch: %s", program, err)
-	}
-
 	l := len(prefix)
 
 	ck1 := ""
@@ -215,11 +261,26 @@ tag_scan:
 		cn = nodelColour
 		ce = endColour
 	}
-print_loop:
-	for i, e := range data {
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	err = each(cursor, count, func(i int, e storage.Element) bool {
 		if earlyStop && len(e.Key) >= len(prefix) && !bytes.Equal(prefix, e.Key[:l]) {
-			fmt.Printf("*** early stop\n")
-			break print_loop
+			if !jsonOutput {
+				fmt.Printf("*** early stop\n")
+			}
+			return false
+		}
+		if nil != until && bytes.Compare(e.Key, until) >= 0 {
+			return false
+		}
+
+		if jsonOutput {
+			err := encoder.Encode(decodeRecord(tag, e.Key, e.Value, testnet))
+			if nil != err {
+				exitwithstatus.Message("%s: JSON encode error: %s", program, err)
+			}
+			return true
 		}
 
 		fmt.Printf("%d: %sKey: %s%x%s\n", i, ck1, ck2, e.Key, ce)
@@ -261,13 +322,75 @@ print_loop:
 
 				case "q", "quit", "e", "exit", "x":
 					fmt.Printf("Terminated\n")
-					return
+					return false
 
 				default:
 					fmt.Printf("Please answer yes or no\n")
 				}
 			}
 		}
+		return true
+	})
+	if nil != err {
+		exitwithstatus.Message("%s: error on Fetch: %s", program, err)
+	}
+}
+
+// returned from the Map callback to end the iteration
+var errStop = errors.New("stop")
+
+// each - call f on up to count elements from the cursor, stops early
+// if f returns false; a zero count reads to the end of the pool
+func each(cursor *storage.FetchCursor, count int, f func(i int, e storage.Element) bool) error {
+	i := 0
+	err := cursor.Map(func(key []byte, value []byte) error {
+		if 0 != count && i >= count {
+			return errStop
+		}
+		if !f(i, storage.Element{Key: key, Value: value}) {
+			return errStop
+		}
+		i += 1
+		return nil
+	})
+	if errStop == err {
+		return nil
+	}
+	return err
+}
+
+// runCheck - run the consistency check and exit with a non-zero status
+// if any problems are found
+func runCheck(program string, jsonOutput bool, testnet bool) {
+	pools := checkPools{
+		transactions:      storage.Pool.Transactions,
+		ownerNextCount:    storage.Pool.OwnerNextCount,
+		ownerList:         storage.Pool.OwnerList,
+		ownerTxIndex:      storage.Pool.OwnerTxIndex,
+		ownerData:         storage.Pool.OwnerData,
+		blockOwnerTxIndex: storage.Pool.BlockOwnerTxIndex,
+		shares:            storage.Pool.Shares,
+		shareQuantity:     storage.Pool.ShareQuantity,
+		shareHolders:      storage.Pool.ShareHolders,
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	report := func(problem *Problem) {
+		if jsonOutput {
+			encoder.Encode(problem)
+		} else {
+			fmt.Printf("%s: %s  %s\n", problem.Pool, problem.Key, problem.Message)
+		}
+	}
+
+	records, problems, err := consistencyCheck(pools, testnet, report)
+	if nil != err {
+		exitwithstatus.Message("%s: check error: %s", program, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "checked: %d records  problems: %d\n", records, problems)
+	if 0 != problems {
+		exitwithstatus.Exit(1)
 	}
 }
 
@@ -4,3 +4,254 @@
 // license that can be found in the LICENSE file.
 
 package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// open an empty database in a temporary directory
+func setup(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "dumpdb")
+	if nil != err {
+		t.Fatalf("temp dir error: %s", err)
+	}
+
+	logging := logger.Configuration{
+		Directory: dir,
+		File:      "testing.log",
+		Size:      1048576,
+		Count:     10,
+		Console:   false,
+		Levels: map[string]string{
+			logger.DefaultTag: "critical",
+		},
+	}
+	if err := logger.Initialise(logging); nil != err {
+		t.Fatalf("logger setup failed: %s", err)
+	}
+
+	err = storage.Initialise(filepath.Join(dir, "test"), storage.ReadWrite)
+	if nil != err {
+		t.Fatalf("storage initialise error: %s", err)
+	}
+
+	return func() {
+		storage.Finalise()
+		logger.Finalise()
+		os.RemoveAll(dir)
+	}
+}
+
+// store a signed issue and its ownership records
+func storeIssue(t *testing.T, blockNumber uint64, nonce uint64) (merkle.Digest, *account.Account) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if nil != err {
+		t.Fatalf("key error: %s", err)
+	}
+	owner := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: publicKey,
+		},
+	}
+
+	issue := &transactionrecord.BitmarkIssue{
+		AssetId: transactionrecord.NewAssetIdentifier([]byte("fingerprint")),
+		Owner:   owner,
+		Nonce:   nonce,
+	}
+	packed, _ := issue.Pack(owner)
+	issue.Signature = ed25519.Sign(privateKey, packed)
+	packed, err = issue.Pack(owner)
+	if nil != err {
+		t.Fatalf("pack error: %s", err)
+	}
+	txId := packed.MakeLink()
+
+	blockNumberBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumberBytes, blockNumber)
+
+	trx, err := storage.NewDBTransaction()
+	if nil != err {
+		t.Fatalf("transaction error: %s", err)
+	}
+	trx.Put(storage.Pool.Transactions, txId[:], blockNumberBytes, packed)
+	ownership.CreateAsset(trx, txId, blockNumber, issue.AssetId, owner)
+	err = trx.Commit()
+	if nil != err {
+		t.Fatalf("commit error: %s", err)
+	}
+	return txId, owner
+}
+
+func remove(t *testing.T, pool storage.Handle, key []byte) {
+	trx, err := storage.NewDBTransaction()
+	if nil != err {
+		t.Fatalf("transaction error: %s", err)
+	}
+	trx.Delete(pool, key)
+	err = trx.Commit()
+	if nil != err {
+		t.Fatalf("commit error: %s", err)
+	}
+}
+
+func checkPoolsFromStorage() checkPools {
+	return checkPools{
+		transactions:      storage.Pool.Transactions,
+		ownerNextCount:    storage.Pool.OwnerNextCount,
+		ownerList:         storage.Pool.OwnerList,
+		ownerTxIndex:      storage.Pool.OwnerTxIndex,
+		ownerData:         storage.Pool.OwnerData,
+		blockOwnerTxIndex: storage.Pool.BlockOwnerTxIndex,
+		shares:            storage.Pool.Shares,
+		shareQuantity:     storage.Pool.ShareQuantity,
+		shareHolders:      storage.Pool.ShareHolders,
+	}
+}
+
+func TestDecodeRecords(t *testing.T) {
+	defer setup(t)()
+
+	txId, owner := storeIssue(t, 5, 1)
+
+	value := storage.Pool.OwnerData.Get(txId[:])
+	r := decodeRecord("O", txId[:], value, true)
+	if "" != r.Error {
+		t.Fatalf("owner data error: %s", r.Error)
+	}
+	od := r.Value.(*OwnerData)
+	if ownership.OwnedAsset != od.Item || 5 != od.TransferBlockNumber || txId != od.IssueTxId || nil == od.AssetId {
+		t.Errorf("owner data: %+v", od)
+	}
+
+	key := append(owner.Bytes(), 0, 0, 0, 0, 0, 0, 0, 0)
+	r = decodeRecord("L", key, storage.Pool.OwnerList.Get(key), true)
+	ol, ok := r.Value.(*OwnerList)
+	if !ok || txId != ol.TxId || 0 != ol.Count || owner.String() != ol.Owner.String() {
+		t.Errorf("owner list: %+v", r)
+	}
+
+	n, packed := storage.Pool.Transactions.GetNB(txId[:])
+	value = append(make([]byte, 8), packed...)
+	binary.BigEndian.PutUint64(value, n)
+	r = decodeRecord("T", txId[:], value, true)
+	tx, ok := r.Value.(*Transaction)
+	if !ok || "BitmarkIssue" != tx.Type || 5 != tx.BlockNumber {
+		t.Errorf("transaction: %+v", r)
+	}
+
+	// invalid data falls back to hex
+	r = decodeRecord("F", txId[:], []byte{1, 2, 3}, true)
+	if "" == r.Error || "010203" != r.Raw || nil != r.Value {
+		t.Errorf("invalid share: %+v", r)
+	}
+
+	// no decoder
+	r = decodeRecord("Z", []byte{1}, []byte{0xff}, true)
+	if "" != r.Error || "ff" != r.Raw {
+		t.Errorf("test data: %+v", r)
+	}
+}
+
+func TestEach(t *testing.T) {
+	defer setup(t)()
+
+	for i := 0; i < 5; i += 1 {
+		storeIssue(t, uint64(i+2), uint64(i+1))
+	}
+
+	n := 0
+	err := each(storage.Pool.OwnerData.NewFetchCursor(), 0, func(i int, e storage.Element) bool {
+		n += 1
+		return true
+	})
+	if nil != err || 5 != n {
+		t.Errorf("all: %d  error: %v", n, err)
+	}
+
+	n = 0
+	err = each(storage.Pool.OwnerData.NewFetchCursor(), 3, func(i int, e storage.Element) bool {
+		n += 1
+		return true
+	})
+	if nil != err || 3 != n {
+		t.Errorf("count: %d  error: %v", n, err)
+	}
+
+	n = 0
+	err = each(storage.Pool.OwnerData.NewFetchCursor(), 0, func(i int, e storage.Element) bool {
+		n += 1
+		return i < 1
+	})
+	if nil != err || 2 != n {
+		t.Errorf("stop: %d  error: %v", n, err)
+	}
+}
+
+func TestConsistencyCheck(t *testing.T) {
+	defer setup(t)()
+
+	txId, owner := storeIssue(t, 2, 1)
+	storeIssue(t, 3, 2)
+
+	problems := []*Problem{}
+	report := func(p *Problem) {
+		problems = append(problems, p)
+	}
+
+	records, count, err := consistencyCheck(checkPoolsFromStorage(), true, report)
+	if nil != err {
+		t.Fatalf("check error: %s", err)
+	}
+	if 0 != count || 0 != len(problems) {
+		t.Fatalf("problems: %d  %+v", count, problems)
+	}
+	if 6 != records {
+		t.Errorf("records: %d  expected: 6", records)
+	}
+
+	// break the owner tx index of the first issue
+	remove(t, storage.Pool.OwnerTxIndex, append(owner.Bytes(), txId[:]...))
+
+	_, count, err = consistencyCheck(checkPoolsFromStorage(), true, report)
+	if nil != err {
+		t.Fatalf("check error: %s", err)
+	}
+	if 1 != count || "L" != problems[0].Pool {
+		t.Errorf("problems: %d  %+v", count, problems)
+	}
+
+	// remove the transaction of the second issue
+	problems = problems[:0]
+	storage.Pool.Transactions.NewFetchCursor().Map(func(key []byte, value []byte) error {
+		if !bytes.Equal(txId[:], key) {
+			remove(t, storage.Pool.Transactions, key)
+		}
+		return nil
+	})
+
+	_, count, err = consistencyCheck(checkPoolsFromStorage(), true, report)
+	if nil != err {
+		t.Fatalf("check error: %s", err)
+	}
+	// owner list: owner unknown; owner data: transaction and issue missing
+	if 4 != count {
+		t.Errorf("problems: %d  %+v", count, problems)
+	}
+}
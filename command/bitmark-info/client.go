@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+)
+
+const (
+	dialTimeout     = 10 * time.Second
+	maximumNodeList = 100 // largest page the node will return
+)
+
+// RPCEmptyArguments - null parameters for RPC call
+type RPCEmptyArguments struct{}
+
+// RPCClient - client connection for RPC calls
+type RPCClient struct {
+	Client *rpc.Client
+}
+
+// NodeInfo - the parts of Node.Info used by the dashboard
+type NodeInfo struct {
+	Chain string `json:"chain"`
+	Mode  string `json:"mode"`
+	Block struct {
+		Height uint64 `json:"height"`
+		Hash   string `json:"hash"`
+	} `json:"block"`
+	Peers               uint64 `json:"peers"`
+	TransactionCounters struct {
+		Pending  int `json:"pending"`
+		Verified int `json:"verified"`
+	} `json:"transactionCounters"`
+	Version string `json:"version"`
+	Uptime  string `json:"uptime"`
+}
+
+// connect to a node over TLS
+func dial(hostPort string) (*RPCClient, error) {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+	}
+	conn, err := tls.DialWithDialer(dialer, "tcp", hostPort, &tls.Config{
+		InsecureSkipVerify: true,
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	// a stalled node must not hold up the whole table
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	return &RPCClient{jsonrpc.NewClient(conn)}, nil
+}
+
+// Close - shutdown the connection
+func (r *RPCClient) Close() {
+	r.Client.Close()
+}
+
+// GetNodeInfo - returns the node info of a node from bitmark rpc
+func (r *RPCClient) GetNodeInfo() (json.RawMessage, error) {
+	args := RPCEmptyArguments{}
+	var reply json.RawMessage
+	err := r.Client.Call("Node.Info", &args, &reply)
+	return reply, err
+}
+
+type nodeListArguments struct {
+	Start uint64 `json:"start,string"`
+	Count int    `json:"count"`
+}
+
+type nodeListReply struct {
+	Nodes []struct {
+		Connections []string `json:"connections"`
+	} `json:"nodes"`
+	NextStart uint64 `json:"nextStart,string"`
+}
+
+// ListNodes - the first RPC connection of every node the node knows
+func (r *RPCClient) ListNodes() ([]string, error) {
+	args := nodeListArguments{
+		Count: maximumNodeList,
+	}
+
+	connections := []string{}
+	for {
+		var reply nodeListReply
+		err := r.Client.Call("Node.List", &args, &reply)
+		if nil != err {
+			return nil, err
+		}
+		for _, n := range reply.Nodes {
+			if len(n.Connections) > 0 {
+				connections = append(connections, n.Connections[0])
+			}
+		}
+		if len(reply.Nodes) < args.Count || reply.NextStart <= args.Start {
+			return connections, nil
+		}
+		args.Start = reply.NextStart
+	}
+}
+
+type blockDumpArguments struct {
+	Height uint64 `json:"height,string"`
+	Binary bool   `json:"binary"`
+}
+
+type blockDumpReply struct {
+	Block struct {
+		Digest blockdigest.Digest `json:"digest"`
+	} `json:"block"`
+}
+
+// BlockHash - hash of the block at a height, in the same form as Node.Info
+func (r *RPCClient) BlockHash(height uint64) (string, error) {
+	args := blockDumpArguments{
+		Height: height,
+	}
+	var reply blockDumpReply
+	err := r.Client.Call("Node.BlockDump", &args, &reply)
+	if nil != err {
+		return "", err
+	}
+	return reply.Block.Digest.String(), nil
+}
@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// a node more than this many blocks below the reference is behind
+const behindLimit = 2
+
+// mode reported by a node that is catching up with its peers
+const modeResynchronise = "Resynchronise"
+
+// nodeState - summary of a node compared to the rest of the cluster
+type nodeState int
+
+// in increasing order of severity
+const (
+	stateOK nodeState = iota
+	stateBehind
+	stateResynchronise
+	stateForked
+	stateUnreachable
+)
+
+func (s nodeState) String() string {
+	switch s {
+	case stateOK:
+		return "ok"
+	case stateBehind:
+		return "behind"
+	case stateResynchronise:
+		return "RESYNC"
+	case stateForked:
+		return "FORKED"
+	case stateUnreachable:
+		return "DOWN"
+	default:
+		return "*unknown*"
+	}
+}
+
+// MarshalText - state as a JSON string
+func (s nodeState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// NodeStatus - result of polling one node
+type NodeStatus struct {
+	Host  string          `json:"host"`
+	Info  json.RawMessage `json:"info,omitempty"`
+	Error string          `json:"error,omitempty"`
+	State nodeState       `json:"state"`
+
+	address string
+	info    *NodeInfo
+	client  *RPCClient
+}
+
+// poll - fetch Node.Info from all nodes in parallel
+//
+// the connections are left open so that block hashes can be compared,
+// call closeAll when finished
+func poll(hosts []string) []*NodeStatus {
+	statuses := make([]*NodeStatus, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		s := &NodeStatus{
+			Host:    "tcp://" + host,
+			State:   stateUnreachable,
+			address: host,
+		}
+		statuses[i] = s
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.fetch()
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+func (s *NodeStatus) fetch() {
+	client, err := dial(s.address)
+	if nil != err {
+		s.Error = err.Error()
+		return
+	}
+
+	raw, err := client.GetNodeInfo()
+	if nil != err {
+		client.Close()
+		s.Error = err.Error()
+		return
+	}
+
+	info := &NodeInfo{}
+	err = json.Unmarshal(raw, info)
+	if nil != err {
+		client.Close()
+		s.Error = err.Error()
+		return
+	}
+
+	s.Info = raw
+	s.info = info
+	s.client = client
+	s.State = stateOK
+}
+
+// closeAll - release the connections opened by poll
+func closeAll(statuses []*NodeStatus) {
+	for _, s := range statuses {
+		if nil != s.client {
+			s.client.Close()
+			s.client = nil
+		}
+	}
+}
+
+// discover - add the nodes known to each host that are not already listed
+func discover(hosts []string) []string {
+	seen := make(map[string]struct{})
+	for _, host := range hosts {
+		seen[host] = struct{}{}
+	}
+
+	result := append([]string{}, hosts...)
+	for _, host := range hosts {
+		client, err := dial(host)
+		if nil != err {
+			continue
+		}
+		connections, err := client.ListNodes()
+		client.Close()
+		if nil != err {
+			continue
+		}
+		for _, c := range connections {
+			if _, ok := seen[c]; !ok {
+				seen[c] = struct{}{}
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+// hashFunc - fetch the hash of the block at a height from a node
+type hashFunc func(s *NodeStatus, height uint64) (string, error)
+
+// hash of a block using the node's open connection
+func blockHashAt(s *NodeStatus, height uint64) (string, error) {
+	return s.client.BlockHash(height)
+}
+
+// analyse - set the state of each node
+//
+// the reference is the most common tip among the nodes in normal
+// mode.  Nodes at other heights are compared at the lower of the
+// two heights, so a node that is merely behind is not reported as a
+// fork.  Returns the reference node, nil if no node could be reached.
+func analyse(statuses []*NodeStatus, hashAt hashFunc) *NodeStatus {
+	reference := findReference(statuses, true)
+	if nil == reference {
+		reference = findReference(statuses, false)
+	}
+	if nil == reference {
+		return nil
+	}
+
+	referenceHeight := reference.info.Block.Height
+	referenceHash := reference.info.Block.Hash
+
+	// hashes from the reference below its tip
+	cache := make(map[uint64]string)
+
+	for _, s := range statuses {
+		if nil == s.info {
+			s.State = stateUnreachable
+			continue
+		}
+
+		height := s.info.Block.Height
+		forked := false
+
+		switch {
+		case height == referenceHeight:
+			forked = s.info.Block.Hash != referenceHash
+
+		case height < referenceHeight:
+			expected, ok := cache[height]
+			if !ok {
+				h, err := hashAt(reference, height)
+				if nil == err {
+					expected = h
+					cache[height] = h
+				}
+			}
+			forked = "" != expected && s.info.Block.Hash != expected
+
+		default:
+			h, err := hashAt(s, referenceHeight)
+			forked = nil == err && h != referenceHash
+		}
+
+		switch {
+		case forked:
+			s.State = stateForked
+		case modeResynchronise == s.info.Mode:
+			s.State = stateResynchronise
+		case height+behindLimit < referenceHeight:
+			s.State = stateBehind
+		default:
+			s.State = stateOK
+		}
+	}
+	return reference
+}
+
+// the first node with the most common tip, preferring the greater
+// height when tips are equally common
+func findReference(statuses []*NodeStatus, normalOnly bool) *NodeStatus {
+	type tip struct {
+		height uint64
+		hash   string
+	}
+	votes := make(map[tip]int)
+	for _, s := range statuses {
+		if nil == s.info || (normalOnly && modeResynchronise == s.info.Mode) {
+			continue
+		}
+		votes[tip{s.info.Block.Height, s.info.Block.Hash}] += 1
+	}
+
+	var reference *NodeStatus
+	best := tip{}
+	for _, s := range statuses {
+		if nil == s.info || (normalOnly && modeResynchronise == s.info.Mode) {
+			continue
+		}
+		t := tip{s.info.Block.Height, s.info.Block.Hash}
+		if nil == reference || votes[t] > votes[best] || (votes[t] == votes[best] && t.height > best.height) {
+			reference = s
+			best = t
+		}
+	}
+	return reference
+}
@@ -4,4 +4,7 @@
 // license that can be found in the LICENSE file.
 
 // Bitmark bitmark-info
+//
+// show the status of a group of bitmarkd nodes as a table, comparing
+// block hashes to find nodes that have forked
 package main
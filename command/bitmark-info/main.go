@@ -6,32 +6,18 @@
 package main
 
 import (
-	"crypto/tls"
+	"bufio"
 	"encoding/json"
 	"fmt"
-	"net/rpc"
-	"net/rpc/jsonrpc"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bitmark-inc/exitwithstatus"
 	"github.com/bitmark-inc/getoptions"
 )
 
-// RPCEmptyArguments - null parameters for RPC call
-type RPCEmptyArguments struct{}
-
-// RPCClient - client connection for RPC calls
-type RPCClient struct {
-	Client *rpc.Client
-}
-
-// GetNodeInfo - returns the node info of a node from bitmark rpc
-func (r *RPCClient) GetNodeInfo() (json.RawMessage, error) {
-	args := RPCEmptyArguments{}
-	var reply json.RawMessage
-	err := r.Client.Call("Node.Info", &args, &reply)
-	return reply, err
-}
-
 // set by the linker: go build -ldflags "-X main.version=M.N" ./...
 var version = "zero" // do not change this value
 
@@ -44,6 +30,11 @@ func main() {
 		// {Long: "verbose", HasArg: getoptions.NO_ARGUMENT, Short: 'v'},
 		// {Long: "quiet", HasArg: getoptions.NO_ARGUMENT, Short: 'q'},
 		{Long: "version", HasArg: getoptions.NO_ARGUMENT, Short: 'V'},
+		{Long: "file", HasArg: getoptions.REQUIRED_ARGUMENT, Short: 'f'},
+		{Long: "discover", HasArg: getoptions.NO_ARGUMENT, Short: 'd'},
+		{Long: "refresh", HasArg: getoptions.REQUIRED_ARGUMENT, Short: 'r'},
+		{Long: "colour", HasArg: getoptions.NO_ARGUMENT, Short: 'g'},
+		{Long: "json", HasArg: getoptions.NO_ARGUMENT, Short: 'j'},
 	}
 
 	program, options, arguments, err := getoptions.GetOS(flags)
@@ -55,41 +46,84 @@ func main() {
 		exitwithstatus.Message("%s: version: %s", program, version)
 	}
 
-	if len(options["help"]) > 0 || 0 == len(arguments) {
-		exitwithstatus.Message("usage: %s [--help] host:port", program)
+	hosts := arguments
+	for _, fileName := range options["file"] {
+		h, err := readHosts(fileName)
+		if err != nil {
+			exitwithstatus.Message("%s: read hosts error: %s", program, err)
+		}
+		hosts = append(hosts, h...)
 	}
 
-	if len(arguments) != 1 {
-		exitwithstatus.Message("%s: extraneous extra arguments", program)
+	if len(options["help"]) > 0 || 0 == len(hosts) {
+		exitwithstatus.Message("usage: %s [--help] [--discover] [--refresh=SECONDS] [--colour] [--json] [--file=FILE] host:port...", program)
 	}
-	hostPort := arguments[0]
 
-	// establish rpc connection over tls
-	conn, err := tls.Dial("tcp", hostPort, &tls.Config{
-		InsecureSkipVerify: true,
-	})
-	if err != nil {
-		exitwithstatus.Message("dial error: %s", err)
+	refresh := 0
+	if len(options["refresh"]) > 0 {
+		refresh, err = strconv.Atoi(options["refresh"][0])
+		if nil != err || refresh < 0 {
+			exitwithstatus.Message("%s: invalid refresh: %q", program, options["refresh"][0])
+		}
 	}
-	defer conn.Close()
-	client := jsonrpc.NewClient(conn)
 
-	r := RPCClient{client}
-
-	reply := map[string]interface{}{
-		"host": fmt.Sprintf("tcp://%s", hostPort),
+	discovery := len(options["discover"]) > 0
+	jsonOutput := len(options["json"]) > 0
+	d := newDashboard(len(options["colour"]) > 0)
+
+	for {
+		nodes := hosts
+		if discovery {
+			nodes = discover(hosts)
+		}
+
+		statuses := poll(nodes)
+		reference := analyse(statuses, blockHashAt)
+		closeAll(statuses)
+
+		if jsonOutput {
+			for _, s := range statuses {
+				b, err := json.Marshal(s)
+				if err != nil {
+					exitwithstatus.Message("incorrect json marshal: %s", err)
+				}
+				fmt.Printf("%s\n", b)
+			}
+		} else {
+			if 0 != refresh {
+				fmt.Print(clearScreen)
+			}
+			d.render(os.Stdout, statuses, reference, time.Now())
+		}
+
+		if 0 == refresh {
+			for _, s := range statuses {
+				if s.State > stateBehind {
+					exitwithstatus.Exit(1)
+				}
+			}
+			return
+		}
+		time.Sleep(time.Duration(refresh) * time.Second)
 	}
+}
 
-	reply["info"], err = r.GetNodeInfo()
-
+// read host:port, one per line, ignoring blanks and # comments
+func readHosts(fileName string) ([]string, error) {
+	f, err := os.Open(fileName)
 	if err != nil {
-		exitwithstatus.Message("rpc error: %s", err)
+		return nil, err
 	}
-
-	b, err := json.Marshal(reply)
-	if err != nil {
-		exitwithstatus.Message("incorrect json marshal: %s", err)
+	defer f.Close()
+
+	hosts := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if "" == line || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hosts = append(hosts, line)
 	}
-
-	fmt.Printf("%s", b)
+	return hosts, scanner.Err()
 }
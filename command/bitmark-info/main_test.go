@@ -4,3 +4,110 @@
 // license that can be found in the LICENSE file.
 
 package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func status(address string, mode string, height uint64, hash string) *NodeStatus {
+	s := &NodeStatus{
+		address: address,
+		info:    &NodeInfo{Mode: mode},
+	}
+	s.info.Block.Height = height
+	s.info.Block.Hash = hash
+	return s
+}
+
+// every node has the same chain apart from node "x" whose blocks
+// from 98 onwards differ
+func testHashAt(s *NodeStatus, height uint64) (string, error) {
+	if "x" == s.address && height >= 98 {
+		return fmt.Sprintf("x%d", height), nil
+	}
+	return fmt.Sprintf("h%d", height), nil
+}
+
+func TestAnalyse(t *testing.T) {
+	statuses := []*NodeStatus{
+		status("a", "Normal", 100, "h100"),
+		status("b", "Normal", 100, "h100"),
+		status("c", "Normal", 99, "h99"),
+		status("d", "Normal", 90, "h90"),
+		status("e", "Resynchronise", 50, "h50"),
+		status("f", "Normal", 100, "y100"),
+		status("x", "Normal", 101, "x101"),
+		status("z", "Normal", 98, "x98"),
+		{address: "down"},
+	}
+
+	reference := analyse(statuses, testHashAt)
+	if nil == reference || "a" != reference.address {
+		t.Fatalf("reference: %+v", reference)
+	}
+
+	expected := []nodeState{
+		stateOK,
+		stateOK,
+		stateOK,
+		stateBehind,
+		stateResynchronise,
+		stateForked,
+		stateForked,
+		stateForked,
+		stateUnreachable,
+	}
+	for i, s := range statuses {
+		if expected[i] != s.State {
+			t.Errorf("%s: state: %s  expected: %s", s.address, s.State, expected[i])
+		}
+	}
+}
+
+func TestAnalyseMajority(t *testing.T) {
+	statuses := []*NodeStatus{
+		status("a", "Normal", 100, "y100"),
+		status("b", "Normal", 100, "h100"),
+		status("c", "Normal", 100, "h100"),
+		status("e", "Resynchronise", 120, "h120"),
+	}
+	reference := analyse(statuses, testHashAt)
+	if nil == reference || "b" != reference.address {
+		t.Fatalf("reference: %+v", reference)
+	}
+	if stateForked != statuses[0].State || stateResynchronise != statuses[3].State {
+		t.Errorf("states: %s %s", statuses[0].State, statuses[3].State)
+	}
+
+	if nil != analyse([]*NodeStatus{{address: "down"}}, testHashAt) {
+		t.Error("reference found with no reachable nodes")
+	}
+}
+
+func TestRender(t *testing.T) {
+	statuses := []*NodeStatus{
+		status("a", "Normal", 100, "h100"),
+		status("e", "Resynchronise", 50, "h50"),
+	}
+	reference := analyse(statuses, testHashAt)
+
+	d := newDashboard(true)
+	now := time.Now()
+
+	buffer := &bytes.Buffer{}
+	d.render(buffer, statuses, reference, now)
+	buffer.Reset()
+	d.render(buffer, statuses, reference, now.Add(90*time.Second))
+
+	output := buffer.String()
+	if !strings.Contains(output, "Resynchronise 1m30s") {
+		t.Errorf("no resynchronise duration in:\n%s", output)
+	}
+	if !strings.Contains(output, "\033[1;33m") || strings.Contains(output, "\xff") {
+		t.Errorf("highlight not rendered in:\n%s", output)
+	}
+}
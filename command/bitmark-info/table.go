@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// colours, wrapped in tabwriter escapes so they take no width
+const (
+	forkedColour = "\xff\033[1;31m\xff"
+	resyncColour = "\xff\033[1;33m\xff"
+	behindColour = "\xff\033[0;36m\xff"
+	downColour   = "\xff\033[1;35m\xff"
+	endColour    = "\xff\033[0m\xff"
+
+	clearScreen = "\033[H\033[2J"
+)
+
+// dashboard - state kept between refreshes
+type dashboard struct {
+	colour bool
+	resync map[string]time.Time // when each node was first seen resynchronising
+}
+
+func newDashboard(colour bool) *dashboard {
+	return &dashboard{
+		colour: colour,
+		resync: make(map[string]time.Time),
+	}
+}
+
+// render - print a table of node statuses
+func (d *dashboard) render(w io.Writer, statuses []*NodeStatus, reference *NodeStatus, now time.Time) {
+
+	fmt.Fprintf(w, "%s  nodes: %d", now.Format(time.RFC3339), len(statuses))
+	if nil != reference {
+		fmt.Fprintf(w, "  height: %d  hash: %s", reference.info.Block.Height, reference.info.Block.Hash)
+	}
+	fmt.Fprintf(w, "\n\n")
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+	fmt.Fprintf(tw, "NODE\tSTATE\tMODE\tHEIGHT\tHASH\tPEERS\tPENDING\tVERIFIED\tVERSION\n")
+
+	for _, s := range statuses {
+		start, end := d.colours(s.State)
+
+		if nil == s.info {
+			delete(d.resync, s.address)
+			fmt.Fprintf(tw, "%s%s\t%s\t\t\t\t\t\t\t%s%s\n", start, s.address, s.State, s.Error, end)
+			continue
+		}
+
+		mode := s.info.Mode
+		if modeResynchronise == mode {
+			since, ok := d.resync[s.address]
+			if !ok {
+				since = now
+				d.resync[s.address] = now
+			}
+			mode += " " + now.Sub(since).Truncate(time.Second).String()
+		} else {
+			delete(d.resync, s.address)
+		}
+
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%d\t%s\t%d\t%d\t%d\t%s%s\n",
+			start,
+			s.address,
+			s.State,
+			mode,
+			s.info.Block.Height,
+			shortHash(s.info.Block.Hash),
+			s.info.Peers,
+			s.info.TransactionCounters.Pending,
+			s.info.TransactionCounters.Verified,
+			s.info.Version,
+			end,
+		)
+	}
+	tw.Flush()
+}
+
+// highlight a row according to state
+func (d *dashboard) colours(state nodeState) (string, string) {
+	if !d.colour {
+		return "", ""
+	}
+	switch state {
+	case stateForked:
+		return forkedColour, endColour
+	case stateResynchronise:
+		return resyncColour, endColour
+	case stateBehind:
+		return behindColour, endColour
+	case stateUnreachable:
+		return downColour, endColour
+	default:
+		return "", ""
+	}
+}
+
+// short form of a block hash for the table
+func shortHash(hash string) string {
+	if len(hash) <= 16 {
+		return hash
+	}
+	return strings.Join([]string{hash[:8], hash[len(hash)-8:]}, "…")
+}
@@ -48,6 +48,8 @@ const (
 	defaultLogCount     = 10          //  number of log files retained
 	defaultLogSize      = 1024 * 1024 // rotate when <logfile> exceeds this size
 
+	defaultProofAuditFile = "proof-audit.log"
+
 	defaultRPCClients = 100          // maximum TCP connections
 	defaultBandwidth  = 25 * 1000000 // 25Mbps
 )
@@ -80,6 +82,8 @@ type Configuration struct {
 
 	CacheDirectory string `gluamapper:"cache_directory" json:"cache_directory"`
 
+	MetadataSchemas string `gluamapper:"metadata_schemas" json:"metadata_schemas"`
+
 	ClientRPC  listeners.RPCConfiguration   `gluamapper:"client_rpc" json:"client_rpc"`
 	HttpsRPC   listeners.HTTPSConfiguration `gluamapper:"https_rpc" json:"https_rpc"`
 	Peering    peer.Configuration           `gluamapper:"peering" json:"peering"`
@@ -127,6 +131,10 @@ func getConfiguration(configurationFileName string) (*Configuration, error) {
 			PreferIPv6:         true,
 		},
 
+		Proofing: proof.Configuration{
+			AuditFile: defaultProofAuditFile,
+		},
+
 		Payment: payment.Configuration{
 			P2PCache: payment.P2PCache{
 				BtcDirectory: defaultBitmarkBtcCacheDirectory,
@@ -222,6 +230,8 @@ func getConfiguration(configurationFileName string) (*Configuration, error) {
 	// optional absolute paths i.e. blank or an absolute path
 	optionalAbsolute := []*string{
 		&options.PidFile,
+		&options.Proofing.AuditFile,
+		&options.MetadataSchemas,
 	}
 	for _, f := range optionalAbsolute {
 		if "" != *f {
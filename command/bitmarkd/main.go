@@ -173,17 +173,30 @@ func main() {
 	}
 	defer asset.Finalise()
 
+	// typed metadata schemas are optional
+	if "" != theConfiguration.MetadataSchemas {
+		n, err := asset.LoadSchemas(theConfiguration.MetadataSchemas)
+		if nil != err {
+			log.Criticalf("metadata schema error: %s", err)
+			exitwithstatus.Message("metadata schema error: %s", err)
+		}
+		log.Infof("loaded: %d metadata schemas", n)
+	}
+
 	// reservoir and block are both ready
 	// so can restore any previously saved transactions
 	// before any peer services are started
 	handles := reservoir.Handles{
 		Assets:            storage.Pool.Assets,
+		AssetVersions:     storage.Pool.AssetVersions,
+		AssetMetadata:     storage.Pool.AssetMetadata,
 		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
 		Transactions:      storage.Pool.Transactions,
 		OwnerTxIndex:      storage.Pool.OwnerTxIndex,
 		OwnerData:         storage.Pool.OwnerData,
 		Shares:            storage.Pool.Shares,
 		ShareQuantity:     storage.Pool.ShareQuantity,
+		ShareHolders:      storage.Pool.ShareHolders,
 	}
 
 	// start the reservoir (verified transaction data cache)
@@ -195,6 +208,12 @@ func main() {
 	}
 	defer reservoir.Finalise()
 
+	err = reservoir.SetAccountLimit(theConfiguration.Proofing.Selection.AccountLimit)
+	if nil != err {
+		log.Criticalf("reservoir account limit error: %s", err)
+		exitwithstatus.Message("reservoir account limit error: %s", err)
+	}
+
 	// block header data
 	log.Info("initialise blockheader")
 	err = blockheader.Initialise()
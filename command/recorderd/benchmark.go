@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+)
+
+const (
+	defaultBenchmarkSeconds = 10
+	benchmarkRecordSize     = 99 // same as a packed block header
+)
+
+// result of hashing with one thread count and memory setting
+type benchmarkResult struct {
+	threads  int
+	memory   int // KiB
+	hashes   uint64
+	duration time.Duration
+}
+
+// hashes per second for all threads
+func (r benchmarkResult) rate() float64 {
+	if r.duration <= 0 {
+		return 0
+	}
+	return float64(r.hashes) / r.duration.Seconds()
+}
+
+// parse benchmark command arguments: [SECONDS [MEMORY-MiB...]]
+func parseBenchmarkArguments(arguments []string) (time.Duration, []int, error) {
+	seconds := defaultBenchmarkSeconds
+	memories := []int{blockdigest.Memory}
+
+	if len(arguments) >= 1 {
+		n, err := strconv.Atoi(arguments[0])
+		if nil != err || n <= 0 {
+			return 0, nil, fmt.Errorf("invalid seconds: %q", arguments[0])
+		}
+		seconds = n
+	}
+
+	if len(arguments) >= 2 {
+		memories = make([]int, 0, len(arguments)-1)
+		for _, a := range arguments[1:] {
+			n, err := strconv.Atoi(a)
+			if nil != err || n <= 0 {
+				return 0, nil, fmt.Errorf("invalid memory: %q", a)
+			}
+			memories = append(memories, n*1024)
+		}
+	}
+
+	return time.Duration(seconds) * time.Second, memories, nil
+}
+
+// thread counts to try: powers of two up to and including the CPU count
+func benchmarkThreadCounts(cpuCount int) []int {
+	counts := make([]int, 0, 8)
+	for n := 1; n < cpuCount; n *= 2 {
+		counts = append(counts, n)
+	}
+	return append(counts, cpuCount)
+}
+
+// run the benchmark and print a table of hash rates
+func runBenchmark(arguments []string) error {
+	duration, memories, err := parseBenchmarkArguments(arguments)
+	if nil != err {
+		return err
+	}
+
+	threadCounts := benchmarkThreadCounts(runtime.NumCPU())
+
+	fmt.Printf("cpu count: %d  duration per test: %s\n\n", runtime.NumCPU(), duration)
+	fmt.Printf("%8s  %10s  %12s  %12s\n", "threads", "memory", "H/s", "H/s/thread")
+
+	for _, memory := range memories {
+		best := benchmarkResult{}
+		for _, threads := range threadCounts {
+			r := benchmarkOnce(threads, memory, duration)
+			fmt.Printf("%8d  %7d MiB  %12.3f  %12.3f\n", r.threads, r.memory/1024, r.rate(), r.rate()/float64(r.threads))
+			if r.rate() > best.rate() {
+				best = r
+			}
+		}
+		fmt.Printf("best for %d MiB: %d threads (%.3f H/s)\n\n", memory/1024, best.threads, best.rate())
+	}
+
+	fmt.Printf("note: blocks are always hashed with %d MiB\n", blockdigest.Memory/1024)
+	return nil
+}
+
+// hash random records on several goroutines for a fixed duration
+func benchmarkOnce(threads int, memory int, duration time.Duration) benchmarkResult {
+	var hashes uint64
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+	start := time.Now()
+
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			record := make([]byte, benchmarkRecordSize)
+			_, _ = rand.Read(record)
+
+			for nonce := uint64(0); ; nonce++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for j := 0; j < blockrecord.NonceSize; j++ {
+					record[len(record)-blockrecord.NonceSize+j] = byte(nonce >> (8 * j))
+				}
+				blockdigest.NewDigestWithMemory(record, memory)
+				atomic.AddUint64(&hashes, 1)
+			}
+		}()
+	}
+
+	time.Sleep(duration)
+	close(stop)
+	wg.Wait()
+
+	return benchmarkResult{
+		threads:  threads,
+		memory:   memory,
+		hashes:   atomic.LoadUint64(&hashes),
+		duration: time.Since(start),
+	}
+}
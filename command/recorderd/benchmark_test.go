@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+)
+
+func TestParseBenchmarkArguments(t *testing.T) {
+	duration, memories, err := parseBenchmarkArguments(nil)
+	if nil != err {
+		t.Fatalf("default arguments error: %s", err)
+	}
+	if defaultBenchmarkSeconds*time.Second != duration {
+		t.Errorf("default duration: %s", duration)
+	}
+	if !reflect.DeepEqual([]int{blockdigest.Memory}, memories) {
+		t.Errorf("default memories: %v", memories)
+	}
+
+	duration, memories, err = parseBenchmarkArguments([]string{"3", "64", "256"})
+	if nil != err {
+		t.Fatalf("arguments error: %s", err)
+	}
+	if 3*time.Second != duration {
+		t.Errorf("duration: %s", duration)
+	}
+	if !reflect.DeepEqual([]int{64 * 1024, 256 * 1024}, memories) {
+		t.Errorf("memories: %v", memories)
+	}
+
+	for _, bad := range [][]string{{"x"}, {"0"}, {"5", "-1"}} {
+		if _, _, err := parseBenchmarkArguments(bad); nil == err {
+			t.Errorf("arguments: %q did not error", bad)
+		}
+	}
+}
+
+func TestBenchmarkThreadCounts(t *testing.T) {
+	expected := map[int][]int{
+		1: {1},
+		4: {1, 2, 4},
+		6: {1, 2, 4, 6},
+	}
+	for cpus, counts := range expected {
+		actual := benchmarkThreadCounts(cpus)
+		if !reflect.DeepEqual(counts, actual) {
+			t.Errorf("cpus: %d  counts: %v  expected: %v", cpus, actual, counts)
+		}
+	}
+}
@@ -8,7 +8,9 @@ package main
 import (
 	"fmt"
 	"path/filepath"
+	"runtime"
 
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
 	"github.com/bitmark-inc/bitmarkd/zmqutil"
 	"github.com/bitmark-inc/exitwithstatus"
 )
@@ -16,6 +18,8 @@ import (
 const (
 	recorderdPublicKeyFilename  = "recorderd.public"
 	recorderdPrivateKeyFilename = "recorderd.private"
+	poolPublicKeyFilename       = "pool.public"
+	poolPrivateKeyFilename      = "pool.private"
 )
 
 // setup command handler
@@ -44,6 +48,25 @@ func processSetupCommand(program string, arguments []string) bool {
 		}
 		fmt.Printf("generated private key: %q and public key: %q\n", privateKeyFilename, publicKeyFilename)
 
+	case "generate-pool-identity", "pool-id":
+		publicKeyFilename := getFilenameWithDirectory(arguments, poolPublicKeyFilename)
+		privateKeyFilename := getFilenameWithDirectory(arguments, poolPrivateKeyFilename)
+
+		err := zmqutil.MakeKeyPair(publicKeyFilename, privateKeyFilename)
+		if nil != err {
+			fmt.Printf("cannot generate private key: %q and public key: %q\n", privateKeyFilename, publicKeyFilename)
+			fmt.Printf("error generating pool key pair: %v\n", err)
+			exitwithstatus.Exit(1)
+		}
+		fmt.Printf("generated private key: %q and public key: %q\n", privateKeyFilename, publicKeyFilename)
+
+	case "benchmark", "bench":
+		err := runBenchmark(arguments)
+		if nil != err {
+			fmt.Printf("benchmark error: %s\n", err)
+			exitwithstatus.Exit(1)
+		}
+
 	case "start", "run":
 		return false // continue processing
 
@@ -69,6 +92,15 @@ func processSetupCommand(program string, arguments []string) bool {
 		fmt.Printf("                                        and the public key in: %q\n", "DIR/"+recorderdPublicKeyFilename)
 		fmt.Printf("\n")
 
+		fmt.Printf("  generate-pool-identity [DIR] (pool-id) - create pool private key in: %q\n", "DIR/"+poolPrivateKeyFilename)
+		fmt.Printf("                                        and the public key in: %q\n", "DIR/"+poolPublicKeyFilename)
+		fmt.Printf("\n")
+
+		fmt.Printf("  benchmark [SECS [MiB...]]  (bench)  - measure argon2 hash rate for 1..%d threads\n", runtime.NumCPU())
+		fmt.Printf("                                        run each test for SECS (default: %d) using\n", defaultBenchmarkSeconds)
+		fmt.Printf("                                        each memory size in MiB (default: %d)\n", blockdigest.Memory/1024)
+		fmt.Printf("\n")
+
 		fmt.Printf("  start                      (run)    - just run the program, same as no arguments\n")
 		fmt.Printf("                                        for convienience when passing script arguments\n")
 		fmt.Printf("\n")
@@ -54,13 +54,38 @@ type PeerType struct {
 
 // ConfigCalendar - scheduling configuration
 type ConfigCalendar struct {
-	Monday    string `gluamapper:"monday" json:"monday"`
-	Tuesday   string `gluamapper:"tuesday" json:"tuesday"`
-	Wednesday string `gluamapper:"wednesday" json:"wednesday"`
-	Thursday  string `gluamapper:"thursday" json:"thursday"`
-	Friday    string `gluamapper:"friday" json:"friday"`
-	Saturday  string `gluamapper:"saturday" json:"saturday"`
-	Sunday    string `gluamapper:"sunday" json:"sunday"`
+	Monday     string                    `gluamapper:"monday" json:"monday"`
+	Tuesday    string                    `gluamapper:"tuesday" json:"tuesday"`
+	Wednesday  string                    `gluamapper:"wednesday" json:"wednesday"`
+	Thursday   string                    `gluamapper:"thursday" json:"thursday"`
+	Friday     string                    `gluamapper:"friday" json:"friday"`
+	Saturday   string                    `gluamapper:"saturday" json:"saturday"`
+	Sunday     string                    `gluamapper:"sunday" json:"sunday"`
+	TimeZone   string                    `gluamapper:"time_zone" json:"time_zone"`
+	Exceptions []ConfigCalendarException `gluamapper:"exceptions" json:"exceptions"`
+}
+
+// ConfigCalendarException - schedule for a date or an inclusive
+// range of dates that replaces the weekday schedule
+type ConfigCalendarException struct {
+	Date    string `gluamapper:"date" json:"date"`
+	EndDate string `gluamapper:"end_date" json:"end_date"`
+	Hours   string `gluamapper:"hours" json:"hours"`
+}
+
+// PoolType - configuration of the optional pool server
+//
+// when enabled the upstream connections in peering are used by the
+// pool and local recorderd workers connect to the publish and submit
+// addresses here
+type PoolType struct {
+	Enable          bool     `gluamapper:"enable" json:"enable"`
+	PrivateKey      string   `gluamapper:"private_key" json:"private_key"`
+	PublicKey       string   `gluamapper:"public_key" json:"public_key"`
+	Publish         []string `gluamapper:"publish" json:"publish"`
+	Submit          []string `gluamapper:"submit" json:"submit"`
+	ShareDifficulty float64  `gluamapper:"share_difficulty" json:"share_difficulty"`
+	ReportFile      string   `gluamapper:"report_file" json:"report_file"`
 }
 
 // type PaymentType struct {
@@ -74,13 +99,15 @@ type ConfigCalendar struct {
 
 // Configuration - configuration file data
 type Configuration struct {
-	DataDirectory string               `gluamapper:"data_directory" json:"data_directory"`
-	PidFile       string               `gluamapper:"pidfile" json:"pidfile"`
-	Chain         string               `gluamapper:"chain" json:"chain"`
-	MaxCPUUsage   int                  `gluamapper:"max_cpu_usage" json:"max_cpu_usage"`
-	Calendar      ConfigCalendar       `gluamapper:"calendar" json:"calendar"`
-	Peering       PeerType             `gluamapper:"peering" json:"peering"`
-	Logging       logger.Configuration `gluamapper:"logging" json:"logging"`
+	DataDirectory   string               `gluamapper:"data_directory" json:"data_directory"`
+	PidFile         string               `gluamapper:"pidfile" json:"pidfile"`
+	Chain           string               `gluamapper:"chain" json:"chain"`
+	MaxCPUUsage     int                  `gluamapper:"max_cpu_usage" json:"max_cpu_usage"`
+	AutoThreadCount bool                 `gluamapper:"auto_thread_count" json:"auto_thread_count"`
+	Calendar        ConfigCalendar       `gluamapper:"calendar" json:"calendar"`
+	Peering         PeerType             `gluamapper:"peering" json:"peering"`
+	Pool            PoolType             `gluamapper:"pool" json:"pool"`
+	Logging         logger.Configuration `gluamapper:"logging" json:"logging"`
 }
 
 // getConfiguration - will read decode and verify the configuration
@@ -100,10 +127,17 @@ func getConfiguration(configurationFileName string) (*Configuration, error) {
 		PidFile:       "", // no PidFile by default
 		Chain:         chain.Bitmark,
 		MaxCPUUsage:   50,
-		Calendar:      ConfigCalendar{},
+
+		AutoThreadCount: false,
+		Calendar:        ConfigCalendar{},
 
 		Peering: PeerType{},
 
+		Pool: PoolType{
+			ShareDifficulty: defaultShareDifficulty,
+			ReportFile:      defaultPoolReportFile,
+		},
+
 		Logging: logger.Configuration{
 			Directory: defaultLogDirectory,
 			File:      defaultLogFile,
@@ -129,6 +163,10 @@ func getConfiguration(configurationFileName string) (*Configuration, error) {
 		options.MaxCPUUsage = 50
 	}
 
+	if options.Pool.ShareDifficulty < defaultShareDifficulty {
+		options.Pool.ShareDifficulty = defaultShareDifficulty
+	}
+
 	// ensure absolute data directory
 	if "" == options.DataDirectory || "~" == options.DataDirectory {
 		return nil, fmt.Errorf("Path: %q is not a valid directory", options.DataDirectory)
@@ -169,6 +207,7 @@ func getConfiguration(configurationFileName string) (*Configuration, error) {
 	// second (or nil if no prefix can be added)
 	mustNotBePaths := [][2]*string{
 		{&options.Logging.File, nil},
+		{&options.Pool.ReportFile, &options.DataDirectory},
 	}
 	for _, f := range mustNotBePaths {
 		switch filepath.Dir(*f[0]) {
@@ -62,6 +62,9 @@ type SingleEvent struct {
 type JobCalendarData struct {
 	flattenEvents     FlattenEvents
 	events            map[time.Weekday][]SingleEvent
+	exceptionEvents   FlattenEvents
+	exceptions        map[string]DayException
+	location          *time.Location
 	rawData           ConfigCalendar
 	rescheduleChannel chan<- struct{}
 	log               *logger.L
@@ -86,6 +89,12 @@ func newJobCalendar(channel chan<- struct{}) JobCalendar {
 			time.Friday:    {},
 			time.Saturday:  {},
 		},
+		exceptionEvents: FlattenEvents{
+			start: []time.Time{},
+			stop:  []time.Time{},
+		},
+		exceptions:        map[string]DayException{},
+		location:          time.Local,
 		rawData:           ConfigCalendar{},
 		rescheduleChannel: channel,
 	}
@@ -115,7 +124,7 @@ func (j *JobCalendarData) SetLog(l *logger.L) {
 }
 
 func (j *JobCalendarData) RunForever() bool {
-	return 0 == len(j.flattenEvents.stop)
+	return 0 == len(j.flattenEvents.stop) && !j.hasExceptionStopAfter(time.Now())
 }
 
 func (j *JobCalendarData) Refresh(calendar ConfigCalendar) {
@@ -124,8 +133,10 @@ func (j *JobCalendarData) Refresh(calendar ConfigCalendar) {
 		j.log.Debug("calendar change")
 		j.setNewCalendar(calendar)
 		j.resetEvents()
+		j.setLocation(calendar.TimeZone)
 		j.parseRawData(calendar)
 		j.removeRedundantStopEvent()
+		j.parseExceptions(calendar.Exceptions)
 		j.printEvents()
 		j.notifyJobManager()
 	}
@@ -134,6 +145,8 @@ func (j *JobCalendarData) Refresh(calendar ConfigCalendar) {
 func (j *JobCalendarData) resetEvents() {
 	j.flattenEvents = j.newEmptyFlattenEvents()
 	j.events = j.newEmptyEvents()
+	j.exceptionEvents = j.newEmptyFlattenEvents()
+	j.exceptions = map[string]DayException{}
 }
 
 func (j *JobCalendarData) notifyJobManager() {
@@ -210,6 +223,11 @@ func isTimeDataFirstEarlierThanSecond(first TimeData, second TimeData) bool {
 }
 
 func (j *JobCalendarData) isTimeBooked(event time.Time) bool {
+	event = event.In(j.timeLocation())
+	if e, ok := j.exceptionOf(event); ok {
+		return e.isBooked(event)
+	}
+
 	weekDay := event.Weekday()
 	events := j.events[weekDay]
 
@@ -245,11 +263,9 @@ func (j *JobCalendarData) PickInitialiseStopEvent(event time.Time) interface{} {
 }
 
 func (j *JobCalendarData) PickNextStartEvent(event time.Time) interface{} {
-	for _, e := range j.flattenEvents.start {
-		if e.After(event) {
-			j.log.Infof("next start event at %s", e)
-			return e
-		}
+	if e, ok := j.nextEvent(j.flattenEvents.start, j.exceptionEvents.start, event); ok {
+		j.log.Infof("next start event at %s", e)
+		return e
 	}
 	j.log.Error("cannot find next start event")
 	j.printEvents()
@@ -257,11 +273,9 @@ func (j *JobCalendarData) PickNextStartEvent(event time.Time) interface{} {
 }
 
 func (j *JobCalendarData) PickNextStopEvent(event time.Time) interface{} {
-	for _, e := range j.flattenEvents.stop {
-		if e.After(event) {
-			j.log.Infof("next stop event at %s", e)
-			return e
-		}
+	if e, ok := j.nextEvent(j.flattenEvents.stop, j.exceptionEvents.stop, event); ok {
+		j.log.Infof("next stop event at %s", e)
+		return e
 	}
 	j.log.Info("cannot find next stop event")
 	j.printEvents()
@@ -372,7 +386,7 @@ func (j *JobCalendarData) parseTimePeriod(period string) (TimeData, TimeData, er
 }
 
 func (j *JobCalendarData) timeByWeekdayAndOffset(day time.Weekday, clock TimeData) time.Time {
-	now := time.Now()
+	now := time.Now().In(j.timeLocation())
 	dayDiffNum := j.weekDayCurrent2Target(now.Weekday(), day)
 	return time.Date(now.Year(), now.Month(), now.Day()+dayDiffNum,
 		int(clock.hour), int(clock.minute), 0, 0, now.Location())
@@ -520,6 +534,8 @@ func (j *JobCalendarData) printEvents() {
 		time.Saturday,
 	}
 
+	j.printExceptions()
+
 	for _, d := range weekdays {
 		j.log.Debugf("%d start flattenEvents: %+v",
 			len(j.flattenEvents.start),
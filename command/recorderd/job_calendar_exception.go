@@ -0,0 +1,287 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	exceptionDateLayout  = "2006-01-02"
+	offClockStr          = "off"
+	maximumExceptionDays = 366
+)
+
+// DayException - schedule for one date that replaces the weekday
+// schedule, no events means no hashing for the whole day
+type DayException struct {
+	day    time.Time
+	events []SingleEvent
+}
+
+func (d DayException) isBooked(event time.Time) bool {
+	for _, e := range d.events {
+		if !event.Before(e.start) && event.Before(e.stop) {
+			return true
+		}
+	}
+	return false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfNextDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+}
+
+func dateKey(t time.Time) string {
+	return t.Format(exceptionDateLayout)
+}
+
+func (j *JobCalendarData) timeLocation() *time.Location {
+	if nil == j.location {
+		return time.Local
+	}
+	return j.location
+}
+
+func (j *JobCalendarData) setLocation(name string) {
+	name = strings.Trim(name, spaceChar)
+	if "" == name {
+		j.location = time.Local
+		return
+	}
+
+	location, err := time.LoadLocation(name)
+	if nil != err {
+		j.log.Errorf("invalid time zone %q, use local time, error: %s", name, err)
+		j.location = time.Local
+		return
+	}
+	j.location = location
+}
+
+func (j *JobCalendarData) exceptionOf(event time.Time) (DayException, bool) {
+	if 0 == len(j.exceptions) {
+		return DayException{}, false
+	}
+	e, ok := j.exceptions[dateKey(event.In(j.timeLocation()))]
+	return e, ok
+}
+
+// later entries override earlier ones, so a single date inside a range
+// can be given a different schedule
+func (j *JobCalendarData) parseExceptions(exceptions []ConfigCalendarException) {
+	location := j.timeLocation()
+
+loop:
+	for _, e := range exceptions {
+		first, err := time.ParseInLocation(exceptionDateLayout, strings.Trim(e.Date, spaceChar), location)
+		if nil != err {
+			j.log.Errorf("invalid exception date %q, error: %s", e.Date, err)
+			continue loop
+		}
+
+		last := first
+		if "" != strings.Trim(e.EndDate, spaceChar) {
+			last, err = time.ParseInLocation(exceptionDateLayout, strings.Trim(e.EndDate, spaceChar), location)
+			if nil != err {
+				j.log.Errorf("invalid exception end date %q, error: %s", e.EndDate, err)
+				continue loop
+			}
+		}
+		if last.Before(first) {
+			j.log.Errorf("invalid exception %s to %s, end date before start date", e.Date, e.EndDate)
+			continue loop
+		}
+
+		for day, n := first, 0; !day.After(last); day, n = startOfNextDay(day), n+1 {
+			if n >= maximumExceptionDays {
+				j.log.Errorf("exception from %s exceeds %d days", e.Date, maximumExceptionDays)
+				break
+			}
+			j.exceptions[dateKey(day)] = j.dayException(day, e.Hours)
+		}
+	}
+
+	j.scheduleExceptionEvents()
+}
+
+func (j *JobCalendarData) dayException(day time.Time, clock string) DayException {
+	clock = strings.Trim(clock, spaceChar)
+	exception := DayException{
+		day:    day,
+		events: []SingleEvent{},
+	}
+
+	if strings.EqualFold(offClockStr, clock) {
+		j.log.Debugf("%s no work", dateKey(day))
+		return exception
+	}
+
+	if allDayClockStr != clock {
+	loop:
+		for _, period := range strings.Split(clock, timePeriodSeparator) {
+			if !j.isValidPeriod(period) {
+				continue loop
+			}
+			t1, t2, err := j.parseTimePeriod(period)
+			if nil != err {
+				j.log.Errorf("error parse time period %s, error: %s", period, err)
+				continue loop
+			}
+			exception.events = append(exception.events, SingleEvent{
+				start: j.timeByDateAndOffset(day, t1),
+				stop:  j.timeByDateAndOffset(day, t2),
+			})
+		}
+	}
+
+	// same as a weekday: no valid period means all day
+	if 0 == len(exception.events) {
+		j.log.Debugf("%s work all day", dateKey(day))
+		exception.events = append(exception.events, SingleEvent{
+			start: day,
+			stop:  startOfNextDay(day),
+		})
+	}
+
+	return exception
+}
+
+func (j *JobCalendarData) timeByDateAndOffset(day time.Time, clock TimeData) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		int(clock.hour), int(clock.minute), 0, 0, day.Location())
+}
+
+// events for every exception date, including the switch back to the
+// weekday schedule at the end of the exception
+func (j *JobCalendarData) scheduleExceptionEvents() {
+	keys := make([]string, 0, len(j.exceptions))
+	for k := range j.exceptions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	events := j.newEmptyFlattenEvents()
+	for _, k := range keys {
+		e := j.exceptions[k]
+
+		if !e.isBooked(e.day) {
+			events.stop = append(events.stop, e.day)
+		}
+		for _, s := range e.events {
+			events.start = append(events.start, s.start)
+			events.stop = append(events.stop, s.stop)
+		}
+
+		end := startOfNextDay(e.day)
+		if _, ok := j.exceptions[dateKey(end)]; ok {
+			continue
+		}
+		if j.isWeekdayBooked(end) {
+			events.start = append(events.start, end)
+		} else {
+			events.stop = append(events.stop, end)
+		}
+	}
+
+	// a stop at the same time as a start is redundant
+	stop := make([]time.Time, 0, len(events.stop))
+	for _, t := range events.stop {
+		if exist, _ := isEventAlreadyExist(events.start, t); !exist {
+			stop = append(stop, t)
+		}
+	}
+	events.stop = stop
+
+	sort.Slice(events.start, func(i, k int) bool {
+		return events.start[i].Before(events.start[k])
+	})
+	sort.Slice(events.stop, func(i, k int) bool {
+		return events.stop[i].Before(events.stop[k])
+	})
+
+	j.exceptionEvents = events
+}
+
+// weekday schedule by clock time, valid for any week
+func (j *JobCalendarData) isWeekdayBooked(event time.Time) bool {
+	event = event.In(j.timeLocation())
+	offset := event.Sub(startOfDay(event))
+
+	for _, e := range j.events[event.Weekday()] {
+		begin := startOfDay(e.start)
+		start := e.start.Sub(begin)
+		if e.stop.IsZero() {
+			if offset >= start {
+				return true
+			}
+			continue
+		}
+		if offset >= start && offset < e.stop.Sub(begin) {
+			return true
+		}
+	}
+	return false
+}
+
+// earliest weekday event not on an exception date or exception event
+// after the given time
+func (j *JobCalendarData) nextEvent(weekly []time.Time, exception []time.Time, event time.Time) (time.Time, bool) {
+	next := time.Time{}
+	found := false
+
+weekly_loop:
+	for _, e := range weekly {
+		if !e.After(event) {
+			continue weekly_loop
+		}
+		if _, masked := j.exceptionOf(e); masked {
+			continue weekly_loop
+		}
+		next = e
+		found = true
+		break weekly_loop
+	}
+
+exception_loop:
+	for _, e := range exception {
+		if !e.After(event) {
+			continue exception_loop
+		}
+		if !found || e.Before(next) {
+			next = e
+			found = true
+		}
+		break exception_loop
+	}
+
+	return next, found
+}
+
+// exception stop events are sorted, so only the last one needs to be
+// checked, an exception that is already over cannot stop hashing
+func (j *JobCalendarData) hasExceptionStopAfter(event time.Time) bool {
+	n := len(j.exceptionEvents.stop)
+	return n > 0 && j.exceptionEvents.stop[n-1].After(event)
+}
+
+func (j *JobCalendarData) printExceptions() {
+	j.log.Debugf("time zone: %s", j.timeLocation())
+	j.log.Debugf("%d exception start events: %+v",
+		len(j.exceptionEvents.start),
+		j.exceptionEvents.start,
+	)
+	j.log.Debugf("%d exception stop events: %+v",
+		len(j.exceptionEvents.stop),
+		j.exceptionEvents.stop,
+	)
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitmark-inc/logger"
+)
+
+func setupExceptionCalendar(calendar ConfigCalendar) *JobCalendarData {
+	setupTestCalendarLogger()
+	j := newJobCalendar(make(chan struct{}, 1)).(*JobCalendarData)
+	j.SetLog(logger.New("test"))
+	j.rawData = calendar
+	j.setLocation(calendar.TimeZone)
+	j.parseRawData(calendar)
+	j.removeRedundantStopEvent()
+	j.parseExceptions(calendar.Exceptions)
+	return j
+}
+
+func localTime(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestExceptionOff(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		Tuesday: "9:00-17:00",
+		Exceptions: []ConfigCalendarException{
+			{Date: "2030-01-01", Hours: "off"}, // Tuesday
+		},
+	})
+	defer teardownCalendar()
+
+	if j.isTimeBooked(localTime(2030, 1, 1, 10, 0)) {
+		t.Error("exception date is booked")
+	}
+	if !j.isTimeBooked(localTime(2030, 1, 2, 10, 0)) {
+		t.Error("weekday after exception is not booked")
+	}
+
+	expectedStart := []time.Time{localTime(2030, 1, 2, 0, 0)}
+	expectedStop := []time.Time{localTime(2030, 1, 1, 0, 0)}
+	if !isTimeSliceEqual(expectedStart, j.exceptionEvents.start) {
+		t.Errorf("start events: %v expected: %v", j.exceptionEvents.start, expectedStart)
+	}
+	if !isTimeSliceEqual(expectedStop, j.exceptionEvents.stop) {
+		t.Errorf("stop events: %v expected: %v", j.exceptionEvents.stop, expectedStop)
+	}
+	if j.RunForever() {
+		t.Error("run forever with exception stop event")
+	}
+
+	next := j.PickNextStartEvent(localTime(2029, 12, 31, 12, 0))
+	if expectedStart[0] != next {
+		t.Errorf("next start: %v expected: %v", next, expectedStart[0])
+	}
+}
+
+func TestExceptionRange(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		Exceptions: []ConfigCalendarException{
+			{Date: "2030-01-05", EndDate: "2030-01-06", Hours: "13:00-15:00"},
+		},
+	})
+	defer teardownCalendar()
+
+	if !j.isTimeBooked(localTime(2030, 1, 6, 14, 0)) {
+		t.Error("exception period is not booked")
+	}
+	if j.isTimeBooked(localTime(2030, 1, 5, 16, 0)) {
+		t.Error("outside exception period is booked")
+	}
+
+	expectedStart := []time.Time{
+		localTime(2030, 1, 5, 13, 0),
+		localTime(2030, 1, 6, 13, 0),
+		localTime(2030, 1, 7, 0, 0),
+	}
+	expectedStop := []time.Time{
+		localTime(2030, 1, 5, 0, 0),
+		localTime(2030, 1, 5, 15, 0),
+		localTime(2030, 1, 6, 0, 0),
+		localTime(2030, 1, 6, 15, 0),
+	}
+	if !isTimeSliceEqual(expectedStart, j.exceptionEvents.start) {
+		t.Errorf("start events: %v expected: %v", j.exceptionEvents.start, expectedStart)
+	}
+	if !isTimeSliceEqual(expectedStop, j.exceptionEvents.stop) {
+		t.Errorf("stop events: %v expected: %v", j.exceptionEvents.stop, expectedStop)
+	}
+
+	next := j.PickNextStopEvent(localTime(2030, 1, 5, 13, 30))
+	if expectedStop[1] != next {
+		t.Errorf("next stop: %v expected: %v", next, expectedStop[1])
+	}
+}
+
+func TestExceptionOverride(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		Exceptions: []ConfigCalendarException{
+			{Date: "2030-02-01", EndDate: "2030-02-03", Hours: "off"},
+			{Date: "2030-02-02", Hours: ""},
+		},
+	})
+	defer teardownCalendar()
+
+	expected := map[time.Time]bool{
+		localTime(2030, 2, 1, 12, 0): false,
+		localTime(2030, 2, 2, 12, 0): true,
+		localTime(2030, 2, 3, 12, 0): false,
+	}
+	for target, booked := range expected {
+		if booked != j.isTimeBooked(target) {
+			t.Errorf("%s booked: %t expected: %t", target, !booked, booked)
+		}
+	}
+}
+
+func TestExceptionInvalid(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		TimeZone: "Nowhere/Invalid",
+		Exceptions: []ConfigCalendarException{
+			{Date: "2030-13-01", Hours: "off"},
+			{Date: "2030-01-05", EndDate: "2030-01-01", Hours: "off"},
+			{Date: "2030-01-05", EndDate: "soon", Hours: "off"},
+		},
+	})
+	defer teardownCalendar()
+
+	if time.Local != j.timeLocation() {
+		t.Errorf("invalid time zone location: %s", j.timeLocation())
+	}
+	if 0 != len(j.exceptions) {
+		t.Errorf("invalid exceptions accepted: %v", j.exceptions)
+	}
+	if !j.RunForever() {
+		t.Error("not run forever without stop events")
+	}
+}
+
+func TestExceptionTimeZone(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		TimeZone: "Asia/Tokyo",
+		Exceptions: []ConfigCalendarException{
+			{Date: "2030-03-01", Hours: "off"},
+		},
+	})
+	defer teardownCalendar()
+
+	// 2030-03-01 00:30 in Tokyo
+	target := time.Date(2030, 2, 28, 15, 30, 0, 0, time.UTC)
+	if j.isTimeBooked(target) {
+		t.Error("exception date in time zone is booked")
+	}
+
+	// 2030-02-28 23:30 in Tokyo
+	target = time.Date(2030, 2, 28, 14, 30, 0, 0, time.UTC)
+	if !j.isTimeBooked(target) {
+		t.Error("day before exception in time zone is not booked")
+	}
+}
+
+func TestNextEventSkipsExceptionDate(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		Exceptions: []ConfigCalendarException{
+			{Date: "2030-04-01", Hours: "off"},
+		},
+	})
+	defer teardownCalendar()
+
+	weekly := []time.Time{
+		localTime(2030, 4, 1, 9, 0),
+		localTime(2030, 4, 3, 9, 0),
+	}
+	next, found := j.nextEvent(weekly, []time.Time{}, localTime(2030, 3, 31, 0, 0))
+	if !found || weekly[1] != next {
+		t.Errorf("next: %v found: %t expected: %v", next, found, weekly[1])
+	}
+
+	next, found = j.nextEvent(weekly, []time.Time{localTime(2030, 4, 2, 0, 0)}, localTime(2030, 3, 31, 0, 0))
+	if !found || localTime(2030, 4, 2, 0, 0) != next {
+		t.Errorf("next: %v found: %t expected exception event", next, found)
+	}
+}
+
+func TestExceptionPastDoesNotStopRunForever(t *testing.T) {
+	j := setupExceptionCalendar(ConfigCalendar{
+		Exceptions: []ConfigCalendarException{
+			{Date: "2000-01-01", EndDate: "2000-01-03", Hours: "off"},
+		},
+	})
+	defer teardownCalendar()
+
+	if 0 == len(j.exceptionEvents.stop) {
+		t.Fatal("no exception stop events")
+	}
+	if !j.RunForever() {
+		t.Error("past exception stops run forever")
+	}
+	if !j.hasExceptionStopAfter(localTime(1999, 12, 31, 12, 0)) {
+		t.Error("exception stop before it starts not found")
+	}
+}
@@ -85,6 +85,12 @@ loop:
 				intf = j.calendar.PickNextStartEvent(now)
 			}
 
+			if nil == intf {
+				j.log.Info("no next start event, wait for reset")
+				<-j.channels.startEventChannel
+				break loop
+			}
+
 			nextEvent := intf.(time.Time)
 			d = j.timeDurationFromSrc2Dest(now, nextEvent)
 			j.log.Infof("next start event at %s, duration: %.1f minutes",
@@ -116,6 +122,13 @@ loop:
 				intf = j.calendar.PickNextStopEvent(now)
 			}
 
+			// e.g. the last stop of a date exception has passed
+			if nil == intf {
+				j.log.Info("no next stop event, wait for reset")
+				<-j.channels.stopEventChannel
+				break loop
+			}
+
 			nextEvent := intf.(time.Time)
 			d = j.timeDurationFromSrc2Dest(now, nextEvent)
 			j.log.Infof("next stop event: %s, duration: %.1f minutes",
@@ -178,6 +178,31 @@ func main() {
 	// connection info
 	log.Debugf("%s = %#v", "Peering", theConfiguration.Peering)
 
+	// pool mode replaces local hashing
+	if theConfiguration.Pool.Enable {
+		err = StartPoolAuthentication()
+		if nil != err {
+			log.Criticalf("zmq.AuthStart(): error: %s", err)
+			exitwithstatus.Message("%s: zmq.AuthStart() error: %s", program, err)
+		}
+
+		pool := newPool(logger.New(poolLoggerPrefix), theConfiguration.Pool.ShareDifficulty)
+		err = StartPool(pool, &theConfiguration.Pool, theConfiguration.Peering.Connect, publicKey, privateKey)
+		if nil != err {
+			log.Criticalf("pool start error: %s", err)
+			exitwithstatus.Message("%s: pool start error: %s", program, err)
+		}
+		StartPoolReport(pool, theConfiguration.Pool.ReportFile)
+		defer pool.writeReport(theConfiguration.Pool.ReportFile)
+
+		// erase the private key from memory
+		//nolint:ignore SA4006 we want to make sure we clean privateKey
+		privateKey = []byte{}
+
+		waitForSignal(log, 0 == len(options["quiet"]))
+		return
+	}
+
 	// internal queues
 	ProofProxy()
 	SubmitQueue()
@@ -257,8 +282,12 @@ connection_setup:
 
 	// abort if no clients were connected
 
-	// wait for CTRL-C before shutting down to allow manual testing
-	if 0 == len(options["quiet"]) {
+	waitForSignal(log, 0 == len(options["quiet"]))
+}
+
+// wait for CTRL-C before shutting down to allow manual testing
+func waitForSignal(log *logger.L, verbose bool) {
+	if verbose {
 		fmt.Printf("\n\nWaiting for CTRL-C (SIGINT) or 'kill <pid>' (SIGTERM)…")
 	}
 
@@ -267,7 +296,7 @@ connection_setup:
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-ch
 	log.Infof("received signal: %v", sig)
-	if 0 == len(options["quiet"]) {
+	if verbose {
 		fmt.Printf("\nreceived signal: %v\n", sig)
 		fmt.Printf("\nshutting down...\n")
 	}
@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/difficulty"
+	"github.com/bitmark-inc/logger"
+)
+
+const (
+	poolLoggerPrefix       = "pool"
+	poolQueueSize          = 32
+	defaultShareDifficulty = 1.0
+	defaultPoolReportFile  = "pool-report.json"
+	poolReportInterval     = time.Minute
+)
+
+// share validation results
+type shareResult int
+
+const (
+	shareAccepted shareResult = iota
+	shareStale
+	shareInvalid
+	shareDuplicate
+	shareLowDifficulty
+	shareUnauthenticated
+)
+
+func (r shareResult) String() string {
+	switch r {
+	case shareAccepted:
+		return "accepted"
+	case shareStale:
+		return "stale job"
+	case shareInvalid:
+		return "invalid nonce"
+	case shareDuplicate:
+		return "duplicate share"
+	case shareLowDifficulty:
+		return "difficulty not met"
+	case shareUnauthenticated:
+		return "worker not authenticated"
+	default:
+		return "unknown"
+	}
+}
+
+// PoolItem - work sent by the pool to its workers
+//
+// this extends PublishedItem with the share difficulty that a worker
+// must meet for the pool to credit a submitted nonce
+type PoolItem struct {
+	Job             string
+	Header          blockrecord.Header
+	ShareDifficulty float64
+}
+
+// a solution that also meets the network difficulty and must be
+// forwarded to the bitmarkd that published the job
+type poolSolution struct {
+	worker      string
+	upstream    int
+	upstreamJob string
+	nonce       []byte
+}
+
+// one job received from an upstream bitmarkd
+type poolJob struct {
+	job         string
+	upstream    int
+	upstreamJob string
+	header      blockrecord.Header
+	shares      map[blockrecord.NonceType]struct{}
+}
+
+// WorkerAccount - per worker share accounting
+type WorkerAccount struct {
+	Worker         string    `json:"worker"`
+	Accepted       uint64    `json:"accepted"`
+	Stale          uint64    `json:"stale"`
+	Invalid        uint64    `json:"invalid"`
+	Duplicate      uint64    `json:"duplicate"`
+	LowDifficulty  uint64    `json:"lowDifficulty"`
+	BlocksFound    uint64    `json:"blocksFound"`
+	BlocksRejected uint64    `json:"blocksRejected"`
+	LastShare      time.Time `json:"lastShare"`
+}
+
+// PoolReport - accounting report for all workers
+type PoolReport struct {
+	Timestamp       time.Time       `json:"timestamp"`
+	ShareDifficulty float64         `json:"shareDifficulty"`
+	TotalAccepted   uint64          `json:"totalAccepted"`
+	TotalBlocks     uint64          `json:"totalBlocks"`
+	Workers         []WorkerAccount `json:"workers"`
+}
+
+// PoolData - state of the pool server
+type PoolData struct {
+	sync.RWMutex
+	log             *logger.L
+	shareDifficulty *difficulty.Difficulty
+	jobCount        uint16
+	jobs            [poolQueueSize]*poolJob
+	accounts        map[string]*WorkerAccount
+	solutions       chan poolSolution
+}
+
+func newPool(log *logger.L, shareDifficulty float64) *PoolData {
+	if shareDifficulty < defaultShareDifficulty {
+		shareDifficulty = defaultShareDifficulty
+	}
+	d := difficulty.New()
+	d.Set(shareDifficulty)
+
+	return &PoolData{
+		log:             log,
+		shareDifficulty: d,
+		accounts:        make(map[string]*WorkerAccount),
+		solutions:       make(chan poolSolution, poolQueueSize),
+	}
+}
+
+// addJob - record a job from an upstream bitmarkd and return the
+// work item to be published to the workers
+func (p *PoolData) addJob(upstream int, item *PublishedItem) *PoolItem {
+	p.Lock()
+	defer p.Unlock()
+
+	p.jobCount += 1 // wraps (uint16)
+	job := &poolJob{
+		job:         fmt.Sprintf("%04x", p.jobCount),
+		upstream:    upstream,
+		upstreamJob: item.Job,
+		header:      item.Header,
+		shares:      make(map[blockrecord.NonceType]struct{}),
+	}
+	p.jobs[p.jobCount%poolQueueSize] = job
+
+	return &PoolItem{
+		Job:             job.job,
+		Header:          item.Header,
+		ShareDifficulty: p.shareDifficulty.Value(),
+	}
+}
+
+// submitShare - validate a share from a worker, account for it and
+// queue a solution if the network difficulty was also met
+func (p *PoolData) submitShare(worker string, jobID string, packed []byte) shareResult {
+	p.Lock()
+	defer p.Unlock()
+
+	account := p.account(worker)
+
+	var job *poolJob
+search:
+	for _, j := range p.jobs {
+		if nil != j && j.job == jobID {
+			job = j
+			break search
+		}
+	}
+	if nil == job {
+		account.Stale += 1
+		return shareStale
+	}
+
+	if len(packed) != blockrecord.NonceSize {
+		account.Invalid += 1
+		return shareInvalid
+	}
+
+	nonce := blockrecord.NonceType(binary.LittleEndian.Uint64(packed))
+	if _, ok := job.shares[nonce]; ok {
+		account.Duplicate += 1
+		return shareDuplicate
+	}
+
+	header := job.header
+	header.Nonce = nonce
+	digest := header.Pack().Digest()
+
+	if digest.Cmp(p.shareDifficulty.BigInt()) > 0 {
+		account.LowDifficulty += 1
+		return shareLowDifficulty
+	}
+
+	job.shares[nonce] = struct{}{}
+	account.Accepted += 1
+	account.LastShare = time.Now()
+
+	if nil != header.Difficulty && digest.Cmp(header.Difficulty.BigInt()) <= 0 {
+		p.log.Infof("worker: %s  job: %s  nonce: 0x%016x meets network difficulty", worker, jobID, nonce)
+		solution := poolSolution{
+			worker:      worker,
+			upstream:    job.upstream,
+			upstreamJob: job.upstreamJob,
+			nonce:       packed,
+		}
+		select {
+		case p.solutions <- solution:
+		default:
+			p.log.Errorf("solution queue full, drop job: %s", jobID)
+		}
+	}
+
+	return shareAccepted
+}
+
+// solutionResult - record the bitmarkd response to a forwarded solution
+func (p *PoolData) solutionResult(worker string, ok bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	account := p.account(worker)
+	if ok {
+		account.BlocksFound += 1
+	} else {
+		account.BlocksRejected += 1
+	}
+}
+
+// fetch or create an account, must hold lock
+func (p *PoolData) account(worker string) *WorkerAccount {
+	account, ok := p.accounts[worker]
+	if !ok {
+		account = &WorkerAccount{
+			Worker: worker,
+		}
+		p.accounts[worker] = account
+	}
+	return account
+}
+
+// Report - snapshot of the per worker accounting
+func (p *PoolData) Report() *PoolReport {
+	p.RLock()
+	defer p.RUnlock()
+
+	report := &PoolReport{
+		Timestamp:       time.Now().UTC(),
+		ShareDifficulty: p.shareDifficulty.Value(),
+		Workers:         make([]WorkerAccount, 0, len(p.accounts)),
+	}
+	for _, account := range p.accounts {
+		report.Workers = append(report.Workers, *account)
+		report.TotalAccepted += account.Accepted
+		report.TotalBlocks += account.BlocksFound
+	}
+	sort.Slice(report.Workers, func(i, j int) bool {
+		return report.Workers[i].Worker < report.Workers[j].Worker
+	})
+
+	return report
+}
+
+// writeReport - save the accounting report as JSON
+//
+// the file is replaced atomically so a reader never sees a partial report
+func (p *PoolData) writeReport(fileName string) error {
+	data, err := json.MarshalIndent(p.Report(), "", "  ")
+	if nil != err {
+		return err
+	}
+
+	tempName := fileName + ".new"
+	err = ioutil.WriteFile(tempName, data, 0600)
+	if nil != err {
+		return err
+	}
+	return os.Rename(tempName, fileName)
+}
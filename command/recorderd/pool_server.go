@@ -0,0 +1,391 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	zmq "github.com/pebbe/zmq4"
+
+	"github.com/bitmark-inc/bitmarkd/util"
+	"github.com/bitmark-inc/bitmarkd/zmqutil"
+	"github.com/bitmark-inc/logger"
+)
+
+const (
+	poolPublisherZapDomain  = "pool-publisher"
+	poolSubmissionZapDomain = "pool-submit"
+)
+
+// request sent by a recorderd worker
+type poolShareRequest struct {
+	Request string
+	Job     string
+	Packed  []byte
+}
+
+// reply to a recorderd worker
+type poolShareReply struct {
+	Job    string `json:"job"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason"`
+}
+
+// StartPoolAuthentication - initialise the ZMQ security subsystem so
+// that each worker's CURVE public key is available on its messages
+func StartPoolAuthentication() error {
+	zmq.AuthSetMetadataHandler(poolAuthMetadata)
+	return zmqutil.StartAuthentication()
+}
+
+// the ZAP reply for a worker carries its authenticated CURVE public key
+// as the user id, unlike the socket identity it cannot be chosen by
+// the worker
+func poolAuthMetadata(version, requestId, domain, address, identity, mechanism string, credentials ...string) map[string]string {
+	metadata := make(map[string]string)
+	if poolSubmissionZapDomain == domain && "CURVE" == mechanism && 1 == len(credentials) {
+		metadata["User-Id"] = hex.EncodeToString([]byte(credentials[0]))
+	}
+	return metadata
+}
+
+// StartPool - bind the pool sockets and connect to all upstream bitmarkd
+//
+// workers are ordinary recorderd processes that use this pool's publish
+// and submit addresses as their connection
+func StartPool(
+	pool *PoolData,
+	configuration *PoolType,
+	connections []Connection,
+	publicKey []byte,
+	privateKey []byte,
+) error {
+
+	log := pool.log
+
+	poolPrivateKey, err := zmqutil.ReadPrivateKey(configuration.PrivateKey)
+	if nil != err {
+		return err
+	}
+	poolPublicKey, err := zmqutil.ReadPublicKey(configuration.PublicKey)
+	if nil != err {
+		return err
+	}
+
+	c, err := util.NewConnections(configuration.Publish)
+	if nil != err {
+		return err
+	}
+	pub4, pub6, err := zmqutil.NewBind(log, zmq.PUB, poolPublisherZapDomain, poolPrivateKey, poolPublicKey, c)
+	if nil != err {
+		return err
+	}
+
+	c, err = util.NewConnections(configuration.Submit)
+	if nil != err {
+		return err
+	}
+	sub4, sub6, err := zmqutil.NewBind(log, zmq.ROUTER, poolSubmissionZapDomain, poolPrivateKey, poolPublicKey, c)
+	if nil != err {
+		return err
+	}
+
+	work := make(chan []byte, poolQueueSize)
+	go poolPublisher(log, work, pub4, pub6)
+	go poolSubmission(pool, sub4, sub6)
+
+	upstreams := make(map[int]chan poolSolution)
+
+connection_setup:
+	for i, remote := range connections {
+
+		serverPublicKey, err := zmqutil.ReadPublicKey(remote.PublicKey)
+		if nil != err {
+			log.Warnf("upstream: %d invalid server publickey: %q error: %s", i, remote.PublicKey, err)
+			continue connection_setup
+		}
+
+		bc, err := util.NewConnection(remote.Blocks)
+		if nil != err {
+			log.Warnf("upstream: %d invalid blocks publisher: %q error: %s", i, remote.Blocks, err)
+			continue connection_setup
+		}
+		blocksAddress, blocksv6 := bc.CanonicalIPandPort("tcp://")
+
+		sc, err := util.NewConnection(remote.Submit)
+		if nil != err {
+			log.Warnf("upstream: %d invalid submit address: %q error: %s", i, remote.Submit, err)
+			continue connection_setup
+		}
+		submitAddress, submitv6 := sc.CanonicalIPandPort("tcp://")
+
+		log.Infof("upstream: %d subscribe: %q  submit: %q", i, remote.Blocks, remote.Submit)
+
+		solutions := make(chan poolSolution, poolQueueSize)
+		err = poolUpstreamSubmitter(pool, i, submitAddress, submitv6, serverPublicKey, publicKey, privateKey, solutions)
+		if nil != err {
+			log.Warnf("upstream: %d submitter failed error: %s", i, err)
+			continue connection_setup
+		}
+
+		err = poolUpstreamSubscriber(pool, i, blocksAddress, blocksv6, serverPublicKey, publicKey, privateKey, work)
+		if nil != err {
+			log.Warnf("upstream: %d subscriber failed error: %s", i, err)
+			continue connection_setup
+		}
+		upstreams[i] = solutions
+	}
+
+	if 0 == len(upstreams) {
+		return fmt.Errorf("no upstream bitmarkd connections")
+	}
+
+	// route solutions to the bitmarkd that published the job
+	go func() {
+		for solution := range pool.solutions {
+			ch, ok := upstreams[solution.upstream]
+			if !ok {
+				log.Errorf("solution for unknown upstream: %d", solution.upstream)
+				continue
+			}
+			ch <- solution
+		}
+	}()
+
+	return nil
+}
+
+// StartPoolReport - periodically save the accounting report
+func StartPoolReport(pool *PoolData, fileName string) {
+	go func() {
+		for range time.Tick(poolReportInterval) {
+			err := pool.writeReport(fileName)
+			if nil != err {
+				pool.log.Errorf("write report: %q  error: %s", fileName, err)
+			}
+		}
+	}()
+}
+
+// publish work to all connected workers
+func poolPublisher(log *logger.L, work <-chan []byte, socket4 *zmq.Socket, socket6 *zmq.Socket) {
+	for data := range work {
+		log.Debugf("publish: %s", data)
+		for _, s := range []*zmq.Socket{socket4, socket6} {
+			if nil == s {
+				continue
+			}
+			_, err := s.SendBytes(data, zmq.DONTWAIT)
+			if nil != err {
+				log.Errorf("publish error: %s", err)
+			}
+		}
+	}
+}
+
+// receive shares from workers
+func poolSubmission(pool *PoolData, socket4 *zmq.Socket, socket6 *zmq.Socket) {
+	log := pool.log
+
+	poller := zmq.NewPoller()
+	if nil != socket4 {
+		poller.Add(socket4, zmq.POLLIN)
+	}
+	if nil != socket6 {
+		poller.Add(socket6, zmq.POLLIN)
+	}
+
+	for {
+		sockets, err := poller.Poll(-1)
+		if nil != err {
+			log.Errorf("poll error: %s", err)
+			continue
+		}
+		for _, polled := range sockets {
+			poolShare(pool, polled.Socket)
+		}
+	}
+}
+
+// process one share, the ROUTER envelope is: identity, empty, data
+func poolShare(pool *PoolData, socket *zmq.Socket) {
+	log := pool.log
+
+	msg, metadata, err := socket.RecvMessageBytesWithMetadata(0, "User-Id")
+	if nil != err {
+		log.Errorf("receive error: %s", err)
+		return
+	}
+	if len(msg) != 3 {
+		log.Warnf("invalid message frame count: %d", len(msg))
+		return
+	}
+
+	// credit the public key that authenticated the connection, the
+	// socket identity is only used to route the reply
+	worker := metadata["User-Id"]
+
+	var request poolShareRequest
+	reply := poolShareReply{}
+	err = json.Unmarshal(msg[2], &request)
+	if "" == worker {
+		log.Warnf("share from unauthenticated worker: %x", msg[0])
+		reply.Reason = shareUnauthenticated.String()
+	} else if nil != err {
+		reply.Reason = shareInvalid.String()
+	} else {
+		result := pool.submitShare(worker, request.Job, request.Packed)
+		log.Infof("worker: %s  job: %s  share: %s", worker, request.Job, result)
+		reply.Job = request.Job
+		reply.OK = shareAccepted == result
+		reply.Reason = result.String()
+	}
+
+	data, err := json.Marshal(reply)
+	logger.PanicIfError("JSON encode error", err)
+
+	_, err = socket.SendMessage(msg[0], msg[1], data)
+	if nil != err {
+		log.Errorf("reply to worker: %s  error: %s", worker, err)
+	}
+}
+
+// subscribe to the jobs of one bitmarkd
+func poolUpstreamSubscriber(
+	pool *PoolData,
+	i int,
+	connectTo string,
+	v6 bool,
+	serverPublicKey []byte,
+	publicKey []byte,
+	privateKey []byte,
+	work chan<- []byte,
+) error {
+
+	log := pool.log
+
+	socket, err := zmq.NewSocket(zmq.SUB)
+	if nil != err {
+		return err
+	}
+
+	socket.SetCurveServer(0)
+	socket.SetCurvePublickey(string(publicKey))
+	socket.SetCurveSecretkey(string(privateKey))
+	socket.SetCurveServerkey(string(serverPublicKey))
+	socket.SetIdentity(string(publicKey))
+	socket.SetIpv6(v6)
+	socket.SetTcpKeepalive(1)
+	socket.SetTcpKeepaliveCnt(5)
+	socket.SetTcpKeepaliveIdle(60)
+	socket.SetTcpKeepaliveIntvl(60)
+	socket.SetSubscribe("")
+
+	err = socket.Connect(connectTo)
+	if nil != err {
+		socket.Close()
+		return err
+	}
+
+	go func() {
+		defer socket.Close()
+
+		for {
+			data, err := socket.RecvBytes(0)
+			logger.PanicIfError("pool subscriber", err)
+
+			var item PublishedItem
+			err = json.Unmarshal(data, &item)
+			if nil != err {
+				log.Errorf("upstream: %d  JSON decode error: %s", i, err)
+				continue
+			}
+
+			poolItem := pool.addJob(i, &item)
+			log.Infof("upstream: %d  job: %s  -> pool job: %s", i, item.Job, poolItem.Job)
+
+			b, err := json.Marshal(poolItem)
+			logger.PanicIfError("JSON encode error", err)
+			work <- b
+		}
+	}()
+
+	return nil
+}
+
+// forward solutions to one bitmarkd
+func poolUpstreamSubmitter(
+	pool *PoolData,
+	i int,
+	connectTo string,
+	v6 bool,
+	serverPublicKey []byte,
+	publicKey []byte,
+	privateKey []byte,
+	solutions <-chan poolSolution,
+) error {
+
+	log := pool.log
+
+	rpc, err := zmq.NewSocket(zmq.REQ)
+	if nil != err {
+		return err
+	}
+
+	rpc.SetCurveServer(0)
+	rpc.SetCurvePublickey(string(publicKey))
+	rpc.SetCurveSecretkey(string(privateKey))
+	rpc.SetCurveServerkey(string(serverPublicKey))
+	rpc.SetIdentity(string(publicKey))
+	rpc.SetIpv6(v6)
+	rpc.SetTcpKeepalive(1)
+	rpc.SetTcpKeepaliveCnt(5)
+	rpc.SetTcpKeepaliveIdle(60)
+	rpc.SetTcpKeepaliveIntvl(60)
+
+	err = rpc.Connect(connectTo)
+	if nil != err {
+		rpc.Close()
+		return err
+	}
+
+	go func() {
+		defer rpc.Close()
+
+		for solution := range solutions {
+			toSend := poolShareRequest{
+				Request: "block.nonce",
+				Job:     solution.upstreamJob,
+				Packed:  solution.nonce,
+			}
+			data, err := json.Marshal(toSend)
+			logger.PanicIfError("JSON encode error", err)
+
+			log.Infof("upstream: %d  submit: %s", i, data)
+
+			_, err = rpc.SendBytes(data, 0)
+			logger.PanicIfError("pool upstream send", err)
+
+			response, err := rpc.RecvBytes(0)
+			logger.PanicIfError("pool upstream recv", err)
+
+			var r poolShareReply
+			err = json.Unmarshal(response, &r)
+			if nil != err {
+				log.Errorf("upstream: %d  JSON decode error: %s", i, err)
+				continue
+			}
+			log.Infof("upstream: %d  job: %s  ok: %v  reason: %s", i, r.Job, r.OK, r.Reason)
+
+			pool.solutionResult(solution.worker, r.OK)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	zmq "github.com/pebbe/zmq4"
+
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/difficulty"
+	"github.com/bitmark-inc/logger"
+)
+
+const (
+	testWorker      = "worker-1"
+	testValidNonce  = 228 // digest meets difficulty 1 for testHeader
+	testFailedNonce = 0   // digest does not meet difficulty 1 for testHeader
+)
+
+func testHeader() blockrecord.Header {
+	return blockrecord.Header{
+		Version:          1,
+		TransactionCount: 1,
+		Number:           2,
+		Timestamp:        1600000000,
+		Difficulty:       difficulty.New(),
+	}
+}
+
+func packNonce(nonce uint64) []byte {
+	b := make([]byte, blockrecord.NonceSize)
+	binary.LittleEndian.PutUint64(b, nonce)
+	return b
+}
+
+func setupPool(t *testing.T) *PoolData {
+	setupLogger(t)
+	return newPool(logger.New("test"), 0)
+}
+
+func teardownPool() {
+	logger.Finalise()
+	removeTestFiles()
+}
+
+func TestPoolAddJob(t *testing.T) {
+	p := setupPool(t)
+	defer teardownPool()
+
+	item := p.addJob(3, &PublishedItem{Job: "0001", Header: testHeader()})
+	if "0001" != item.Job {
+		t.Errorf("pool job: %q expected: %q", item.Job, "0001")
+	}
+	if defaultShareDifficulty != item.ShareDifficulty {
+		t.Errorf("share difficulty: %f expected: %f", item.ShareDifficulty, defaultShareDifficulty)
+	}
+
+	job := p.jobs[1]
+	if nil == job || 3 != job.upstream || "0001" != job.upstreamJob {
+		t.Errorf("unexpected job entry: %+v", job)
+	}
+}
+
+func TestPoolSubmitShareRejected(t *testing.T) {
+	p := setupPool(t)
+	defer teardownPool()
+
+	item := p.addJob(0, &PublishedItem{Job: "abcd", Header: testHeader()})
+
+	if r := p.submitShare(testWorker, "ffff", packNonce(testValidNonce)); shareStale != r {
+		t.Errorf("unknown job result: %s", r)
+	}
+	if r := p.submitShare(testWorker, item.Job, []byte{1, 2, 3}); shareInvalid != r {
+		t.Errorf("short nonce result: %s", r)
+	}
+	if r := p.submitShare(testWorker, item.Job, packNonce(testFailedNonce)); shareLowDifficulty != r {
+		t.Errorf("low difficulty result: %s", r)
+	}
+
+	account := p.accounts[testWorker]
+	if 1 != account.Stale || 1 != account.Invalid || 1 != account.LowDifficulty || 0 != account.Accepted {
+		t.Errorf("unexpected account: %+v", account)
+	}
+	if 0 != len(p.solutions) {
+		t.Errorf("solution queued for rejected shares")
+	}
+}
+
+func TestPoolSubmitShareAccepted(t *testing.T) {
+	p := setupPool(t)
+	defer teardownPool()
+
+	item := p.addJob(2, &PublishedItem{Job: "abcd", Header: testHeader()})
+
+	if r := p.submitShare(testWorker, item.Job, packNonce(testValidNonce)); shareAccepted != r {
+		t.Fatalf("valid share result: %s", r)
+	}
+	if r := p.submitShare(testWorker, item.Job, packNonce(testValidNonce)); shareDuplicate != r {
+		t.Errorf("duplicate share result: %s", r)
+	}
+
+	account := p.accounts[testWorker]
+	if 1 != account.Accepted || 1 != account.Duplicate {
+		t.Errorf("unexpected account: %+v", account)
+	}
+
+	// network difficulty is also 1 so the share is a solution
+	if 1 != len(p.solutions) {
+		t.Fatalf("solutions queued: %d expected: 1", len(p.solutions))
+	}
+	solution := <-p.solutions
+	if testWorker != solution.worker || 2 != solution.upstream || "abcd" != solution.upstreamJob {
+		t.Errorf("unexpected solution: %+v", solution)
+	}
+
+	p.solutionResult(testWorker, true)
+	if 1 != account.BlocksFound {
+		t.Errorf("blocks found: %d expected: 1", account.BlocksFound)
+	}
+}
+
+func TestPoolReport(t *testing.T) {
+	p := setupPool(t)
+	defer teardownPool()
+
+	p.account("b").Accepted = 3
+	p.account("a").Accepted = 2
+	p.account("a").BlocksFound = 1
+
+	report := p.Report()
+	if 2 != len(report.Workers) {
+		t.Fatalf("workers: %d expected: 2", len(report.Workers))
+	}
+	if "a" != report.Workers[0].Worker || "b" != report.Workers[1].Worker {
+		t.Errorf("workers not sorted: %+v", report.Workers)
+	}
+	if 5 != report.TotalAccepted || 1 != report.TotalBlocks {
+		t.Errorf("unexpected totals: %d  %d", report.TotalAccepted, report.TotalBlocks)
+	}
+
+	fileName := "test-pool-report.json"
+	defer os.Remove(fileName)
+
+	err := p.writeReport(fileName)
+	if nil != err {
+		t.Fatalf("write report error: %s", err)
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		t.Fatalf("read report error: %s", err)
+	}
+	var saved PoolReport
+	err = json.Unmarshal(data, &saved)
+	if nil != err {
+		t.Fatalf("decode report error: %s", err)
+	}
+	if 2 != len(saved.Workers) || 5 != saved.TotalAccepted {
+		t.Errorf("unexpected saved report: %+v", saved)
+	}
+}
+
+func TestPoolShareCreditsAuthenticatedKey(t *testing.T) {
+	p := setupPool(t)
+	defer teardownPool()
+
+	err := StartPoolAuthentication()
+	if nil != err {
+		t.Fatalf("start authentication error: %s", err)
+	}
+	zmq.AuthCurveAdd(poolSubmissionZapDomain, zmq.CURVE_ALLOW_ANY)
+
+	serverPublic, serverPrivate, err := zmq.NewCurveKeypair()
+	if nil != err {
+		t.Fatalf("server keypair error: %s", err)
+	}
+	workerPublic, workerPrivate, err := zmq.NewCurveKeypair()
+	if nil != err {
+		t.Fatalf("worker keypair error: %s", err)
+	}
+
+	server, err := zmq.NewSocket(zmq.ROUTER)
+	if nil != err {
+		t.Fatalf("server socket error: %s", err)
+	}
+	defer server.Close()
+	server.SetLinger(0)
+	server.SetZapDomain(poolSubmissionZapDomain)
+	server.ServerAuthCurve(poolSubmissionZapDomain, serverPrivate)
+	err = server.Bind("tcp://127.0.0.1:*")
+	if nil != err {
+		t.Fatalf("server bind error: %s", err)
+	}
+	endpoint, _ := server.GetLastEndpoint()
+
+	// the worker claims another worker's identity
+	worker, err := zmq.NewSocket(zmq.REQ)
+	if nil != err {
+		t.Fatalf("worker socket error: %s", err)
+	}
+	defer worker.Close()
+	worker.SetLinger(0)
+	worker.SetIdentity("another-worker")
+	worker.ClientAuthCurve(serverPublic, workerPublic, workerPrivate)
+	err = worker.Connect(endpoint)
+	if nil != err {
+		t.Fatalf("worker connect error: %s", err)
+	}
+
+	item := p.addJob(0, &PublishedItem{Job: "abcd", Header: testHeader()})
+	request, _ := json.Marshal(poolShareRequest{
+		Request: "share",
+		Job:     item.Job,
+		Packed:  packNonce(testValidNonce),
+	})
+	_, err = worker.SendBytes(request, 0)
+	if nil != err {
+		t.Fatalf("worker send error: %s", err)
+	}
+
+	poolShare(p, server)
+
+	data, err := worker.RecvBytes(0)
+	if nil != err {
+		t.Fatalf("worker receive error: %s", err)
+	}
+	var reply poolShareReply
+	err = json.Unmarshal(data, &reply)
+	if nil != err || !reply.OK {
+		t.Fatalf("unexpected reply: %s  error: %v", data, err)
+	}
+
+	key := hex.EncodeToString([]byte(zmq.Z85decode(workerPublic)))
+	if _, ok := p.accounts["another-worker"]; ok {
+		t.Errorf("share credited to the socket identity")
+	}
+	if account, ok := p.accounts[key]; !ok || 1 != account.Accepted {
+		t.Errorf("share not credited to worker key: %s  accounts: %+v", key, p.accounts)
+	}
+}
+
+func TestPoolAuthMetadata(t *testing.T) {
+	key := string([]byte{1, 2, 3, 4})
+
+	metadata := poolAuthMetadata("1.0", "1", poolSubmissionZapDomain, "127.0.0.1", "another-worker", "CURVE", key)
+	if "01020304" != metadata["User-Id"] {
+		t.Errorf("user id: %q expected: %q", metadata["User-Id"], "01020304")
+	}
+
+	metadata = poolAuthMetadata("1.0", "1", poolPublisherZapDomain, "127.0.0.1", "", "CURVE", key)
+	if _, ok := metadata["User-Id"]; ok {
+		t.Errorf("user id set for publisher: %+v", metadata)
+	}
+
+	metadata = poolAuthMetadata("1.0", "1", poolSubmissionZapDomain, "127.0.0.1", "", "NULL")
+	if _, ok := metadata["User-Id"]; ok {
+		t.Errorf("user id set without a key: %+v", metadata)
+	}
+}
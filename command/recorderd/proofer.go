@@ -6,10 +6,12 @@
 package main
 
 import (
+	"crypto/rand"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/bitmark-inc/bitmarkd/blockdigest"
 	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/difficulty"
 	"github.com/bitmark-inc/exitwithstatus"
 	"github.com/bitmark-inc/logger"
 )
@@ -28,6 +31,7 @@ const (
 	dispatch            = "inproc://blocks.dispatch" // proofer fetches from here
 	errorProoferID      = -1
 	prooferLoggerPrefix = "proofer"
+	autoTuneInterval    = 5 * time.Minute
 )
 
 var (
@@ -43,6 +47,7 @@ type Proofer interface {
 
 type ProoferData struct {
 	sync.RWMutex
+	adjust                sync.Mutex
 	eventuallyThreadCount uint32
 	prevThreadCount       uint32
 	proofIDs              []bool
@@ -51,6 +56,10 @@ type ProoferData struct {
 	workingNow            bool
 	cpuCount              int
 	reader                ConfigReader
+	hashCount             uint64
+	tuner                 ThreadTuner
+	tunerStop             chan struct{}
+	tunedThreadCount      uint32
 }
 
 func newProofer(log *logger.L, reader ConfigReader) Proofer {
@@ -62,6 +71,7 @@ func newProofer(log *logger.L, reader ConfigReader) Proofer {
 		cpuCount:    cpuCount,
 		workingNow:  true,
 		reader:      reader,
+		tuner:       newThreadTuner(),
 	}
 }
 
@@ -69,8 +79,10 @@ func (p *ProoferData) StartHashing() {
 	p.log.Infof("receive start hashing request, current active thread %d",
 		p.targetThreadCount())
 	p.setWorking(true)
+	p.adjust.Lock()
+	defer p.adjust.Unlock()
 	if p.targetThreadCount() < 1 {
-		p.createProofer(p.reader.OptimalThreadCount())
+		p.createProofer(p.threadTarget())
 	}
 }
 
@@ -78,6 +90,8 @@ func (p *ProoferData) StopHashing() {
 	p.log.Infof("receive stop hashing request, current active thread %d",
 		p.targetThreadCount())
 	p.setWorking(false)
+	p.adjust.Lock()
+	defer p.adjust.Unlock()
 	p.deleteProofer(int32(p.targetThreadCount()))
 }
 
@@ -100,9 +114,11 @@ func (p *ProoferData) setWorking(working bool) {
 }
 
 func (p *ProoferData) Refresh() {
+	p.refreshAutoTuning()
+
 	p.log.Infof("goroutine active count: %d, target count: %d",
 		p.targetThreadCount(),
-		p.reader.OptimalThreadCount(),
+		p.threadTarget(),
 	)
 
 	p.log.Infof("proofer setting change: %t, workable: %t",
@@ -114,8 +130,94 @@ func (p *ProoferData) Refresh() {
 		return
 	}
 
+	p.adjustThreadCount(p.threadTarget())
+}
+
+// start or stop the thread count tuner to follow the configuration
+func (p *ProoferData) refreshAutoTuning() {
+	configuration, err := p.reader.GetConfig()
+	if nil != err {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if configuration.AutoThreadCount && nil == p.tunerStop {
+		p.log.Info("start thread count auto tuning")
+		p.tunerStop = make(chan struct{})
+		p.tunedThreadCount = 0
+		p.tuner.Reset()
+		go p.autoTune(p.tunerStop)
+	} else if !configuration.AutoThreadCount && nil != p.tunerStop {
+		p.log.Info("stop thread count auto tuning")
+		close(p.tunerStop)
+		p.tunerStop = nil
+		p.tunedThreadCount = 0
+	}
+}
+
+// the thread count to run: the tuned value if auto tuning, but never
+// more than the configured maximum
+func (p *ProoferData) threadTarget() uint32 {
+	limit := p.reader.OptimalThreadCount()
+
+	p.RLock()
+	defer p.RUnlock()
+
+	if nil == p.tunerStop || 0 == p.tunedThreadCount || p.tunedThreadCount > limit {
+		return limit
+	}
+	return p.tunedThreadCount
+}
+
+// periodically measure throughput and adjust the thread count
+func (p *ProoferData) autoTune(stop <-chan struct{}) {
+	atomic.StoreUint64(&p.hashCount, 0)
+	start := time.Now()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(autoTuneInterval):
+		}
+
+		hashes := atomic.SwapUint64(&p.hashCount, 0)
+		elapsed := time.Since(start)
+		start = time.Now()
+
+		if !p.IsWorking() {
+			continue
+		}
+
+		current := p.targetThreadCount()
+		rate := float64(hashes) / elapsed.Seconds()
+		next := p.tuner.Next(current, rate, p.reader.OptimalThreadCount())
+
+		p.log.Infof("auto tune: threads: %d  rate: %f H/s  next threads: %d", current, rate, next)
+
+		if next == current {
+			continue
+		}
+
+		p.Lock()
+		p.tunedThreadCount = next
+		p.Unlock()
+
+		if p.IsWorking() {
+			p.adjustThreadCount(next)
+		}
+	}
+}
+
+// create or delete hashing goroutines to reach the target
+func (p *ProoferData) adjustThreadCount(target uint32) {
+	p.adjust.Lock()
+	defer p.adjust.Unlock()
+
 	increment := p.differenceToTargetThreadCount(
-		p.reader.OptimalThreadCount(),
+		target,
 		p.targetThreadCount(),
 	)
 
@@ -130,7 +232,7 @@ func (p *ProoferData) Refresh() {
 }
 
 func (p *ProoferData) changed() bool {
-	return p.prevThreadCount != p.reader.OptimalThreadCount()
+	return p.prevThreadCount != p.threadTarget()
 }
 
 func (p *ProoferData) activeThreadIncrement(threadNum uint32) {
@@ -340,6 +442,22 @@ func (p *ProoferData) ProofThread(log *logger.L, threadNum uint32) error {
 			start := time.Now()
 			count := 0
 			blk := item.Header
+
+			// pool work: start from a random nonce so that
+			// workers do not search the same range, and only
+			// submit digests that meet the share difficulty
+			var shareTarget *big.Int
+			if item.ShareDifficulty > 0 {
+				share := difficulty.New()
+				share.Set(item.ShareDifficulty)
+				shareTarget = share.BigInt()
+
+				randomBytes := make([]byte, 8)
+				_, err := rand.Read(randomBytes)
+				if nil == err {
+					blk.Nonce = blockrecord.NonceType(binary.LittleEndian.Uint64(randomBytes))
+				}
+			}
 		nonceLoop:
 			for i := 0; true; i++ {
 				select {
@@ -364,12 +482,14 @@ func (p *ProoferData) ProofThread(log *logger.L, threadNum uint32) error {
 				digest := blockdigest.NewDigest(packed[:])
 
 				count++
+				atomic.AddUint64(&p.hashCount, 1)
 
 				if 0 == i%10 {
 					log.Infof("nonce[%d]: 0x%08x", i, blk.Nonce)
 				}
 				// possible value if leading zero byte
-				if 0 == digest[31] {
+				// or pool share difficulty is met
+				if nil != shareTarget && digest.Cmp(shareTarget) <= 0 || nil == shareTarget && 0 == digest[31] {
 
 					log.Infof("job: %q nonce: 0x%016x", item.Job, blk.Nonce)
 					log.Infof("digest: %v", digest)
@@ -176,10 +176,18 @@ func Submitter(i int, connectTo string, v6 bool, serverPublicKey []byte, publicK
 			logger.PanicIfError("rpc recv", err)
 			log.Debugf("rpc: received data: %s", response)
 
-			var r interface{}
+			var r struct {
+				Job    string `json:"job"`
+				OK     bool   `json:"ok"`
+				Reason string `json:"reason"`
+			}
 			err = json.Unmarshal([]byte(response), &r)
 			logger.PanicIfError("unmarshal response: error: ", err)
-			log.Infof("rpc: received from server: %v", r)
+			if r.OK {
+				log.Infof("rpc: job: %s accepted", r.Job)
+			} else {
+				log.Warnf("rpc: job: %s rejected: %s", r.Job, r.Reason)
+			}
 		}
 
 	}()
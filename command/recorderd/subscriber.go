@@ -15,11 +15,14 @@ import (
 	"github.com/bitmark-inc/logger"
 )
 
-// sent by bitmarkd
+// sent by bitmarkd or by a pool
 // ***** FIX THIS: need to refactor
 type PublishedItem struct {
 	Job    string
 	Header blockrecord.Header
+
+	// only set by a pool, a share must meet this difficulty
+	ShareDifficulty float64
 }
 
 // subscriber thread
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+)
+
+const (
+	// a rate below this fraction of the previous rate for the same
+	// thread count means the machine is busier than before
+	tunerDropRatio = 0.9
+)
+
+// ThreadTuner - picks a hashing thread count from measured throughput
+type ThreadTuner interface {
+	Next(current uint32, rate float64, limit uint32) uint32
+	Reset()
+}
+
+// ThreadTunerData - hill climbing over thread counts
+//
+// the most recent rate for each thread count is kept and the tuner
+// moves one thread at a time towards the better neighbour, never
+// exceeding the limit set by the max_cpu_usage configuration
+type ThreadTunerData struct {
+	sync.Mutex
+	rates map[uint32]float64
+}
+
+func newThreadTuner() ThreadTuner {
+	return &ThreadTunerData{
+		rates: make(map[uint32]float64),
+	}
+}
+
+// Reset - forget all measurements
+func (t *ThreadTunerData) Reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.rates = make(map[uint32]float64)
+}
+
+// Next - record the hash rate measured with the current thread count
+// and return the thread count to use next
+func (t *ThreadTunerData) Next(current uint32, rate float64, limit uint32) uint32 {
+	t.Lock()
+	defer t.Unlock()
+
+	if limit < minThreadCount {
+		limit = minThreadCount
+	}
+	if current > limit {
+		return limit
+	}
+	if current < minThreadCount {
+		return minThreadCount
+	}
+
+	previous, measured := t.rates[current]
+
+	// throughput dropped: other load on the machine, so
+	// previous measurements are stale; back off one thread
+	if measured && rate < previous*tunerDropRatio {
+		t.rates = map[uint32]float64{
+			current: rate,
+		}
+		if current > minThreadCount {
+			return current - 1
+		}
+		return current
+	}
+
+	t.rates[current] = rate
+
+	up := current + 1
+	if up <= limit {
+		upRate, ok := t.rates[up]
+		if !ok || upRate > rate {
+			return up
+		}
+	}
+
+	if current > minThreadCount {
+		down := current - 1
+		if downRate, ok := t.rates[down]; ok && downRate > rate {
+			return down
+		}
+	}
+
+	return current
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestThreadTunerClimb(t *testing.T) {
+	tuner := newThreadTuner()
+
+	steps := []struct {
+		current  uint32
+		rate     float64
+		limit    uint32
+		expected uint32
+	}{
+		{1, 10, 4, 2}, // unknown neighbour, try more threads
+		{2, 19, 4, 3},
+		{3, 25, 4, 4},
+		{4, 22, 4, 3}, // four is worse than three
+		{3, 25, 4, 3}, // best found
+		{3, 25, 2, 2}, // limit lowered by configuration
+	}
+
+	for i, s := range steps {
+		actual := tuner.Next(s.current, s.rate, s.limit)
+		if s.expected != actual {
+			t.Errorf("%d: current: %d  rate: %f  next: %d  expected: %d", i, s.current, s.rate, actual, s.expected)
+		}
+	}
+}
+
+func TestThreadTunerThroughputDrop(t *testing.T) {
+	tuner := newThreadTuner()
+
+	if next := tuner.Next(3, 30, 3); 3 != next {
+		t.Fatalf("next: %d expected: 3", next)
+	}
+
+	// same thread count, much lower rate
+	if next := tuner.Next(3, 20, 3); 2 != next {
+		t.Errorf("after drop next: %d expected: 2", next)
+	}
+
+	// old measurement is discarded so fewer threads is compared
+	// against the lower rate
+	if next := tuner.Next(2, 21, 3); 2 != next {
+		t.Errorf("after drop recovery next: %d expected: 2", next)
+	}
+}
+
+func TestThreadTunerMinimum(t *testing.T) {
+	tuner := newThreadTuner()
+
+	if next := tuner.Next(1, 10, 1); 1 != next {
+		t.Errorf("next: %d expected: 1", next)
+	}
+	if next := tuner.Next(1, 1, 1); 1 != next {
+		t.Errorf("after drop next: %d expected: 1", next)
+	}
+	if next := tuner.Next(0, 1, 0); minThreadCount != next {
+		t.Errorf("zero threads next: %d expected: %d", next, minThreadCount)
+	}
+}
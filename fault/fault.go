@@ -23,12 +23,14 @@ func e(s string) error {
 var (
 	AddressIsNil                          = e("address is nil")
 	AlreadyInitialised                    = e("already initialised")
+	AssetAmendmentIsPending               = e("asset amendment is pending")
 	AssetFingerprintIsRequired            = e("asset fingerprint is required")
 	AssetIsNotIndexed                     = e("asset is not indexed")
 	AssetMetadataIsRequired               = e("asset metadata is required")
 	AssetMetadataMustBeMap                = e("asset metadata must be map")
 	AssetNotFound                         = e("asset not found")
 	AssetsAlreadyRegistered               = e("assets already registered")
+	AssetVersionOutOfSequence             = e("asset version out of sequence")
 	BitcoinAddressForWrongNetwork         = e("bitcoin address for wrong network")
 	BitcoinAddressIsNotSupported          = e("bitcoin address is not supported")
 	BlockAlreadyProcessed                 = e("block already processed")
@@ -44,6 +46,7 @@ var (
 	CannotDecodePrivateKey                = e("cannot decode private key")
 	CannotDecodeSeed                      = e("cannot decode seed")
 	CanOnlyConvertAssetsToShares          = e("can only convert assets to shares")
+	CanOnlyRedeemShares                   = e("can only redeem shares")
 	CertificateFileAlreadyExists          = e("certificate file already exists")
 	ChecksumMismatch                      = e("checksum mismatch")
 	ClientSocketNotConnected              = e("client socket not connected")
@@ -59,6 +62,7 @@ var (
 	DescriptionIsRequired                 = e("description is required")
 	DifficultyDoesNotMatchCalculated      = e("difficulty does not match calculated")
 	DoubleTransferAttempt                 = e("double transfer attempt")
+	EscrowCurrencyMismatch                = e("escrow currency mismatch")
 	FileDoesNotExist                      = e("file does not exist")
 	FileNameIsRequired                    = e("file name is required")
 	FingerprintTooLong                    = e("fingerprint too long")
@@ -73,6 +77,7 @@ var (
 	IncorrectBlockRangeToRollback         = e("incorrect block range to rollback")
 	IncorrectChain                        = e("incorrect chain")
 	InsufficientShares                    = e("insufficient shares")
+	InvalidAssetVersion                   = e("invalid asset version")
 	InvalidBitcoinAddress                 = e("invalid bitcoin address")
 	InvalidBlockHeaderDifficulty          = e("invalid block header difficulty")
 	InvalidBlockHeaderSize                = e("invalid block header size")
@@ -84,15 +89,20 @@ var (
 	InvalidCurrency                       = e("invalid currency")
 	InvalidCurrencyAddress                = e("invalid currency address")
 	InvalidCursor                         = e("invalid cursor")
+	InvalidDerivationPath                 = e("invalid derivation path")
 	InvalidDnsTxtRecord                   = e("invalid dns txt record")
 	InvalidFingerprint                    = e("invalid fingerprint")
+	InvalidIdentityBundle                 = e("invalid identity bundle")
 	InvalidIdentityName                   = e("invalid identity name")
+	InvalidIdentityVersion                = e("invalid identity version")
 	InvalidIpAddress                      = e("invalid ip address")
 	InvalidItem                           = e("invalid item")
 	InvalidKeyLength                      = e("invalid key length")
 	InvalidKeyType                        = e("invalid key type")
 	InvalidLength                         = e("invalid length")
 	InvalidLitecoinAddress                = e("invalid litecoin address")
+	InvalidMetadataSchema                 = e("invalid metadata schema")
+	InvalidMetadataValue                  = e("invalid metadata value")
 	InvalidNodeDomain                     = e("invalid node domain")
 	InvalidNonce                          = e("invalid nonce")
 	InvalidOwnerOrRegistrant              = e("invalid owner or registrant")
@@ -102,6 +112,7 @@ var (
 	InvalidPortNumber                     = e("invalid port number")
 	InvalidPrivateKey                     = e("invalid private key")
 	InvalidProofSigningKey                = e("invalid proof signing key")
+	InvalidProvenanceBundle               = e("invalid provenance bundle")
 	InvalidPublicKey                      = e("invalid public key")
 	InvalidRecoveryPhraseLength           = e("invalid recovery phrase length")
 	InvalidSecretKeyLength                = e("invalid secret key length")
@@ -109,18 +120,23 @@ var (
 	InvalidSeedLength                     = e("invalid seed length")
 	InvalidSignature                      = e("invalid signature")
 	InvalidTimestamp                      = e("invalid timestamp")
+	InvalidTransactionFile                = e("invalid transaction file")
 	KeyFileAlreadyExists                  = e("key file already exists")
 	LinkToInvalidOrUnconfirmedTransaction = e("link to invalid or unconfirmed transaction")
 	LitecoinAddressForWrongNetwork        = e("litecoin address for wrong network")
 	LitecoinAddressIsNotSupported         = e("litecoin address is not supported")
+	LocalChainNotAllowed                  = e("local chain not allowed")
 	MakeBlockTransferFailed               = e("make block transfer failed")
 	MakeGrantFailed                       = e("make grant failed")
 	MakeIssueFailed                       = e("make issue failed")
+	MakeRedemptionFailed                  = e("make redemption failed")
 	MakeShareFailed                       = e("make share failed")
 	MakeSwapFailed                        = e("make swap failed")
 	MakeTransferFailed                    = e("make transfer failed")
 	MerkleRootDoesNotMatch                = e("merkle root does not match")
 	MetadataIsNotMap                      = e("metadata is not map")
+	MetadataKeyNotInSchema                = e("metadata key not in schema")
+	MetadataSchemaNotFound                = e("metadata schema not found")
 	MetadataTooLong                       = e("metadata too long")
 	MissingBlockOwner                     = e("missing block owner")
 	MissingOwnerData                      = e("missing owner data")
@@ -128,6 +144,7 @@ var (
 	MissingPaymentBitcoinSection          = e("missing payment bitcoin section")
 	MissingPaymentLitecoinSection         = e("missing payment litecoin section")
 	MissingPreviousBlockHeader            = e("missing previous block header")
+	MissingRequiredMetadata               = e("missing required metadata")
 	MissingReservoir                      = e("missing reservoir interface")
 	NameTooLong                           = e("name too long")
 	NilPointer                            = e("nil pointer")
@@ -152,27 +169,42 @@ var (
 	OwnershipIsNotIndexed                 = e("ownership is not indexed")
 	PasswordMismatch                      = e("password mismatch")
 	PayIdAlreadyUsed                      = e("pay id already used")
+	PayIdNotFound                         = e("pay id not found")
 	PaymentAddressTooLong                 = e("payment address too long")
 	PreviousBlockDigestDoesNotMatch       = e("previous block digest does not match")
 	PreviousOwnershipWasNotDeleted        = e("previous ownership was not deleted")
 	PreviousTransactionWasNotDeleted      = e("previous transaction was not deleted")
 	ProcessStopping                       = e("process stopping")
+	ProvenanceNotTrusted                  = e("provenance not linked to a trusted block or signer")
+	QuorumNotReached                      = e("quorum not reached")
 	RateLimiting                          = e("rate limiting")
 	RecordHasExpired                      = e("record has expired")
+	RecordNotAllowedInBlockVersion        = e("record not allowed in block version")
+	SequenceNotIncreased                  = e("sequence not increased")
 	ShareIdsCannotBeIdentical             = e("share ids cannot be identical")
+	ShareNotFound                         = e("share not found")
+	ShareQuantityMismatch                 = e("share quantity mismatch")
 	ShareQuantityTooSmall                 = e("share quantity too small")
 	SignatureTooLong                      = e("signature too long")
+	SwapLegCountOutOfRange                = e("swap leg count out of range")
+	SwapLegIsDuplicated                   = e("swap leg is duplicated")
+	SwapMustBeTwoWay                      = e("swap must be two way")
 	TimeoutWaitingForHeader               = e("timeout waiting for header")
 	TooManyItemsToProcess                 = e("too many items to process")
+	TooManyPendingFreeIssues              = e("too many pending free issues")
 	TransactionAlreadyExists              = e("transaction already exists")
+	TransactionAlreadySigned              = e("transaction already signed")
 	TransactionCountOutOfRange            = e("transaction count out of range")
 	TransactionHexDataIsRequired          = e("transaction hex data is required")
 	TransactionIdIsRequired               = e("transaction id is required")
+	TransactionIsNotABlockFoundation      = e("transaction is not a block foundation")
 	TransactionIsNotAnAsset               = e("transaction is not an asset")
 	TransactionIsNotAnIssue               = e("transaction is not an issue")
 	TransactionIsNotATransfer             = e("transaction is not a transfer")
+	TransactionIsNotFullySigned           = e("transaction is not fully signed")
 	TransactionIsNotIndexed               = e("transaction is not indexed")
 	TransactionLinksToSelf                = e("transaction links to self")
+	TransactionNotFoundInBlock            = e("transaction not found in block")
 	UnexpectedTransactionRecord           = e("unexpected transaction record")
 	UnmarshalTextFailed                   = e("unmarshal text failed")
 	UnsupportedCurrency                   = e("unsupported currency")
@@ -180,7 +212,9 @@ var (
 	VotesWithEmptyWinner                  = e("votes with empty winner")
 	VotesWithZeroCount                    = e("votes with zero count")
 	VotesWithZeroHeight                   = e("votes with zero height")
+	WatchNotSupported                     = e("watch not supported")
 	WrongNetworkForPublicKey              = e("wrong network for public key")
 	WrongPassword                         = e("wrong password")
+	WrongSigningIdentity                  = e("wrong signing identity")
 	WrongEndpointString                   = e("wrong zmq protocol string")
 )
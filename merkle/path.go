@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package merkle
+
+// PathStep - one level of a merkle inclusion path
+type PathStep struct {
+	Digest Digest `json:"digest"`
+	Left   bool   `json:"left,omitempty"` // digest is the left operand
+}
+
+// InclusionPath - sibling digests needed to recompute the root of
+// FullMerkleTree(txIds) from the transaction at index
+//
+// returns nil if index is out of range
+func InclusionPath(txIds []Digest, index int) []PathStep {
+	if index < 0 || index >= len(txIds) {
+		return nil
+	}
+
+	tree := FullMerkleTree(txIds)
+	path := make([]PathStep, 0)
+
+	start := 0
+	for width := len(txIds); width > 1; width = (width + 1) / 2 {
+		sibling := index ^ 1
+		if sibling >= width {
+			sibling = index // odd number: paired with itself
+		}
+		path = append(path, PathStep{
+			Digest: tree[start+sibling],
+			Left:   sibling < index,
+		})
+		start += width
+		index /= 2
+	}
+	return path
+}
+
+// RootFromPath - recompute a merkle root from a transaction id and its
+// inclusion path
+func RootFromPath(txId Digest, path []PathStep) Digest {
+	d := txId
+	for _, step := range path {
+		if step.Left {
+			d = NewDigest(append(step.Digest[:], d[:]...))
+		} else {
+			d = NewDigest(append(d[:], step.Digest[:]...))
+		}
+	}
+	return d
+}
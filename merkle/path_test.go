@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package merkle_test
+
+import (
+	"testing"
+
+	"github.com/bitmark-inc/bitmarkd/merkle"
+)
+
+// every transaction of trees with odd and even widths
+func TestInclusionPath(t *testing.T) {
+
+	for n := 1; n <= 11; n += 1 {
+		ids := make([]merkle.Digest, n)
+		for i := range ids {
+			ids[i] = merkle.NewDigest([]byte{byte(n), byte(i)})
+		}
+
+		tree := merkle.FullMerkleTree(ids)
+		root := tree[len(tree)-1]
+
+		for i := range ids {
+			path := merkle.InclusionPath(ids, i)
+			if actual := merkle.RootFromPath(ids[i], path); root != actual {
+				t.Errorf("n: %d  i: %d  root: %#v  expected: %#v", n, i, actual, root)
+			}
+			if i > 0 && root == merkle.RootFromPath(ids[i-1], path) {
+				t.Errorf("n: %d  i: %d  path accepts the wrong transaction", n, i)
+			}
+		}
+
+		if nil != merkle.InclusionPath(ids, n) || nil != merkle.InclusionPath(ids, -1) {
+			t.Errorf("n: %d  path for index out of range", n)
+		}
+	}
+}
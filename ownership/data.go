@@ -175,6 +175,9 @@ func (a AssetOwnerData) TransferBlockNumber() uint64 {
 func (a AssetOwnerData) IssueBlockNumber() uint64 {
 	return a.issueBlockNumber
 }
+func (a AssetOwnerData) AssetId() transactionrecord.AssetIdentifier {
+	return a.assetId
+}
 
 // Pack - pack block owner data to byte slice
 func (b BlockOwnerData) Pack() PackedOwnerData {
@@ -238,6 +241,9 @@ func (a ShareOwnerData) TransferBlockNumber() uint64 {
 func (a ShareOwnerData) IssueBlockNumber() uint64 {
 	return a.issueBlockNumber
 }
+func (a ShareOwnerData) AssetId() transactionrecord.AssetIdentifier {
+	return a.assetId
+}
 
 // Unpack - unpack record into the appropriate type
 func (packed PackedOwnerData) Unpack() (OwnerData, error) {
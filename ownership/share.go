@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ownership
+
+import (
+	"encoding/binary"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/logger"
+)
+
+// from storage/setup.go:
+//
+// Bitmark Shares:
+//   ShareQuantity  owner ⧺ share id → balance
+//   ShareHolders   share id ⧺ owner → balance
+//   Shares         share id → total ⧺ share tx id
+
+// SetShareBalance - update the balance of a share held by an account in
+// both the owner and the holder indexes, a zero balance is deleted
+func SetShareBalance(trx storage.Transaction, owner *account.Account, shareId merkle.Digest, balance uint64) {
+	qKey := append(owner.Bytes(), shareId[:]...)
+	sKey := append(shareId[:], owner.Bytes()...)
+
+	if 0 == balance {
+		trx.Delete(storage.Pool.ShareQuantity, qKey)
+		trx.Delete(storage.Pool.ShareHolders, sKey)
+		return
+	}
+	trx.PutN(storage.Pool.ShareQuantity, qKey, balance)
+	trx.PutN(storage.Pool.ShareHolders, sKey, balance)
+}
+
+// RebuildShareHolders - recreate the holder index from the owner index
+// for a database created before the holder index existed
+func RebuildShareHolders(trx storage.Transaction) error {
+	return storage.Pool.ShareQuantity.NewFetchCursor().Map(func(key []byte, value []byte) error {
+		split := len(key) - merkle.DigestLength
+		if split <= 0 {
+			return nil
+		}
+		sKey := append(append([]byte{}, key[split:]...), key[:split]...)
+		trx.Put(storage.Pool.ShareHolders, sKey, value, []byte{})
+		return nil
+	})
+}
+
+// Redeem - burn every unit of a share and make the redeeming account
+// the owner of the bitmark again
+//
+// the caller must already have checked that newOwner holds the whole
+// quantity of the share
+func Redeem(
+	trx storage.Transaction,
+	shareTxId merkle.Digest,
+	redeemTxId merkle.Digest,
+	redeemBlockNumber uint64,
+	shareOwner *account.Account,
+	newOwner *account.Account,
+) {
+	// ensure single threaded
+	toLock.Lock()
+	defer toLock.Unlock()
+
+	ownerData, err := GetOwnerData(trx, shareTxId, storage.Pool.OwnerData)
+	if nil != err {
+		logger.Criticalf("ownership.Redeem: invalid owner data for tx id: %s  error: %s", shareTxId, err)
+		logger.Panic("ownership.Redeem: Ownership database corrupt")
+	}
+	shareData, ok := ownerData.(*ShareOwnerData)
+	if !ok {
+		logger.Criticalf("ownership.Redeem: owner data for tx id: %s is not a share", shareTxId)
+		logger.Panic("ownership.Redeem: Ownership database corrupt")
+	}
+
+	shareId := shareData.issueTxId
+	trx.Delete(storage.Pool.Shares, shareId[:])
+	SetShareBalance(trx, newOwner, shareId, 0)
+
+	transfer(trx, shareTxId, redeemTxId, redeemBlockNumber, shareOwner, newOwner, 0)
+}
+
+// Unredeem - reverse Redeem, recreating the share with the whole
+// quantity held by the redeeming account
+func Unredeem(
+	trx storage.Transaction,
+	redeemTxId merkle.Digest,
+	shareTxId merkle.Digest,
+	shareBlockNumber uint64,
+	redeemOwner *account.Account,
+	shareOwner *account.Account,
+	quantity uint64,
+) {
+	// ensure single threaded
+	toLock.Lock()
+	defer toLock.Unlock()
+
+	ownerData, err := GetOwnerData(trx, redeemTxId, storage.Pool.OwnerData)
+	if nil != err {
+		logger.Criticalf("ownership.Unredeem: invalid owner data for tx id: %s  error: %s", redeemTxId, err)
+		logger.Panic("ownership.Unredeem: Ownership database corrupt")
+	}
+	assetData, ok := ownerData.(*AssetOwnerData)
+	if !ok {
+		logger.Criticalf("ownership.Unredeem: owner data for tx id: %s is not an asset", redeemTxId)
+		logger.Panic("ownership.Unredeem: Ownership database corrupt")
+	}
+
+	// only delete the redeemed ownership
+	transfer(trx, redeemTxId, shareTxId, shareBlockNumber, redeemOwner, nil, 0)
+
+	shareId := assetData.issueTxId
+	shareData := make([]byte, 8, 8+merkle.DigestLength)
+	binary.BigEndian.PutUint64(shareData, quantity)
+	shareData = append(shareData, shareTxId[:]...)
+	trx.Put(storage.Pool.Shares, shareId[:], shareData, []byte{})
+
+	SetShareBalance(trx, redeemOwner, shareId, quantity)
+
+	newOwnerData := ShareOwnerData{
+		transferBlockNumber: shareBlockNumber,
+		issueTxId:           assetData.issueTxId,
+		issueBlockNumber:    assetData.issueBlockNumber,
+		assetId:             assetData.assetId,
+	}
+	create(trx, shareTxId, newOwnerData, shareOwner)
+}
@@ -133,8 +133,7 @@ func transfer(
 			trx.Put(storage.Pool.Shares, shareId[:], shareData, []byte{})
 
 			// initially total quantity goes to the creator
-			fKey := append(currentOwner.Bytes(), shareId[:]...)
-			trx.Put(storage.Pool.ShareQuantity, fKey, quantityBytes, []byte{})
+			SetShareBalance(trx, currentOwner, shareId, quantity)
 
 			// convert to share and update
 			newOwnerData := ShareOwnerData{
@@ -174,7 +173,8 @@ func transfer(
 			logger.Panic("ownership.Transfer: Ownership database corrupt")
 		}
 
-		// Note: only called on delete (block/store.go prevents share back to asset)
+		// Note: only called on delete or by a share redemption
+		//       (block/store.go prevents a transfer of a share)
 
 		// convert to transfer and update
 		newOwnerData := AssetOwnerData{
@@ -183,7 +183,7 @@ func transfer(
 			issueBlockNumber:    ownerData.issueBlockNumber,
 			assetId:             ownerData.assetId,
 		}
-		create(trx, transferTxId, newOwnerData, currentOwner)
+		create(trx, transferTxId, newOwnerData, newOwner)
 
 	default:
 		// panic if not an asset (this should have been checked earlier)
@@ -302,6 +302,23 @@ func OwnerOf(trx storage.Transaction, txId merkle.Digest) (uint64, *account.Acco
 	case *transactionrecord.BlockOwnerTransfer:
 		return blockNumber, tx.Owner
 
+	case *transactionrecord.BitmarkShare:
+		// a share stays with the owner of the bitmark it converted
+		_, owner := OwnerOf(trx, tx.Link)
+		return blockNumber, owner
+
+	case *transactionrecord.ShareRedemption:
+		return blockNumber, tx.Owner
+
+	case *transactionrecord.AtomicSwap:
+		// each bitmark moved by a swap is owned under its leg id
+		swapTxId := transactionrecord.Packed(packed).MakeLink()
+		leg, ok := tx.BitmarkLeg(swapTxId, txId)
+		if !ok {
+			return 0, nil
+		}
+		return blockNumber, leg.Recipient
+
 	default:
 		logger.Panicf("block.OwnerOf: incorrect transaction: %v", transaction)
 		return 0, nil
@@ -249,6 +249,15 @@ func processTransfer(packed []byte) error {
 		case *transactionrecord.ShareSwap:
 			_, duplicate, err = rsvr.StoreSwap(tx)
 
+		case *transactionrecord.ShareRedemption:
+			_, duplicate, err = rsvr.StoreRedemption(tx)
+
+		case *transactionrecord.AtomicSwap:
+			_, duplicate, err = rsvr.StoreAtomicSwap(tx)
+
+		case *transactionrecord.AssetAmendment:
+			_, duplicate, err = rsvr.StoreAmendment(tx)
+
 		default:
 			return fault.TransactionIsNotATransfer
 		}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package proof
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bitmark-inc/logger"
+)
+
+// reasons for accepting or rejecting a submission
+const (
+	ReasonAccepted       = "accepted"
+	ReasonInvalidRequest = "invalid request"
+	ReasonStaleJob       = "stale job"
+	ReasonNotNormalMode  = "not normal mode"
+	ReasonBadNonce       = "bad nonce"
+	ReasonDifficultyMiss = "difficulty miss"
+	ReasonSignatureError = "signature error"
+)
+
+const (
+	defaultAuditSize    = 1024 * 1024 // rotate when the audit file exceeds this size
+	minimumAuditSize    = 4096
+	auditRecentSize     = 100 // number of records kept in memory
+	auditBackupSuffix   = ".1"
+	auditFilePermission = 0600
+)
+
+// SubmissionRecord - the result of one submission from a proofer
+type SubmissionRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Job         string    `json:"job"`
+	Request     string    `json:"request"`
+	Nonce       string    `json:"nonce,omitempty"`
+	Reason      string    `json:"reason"`
+	Digest      string    `json:"digest,omitempty"`
+	Difficulty  float64   `json:"difficulty,omitempty"`
+	BlockNumber uint64    `json:"blockNumber,omitempty"`
+}
+
+// bounded record of submissions
+//
+// the most recent records are kept in memory for RPC and every record
+// is appended to a JSON lines file that is rotated to a single backup
+// when it exceeds the maximum size
+type audit struct {
+	sync.Mutex
+
+	log         *logger.L
+	fileName    string
+	maximumSize int64
+
+	recent []SubmissionRecord // ring buffer
+	next   int
+	full   bool
+	counts map[string]uint64
+}
+
+// initialise the audit and load the most recent records from the
+// previous run, a blank file name keeps the records only in memory
+func (a *audit) initialise(log *logger.L, fileName string, maximumSize int64) {
+	a.Lock()
+	defer a.Unlock()
+
+	if maximumSize <= 0 {
+		maximumSize = defaultAuditSize
+	} else if maximumSize < minimumAuditSize {
+		maximumSize = minimumAuditSize
+	}
+
+	a.log = log
+	a.fileName = fileName
+	a.maximumSize = maximumSize
+	a.recent = make([]SubmissionRecord, auditRecentSize)
+	a.next = 0
+	a.full = false
+	a.counts = make(map[string]uint64)
+
+	if "" == fileName {
+		return
+	}
+
+	for _, f := range []string{fileName + auditBackupSuffix, fileName} {
+		a.load(f)
+	}
+}
+
+// read the records of an existing audit file into the ring
+func (a *audit) load(fileName string) {
+	f, err := os.Open(fileName)
+	if nil != err {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r SubmissionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); nil != err {
+			a.log.Warnf("audit file: %q  skip invalid record: %s", fileName, err)
+			continue
+		}
+		a.push(r)
+	}
+}
+
+// add a record to the ring, must hold the lock
+func (a *audit) push(r SubmissionRecord) {
+	a.recent[a.next] = r
+	a.next += 1
+	if a.next >= len(a.recent) {
+		a.next = 0
+		a.full = true
+	}
+}
+
+// record one submission
+func (a *audit) add(r SubmissionRecord) {
+	a.Lock()
+	defer a.Unlock()
+
+	if nil == a.counts {
+		return
+	}
+
+	a.counts[r.Reason] += 1
+	a.push(r)
+
+	if "" == a.fileName {
+		return
+	}
+
+	err := a.write(r)
+	if nil != err {
+		a.log.Errorf("audit file: %q  write error: %s", a.fileName, err)
+	}
+}
+
+// append to the audit file, rotating first if it is too large
+func (a *audit) write(r SubmissionRecord) error {
+	data, err := json.Marshal(r)
+	if nil != err {
+		return err
+	}
+	data = append(data, '\n')
+
+	if info, err := os.Stat(a.fileName); nil == err && info.Size()+int64(len(data)) > a.maximumSize {
+		err := os.Rename(a.fileName, a.fileName+auditBackupSuffix)
+		if nil != err {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(a.fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, auditFilePermission)
+	if nil != err {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if nil != err {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// counts since start and up to count of the most recent records,
+// newest first
+func (a *audit) read(count int) (map[string]uint64, []SubmissionRecord) {
+	a.Lock()
+	defer a.Unlock()
+
+	counts := make(map[string]uint64, len(a.counts))
+	for k, v := range a.counts {
+		counts[k] = v
+	}
+
+	available := a.next
+	if a.full {
+		available = len(a.recent)
+	}
+	if count <= 0 || count > available {
+		count = available
+	}
+
+	records := make([]SubmissionRecord, 0, count)
+	for i, n := a.next, 0; n < count; n += 1 {
+		i -= 1
+		if i < 0 {
+			i = len(a.recent) - 1
+		}
+		records = append(records, a.recent[i])
+	}
+
+	return counts, records
+}
+
+// Submissions - counts of each submission result since start and the
+// most recent submission records, newest first
+func Submissions(count int) (map[string]uint64, []SubmissionRecord) {
+	return globalData.sub.audit.read(count)
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package proof
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
+	"github.com/bitmark-inc/logger"
+)
+
+func testRecord(job string, reason string) SubmissionRecord {
+	return SubmissionRecord{
+		Timestamp: time.Unix(1600000000, 0).UTC(),
+		Job:       job,
+		Request:   "block.nonce",
+		Nonce:     "0102030405060708",
+		Reason:    reason,
+	}
+}
+
+func TestAuditMemoryOnly(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	var a audit
+	a.initialise(logger.New(fixtures.LogCategory), "", 0)
+
+	counts, records := a.read(10)
+	assert.Equal(t, 0, len(counts), "wrong initial counts")
+	assert.Equal(t, 0, len(records), "wrong initial records")
+
+	a.add(testRecord("0001", ReasonStaleJob))
+	a.add(testRecord("0002", ReasonDifficultyMiss))
+	a.add(testRecord("0003", ReasonDifficultyMiss))
+
+	counts, records = a.read(0)
+	assert.Equal(t, uint64(1), counts[ReasonStaleJob], "wrong stale count")
+	assert.Equal(t, uint64(2), counts[ReasonDifficultyMiss], "wrong difficulty count")
+	assert.Equal(t, 3, len(records), "wrong record count")
+	assert.Equal(t, "0003", records[0].Job, "newest record not first")
+	assert.Equal(t, "0001", records[2].Job, "oldest record not last")
+
+	_, records = a.read(2)
+	assert.Equal(t, 2, len(records), "wrong limited record count")
+	assert.Equal(t, "0002", records[1].Job, "wrong limited record")
+}
+
+func TestAuditRingWraps(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	var a audit
+	a.initialise(logger.New(fixtures.LogCategory), "", 0)
+
+	for i := 0; i < auditRecentSize+5; i += 1 {
+		a.add(testRecord(string(rune('a'+i%26)), ReasonAccepted))
+	}
+
+	counts, records := a.read(0)
+	assert.Equal(t, uint64(auditRecentSize+5), counts[ReasonAccepted], "wrong accepted count")
+	assert.Equal(t, auditRecentSize, len(records), "wrong record count")
+}
+
+func TestAuditFileRotateAndReload(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	fileName := filepath.Join(fixtures.TestingDirName, "audit.log")
+	defer os.Remove(fileName)
+	defer os.Remove(fileName + auditBackupSuffix)
+
+	var a audit
+	a.initialise(logger.New(fixtures.LogCategory), fileName, minimumAuditSize)
+
+	for i := 0; i < auditRecentSize; i += 1 {
+		a.add(testRecord("0001", ReasonBadNonce))
+	}
+
+	_, err := os.Stat(fileName + auditBackupSuffix)
+	assert.Nil(t, err, "audit file not rotated")
+
+	info, err := os.Stat(fileName)
+	assert.Nil(t, err, "audit file missing after rotate")
+	assert.True(t, info.Size() <= minimumAuditSize, "audit file too large")
+
+	data, err := ioutil.ReadFile(fileName + auditBackupSuffix)
+	assert.Nil(t, err, "backup file unreadable")
+	assert.True(t, int64(len(data)) <= minimumAuditSize, "backup file too large")
+	assert.True(t, strings.Contains(string(data), ReasonBadNonce), "backup file missing reason")
+
+	// a restart reloads the most recent records but not the counts
+	var b audit
+	b.initialise(logger.New(fixtures.LogCategory), fileName, minimumAuditSize)
+	counts, records := b.read(0)
+	assert.Equal(t, 0, len(counts), "counts restored from file")
+	assert.True(t, len(records) > 0, "records not restored from file")
+	assert.Equal(t, ReasonBadNonce, records[0].Reason, "wrong restored reason")
+}
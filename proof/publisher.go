@@ -8,14 +8,11 @@ package proof
 import (
 	"crypto/rand"
 	"encoding/binary"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	zmq "github.com/pebbe/zmq4"
-	"golang.org/x/crypto/ed25519"
 
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/blockheader"
@@ -53,7 +50,8 @@ type publisher struct {
 	socket6            *zmq.Socket
 	paymentAddress     map[currency.Currency]string
 	owner              *account.Account
-	privateKey         []byte
+	signer             Signer
+	selector           reservoir.Selector
 	internalHashEnable bool
 }
 
@@ -124,28 +122,24 @@ func (pub *publisher) initialise(configuration *Configuration) error {
 		pub.paymentAddress[paymentCurrency] = currencyAddress
 	}
 
-	s := strings.TrimSpace(configuration.SigningKey)
-	if strings.HasPrefix(s, taggedSeed) {
-		privateKey, err := account.PrivateKeyFromBase58Seed(s[len(taggedSeed):])
-		if nil != err {
-			return err
-		}
-		pub.privateKey = privateKey.PrivateKeyBytes()
-		pub.owner = privateKey.Account()
-	} else if strings.HasPrefix(s, taggedPrivate) {
-		b, err := hex.DecodeString(s[len(taggedPrivate):])
-		if err != nil {
-			return err
-		}
-		privateKey, err := account.PrivateKeyFromBytes(b)
-		if nil != err {
-			return err
-		}
-		pub.privateKey = privateKey.PrivateKeyBytes()
-		pub.owner = privateKey.Account()
-	} else {
-		return fault.InvalidProofSigningKey
+	signer, err := newSigner(configuration)
+	if nil != err {
+		log.Errorf("block owner signer error: %s", err)
+		return err
+	}
+	pub.signer = signer
+	pub.owner = signer.Account()
+
+	selector, err := reservoir.NewSelector(reservoir.SelectionPolicy{
+		PaidFirst:    configuration.Selection.PaidFirst,
+		OldestFirst:  configuration.Selection.OldestFirst,
+		AccountLimit: configuration.Selection.AccountLimit,
+	})
+	if nil != err {
+		log.Errorf("transaction selection error: %s", err)
+		return err
 	}
+	pub.selector = selector
 
 	// when chain is local, use internal hasher
 	if mode.ChainName() == chain.Local && pub.internalHashEnable {
@@ -238,7 +232,7 @@ func (pub *publisher) process() {
 	}
 
 	// note: fetch one less tx because of foundation record
-	pooledTxIds, transactions, err := reservoir.FetchVerified(blockrecord.MaximumTransactions - 1)
+	pooledTxIds, transactions, err := pub.selector.FetchVerified(blockrecord.MaximumTransactions - 1)
 	if nil != err {
 		pub.log.Errorf("Error on Fetch: %v", err)
 		return
@@ -266,8 +260,12 @@ func (pub *publisher) process() {
 
 	// sign the record and attach signature
 	partiallyPacked, _ := blockFoundation.Pack(pub.owner) // ignore error to get packed without signature
-	signature := ed25519.Sign(pub.privateKey[:], partiallyPacked)
-	blockFoundation.Signature = signature[:]
+	signature, err := pub.signer.Sign(partiallyPacked)
+	if nil != err {
+		pub.log.Errorf("sign block foundation error: %s", err)
+		return
+	}
+	blockFoundation.Signature = signature
 
 	// re-pack to makesure signature is valid
 	packedBI, err := blockFoundation.Pack(pub.owner)
@@ -7,13 +7,16 @@ package proof
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"sync"
 
 	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
 	"github.com/bitmark-inc/bitmarkd/messagebus"
 	"github.com/bitmark-inc/bitmarkd/mode"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
 	"github.com/bitmark-inc/logger"
 )
 
@@ -90,11 +93,15 @@ func enqueueToJobQueue(item *PublishedItem, txdata []byte) {
 	jobQueue.Unlock()
 }
 
-func matchToJobQueue(received *SubmittedItem, log *logger.L) (success bool) {
+// match a submission to a queued job, the record is filled in with
+// the reason for accepting or rejecting the submission
+func matchToJobQueue(received *SubmittedItem, record *SubmissionRecord, log *logger.L) (success bool) {
 	jobQueue.Lock()
 	defer jobQueue.Unlock()
 
 	job := received.Job
+	record.Job = job
+	record.Request = received.Request
 
 	var entry *entryType
 search:
@@ -106,11 +113,15 @@ search:
 	}
 
 	if nil == entry {
+		record.Reason = ReasonStaleJob
 		return
 	}
 
+	record.BlockNumber = entry.item.Header.Number
+
 	// if not normal abandon the queue and the submission
 	if !mode.Is(mode.Normal) {
+		record.Reason = ReasonNotNormalMode
 		goto cleanup
 	}
 
@@ -118,8 +129,11 @@ search:
 
 	case "block.nonce":
 		if len(received.Packed) != blockrecord.NonceSize {
+			record.Reason = ReasonBadNonce
 			return
 		}
+		record.Nonce = hex.EncodeToString(received.Packed)
+
 		entry.item.Header.Nonce = blockrecord.NonceType(binary.LittleEndian.Uint64(received.Packed))
 		ph := entry.item.Header.Pack()
 		digest := ph.Digest()
@@ -127,18 +141,32 @@ search:
 		diff := entry.item.Header.Difficulty
 		log.Debugf("incoming block difficulty: %f", diff.Value())
 
+		record.Digest = digest.String()
+		record.Difficulty = diff.Value()
+
 		if !digest.IsValidByDifficulty(diff, mode.ChainName()) {
 			log.Infof("digest %s, difficulty %064x, digest not match difficulty criteria", digest.String(), diff.BigInt())
+			record.Reason = ReasonDifficultyMiss
 			return
 		}
 
+		if err := verifyTxZero(entry.item.TxZero); nil != err {
+			log.Errorf("job: %s  base transaction signature error: %s", job, err)
+			record.Reason = ReasonSignatureError
+			goto cleanup
+		}
+
 		packedBlock := ph[:] //make([]byte,len(ph)+len(entry.item.Base)+len(entry.transactions))
 		packedBlock = append(packedBlock, entry.item.TxZero...)
 		packedBlock = append(packedBlock, entry.transactions...)
 
 		// broadcast this packedBlock for processing
 		messagebus.Bus.Blockstore.Send("local", packedBlock)
+		record.Reason = ReasonAccepted
 		success = true
+
+	default:
+		record.Reason = ReasonInvalidRequest
 	}
 
 cleanup:
@@ -153,3 +181,20 @@ cleanup:
 
 	return
 }
+
+// check the signature of the base transaction before broadcasting the
+// block, re-packing verifies the signature against the owner
+func verifyTxZero(txZero []byte) error {
+	transaction, _, err := transactionrecord.Packed(txZero).Unpack(mode.IsTesting())
+	if nil != err {
+		return err
+	}
+
+	foundation, ok := transaction.(*transactionrecord.BlockFoundation)
+	if !ok {
+		return fault.TransactionIsNotABlockFoundation
+	}
+
+	_, err = foundation.Pack(foundation.Owner)
+	return err
+}
@@ -24,13 +24,26 @@ const (
 // a block of configuration data
 // this is read from the configuration file
 type Configuration struct {
-	Publish            []string          `gluamapper:"publish" json:"publish"`
-	Submit             []string          `gluamapper:"submit" json:"submit"`
-	PrivateKey         string            `gluamapper:"private_key" json:"private_key"`
-	PublicKey          string            `gluamapper:"public_key" json:"public_key"`
-	SigningKey         string            `gluamapper:"signing_key" json:"signing_key"`
-	PaymentAddr        map[string]string `gluamapper:"payment_address" json:"payment_address"`
-	InternalHashEnable bool              `gluamapper:"local_use_internal_hash" json:"local_use_internal_hash"`
+	Publish            []string               `gluamapper:"publish" json:"publish"`
+	Submit             []string               `gluamapper:"submit" json:"submit"`
+	PrivateKey         string                 `gluamapper:"private_key" json:"private_key"`
+	PublicKey          string                 `gluamapper:"public_key" json:"public_key"`
+	SigningKey         string                 `gluamapper:"signing_key" json:"signing_key"`
+	Signer             SignerConfiguration    `gluamapper:"signer" json:"signer"`
+	PaymentAddr        map[string]string      `gluamapper:"payment_address" json:"payment_address"`
+	InternalHashEnable bool                   `gluamapper:"local_use_internal_hash" json:"local_use_internal_hash"`
+	AuditFile          string                 `gluamapper:"audit_file" json:"audit_file"`
+	AuditSize          int64                  `gluamapper:"audit_size" json:"audit_size"`
+	Selection          SelectionConfiguration `gluamapper:"selection" json:"selection"`
+}
+
+// SelectionConfiguration - how verified transactions are chosen for a
+// new block, the defaults keep the fixed order of free issues, paid
+// issues then transactions
+type SelectionConfiguration struct {
+	PaidFirst    bool `gluamapper:"paid_first" json:"paid_first"`
+	OldestFirst  bool `gluamapper:"oldest_first" json:"oldest_first"`
+	AccountLimit int  `gluamapper:"account_limit" json:"account_limit"`
 }
 
 // globals for background process
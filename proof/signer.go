@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package proof
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/mode"
+)
+
+const (
+	defaultSignerTimeout = 10 * time.Second
+	signerRequestSign    = "sign"
+	signerKindFoundation = "blockFoundation"
+)
+
+// SignerConfiguration - external signing process for the block owner
+// key, a blank socket uses the signing_key from the configuration
+type SignerConfiguration struct {
+	Socket  string `gluamapper:"socket" json:"socket"`
+	Account string `gluamapper:"account" json:"account"`
+	Timeout int    `gluamapper:"timeout" json:"timeout"` // seconds
+}
+
+// Signer - signs block foundation records on behalf of the block owner
+type Signer interface {
+	Account() *account.Account
+	Sign(message []byte) ([]byte, error)
+}
+
+// create the signer selected by the configuration
+func newSigner(configuration *Configuration) (Signer, error) {
+	if "" == strings.TrimSpace(configuration.Signer.Socket) {
+		return newFileSigner(configuration.SigningKey)
+	}
+	return newSocketSigner(&configuration.Signer)
+}
+
+// signer holding the private key in memory
+type fileSigner struct {
+	owner      *account.Account
+	privateKey []byte
+}
+
+// decode a key from the proof.live or proof.test file
+func newFileSigner(signingKey string) (Signer, error) {
+	s := strings.TrimSpace(signingKey)
+
+	var privateKey *account.PrivateKey
+	if strings.HasPrefix(s, taggedSeed) {
+		key, err := account.PrivateKeyFromBase58Seed(s[len(taggedSeed):])
+		if nil != err {
+			return nil, err
+		}
+		privateKey = key
+	} else if strings.HasPrefix(s, taggedPrivate) {
+		b, err := hex.DecodeString(s[len(taggedPrivate):])
+		if err != nil {
+			return nil, err
+		}
+		key, err := account.PrivateKeyFromBytes(b)
+		if nil != err {
+			return nil, err
+		}
+		privateKey = key
+	} else {
+		return nil, fault.InvalidProofSigningKey
+	}
+
+	return &fileSigner{
+		owner:      privateKey.Account(),
+		privateKey: privateKey.PrivateKeyBytes(),
+	}, nil
+}
+
+// Account - the block owner
+func (s *fileSigner) Account() *account.Account {
+	return s.owner
+}
+
+// Sign - sign with the in-memory private key
+func (s *fileSigner) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, message), nil
+}
+
+// signer that forwards each request to a process listening on a local
+// Unix socket, e.g. a bridge to a hardware security module
+//
+// the protocol is one JSON object per line in each direction:
+//
+//	request:  {"request":"sign","kind":"blockFoundation","account":"<base58>","message":"<hex>"}
+//	reply:    {"signature":"<hex>"} or {"error":"<text>"}
+//
+// the signature is verified before it is used
+type socketSigner struct {
+	socket  string
+	owner   *account.Account
+	timeout time.Duration
+}
+
+type signerRequest struct {
+	Request string `json:"request"`
+	Kind    string `json:"kind"`
+	Account string `json:"account"`
+	Message string `json:"message"`
+}
+
+type signerReply struct {
+	Signature string `json:"signature"`
+	Error     string `json:"error"`
+}
+
+func newSocketSigner(configuration *SignerConfiguration) (Signer, error) {
+	owner, err := account.AccountFromBase58(strings.TrimSpace(configuration.Account))
+	if nil != err {
+		return nil, err
+	}
+	if owner.IsTesting() != mode.IsTesting() {
+		return nil, fault.WrongNetworkForPublicKey
+	}
+
+	timeout := defaultSignerTimeout
+	if configuration.Timeout > 0 {
+		timeout = time.Duration(configuration.Timeout) * time.Second
+	}
+
+	return &socketSigner{
+		socket:  configuration.Socket,
+		owner:   owner,
+		timeout: timeout,
+	}, nil
+}
+
+// Account - the block owner
+func (s *socketSigner) Account() *account.Account {
+	return s.owner
+}
+
+// Sign - request a signature from the external signer
+func (s *socketSigner) Sign(message []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("unix", s.socket, s.timeout)
+	if nil != err {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(s.timeout))
+	if nil != err {
+		return nil, err
+	}
+
+	request, err := json.Marshal(signerRequest{
+		Request: signerRequestSign,
+		Kind:    signerKindFoundation,
+		Account: s.owner.String(),
+		Message: hex.EncodeToString(message),
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	_, err = conn.Write(append(request, '\n'))
+	if nil != err {
+		return nil, err
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if nil != err {
+		return nil, err
+	}
+
+	var reply signerReply
+	err = json.Unmarshal(line, &reply)
+	if nil != err {
+		return nil, err
+	}
+	if "" != reply.Error {
+		return nil, fmt.Errorf("signer: %s", reply.Error)
+	}
+
+	signature, err := hex.DecodeString(reply.Signature)
+	if nil != err {
+		return nil, err
+	}
+
+	err = s.owner.CheckSignature(message, signature)
+	if nil != err {
+		return nil, err
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package proof
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+)
+
+// ED25519 livenet private key prefix
+const testPrivateKeyVariant = "10"
+
+func testKeys(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey, *account.Account) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	assert.Nil(t, err, "generate key")
+
+	owner := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      false,
+			PublicKey: publicKey,
+		},
+	}
+	return publicKey, privateKey, owner
+}
+
+func TestFileSigner(t *testing.T) {
+	publicKey, privateKey, owner := testKeys(t)
+
+	signer, err := newFileSigner(taggedPrivate + testPrivateKeyVariant + hex.EncodeToString(privateKey) + "\n")
+	assert.Nil(t, err, "file signer")
+	assert.Equal(t, owner.String(), signer.Account().String(), "wrong account")
+
+	message := []byte("block foundation")
+	signature, err := signer.Sign(message)
+	assert.Nil(t, err, "sign")
+	assert.True(t, ed25519.Verify(publicKey, message, signature), "signature not valid")
+}
+
+func TestFileSignerInvalidKey(t *testing.T) {
+	_, err := newFileSigner("KEY:0123")
+	assert.Equal(t, fault.InvalidProofSigningKey, err, "wrong error")
+}
+
+// serve a single signing request
+func serveSigner(t *testing.T, listener net.Listener, reply func(request signerRequest) signerReply) {
+	conn, err := listener.Accept()
+	if nil != err {
+		return
+	}
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	assert.Nil(t, err, "read request")
+
+	var request signerRequest
+	err = json.Unmarshal(line, &request)
+	assert.Nil(t, err, "decode request")
+
+	data, err := json.Marshal(reply(request))
+	assert.Nil(t, err, "encode reply")
+	_, _ = conn.Write(append(data, '\n'))
+}
+
+func TestSocketSigner(t *testing.T) {
+	publicKey, privateKey, owner := testKeys(t)
+
+	dir, err := ioutil.TempDir("", "signer")
+	assert.Nil(t, err, "temp dir")
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "signer.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.Nil(t, err, "listen")
+	defer listener.Close()
+
+	signer, err := newSocketSigner(&SignerConfiguration{
+		Socket:  socket,
+		Account: owner.String(),
+	})
+	assert.Nil(t, err, "socket signer")
+	assert.Equal(t, owner.String(), signer.Account().String(), "wrong account")
+
+	message := []byte("block foundation")
+
+	// valid signature
+	go serveSigner(t, listener, func(request signerRequest) signerReply {
+		assert.Equal(t, signerRequestSign, request.Request, "wrong request")
+		assert.Equal(t, signerKindFoundation, request.Kind, "wrong kind")
+		assert.Equal(t, owner.String(), request.Account, "wrong request account")
+		m, err := hex.DecodeString(request.Message)
+		assert.Nil(t, err, "decode message")
+		return signerReply{
+			Signature: hex.EncodeToString(ed25519.Sign(privateKey, m)),
+		}
+	})
+	signature, err := signer.Sign(message)
+	assert.Nil(t, err, "sign")
+	assert.True(t, ed25519.Verify(publicKey, message, signature), "signature not valid")
+
+	// signature by a different key
+	_, otherKey, _ := testKeys(t)
+	go serveSigner(t, listener, func(request signerRequest) signerReply {
+		return signerReply{
+			Signature: hex.EncodeToString(ed25519.Sign(otherKey, message)),
+		}
+	})
+	_, err = signer.Sign(message)
+	assert.Equal(t, fault.InvalidSignature, err, "wrong signature accepted")
+
+	// signer refused
+	go serveSigner(t, listener, func(request signerRequest) signerReply {
+		return signerReply{
+			Error: "locked",
+		}
+	})
+	_, err = signer.Sign(message)
+	assert.NotNil(t, err, "refusal not reported")
+}
+
+func TestSocketSignerInvalidAccount(t *testing.T) {
+	_, err := newSocketSigner(&SignerConfiguration{
+		Socket:  "/nonexistent",
+		Account: "not-an-account",
+	})
+	assert.NotNil(t, err, "invalid account accepted")
+}
@@ -36,6 +36,7 @@ type submission struct {
 	minedBlockCount    counter.Counter
 	failedBlockCount   counter.Counter
 	internalHashEnable bool
+	audit              audit
 }
 
 // initialise the submission
@@ -46,6 +47,8 @@ func (sub *submission) initialise(configuration *Configuration) error {
 
 	log.Info("initialising…")
 
+	sub.audit.initialise(log, configuration.AuditFile, configuration.AuditSize)
+
 	var err error
 	// signalling channel
 	sub.sigReceive, sub.sigSend, err = zmqutil.NewSignalPair(submissionSignal)
@@ -163,6 +166,9 @@ func (sub *submission) process(socket *zmq.Socket) {
 	log.Infof("received message: %q", data)
 
 	ok := false
+	record := SubmissionRecord{
+		Reason: ReasonInvalidRequest,
+	}
 	var request SubmittedItem
 	err = json.Unmarshal([]byte(data[0]), &request)
 	if nil != err {
@@ -171,11 +177,14 @@ func (sub *submission) process(socket *zmq.Socket) {
 
 		log.Infof("received message: %v", request)
 
-		ok = matchToJobQueue(&request, log)
+		ok = matchToJobQueue(&request, &record, log)
 
-		log.Infof("maches: %v", ok)
+		log.Infof("maches: %v  reason: %s", ok, record.Reason)
 	}
 
+	record.Timestamp = time.Now().UTC()
+	sub.audit.add(record)
+
 	// increase minedBlockCount
 	if ok {
 		// do a little delay average around 50ms
@@ -192,11 +201,13 @@ func (sub *submission) process(socket *zmq.Socket) {
 	}
 
 	response := struct {
-		Job string `json:"job"`
-		OK  bool   `json:"ok"`
+		Job    string `json:"job"`
+		OK     bool   `json:"ok"`
+		Reason string `json:"reason"`
 	}{
-		Job: request.Job,
-		OK:  ok,
+		Job:    request.Job,
+		OK:     ok,
+		Reason: record.Reason,
 	}
 
 	result, err := json.Marshal(response)
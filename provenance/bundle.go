@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package provenance
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// Version - current bundle format
+const Version = 1
+
+// Bundle - every record in a provenance chain with the proof that each
+// was included in a block
+//
+// records are ordered from the most recent transfer back to the issue
+// and finally the asset, or to the foundation for block ownership
+type Bundle struct {
+	Version   int               `json:"version"`
+	Chain     string            `json:"chain"`
+	Records   []Record          `json:"records"`
+	Signer    *account.Account  `json:"signer,omitempty"`
+	Signature account.Signature `json:"signature,omitempty"`
+}
+
+// Record - a transaction, the header of the block containing it and the
+// merkle path from the transaction to the header's merkle root
+type Record struct {
+	Packed      transactionrecord.Packed `json:"packed"`
+	BlockNumber uint64                   `json:"blockNumber,string"`
+	Header      Bytes                    `json:"header"`
+	Path        []merkle.PathStep        `json:"path"`
+}
+
+// Bytes - binary data represented as hex in JSON
+type Bytes []byte
+
+// MarshalText - convert to hex
+func (b Bytes) MarshalText() ([]byte, error) {
+	buffer := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(buffer, b)
+	return buffer, nil
+}
+
+// UnmarshalText - convert from hex
+func (b *Bytes) UnmarshalText(s []byte) error {
+	buffer := make([]byte, hex.DecodedLen(len(s)))
+	_, err := hex.Decode(buffer, s)
+	if nil != err {
+		return err
+	}
+	*b = buffer
+	return nil
+}
+
+// Matcher - select a transaction from a block
+type Matcher func(index int, txId merkle.Digest, transaction transactionrecord.Transaction) bool
+
+// MatchTxId - select the transaction with a specific id
+func MatchTxId(id merkle.Digest) Matcher {
+	return func(_ int, txId merkle.Digest, _ transactionrecord.Transaction) bool {
+		return id == txId
+	}
+}
+
+// MatchAsset - select the asset record with a specific asset id
+func MatchAsset(assetId transactionrecord.AssetIdentifier) Matcher {
+	return func(_ int, _ merkle.Digest, transaction transactionrecord.Transaction) bool {
+		asset, ok := transaction.(*transactionrecord.AssetData)
+		return ok && assetId == asset.AssetId()
+	}
+}
+
+// MatchSwapLeg - select the atomic swap with a specific bitmark leg id
+func MatchSwapLeg(legId merkle.Digest) Matcher {
+	return func(_ int, txId merkle.Digest, transaction transactionrecord.Transaction) bool {
+		swap, ok := transaction.(*transactionrecord.AtomicSwap)
+		if !ok {
+			return false
+		}
+		_, ok = swap.BitmarkLeg(txId, legId)
+		return ok
+	}
+}
+
+// MatchFoundation - select the foundation, always the first transaction
+func MatchFoundation() Matcher {
+	return func(index int, _ merkle.Digest, _ transactionrecord.Transaction) bool {
+		return 0 == index
+	}
+}
+
+// NewRecord - extract a transaction and its inclusion proof from a
+// complete packed block as returned by Node.BlockDump
+func NewRecord(packedBlock []byte, testnet bool, match Matcher) (*Record, error) {
+
+	packedHeader := blockrecord.PackedHeader{}
+	if len(packedBlock) < len(packedHeader) {
+		return nil, fault.InvalidBlockHeaderSize
+	}
+	copy(packedHeader[:], packedBlock)
+
+	header, err := packedHeader.Unpack()
+	if nil != err {
+		return nil, err
+	}
+
+	txIds := make([]merkle.Digest, 0, header.TransactionCount)
+	found := -1
+	var packed transactionrecord.Packed
+
+	data := packedBlock[len(packedHeader):]
+	for i := 0; len(data) > 0; i += 1 {
+		transaction, n, err := transactionrecord.Packed(data).Unpack(testnet)
+		if nil != err {
+			return nil, err
+		}
+		txId := merkle.NewDigest(data[:n])
+		if found < 0 && match(i, txId, transaction) {
+			found = i
+			packed = append(transactionrecord.Packed{}, data[:n]...)
+		}
+		txIds = append(txIds, txId)
+		data = data[n:]
+	}
+
+	if found < 0 {
+		return nil, fault.TransactionNotFoundInBlock
+	}
+
+	return &Record{
+		Packed:      packed,
+		BlockNumber: header.Number,
+		Header:      Bytes(packedHeader[:]),
+		Path:        merkle.InclusionPath(txIds, found),
+	}, nil
+}
+
+// Sign - sign the bundle as its exporter
+func (bundle *Bundle) Sign(signer *account.Account, sign func(message []byte) ([]byte, error)) error {
+	bundle.Signer = signer
+	signature, err := sign(bundle.signingMessage())
+	if nil != err {
+		return err
+	}
+	bundle.Signature = signature
+	return nil
+}
+
+// digest of the bundle contents excluding the signature
+func (bundle *Bundle) signingMessage() []byte {
+	message := make([]byte, 0, 1024)
+	message = append(message, byte(bundle.Version))
+	message = append(message, bundle.Chain...)
+	message = append(message, 0)
+
+	n := make([]byte, 8)
+	for _, r := range bundle.Records {
+		binary.BigEndian.PutUint64(n, uint64(len(r.Packed)))
+		message = append(message, n...)
+		message = append(message, r.Packed...)
+		binary.BigEndian.PutUint64(n, r.BlockNumber)
+		message = append(message, n...)
+		message = append(message, r.Header...)
+		for _, step := range r.Path {
+			if step.Left {
+				message = append(message, 1)
+			} else {
+				message = append(message, 0)
+			}
+			message = append(message, step.Digest[:]...)
+		}
+		message = append(message, 0xff)
+	}
+	digest := merkle.NewDigest(message)
+	return digest[:]
+}
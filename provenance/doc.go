@@ -0,0 +1,8 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package provenance - self contained proof of a bitmark's history
+// that can be checked without access to a node
+package provenance
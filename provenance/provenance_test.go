@@ -0,0 +1,588 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package provenance_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/difficulty"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/provenance"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+type keyPair struct {
+	account    *account.Account
+	privateKey ed25519.PrivateKey
+}
+
+func newKeyPair(t *testing.T) keyPair {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if nil != err {
+		t.Fatalf("key error: %s", err)
+	}
+	return keyPair{
+		account: &account.Account{
+			AccountInterface: &account.ED25519Account{
+				Test:      true,
+				PublicKey: publicKey,
+			},
+		},
+		privateKey: privateKey,
+	}
+}
+
+// pack a record signed by the given key
+func sign(t *testing.T, signer keyPair, pack func() (transactionrecord.Packed, error), setSignature func(account.Signature)) transactionrecord.Packed {
+	packed, _ := pack()
+	setSignature(ed25519.Sign(signer.privateKey, packed))
+	packed, err := pack()
+	if nil != err {
+		t.Fatalf("pack error: %s", err)
+	}
+	return packed
+}
+
+// build a packed block containing the given transactions
+func makeBlock(number uint64, txs ...transactionrecord.Packed) []byte {
+	txIds := make([]merkle.Digest, len(txs))
+	for i, tx := range txs {
+		txIds[i] = merkle.NewDigest(tx)
+	}
+	tree := merkle.FullMerkleTree(txIds)
+
+	header := blockrecord.Header{
+		Version:          blockrecord.Version,
+		TransactionCount: uint16(len(txs)),
+		Number:           number,
+		MerkleRoot:       tree[len(tree)-1],
+		Timestamp:        1500000000 + number,
+		Difficulty:       difficulty.New(),
+	}
+	packedHeader := header.Pack()
+	block := append([]byte{}, packedHeader[:]...)
+	for _, tx := range txs {
+		block = append(block, tx...)
+	}
+	return block
+}
+
+type chainFixture struct {
+	bundle  *provenance.Bundle
+	options *provenance.Options
+	assetId transactionrecord.AssetIdentifier
+	issueId merkle.Digest
+	owner   *account.Account
+}
+
+type recordSource struct {
+	block []byte
+	match provenance.Matcher
+}
+
+// bundle for the local chain trusting the block of its oldest record
+func makeBundle(t *testing.T, sources ...recordSource) chainFixture {
+	bundle := &provenance.Bundle{
+		Version: provenance.Version,
+		Chain:   chain.Local,
+	}
+	for _, source := range sources {
+		r, err := provenance.NewRecord(source.block, true, source.match)
+		if nil != err {
+			t.Fatalf("new record error: %s", err)
+		}
+		bundle.Records = append(bundle.Records, *r)
+	}
+
+	packedHeader := blockrecord.PackedHeader{}
+	copy(packedHeader[:], sources[len(sources)-1].block)
+
+	return chainFixture{
+		bundle: bundle,
+		options: &provenance.Options{
+			Chain:         chain.Local,
+			AllowLocal:    true,
+			TrustedBlocks: []blockdigest.Digest{packedHeader.Digest()},
+		},
+	}
+}
+
+// asset and issue in block 2, then a transfer in block 4
+func makeChain(t *testing.T) chainFixture {
+	registrant := newKeyPair(t)
+	first := newKeyPair(t)
+	second := newKeyPair(t)
+
+	asset := &transactionrecord.AssetData{
+		Name:        "test asset",
+		Fingerprint: "0123456789abcdef",
+		Metadata:    "description\x00provenance",
+		Registrant:  registrant.account,
+	}
+	packedAsset := sign(t, registrant,
+		func() (transactionrecord.Packed, error) { return asset.Pack(registrant.account) },
+		func(s account.Signature) { asset.Signature = s })
+
+	issues := make([]transactionrecord.Packed, 3)
+	for i := range issues {
+		issue := &transactionrecord.BitmarkIssue{
+			AssetId: asset.AssetId(),
+			Owner:   first.account,
+			Nonce:   uint64(i + 1),
+		}
+		issues[i] = sign(t, first,
+			func() (transactionrecord.Packed, error) { return issue.Pack(first.account) },
+			func(s account.Signature) { issue.Signature = s })
+	}
+	issueId := merkle.NewDigest(issues[1])
+
+	transfer := &transactionrecord.BitmarkTransferUnratified{
+		Link:  issueId,
+		Owner: second.account,
+	}
+	packedTransfer := sign(t, first,
+		func() (transactionrecord.Packed, error) { return transfer.Pack(first.account) },
+		func(s account.Signature) { transfer.Signature = s })
+	transferId := merkle.NewDigest(packedTransfer)
+
+	block2 := makeBlock(2, packedAsset, issues[0], issues[1], issues[2])
+	block4 := makeBlock(4, issues[2], packedTransfer)
+
+	f := makeBundle(t,
+		recordSource{block4, provenance.MatchTxId(transferId)},
+		recordSource{block2, provenance.MatchTxId(issueId)},
+		recordSource{block2, provenance.MatchAsset(asset.AssetId())},
+	)
+
+	return chainFixture{
+		bundle:  f.bundle,
+		options: f.options,
+		assetId: asset.AssetId(),
+		issueId: issueId,
+		owner:   second.account,
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f := makeChain(t)
+
+	// round trip through JSON as the command line tools do
+	buffer, err := json.Marshal(f.bundle)
+	if nil != err {
+		t.Fatalf("marshal error: %s", err)
+	}
+	bundle := &provenance.Bundle{}
+	err = json.Unmarshal(buffer, bundle)
+	if nil != err {
+		t.Fatalf("unmarshal error: %s", err)
+	}
+
+	result, err := provenance.Verify(bundle, f.options)
+	if nil != err {
+		t.Fatalf("verify error: %s", err)
+	}
+	if nil == result.AssetId || f.assetId != *result.AssetId {
+		t.Errorf("asset id: %v  expected: %v", result.AssetId, f.assetId)
+	}
+	if nil == result.BitmarkId || f.issueId != *result.BitmarkId {
+		t.Errorf("bitmark id: %v  expected: %v", result.BitmarkId, f.issueId)
+	}
+	if f.owner.String() != result.Owner.String() {
+		t.Errorf("owner: %s  expected: %s", result.Owner, f.owner)
+	}
+	if 3 != result.Records {
+		t.Errorf("records: %d  expected: 3", result.Records)
+	}
+}
+
+func TestVerifySigned(t *testing.T) {
+	f := makeChain(t)
+	exporter := newKeyPair(t)
+
+	err := f.bundle.Sign(exporter.account, func(message []byte) ([]byte, error) {
+		return ed25519.Sign(exporter.privateKey, message), nil
+	})
+	if nil != err {
+		t.Fatalf("sign error: %s", err)
+	}
+
+	result, err := provenance.Verify(f.bundle, f.options)
+	if nil != err {
+		t.Fatalf("verify error: %s", err)
+	}
+	if exporter.account.String() != result.Signer.String() {
+		t.Errorf("signer: %s  expected: %s", result.Signer, exporter.account)
+	}
+
+	// any change after signing invalidates the signature
+	f.bundle.Records[0].BlockNumber += 1
+	_, err = provenance.Verify(f.bundle, f.options)
+	if fault.InvalidSignature != err {
+		t.Errorf("tampered: error: %v  expected: %s", err, fault.InvalidSignature)
+	}
+}
+
+func TestVerifyFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(f chainFixture)
+		index  int
+		err    error
+	}{
+		{
+			name: "merkle path",
+			modify: func(f chainFixture) {
+				f.bundle.Records[1].Path[0].Digest[0] ^= 1
+			},
+			index: 1,
+			err:   fault.MerkleRootDoesNotMatch,
+		},
+		{
+			name: "header from another block",
+			modify: func(f chainFixture) {
+				f.bundle.Records[0].Header = f.bundle.Records[1].Header
+				f.bundle.Records[0].BlockNumber = f.bundle.Records[1].BlockNumber
+			},
+			index: 0,
+			err:   fault.MerkleRootDoesNotMatch,
+		},
+		{
+			name: "block number",
+			modify: func(f chainFixture) {
+				f.bundle.Records[0].BlockNumber = 3
+			},
+			index: 0,
+			err:   fault.InvalidProvenanceBundle,
+		},
+		{
+			name: "missing issue",
+			modify: func(f chainFixture) {
+				f.bundle.Records = append(f.bundle.Records[:1], f.bundle.Records[2])
+			},
+			index: 0,
+			err:   fault.TransactionIsNotAnIssue,
+		},
+		{
+			name: "no asset",
+			modify: func(f chainFixture) {
+				f.bundle.Records = f.bundle.Records[:2]
+			},
+			index: 1,
+			err:   fault.InvalidProvenanceBundle,
+		},
+	}
+
+	for _, test := range tests {
+		f := makeChain(t)
+		test.modify(f)
+		_, err := provenance.Verify(f.bundle, f.options)
+		recordError, ok := err.(*provenance.RecordError)
+		if !ok {
+			t.Errorf("%s: error: %v  expected a record error", test.name, err)
+			continue
+		}
+		if test.index != recordError.Index || test.err != recordError.Err {
+			t.Errorf("%s: error: %s  expected: record[%d]: %s", test.name, err, test.index, test.err)
+		}
+	}
+
+	f := makeChain(t)
+	f.bundle.Records = nil
+	_, err := provenance.Verify(f.bundle, f.options)
+	if fault.InvalidProvenanceBundle != err {
+		t.Errorf("empty: error: %v  expected: %s", err, fault.InvalidProvenanceBundle)
+	}
+}
+
+func TestVerifyTrust(t *testing.T) {
+	exporter := newKeyPair(t)
+	other := newKeyPair(t)
+
+	tests := []struct {
+		name   string
+		modify func(f chainFixture)
+		err    error
+	}{
+		{
+			name: "no trusted block",
+			modify: func(f chainFixture) {
+				f.options.TrustedBlocks = nil
+			},
+			err: fault.ProvenanceNotTrusted,
+		},
+		{
+			name: "trusted signer",
+			modify: func(f chainFixture) {
+				f.options.TrustedBlocks = nil
+				f.options.TrustedSigners = []*account.Account{exporter.account}
+			},
+			err: nil,
+		},
+		{
+			name: "untrusted signer",
+			modify: func(f chainFixture) {
+				f.options.TrustedBlocks = nil
+				f.options.TrustedSigners = []*account.Account{other.account}
+			},
+			err: fault.ProvenanceNotTrusted,
+		},
+		{
+			name: "other chain",
+			modify: func(f chainFixture) {
+				f.options.Chain = chain.Testing
+			},
+			err: fault.InvalidChain,
+		},
+		{
+			name: "local not allowed",
+			modify: func(f chainFixture) {
+				f.options.AllowLocal = false
+			},
+			err: fault.LocalChainNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		f := makeChain(t)
+		err := f.bundle.Sign(exporter.account, func(message []byte) ([]byte, error) {
+			return ed25519.Sign(exporter.privateKey, message), nil
+		})
+		if nil != err {
+			t.Fatalf("%s: sign error: %s", test.name, err)
+		}
+		test.modify(f)
+		_, err = provenance.Verify(f.bundle, f.options)
+		if test.err != err {
+			t.Errorf("%s: error: %v  expected: %v", test.name, err, test.err)
+		}
+	}
+}
+
+// headers are only trusted as far as the minimum difficulty allows
+func TestVerifyMinimumDifficulty(t *testing.T) {
+	f := makeChain(t)
+	f.bundle.Chain = chain.Testing
+	f.options.Chain = chain.Testing
+	f.options.MinimumDifficulty = 1e9
+
+	_, err := provenance.Verify(f.bundle, f.options)
+	recordError, ok := err.(*provenance.RecordError)
+	if !ok || 0 != recordError.Index || fault.InvalidBlockHeaderDifficulty != recordError.Err {
+		t.Errorf("error: %v  expected: record[0]: %s", err, fault.InvalidBlockHeaderDifficulty)
+	}
+}
+
+// conversion to shares leaves no owner until every share is redeemed
+func TestVerifyShareRedemption(t *testing.T) {
+	registrant := newKeyPair(t)
+	first := newKeyPair(t)
+	holder := newKeyPair(t)
+
+	asset := &transactionrecord.AssetData{
+		Name:        "shared asset",
+		Fingerprint: "fedcba9876543210",
+		Metadata:    "description\x00redemption",
+		Registrant:  registrant.account,
+	}
+	packedAsset := sign(t, registrant,
+		func() (transactionrecord.Packed, error) { return asset.Pack(registrant.account) },
+		func(s account.Signature) { asset.Signature = s })
+
+	issue := &transactionrecord.BitmarkIssue{
+		AssetId: asset.AssetId(),
+		Owner:   first.account,
+		Nonce:   1,
+	}
+	packedIssue := sign(t, first,
+		func() (transactionrecord.Packed, error) { return issue.Pack(first.account) },
+		func(s account.Signature) { issue.Signature = s })
+	issueId := merkle.NewDigest(packedIssue)
+
+	// every block needs at least two transactions
+	filler := &transactionrecord.BitmarkIssue{
+		AssetId: asset.AssetId(),
+		Owner:   first.account,
+		Nonce:   2,
+	}
+	packedFiller := sign(t, first,
+		func() (transactionrecord.Packed, error) { return filler.Pack(first.account) },
+		func(s account.Signature) { filler.Signature = s })
+
+	share := &transactionrecord.BitmarkShare{
+		Link:     issueId,
+		Quantity: 100,
+	}
+	packedShare := sign(t, first,
+		func() (transactionrecord.Packed, error) { return share.Pack(first.account) },
+		func(s account.Signature) { share.Signature = s })
+	shareId := merkle.NewDigest(packedShare)
+
+	redemption := &transactionrecord.ShareRedemption{
+		Link:     shareId,
+		Quantity: 100,
+		Owner:    holder.account,
+	}
+	packedRedemption := sign(t, holder,
+		func() (transactionrecord.Packed, error) { return redemption.Pack(holder.account) },
+		func(s account.Signature) { redemption.Signature = s })
+	redemptionId := merkle.NewDigest(packedRedemption)
+
+	block2 := makeBlock(2, packedAsset, packedIssue)
+	block3 := makeBlock(3, packedFiller, packedShare)
+	block5 := makeBlock(5, packedFiller, packedRedemption)
+
+	f := makeBundle(t,
+		recordSource{block5, provenance.MatchTxId(redemptionId)},
+		recordSource{block3, provenance.MatchTxId(shareId)},
+		recordSource{block2, provenance.MatchTxId(issueId)},
+		recordSource{block2, provenance.MatchAsset(asset.AssetId())},
+	)
+
+	result, err := provenance.Verify(f.bundle, f.options)
+	if nil != err {
+		t.Fatalf("verify error: %s", err)
+	}
+	if nil == result.Owner || holder.account.String() != result.Owner.String() {
+		t.Errorf("owner: %s  expected: %s", result.Owner, holder.account)
+	}
+	if redemptionId != result.TxId {
+		t.Errorf("tx id: %s  expected: %s", result.TxId, redemptionId)
+	}
+
+	// a redemption must link to the share it ends
+	f.bundle.Records = append(f.bundle.Records[:1], f.bundle.Records[2:]...)
+	_, err = provenance.Verify(f.bundle, f.options)
+	recordError, ok := err.(*provenance.RecordError)
+	if !ok || 0 != recordError.Index || fault.LinkToInvalidOrUnconfirmedTransaction != recordError.Err {
+		t.Errorf("error: %v  expected: record[0]: %s", err, fault.LinkToInvalidOrUnconfirmedTransaction)
+	}
+}
+
+// a bitmark moved by an atomic swap is followed through its leg id
+func TestVerifyAtomicSwap(t *testing.T) {
+	registrant := newKeyPair(t)
+	first := newKeyPair(t)
+	second := newKeyPair(t)
+	third := newKeyPair(t)
+
+	asset := &transactionrecord.AssetData{
+		Name:        "swapped asset",
+		Fingerprint: "0011223344556677",
+		Metadata:    "description\x00swap",
+		Registrant:  registrant.account,
+	}
+	packedAsset := sign(t, registrant,
+		func() (transactionrecord.Packed, error) { return asset.Pack(registrant.account) },
+		func(s account.Signature) { asset.Signature = s })
+
+	// the last issue is not part of the swap
+	issueIds := make([]merkle.Digest, 3)
+	packedIssues := make([]transactionrecord.Packed, 3)
+	for i, k := range []keyPair{second, first, first} {
+		issue := &transactionrecord.BitmarkIssue{
+			AssetId: asset.AssetId(),
+			Owner:   k.account,
+			Nonce:   uint64(i + 1),
+		}
+		packedIssues[i] = sign(t, k,
+			func() (transactionrecord.Packed, error) { return issue.Pack(k.account) },
+			func(s account.Signature) { issue.Signature = s })
+		issueIds[i] = merkle.NewDigest(packedIssues[i])
+	}
+
+	// the followed bitmark is the second leg
+	swap := &transactionrecord.AtomicSwap{
+		Bitmarks: []transactionrecord.SwapBitmarkLeg{
+			{Link: issueIds[0], Owner: second.account, Recipient: first.account},
+			{Link: issueIds[1], Owner: first.account, Recipient: second.account},
+		},
+		OwnerOne:    first.account,
+		OwnerTwo:    second.account,
+		BeforeBlock: 100,
+	}
+	message, _ := swap.Pack(first.account)
+	swap.Signature = ed25519.Sign(first.privateKey, message)
+	message, _ = swap.Pack(first.account)
+	swap.Countersignature = ed25519.Sign(second.privateKey, message)
+	packedSwap, err := swap.Pack(first.account)
+	if nil != err {
+		t.Fatalf("swap pack error: %s", err)
+	}
+	legId := transactionrecord.SwapLegTxId(merkle.NewDigest(packedSwap), 1)
+
+	transfer := &transactionrecord.BitmarkTransferUnratified{
+		Link:  legId,
+		Owner: third.account,
+	}
+	packedTransfer := sign(t, second,
+		func() (transactionrecord.Packed, error) { return transfer.Pack(second.account) },
+		func(s account.Signature) { transfer.Signature = s })
+	transferId := merkle.NewDigest(packedTransfer)
+
+	block2 := makeBlock(2, packedAsset, packedIssues[0], packedIssues[1], packedIssues[2])
+	block4 := makeBlock(4, packedIssues[0], packedSwap)
+	block6 := makeBlock(6, packedIssues[0], packedTransfer)
+
+	f := makeBundle(t,
+		recordSource{block6, provenance.MatchTxId(transferId)},
+		recordSource{block4, provenance.MatchSwapLeg(legId)},
+		recordSource{block2, provenance.MatchTxId(issueIds[1])},
+		recordSource{block2, provenance.MatchAsset(asset.AssetId())},
+	)
+
+	result, err := provenance.Verify(f.bundle, f.options)
+	if nil != err {
+		t.Fatalf("verify error: %s", err)
+	}
+	if nil == result.Owner || third.account.String() != result.Owner.String() {
+		t.Errorf("owner: %s  expected: %s", result.Owner, third.account)
+	}
+
+	// ending at the swap reports its recipient under the leg id
+	f.bundle.Records = f.bundle.Records[1:]
+	result, err = provenance.Verify(f.bundle, f.options)
+	if nil != err {
+		t.Fatalf("verify at swap error: %s", err)
+	}
+	if legId != result.TxId || second.account.String() != result.Owner.String() {
+		t.Errorf("tx id: %s  owner: %s  expected: %s  %s", result.TxId, result.Owner, legId, second.account)
+	}
+
+	// the other leg moves the other bitmark
+	other, err := provenance.NewRecord(block2, true, provenance.MatchTxId(issueIds[0]))
+	if nil != err {
+		t.Fatalf("new record error: %s", err)
+	}
+	f.bundle.Records[1] = *other
+	result, err = provenance.Verify(f.bundle, f.options)
+	if nil != err {
+		t.Fatalf("verify other leg error: %s", err)
+	}
+	if transactionrecord.SwapLegTxId(merkle.NewDigest(packedSwap), 0) != result.TxId || first.account.String() != result.Owner.String() {
+		t.Errorf("other leg: tx id: %s  owner: %s", result.TxId, result.Owner)
+	}
+
+	// the swap must move this bitmark
+	unrelated, err := provenance.NewRecord(block2, true, provenance.MatchTxId(issueIds[2]))
+	if nil != err {
+		t.Fatalf("new record error: %s", err)
+	}
+	f.bundle.Records[1] = *unrelated
+	_, err = provenance.Verify(f.bundle, f.options)
+	recordError, ok := err.(*provenance.RecordError)
+	if !ok || 0 != recordError.Index || fault.LinkToInvalidOrUnconfirmedTransaction != recordError.Err {
+		t.Errorf("error: %v  expected: record[0]: %s", err, fault.LinkToInvalidOrUnconfirmedTransaction)
+	}
+}
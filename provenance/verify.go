@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package provenance
+
+import (
+	"fmt"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/blockdigest"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/difficulty"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// Result - summary of a verified bundle
+type Result struct {
+	Chain     string                             `json:"chain"`
+	AssetId   *transactionrecord.AssetIdentifier `json:"assetId,omitempty"`
+	BitmarkId *merkle.Digest                     `json:"bitmarkId,omitempty"`
+	TxId      merkle.Digest                      `json:"txId"`
+	Owner     *account.Account                   `json:"owner,omitempty"`
+	Records   int                                `json:"records"`
+	Signer    *account.Account                   `json:"signer,omitempty"`
+}
+
+// RecordError - the reason a record in the bundle was rejected
+type RecordError struct {
+	Index int // position in Bundle.Records
+	Err   error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record[%d]: %s", e.Index, e.Err)
+}
+
+// Options - what the verifier trusts, nothing here is taken from the bundle
+type Options struct {
+	Chain             string               // the bundle must be for this chain
+	AllowLocal        bool                 // local chain headers have no proof of work
+	MinimumDifficulty float64              // every header must also satisfy this
+	TrustedBlocks     []blockdigest.Digest // digests of blocks known to be on the chain
+	TrustedSigners    []*account.Account   // exporters whose signature is trusted
+}
+
+// a record after its inclusion proof has been checked
+type verified struct {
+	transaction transactionrecord.Transaction
+	txId        merkle.Digest
+	blockNumber uint64
+	blockDigest blockdigest.Digest
+}
+
+// Verify - check a bundle without access to a node
+//
+// each record must be a single signed transaction included in the
+// block whose header is supplied, the headers must satisfy both their
+// own difficulty and the minimum difficulty from the options and the
+// records must form an unbroken chain from the asset or foundation to
+// the most recent owner
+//
+// the bundle is only accepted if one of its blocks is a trusted block
+// or it is signed by a trusted signer
+func Verify(bundle *Bundle, options *Options) (*Result, error) {
+
+	if Version != bundle.Version {
+		return nil, fault.InvalidProvenanceBundle
+	}
+	if !chain.Valid(bundle.Chain) || options.Chain != bundle.Chain {
+		return nil, fault.InvalidChain
+	}
+	if chain.Local == bundle.Chain && !options.AllowLocal {
+		return nil, fault.LocalChainNotAllowed
+	}
+	if 0 == len(bundle.Records) {
+		return nil, fault.InvalidProvenanceBundle
+	}
+
+	trusted := false
+	if nil != bundle.Signer {
+		err := bundle.Signer.CheckSignature(bundle.signingMessage(), bundle.Signature)
+		if nil != err {
+			return nil, err
+		}
+		for _, signer := range options.TrustedSigners {
+			if signer.String() == bundle.Signer.String() {
+				trusted = true
+			}
+		}
+	} else if 0 != len(bundle.Signature) {
+		return nil, fault.InvalidSignature
+	}
+
+	// Set keeps the minimum of one for any smaller value
+	minimum := difficulty.New()
+	minimum.Set(options.MinimumDifficulty)
+
+	testnet := chain.Bitmark != bundle.Chain
+	records := make([]verified, len(bundle.Records))
+	for i := range bundle.Records {
+		v, err := verifyRecord(&bundle.Records[i], bundle.Chain, testnet, minimum)
+		if nil != err {
+			return nil, &RecordError{Index: i, Err: err}
+		}
+		records[i] = *v
+		for _, digest := range options.TrustedBlocks {
+			if digest == v.blockDigest {
+				trusted = true
+			}
+		}
+	}
+	if !trusted {
+		return nil, fault.ProvenanceNotTrusted
+	}
+
+	result := &Result{
+		Chain:   bundle.Chain,
+		Records: len(records),
+		Signer:  bundle.Signer,
+	}
+
+	// walk the chain from the oldest record
+	i := len(records) - 1
+	var owner *account.Account
+	var previous *verified
+	foundation := false
+
+	switch tx := records[i].transaction.(type) {
+
+	case *transactionrecord.AssetData:
+		if _, err := tx.Pack(tx.Registrant); nil != err {
+			return nil, &RecordError{Index: i, Err: err}
+		}
+		assetId := tx.AssetId()
+		asset := &records[i]
+
+		i -= 1
+		if i < 0 {
+			return nil, &RecordError{Index: 0, Err: fault.InvalidProvenanceBundle}
+		}
+		issue, ok := records[i].transaction.(*transactionrecord.BitmarkIssue)
+		if !ok {
+			return nil, &RecordError{Index: i, Err: fault.TransactionIsNotAnIssue}
+		}
+		if issue.AssetId != assetId {
+			return nil, &RecordError{Index: i, Err: fault.AssetNotFound}
+		}
+		if records[i].blockNumber < asset.blockNumber {
+			return nil, &RecordError{Index: i, Err: fault.HeightOutOfSequence}
+		}
+		if _, err := issue.Pack(issue.Owner); nil != err {
+			return nil, &RecordError{Index: i, Err: err}
+		}
+		bitmarkId := records[i].txId
+		result.AssetId = &assetId
+		result.BitmarkId = &bitmarkId
+		owner = issue.Owner
+
+	case *transactionrecord.BlockFoundation:
+		if _, err := tx.Pack(tx.Owner); nil != err {
+			return nil, &RecordError{Index: i, Err: err}
+		}
+		records[i].txId = blockrecord.FoundationTxId(records[i].blockNumber, records[i].blockDigest)
+		owner = tx.Owner
+		foundation = true
+
+	case *transactionrecord.OldBaseData:
+		if _, err := tx.Pack(tx.Owner); nil != err {
+			return nil, &RecordError{Index: i, Err: err}
+		}
+		records[i].txId = blockrecord.FoundationTxId(records[i].blockNumber, records[i].blockDigest)
+		owner = tx.Owner
+		foundation = true
+
+	default:
+		return nil, &RecordError{Index: i, Err: fault.InvalidProvenanceBundle}
+	}
+	previous = &records[i]
+
+	for i -= 1; i >= 0; i -= 1 {
+		r := &records[i]
+
+		if r.blockNumber < previous.blockNumber {
+			return nil, &RecordError{Index: i, Err: fault.HeightOutOfSequence}
+		}
+
+		switch tx := r.transaction.(type) {
+
+		case *transactionrecord.ShareRedemption:
+			// returns the bitmark to the holder of every share
+			share, ok := previous.transaction.(*transactionrecord.BitmarkShare)
+			if !ok || tx.Link != previous.txId {
+				return nil, &RecordError{Index: i, Err: fault.LinkToInvalidOrUnconfirmedTransaction}
+			}
+			if tx.Quantity != share.Quantity {
+				return nil, &RecordError{Index: i, Err: fault.ShareQuantityMismatch}
+			}
+			if _, err := tx.Pack(tx.Owner); nil != err {
+				return nil, &RecordError{Index: i, Err: err}
+			}
+			owner = tx.Owner
+
+		case *transactionrecord.AtomicSwap:
+			// only the bitmark leg moving this bitmark matters, a
+			// later transfer links to that leg rather than the swap
+			if nil == owner || foundation {
+				return nil, &RecordError{Index: i, Err: fault.TransactionIsNotATransfer}
+			}
+			leg := -1
+			for j := range tx.Bitmarks {
+				if tx.Bitmarks[j].Link == previous.txId {
+					leg = j
+				}
+			}
+			if leg < 0 {
+				return nil, &RecordError{Index: i, Err: fault.LinkToInvalidOrUnconfirmedTransaction}
+			}
+			if tx.Bitmarks[leg].Owner.String() != owner.String() {
+				return nil, &RecordError{Index: i, Err: fault.InvalidOwnerOrRegistrant}
+			}
+			if _, err := tx.Pack(tx.OwnerOne); nil != err {
+				return nil, &RecordError{Index: i, Err: err}
+			}
+			r.txId = transactionrecord.SwapLegTxId(r.txId, leg)
+			owner = tx.Bitmarks[leg].Recipient
+
+		case transactionrecord.BitmarkTransfer:
+			if nil == owner {
+				return nil, &RecordError{Index: i, Err: fault.TransactionIsNotATransfer}
+			}
+			if _, isBlock := tx.(*transactionrecord.BlockOwnerTransfer); isBlock != foundation {
+				return nil, &RecordError{Index: i, Err: fault.InvalidProvenanceBundle}
+			}
+			if tx.GetLink() != previous.txId {
+				return nil, &RecordError{Index: i, Err: fault.LinkToInvalidOrUnconfirmedTransaction}
+			}
+			if _, err := tx.Pack(owner); nil != err {
+				return nil, &RecordError{Index: i, Err: err}
+			}
+
+			// nil after conversion to shares until a redemption
+			owner = tx.GetOwner()
+
+		default:
+			return nil, &RecordError{Index: i, Err: fault.TransactionIsNotATransfer}
+		}
+		previous = r
+	}
+
+	result.TxId = previous.txId
+	result.Owner = owner
+	return result, nil
+}
+
+// check a record is in the block described by its header
+func verifyRecord(r *Record, chainName string, testnet bool, minimum *difficulty.Difficulty) (*verified, error) {
+
+	transaction, n, err := r.Packed.Unpack(testnet)
+	if nil != err {
+		return nil, err
+	}
+	if n != len(r.Packed) {
+		return nil, fault.InvalidLength
+	}
+
+	packedHeader := blockrecord.PackedHeader{}
+	if len(r.Header) != len(packedHeader) {
+		return nil, fault.InvalidBlockHeaderSize
+	}
+	copy(packedHeader[:], r.Header)
+
+	header, err := packedHeader.Unpack()
+	if nil != err {
+		return nil, err
+	}
+	if header.Number != r.BlockNumber {
+		return nil, fault.InvalidProvenanceBundle
+	}
+
+	digest := packedHeader.Digest()
+	if !digest.IsValidByDifficulty(header.Difficulty, chainName) || !digest.IsValidByDifficulty(minimum, chainName) {
+		return nil, fault.InvalidBlockHeaderDifficulty
+	}
+
+	txId := merkle.NewDigest(r.Packed)
+	if merkle.RootFromPath(txId, r.Path) != header.MerkleRoot {
+		return nil, fault.MerkleRootDoesNotMatch
+	}
+
+	return &verified{
+		transaction: transaction,
+		txId:        txId,
+		blockNumber: header.Number,
+		blockDigest: digest,
+	}, nil
+}
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/constants"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// AmendmentInfo - result returned by store amendment
+type AmendmentInfo struct {
+	Id       pay.PayId
+	TxId     merkle.Digest
+	Packed   []byte
+	Payments []transactionrecord.PaymentAlternative
+}
+
+// returned data from verifyAmendment
+type verifiedAmendmentInfo struct {
+	txId             merkle.Digest
+	packed           []byte
+	assetBlockNumber uint64
+}
+
+// storeAmendment - verify and store an asset metadata amendment
+func storeAmendment(
+	amendment *transactionrecord.AssetAmendment,
+	assetHandle storage.Handle,
+	assetVersionHandle storage.Handle,
+	blockOwnerPaymentHandle storage.Handle,
+	transactionHandle storage.Handle,
+) (*AmendmentInfo, bool, error) {
+	if nil == assetHandle || nil == assetVersionHandle || nil == blockOwnerPaymentHandle || nil == transactionHandle {
+		return nil, false, fault.NilPointer
+	}
+
+	err := checkBlockVersion(amendment)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// typed metadata must match its schema, peers' amendments arrive
+	// here too so this cannot be left to the RPC
+	err = asset.ValidateMetadata(amendment.Metadata)
+	if nil != err {
+		return nil, false, err
+	}
+
+	globalData.Lock()
+	defer globalData.Unlock()
+
+	verifyResult, duplicate, err := verifyAmendment(amendment, assetHandle, assetVersionHandle, transactionHandle)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// compute pay id
+	packedAmendment := verifyResult.packed
+	payId := pay.NewPayId([][]byte{packedAmendment})
+
+	txId := verifyResult.txId
+
+	// there is no transfer so the registration block owner
+	// receives both fees
+	payments := getPayments(0, verifyResult.assetBlockNumber, nil, blockOwnerPaymentHandle)
+
+	result := &AmendmentInfo{
+		Id:       payId,
+		TxId:     txId,
+		Packed:   packedAmendment,
+		Payments: payments,
+	}
+
+	// if already seen just return pay id and previous payments if present
+	entry, ok := globalData.pendingTransactions[payId]
+	if ok {
+		if nil != entry.payments {
+			result.Payments = entry.payments
+		} else {
+			// this would mean that reservoir data is corrupt
+			logger.Panicf("storeAmendment: failed to get current payment data for: %s  payid: %s", txId, payId)
+		}
+		return result, true, nil
+	}
+
+	// if duplicates were detected, but different duplicates were present
+	// then it is an error
+	if duplicate {
+		return nil, true, fault.TransactionAlreadyExists
+	}
+
+	amendmentItem := &transactionData{
+		txId:        txId,
+		transaction: amendment,
+		packed:      packedAmendment,
+		received:    time.Now(),
+	}
+
+	// already received the payment for the amendment
+	// approve the amendment immediately if payment is ok
+	detail, ok := globalData.orphanPayments[payId]
+	if ok || globalData.autoVerify {
+		if acceptablePayment(detail, payments) {
+			globalData.verifiedTransactions[payId] = amendmentItem
+			globalData.verifiedIndex[txId] = payId
+			delete(globalData.pendingTransactions, payId)
+			delete(globalData.pendingIndex, txId)
+			delete(globalData.orphanPayments, payId)
+			statusChanged()
+
+			globalData.inProgressAssets[amendment.AssetId] = txId
+			journalTransaction(result.Packed)
+			return result, false, nil
+		}
+	}
+
+	// waiting for the payment to come
+	payment := &transactionPaymentData{
+		payId:     payId,
+		tx:        amendmentItem,
+		payments:  payments,
+		expiresAt: time.Now().Add(constants.ReservoirTimeout),
+	}
+
+	if len(globalData.pendingTransactions) >= maximumPendingTransactions {
+		return nil, false, fault.BufferCapacityLimit
+	}
+
+	globalData.pendingTransactions[payId] = payment
+	globalData.pendingIndex[txId] = payId
+	statusChanged()
+	globalData.inProgressAssets[amendment.AssetId] = txId
+
+	journalTransaction(result.Packed)
+	return result, false, nil
+}
+
+// verify that an amendment is ok
+// ensure lock is held before calling
+func verifyAmendment(
+	amendment *transactionrecord.AssetAmendment,
+	assetHandle storage.Handle,
+	assetVersionHandle storage.Handle,
+	transactionHandle storage.Handle,
+) (*verifiedAmendmentInfo, bool, error) {
+
+	assetBlockNumber, err := asset.CheckAmendment(nil, amendment, assetHandle, assetVersionHandle)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// pack amendment and check signature
+	packedAmendment, err := amendment.Pack(amendment.Registrant)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// transfer identifier and check for duplicate
+	txId := packedAmendment.MakeLink()
+
+	// only one amendment of an asset can be pending at a time
+	pendingTxId, ok := globalData.inProgressAssets[amendment.AssetId]
+	if ok && pendingTxId != txId {
+		return nil, false, fault.AssetAmendmentIsPending
+	}
+
+	// check for double spend
+	_, okP := globalData.pendingIndex[txId]
+	_, okV := globalData.verifiedIndex[txId]
+
+	duplicate := false
+	if okP {
+		// if both then it is a possible duplicate
+		// (depends on later pay id check)
+		duplicate = true
+	}
+
+	// a single verified transfer fails the whole block
+	if okV {
+		return nil, false, fault.TransactionAlreadyExists
+	}
+	// a single confirmed transfer fails the whole block
+	if transactionHandle.Has(txId[:]) {
+		return nil, false, fault.TransactionAlreadyExists
+	}
+
+	result := &verifiedAmendmentInfo{
+		txId:             txId,
+		packed:           packedAmendment,
+		assetBlockNumber: assetBlockNumber,
+	}
+	return result, duplicate, nil
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// sign an amendment by the given private key
+func signAmendment(t *testing.T, a *transactionrecord.AssetAmendment, key []byte) (merkle.Digest, transactionrecord.Packed) {
+	packed, err := a.Pack(a.Registrant)
+	if fault.InvalidSignature != err {
+		t.Fatalf("amendment pack error: %s", err)
+	}
+	a.Signature = ed25519.Sign(key, packed)
+	packed, err = a.Pack(a.Registrant)
+	if nil != err {
+		t.Fatalf("signed amendment pack error: %s", err)
+	}
+	return packed.MakeLink(), packed
+}
+
+func TestAssetAmendment(t *testing.T) {
+	setup(t, chain.Testing)
+	defer teardown()
+
+	// another test may have left the reservoir running
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Assets:            storage.Pool.Assets,
+		AssetVersions:     storage.Pool.AssetVersions,
+		Transactions:      storage.Pool.Transactions,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+	}, false)
+	assert.Nil(t, err, "reservoir initialise")
+	defer reservoir.Finalise()
+
+	rsvr := reservoir.Get()
+
+	amend := func(version uint64, metadata string, registrant *account.Account, key []byte) (*transactionrecord.AssetAmendment, merkle.Digest, transactionrecord.Packed) {
+		a := &transactionrecord.AssetAmendment{
+			AssetId:    assetID,
+			Version:    version,
+			Metadata:   metadata,
+			Registrant: registrant,
+		}
+		txId, packed := signAmendment(t, a, key)
+		return a, txId, packed
+	}
+
+	// the asset must be confirmed before it can be amended
+	a, _, _ := amend(1, "owner\x00amended", &owner, privateKey)
+	_, _, err = rsvr.StoreAmendment(a)
+	assert.Equal(t, fault.AssetNotFound, err, "amend unconfirmed asset")
+
+	// asset registered in block 1
+	packedAsset, err := assetData.Pack(&owner)
+	assert.Nil(t, err, "asset pack")
+	blockNumberKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumberKey, 1)
+
+	trx, _ := storage.NewDBTransaction()
+	payments, _ := currencyMap.Pack(true)
+	trx.Put(storage.Pool.BlockOwnerPayment, blockNumberKey, payments, []byte{})
+	trx.Put(storage.Pool.Assets, assetID[:], blockNumberKey, packedAsset)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	// only the registrant can amend
+	a, _, _ = amend(1, "owner\x00amended", &owner2, privateKey2)
+	_, _, err = rsvr.StoreAmendment(a)
+	assert.Equal(t, fault.InvalidOwnerOrRegistrant, err, "amend by other account")
+
+	// versions cannot be skipped
+	a, _, _ = amend(2, "owner\x00amended", &owner, privateKey)
+	_, _, err = rsvr.StoreAmendment(a)
+	assert.Equal(t, fault.AssetVersionOutOfSequence, err, "skip a version")
+
+	// amended metadata must match its schema like a registration
+	_, err = asset.RegisterSchema([]byte(`{"name": "artwork", "required": ["artist"], "properties": {"artist": {"type": "string"}}}`))
+	assert.Nil(t, err, "register schema")
+	defer asset.ClearSchemas()
+
+	a, _, _ = amend(1, "_schema\x00artwork\x00year\x002020", &owner, privateKey)
+	_, _, err = rsvr.StoreAmendment(a)
+	assert.Equal(t, fault.MissingRequiredMetadata, err, "amend with metadata failing schema")
+
+	a, amendTxId, packedAmendment := amend(1, "owner\x00amended", &owner, privateKey)
+	info, duplicate, err := rsvr.StoreAmendment(a)
+	assert.Nil(t, err, "amend")
+	assert.False(t, duplicate, "first amendment is a duplicate")
+	assert.Equal(t, amendTxId, info.TxId, "tx id")
+	assert.Equal(t, 2, len(info.Payments), "payment currencies")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(amendTxId), "state")
+
+	_, duplicate, err = rsvr.StoreAmendment(a)
+	assert.Nil(t, err, "repeat amendment")
+	assert.True(t, duplicate, "repeat amendment is not a duplicate")
+
+	// a second amendment must wait for the first to confirm
+	other, _, _ := amend(1, "owner\x00other", &owner, privateKey)
+	_, _, err = rsvr.StoreAmendment(other)
+	assert.Equal(t, fault.AssetAmendmentIsPending, err, "second pending amendment")
+
+	// confirm the amendment in block 2
+	reservoir.Disable()
+	reservoir.ClearSpend()
+	reservoir.DeleteByTxId(amendTxId)
+
+	binary.BigEndian.PutUint64(blockNumberKey, 2)
+	trx, _ = storage.NewDBTransaction()
+	trx.Put(storage.Pool.Transactions, amendTxId[:], blockNumberKey, packedAmendment)
+	trx.Put(storage.Pool.AssetVersions, asset.VersionKey(assetID, 1), blockNumberKey, packedAmendment)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	reservoir.Rescan()
+	reservoir.Enable()
+
+	assert.Equal(t, reservoir.StateConfirmed, rsvr.TransactionStatus(amendTxId), "state")
+
+	// the original asset is unchanged
+	_, stored := storage.Pool.Assets.GetNB(assetID[:])
+	assert.Equal(t, []byte(packedAsset), stored, "original asset was changed")
+
+	amendments, err := asset.Amendments(assetID, storage.Pool.AssetVersions)
+	assert.Nil(t, err, "amendments")
+	assert.Equal(t, 1, len(amendments), "amendment count")
+	assert.Equal(t, uint64(1), amendments[0].Version, "version")
+	assert.Equal(t, uint64(2), amendments[0].BlockNumber, "block number")
+	assert.Equal(t, amendTxId, amendments[0].TxId, "amendment tx id")
+	assert.Equal(t, "owner\x00amended", amendments[0].Metadata, "metadata")
+
+	// the confirmed version cannot be reused but the next one is free
+	_, _, err = rsvr.StoreAmendment(other)
+	assert.Equal(t, fault.AssetVersionOutOfSequence, err, "reuse a version")
+
+	a, _, _ = amend(2, "owner\x00again", &owner, privateKey)
+	_, _, err = rsvr.StoreAmendment(a)
+	assert.Nil(t, err, "amend next version")
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/blockheader"
+	"github.com/bitmark-inc/bitmarkd/constants"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// AtomicSwapInfo - result returned by store atomic swap
+type AtomicSwapInfo struct {
+	Id       pay.PayId
+	TxId     merkle.Digest
+	Packed   []byte
+	Payments []transactionrecord.PaymentAlternative
+}
+
+// returned data from verifyAtomicSwap
+type verifiedAtomicSwapInfo struct {
+	balances  []uint64
+	txId      merkle.Digest
+	packed    []byte
+	ownerData []ownership.OwnerData // one for each fee to be paid
+}
+
+// storeAtomicSwap - verify and store an atomic swap request
+func storeAtomicSwap(
+	swap *transactionrecord.AtomicSwap,
+	shareQuantityHandle storage.Handle,
+	shareHandle storage.Handle,
+	ownerTxHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+	blockOwnerPaymentHandle storage.Handle,
+	transactionHandle storage.Handle,
+) (*AtomicSwapInfo, bool, error) {
+	if nil == shareQuantityHandle || nil == shareHandle || nil == ownerTxHandle || nil == ownerDataHandle || nil == blockOwnerPaymentHandle || nil == transactionHandle {
+		return nil, false, fault.NilPointer
+	}
+
+	err := checkBlockVersion(swap)
+	if nil != err {
+		return nil, false, err
+	}
+
+	globalData.Lock()
+	defer globalData.Unlock()
+
+	verifyResult, duplicate, err := verifyAtomicSwap(swap, shareQuantityHandle, shareHandle, ownerTxHandle, ownerDataHandle, transactionHandle)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// compute pay id
+	packedSwap := verifyResult.packed
+	payId := pay.NewPayId([][]byte{packedSwap})
+
+	txId := verifyResult.txId
+
+	// every bitmark leg pays its own transfer fee
+	payments := []transactionrecord.PaymentAlternative(nil)
+	for _, ownerData := range verifyResult.ownerData {
+		legPayments := getPayments(ownerData.TransferBlockNumber(), ownerData.IssueBlockNumber(), nil, blockOwnerPaymentHandle)
+		payments = mergePayments(payments, legPayments)
+	}
+
+	result := &AtomicSwapInfo{
+		Id:       payId,
+		TxId:     txId,
+		Packed:   packedSwap,
+		Payments: payments,
+	}
+
+	// if already seen just return pay id and previous payments if present
+	entry, ok := globalData.pendingTransactions[payId]
+	if ok {
+		if nil != entry.payments {
+			result.Payments = entry.payments
+		} else {
+			// this would mean that reservoir data is corrupt
+			logger.Panicf("storeAtomicSwap: failed to get current payment data for: %s  payid: %s", txId, payId)
+		}
+		return result, true, nil
+	}
+
+	// if duplicates were detected, but different duplicates were present
+	// then it is an error
+	if duplicate {
+		return nil, true, fault.TransactionAlreadyExists
+	}
+
+	// shares already spent by other pending records cannot be swapped
+	for i, leg := range swap.Shares {
+		spendKey := makeSpendKey(leg.Owner, leg.ShareId)
+		if verifyResult.balances[i]-globalData.spend[spendKey] < leg.Quantity {
+			return nil, false, fault.InsufficientShares
+		}
+	}
+
+	swapItem := &transactionData{
+		txId:        txId,
+		transaction: swap,
+		packed:      packedSwap,
+		received:    time.Now(),
+	}
+
+	// already received the payment for the swap
+	// approve the swap immediately if payment is ok
+	detail, ok := globalData.orphanPayments[payId]
+	if ok || globalData.autoVerify {
+		if acceptablePayment(detail, payments) {
+			globalData.verifiedTransactions[payId] = swapItem
+			globalData.verifiedIndex[txId] = payId
+			delete(globalData.pendingTransactions, payId)
+			delete(globalData.pendingIndex, txId)
+			delete(globalData.orphanPayments, payId)
+			statusChanged()
+
+			reserveAtomicSwap(txId, swap)
+			journalTransaction(result.Packed)
+			return result, false, nil
+		}
+	}
+
+	// waiting for the payment to come
+	payment := &transactionPaymentData{
+		payId:     payId,
+		tx:        swapItem,
+		payments:  payments,
+		expiresAt: time.Now().Add(constants.ReservoirTimeout),
+	}
+
+	if len(globalData.pendingTransactions) >= maximumPendingTransactions {
+		return nil, false, fault.BufferCapacityLimit
+	}
+
+	globalData.pendingTransactions[payId] = payment
+	globalData.pendingIndex[txId] = payId
+	statusChanged()
+	reserveAtomicSwap(txId, swap)
+
+	journalTransaction(result.Packed)
+	return result, false, nil
+}
+
+// mark the bitmarks and shares of a swap as in use
+// ensure lock is held before calling
+func reserveAtomicSwap(txId merkle.Digest, swap *transactionrecord.AtomicSwap) {
+	for _, leg := range swap.Bitmarks {
+		globalData.inProgressLinks[leg.Link] = txId
+	}
+	for _, leg := range swap.Shares {
+		spendKey := makeSpendKey(leg.Owner, leg.ShareId)
+		globalData.spend[spendKey] += leg.Quantity
+	}
+}
+
+// CheckAtomicSwap - check that every leg of a swap can be applied
+//
+// each bitmark must still be owned by its sender and each share grant
+// must be covered by the sender's balance, returns the balance of the
+// sender of each share leg
+func CheckAtomicSwap(
+	trx storage.Transaction,
+	swap *transactionrecord.AtomicSwap,
+	shareQuantityHandle storage.Handle,
+	ownerTxHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+) ([]uint64, error) {
+	if nil == shareQuantityHandle || nil == ownerTxHandle || nil == ownerDataHandle {
+		return nil, fault.NilPointer
+	}
+
+	for _, leg := range swap.Bitmarks {
+		ownerData, err := CheckTransferOwner(trx, leg.Link, leg.Owner, ownerTxHandle, ownerDataHandle)
+		if nil != err {
+			return nil, err
+		}
+		switch ownerData.(type) {
+		case *ownership.AssetOwnerData:
+			// only an asset can be swapped
+		case *ownership.ShareOwnerData:
+			return nil, fault.CannotConvertSharesBackToAssets
+		default:
+			return nil, fault.LinkToInvalidOrUnconfirmedTransaction
+		}
+	}
+
+	balances := make([]uint64, len(swap.Shares))
+	for i, leg := range swap.Shares {
+		grant := &transactionrecord.ShareGrant{
+			ShareId:   leg.ShareId,
+			Quantity:  leg.Quantity,
+			Owner:     leg.Owner,
+			Recipient: leg.Recipient,
+		}
+		balance, err := CheckGrantBalance(trx, grant, shareQuantityHandle)
+		if nil != err {
+			return nil, err
+		}
+		balances[i] = balance
+	}
+
+	return balances, nil
+}
+
+// verify that an atomic swap is ok
+// ensure lock is held before calling
+func verifyAtomicSwap(
+	swap *transactionrecord.AtomicSwap,
+	shareQuantityHandle storage.Handle,
+	shareHandle storage.Handle,
+	ownerTxHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+	transactionHandle storage.Handle,
+) (*verifiedAtomicSwapInfo, bool, error) {
+
+	height := blockheader.Height()
+	if swap.BeforeBlock <= height {
+		return nil, false, fault.RecordHasExpired
+	}
+
+	balances, err := CheckAtomicSwap(nil, swap, shareQuantityHandle, ownerTxHandle, ownerDataHandle)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// pack swap and check both signatures
+	packedSwap, err := swap.Pack(swap.OwnerOne)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// transfer identifier and check for duplicate
+	txId := packedSwap.MakeLink()
+
+	// a bitmark cannot be in two different pending records
+	for _, leg := range swap.Bitmarks {
+		linkTxId, ok := globalData.inProgressLinks[leg.Link]
+		if ok && linkTxId != txId {
+			return nil, false, fault.DoubleTransferAttempt
+		}
+	}
+
+	// check for double spend
+	_, okP := globalData.pendingIndex[txId]
+	_, okV := globalData.verifiedIndex[txId]
+
+	duplicate := false
+	if okP {
+		// if both then it is a possible duplicate
+		// (depends on later pay id check)
+		duplicate = true
+	}
+
+	// a single verified transfer fails the whole block
+	if okV {
+		return nil, false, fault.TransactionAlreadyExists
+	}
+	// a single confirmed transfer fails the whole block
+	if transactionHandle.Has(txId[:]) {
+		return nil, false, fault.TransactionAlreadyExists
+	}
+
+	// a fee is charged for each bitmark leg, a swap of only shares
+	// is charged once on the first share whose owner data is under
+	// the tx id of the share record
+	ownerData := make([]ownership.OwnerData, 0, len(swap.Bitmarks))
+	for _, leg := range swap.Bitmarks {
+		legData, err := ownership.GetOwnerData(nil, leg.Link, ownerDataHandle)
+		if nil != err {
+			return nil, false, fault.DoubleTransferAttempt
+		}
+		ownerData = append(ownerData, legData)
+	}
+	if 0 == len(ownerData) {
+		_, shareTxId := shareHandle.GetNB(swap.Shares[0].ShareId[:])
+		if nil == shareTxId {
+			return nil, false, fault.DoubleTransferAttempt
+		}
+		shareData, err := ownership.GetOwnerDataB(nil, shareTxId, ownerDataHandle)
+		if nil != err {
+			return nil, false, fault.DoubleTransferAttempt
+		}
+		ownerData = append(ownerData, shareData)
+	}
+
+	result := &verifiedAtomicSwapInfo{
+		balances:  balances,
+		txId:      txId,
+		packed:    packedSwap,
+		ownerData: ownerData,
+	}
+	return result, duplicate, nil
+}
@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// sign an atomic swap by both parties
+func signAtomicSwap(t *testing.T, swap *transactionrecord.AtomicSwap) (transactionrecord.Packed, merkle.Digest) {
+	packed, err := swap.Pack(swap.OwnerOne)
+	if fault.InvalidSignature != err {
+		t.Fatalf("swap pack error: %s", err)
+	}
+	swap.Signature = ed25519.Sign(privateKey, packed)
+	packed, err = swap.Pack(swap.OwnerOne)
+	if fault.InvalidSignature != err {
+		t.Fatalf("signed swap pack error: %s", err)
+	}
+	swap.Countersignature = ed25519.Sign(privateKey2, packed)
+	packed, err = swap.Pack(swap.OwnerOne)
+	if nil != err {
+		t.Fatalf("countersigned swap pack error: %s", err)
+	}
+	return packed, packed.MakeLink()
+}
+
+func TestAtomicSwap(t *testing.T) {
+	setup(t, chain.Testing)
+	defer teardown()
+
+	// another test may have left the reservoir running
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Transactions:      storage.Pool.Transactions,
+		OwnerTxIndex:      storage.Pool.OwnerTxIndex,
+		OwnerData:         storage.Pool.OwnerData,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+		Shares:            storage.Pool.Shares,
+		ShareQuantity:     storage.Pool.ShareQuantity,
+		ShareHolders:      storage.Pool.ShareHolders,
+	}, false)
+	assert.Nil(t, err, "reservoir initialise")
+	defer reservoir.Finalise()
+
+	rsvr := reservoir.Get()
+
+	// first owner holds a bitmark, second owner holds the shares of another
+	issueTxId := merkle.Digest{3, 3, 3}
+	otherIssueTxId := merkle.Digest{4, 4, 4}
+	shareTxId := merkle.Digest{5, 5, 5}
+	shareId := otherIssueTxId
+
+	trx, _ := storage.NewDBTransaction()
+	payments, _ := currencyMap.Pack(true)
+	for bn := uint64(1); bn <= 3; bn += 1 {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, bn)
+		trx.Put(storage.Pool.BlockOwnerPayment, key, payments, []byte{})
+	}
+	ownership.CreateAsset(trx, issueTxId, 1, assetID, &owner)
+	ownership.CreateAsset(trx, otherIssueTxId, 1, assetID, &owner2)
+	ownership.Share(trx, otherIssueTxId, shareTxId, 2, &owner2, shareQuantity)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	swapFor := func(quantity uint64) *transactionrecord.AtomicSwap {
+		return &transactionrecord.AtomicSwap{
+			Bitmarks: []transactionrecord.SwapBitmarkLeg{
+				{
+					Link:      issueTxId,
+					Owner:     &owner,
+					Recipient: &owner2,
+				},
+			},
+			Shares: []transactionrecord.SwapShareLeg{
+				{
+					ShareId:   shareId,
+					Quantity:  quantity,
+					Owner:     &owner2,
+					Recipient: &owner,
+				},
+			},
+			OwnerOne:    &owner,
+			OwnerTwo:    &owner2,
+			BeforeBlock: 100,
+		}
+	}
+
+	// the share leg must be covered by the balance
+	swap := swapFor(shareQuantity + 1)
+	signAtomicSwap(t, swap)
+	_, _, err = rsvr.StoreAtomicSwap(swap)
+	assert.Equal(t, fault.InsufficientShares, err, "swap more than the balance")
+
+	// the bitmark leg must come from its current owner
+	swap = swapFor(10)
+	swap.Bitmarks[0].Link = otherIssueTxId
+	signAtomicSwap(t, swap)
+	_, _, err = rsvr.StoreAtomicSwap(swap)
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "swap a bitmark not owned")
+
+	swap = swapFor(10)
+	packed, swapTxId := signAtomicSwap(t, swap)
+	info, duplicate, err := rsvr.StoreAtomicSwap(swap)
+	assert.Nil(t, err, "swap")
+	assert.False(t, duplicate, "first swap is a duplicate")
+	assert.Equal(t, swapTxId, info.TxId, "tx id")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(swapTxId), "state")
+
+	_, duplicate, err = rsvr.StoreAtomicSwap(swap)
+	assert.Nil(t, err, "repeat swap")
+	assert.True(t, duplicate, "repeat swap is not a duplicate")
+
+	// the bitmark is held by the pending swap
+	other := swapFor(20)
+	signAtomicSwap(t, other)
+	_, _, err = rsvr.StoreAtomicSwap(other)
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "bitmark in two swaps")
+
+	// confirm the swap as block store would
+	legTxId := transactionrecord.SwapLegTxId(swapTxId, 0)
+	blockNumberKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumberKey, 3)
+
+	reservoir.Disable()
+	reservoir.ClearSpend()
+	trx, _ = storage.NewDBTransaction()
+	trx.Put(storage.Pool.Transactions, swapTxId[:], blockNumberKey, packed)
+	trx.Put(storage.Pool.Transactions, legTxId[:], blockNumberKey, packed)
+	ownership.Transfer(trx, issueTxId, legTxId, 3, &owner, &owner2)
+	ownership.SetShareBalance(trx, &owner2, shareId, shareQuantity-10)
+	ownership.SetShareBalance(trx, &owner, shareId, 10)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+	reservoir.DeleteByTxId(swapTxId)
+	reservoir.Rescan()
+	reservoir.Enable()
+	assert.Equal(t, reservoir.StateConfirmed, rsvr.TransactionStatus(swapTxId), "swap not confirmed")
+
+	blockNumber, legOwner := ownership.OwnerOf(nil, legTxId)
+	assert.Equal(t, uint64(3), blockNumber, "leg block number")
+	assert.Equal(t, owner2.String(), legOwner.String(), "leg owner")
+
+	// the recipient can transfer the bitmark on through the leg id
+	transfer := &transactionrecord.BitmarkTransferUnratified{
+		Link:  legTxId,
+		Owner: &owner,
+	}
+	transferPacked, _ := transfer.Pack(&owner2)
+	transfer.Signature = ed25519.Sign(privateKey2, transferPacked)
+	_, _, err = rsvr.StoreTransfer(transfer)
+	assert.Nil(t, err, "transfer after swap")
+}
+
+func TestAtomicSwapFeeForEachBitmark(t *testing.T) {
+	setup(t, chain.Testing)
+	defer teardown()
+
+	// another test may have left the reservoir running
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Transactions:      storage.Pool.Transactions,
+		OwnerTxIndex:      storage.Pool.OwnerTxIndex,
+		OwnerData:         storage.Pool.OwnerData,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+		Shares:            storage.Pool.Shares,
+		ShareQuantity:     storage.Pool.ShareQuantity,
+		ShareHolders:      storage.Pool.ShareHolders,
+	}, false)
+	assert.Nil(t, err, "reservoir initialise")
+	defer reservoir.Finalise()
+
+	rsvr := reservoir.Get()
+
+	// first owner holds three bitmarks, second owner holds shares
+	issueTxIds := []merkle.Digest{{3, 3, 3}, {6, 6, 6}, {7, 7, 7}}
+	otherIssueTxId := merkle.Digest{4, 4, 4}
+	shareTxId := merkle.Digest{5, 5, 5}
+
+	trx, _ := storage.NewDBTransaction()
+	payments, _ := currencyMap.Pack(true)
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, 1)
+	trx.Put(storage.Pool.BlockOwnerPayment, key, payments, []byte{})
+	for _, issueTxId := range issueTxIds {
+		ownership.CreateAsset(trx, issueTxId, 1, assetID, &owner)
+	}
+	ownership.CreateAsset(trx, otherIssueTxId, 1, assetID, &owner2)
+	ownership.Share(trx, otherIssueTxId, shareTxId, 1, &owner2, shareQuantity)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	swapOf := func(links ...merkle.Digest) *transactionrecord.AtomicSwap {
+		swap := &transactionrecord.AtomicSwap{
+			Shares: []transactionrecord.SwapShareLeg{
+				{
+					ShareId:   otherIssueTxId,
+					Quantity:  10,
+					Owner:     &owner2,
+					Recipient: &owner,
+				},
+			},
+			OwnerOne:    &owner,
+			OwnerTwo:    &owner2,
+			BeforeBlock: 100,
+		}
+		for _, link := range links {
+			swap.Bitmarks = append(swap.Bitmarks, transactionrecord.SwapBitmarkLeg{
+				Link:      link,
+				Owner:     &owner,
+				Recipient: &owner2,
+			})
+		}
+		signAtomicSwap(t, swap)
+		return swap
+	}
+
+	// the fee of a single bitmark from the same block
+	single, _, err := rsvr.StoreAtomicSwap(swapOf(issueTxIds[2]))
+	assert.Nil(t, err, "single swap")
+
+	oneFee := make(map[string]uint64)
+	for _, alternative := range single.Payments {
+		if currency.Litecoin == alternative[0].Currency {
+			for _, p := range alternative {
+				oneFee[p.Address] += p.Amount
+			}
+		}
+	}
+	assert.NotEmpty(t, oneFee, "single fee")
+	twoFees := make(map[string]uint64)
+	for address, amount := range oneFee {
+		twoFees[address] = 2 * amount
+	}
+
+	info, _, err := rsvr.StoreAtomicSwap(swapOf(issueTxIds[0], issueTxIds[1]))
+	assert.Nil(t, err, "swap")
+
+	// paying for one bitmark leaves the swap pending
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "one-fee",
+		Amounts:  oneFee,
+	})
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state after one fee")
+
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "both-fees",
+		Amounts:  twoFees,
+	})
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "state after both fees")
+}
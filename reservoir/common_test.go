@@ -14,6 +14,7 @@ import (
 
 	"github.com/bitmark-inc/bitmarkd/block"
 	"github.com/bitmark-inc/bitmarkd/blockheader"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
 	"github.com/bitmark-inc/bitmarkd/chain"
 	"github.com/bitmark-inc/bitmarkd/mode"
 	"github.com/bitmark-inc/bitmarkd/reservoir/mocks"
@@ -82,6 +83,10 @@ func setup(t *testing.T, theChain ...string) {
 	if nil != err {
 		t.Fatalf("block initialise error: %s", err)
 	}
+
+	// chain is at the current block version so all records are allowed
+	height, digest, _, timestamp := blockheader.Get()
+	blockheader.Set(height, digest, blockrecord.Version, timestamp)
 }
 
 // post test cleanup
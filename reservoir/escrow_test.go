@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+const escrowAddress = "mmCKZS7toE69QgXNs1JZcjW6LFj8LfUbz6"
+
+// issue the test asset to the first owner in block 1 and start the
+// reservoir
+func setupEscrow(t *testing.T, autoVerify bool) reservoir.Reservoir {
+	setup(t, chain.Testing)
+
+	// another test may have left the reservoir running
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Transactions:      storage.Pool.Transactions,
+		OwnerTxIndex:      storage.Pool.OwnerTxIndex,
+		OwnerData:         storage.Pool.OwnerData,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+	}, autoVerify)
+	assert.Nil(t, err, "reservoir initialise")
+
+	blockNumberKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumberKey, 1)
+
+	packedIssue, err := assetIssuance.Pack(&owner)
+	assert.Nil(t, err, "issue pack")
+
+	trx, _ := storage.NewDBTransaction()
+	payments, _ := currencyMap.Pack(true)
+	trx.Put(storage.Pool.BlockOwnerPayment, blockNumberKey, payments, []byte{})
+	trx.Put(storage.Pool.Transactions, assetTxID[:], blockNumberKey, packedIssue)
+	ownership.CreateAsset(trx, assetTxID, 1, assetID, &owner)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	return reservoir.Get()
+}
+
+// a transfer of the test issue carrying an escrow
+func escrowTransfer(t *testing.T, escrow *transactionrecord.Payment) *transactionrecord.BitmarkTransferUnratified {
+	transfer := &transactionrecord.BitmarkTransferUnratified{
+		Link:   assetTxID,
+		Escrow: escrow,
+		Owner:  &owner2,
+	}
+	packed, err := transfer.Pack(&owner)
+	if fault.InvalidSignature != err {
+		t.Fatalf("transfer pack error: %s", err)
+	}
+	transfer.Signature = ed25519.Sign(privateKey, packed)
+	return transfer
+}
+
+func TestTransferEscrow(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	escrow := &transactionrecord.Payment{
+		Currency: currency.Litecoin,
+		Address:  escrowAddress,
+		Amount:   250000,
+	}
+
+	info, duplicate, err := rsvr.StoreTransfer(escrowTransfer(t, escrow))
+	assert.Nil(t, err, "store transfer")
+	assert.False(t, duplicate, "first transfer is a duplicate")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state")
+
+	// only the escrow currency can be used and the escrow is included
+	assert.Equal(t, 1, len(info.Payments), "payment alternatives")
+	alternative := info.Payments[0]
+	assert.Equal(t, escrow, alternative[len(alternative)-1], "escrow payment")
+	assert.NotNil(t, info.Escrow, "escrow info")
+	assert.False(t, info.Escrow.Paid, "escrow paid")
+	assert.NotNil(t, info.Escrow.ExpiresAt, "escrow expiry")
+
+	// repeated submission reports the same escrow
+	repeat, duplicate, err := rsvr.StoreTransfer(escrowTransfer(t, escrow))
+	assert.Nil(t, err, "repeat transfer")
+	assert.True(t, duplicate, "repeat transfer is not a duplicate")
+	assert.Equal(t, info.Escrow.ExpiresAt, repeat.Escrow.ExpiresAt, "repeat expiry")
+
+	fees := make(map[string]uint64)
+	for _, p := range alternative[:len(alternative)-1] {
+		fees[p.Address] += p.Amount
+	}
+
+	// paying only the fees leaves the transfer pending
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "fees-only",
+		Amounts:  fees,
+	})
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state after fees")
+
+	// short escrow leaves the transfer pending
+	short := make(map[string]uint64)
+	for a, v := range fees {
+		short[a] = v
+	}
+	short[escrowAddress] = escrow.Amount - 1
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "short-escrow",
+		Amounts:  short,
+	})
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state after short escrow")
+
+	// fees and escrow verify the transfer
+	short[escrowAddress] = escrow.Amount
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "full-escrow",
+		Amounts:  short,
+	})
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "state after escrow")
+}
+
+func TestTransferEscrowNotAutoVerified(t *testing.T) {
+	rsvr := setupEscrow(t, true)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	escrow := &transactionrecord.Payment{
+		Currency: currency.Bitcoin,
+		Address:  "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn",
+		Amount:   10000,
+	}
+
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, escrow))
+	assert.Nil(t, err, "store transfer")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "escrow auto verified")
+	assert.Equal(t, currency.Bitcoin, info.Payments[0][0].Currency, "payment currency")
+	assert.False(t, info.Escrow.Paid, "escrow paid")
+}
+
+// an escrow to a fee address must be paid on top of the fee
+func TestTransferEscrowSameAddressAsFee(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	escrow := &transactionrecord.Payment{
+		Currency: currency.Litecoin,
+		Address:  currencyMap[currency.Litecoin],
+		Amount:   250000,
+	}
+
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, escrow))
+	assert.Nil(t, err, "store transfer")
+
+	total := make(map[string]uint64)
+	for _, p := range info.Payments[0] {
+		total[p.Address] += p.Amount
+	}
+	assert.True(t, total[escrow.Address] > escrow.Amount, "escrow address has no fee")
+
+	// each amount alone is not enough
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "largest-amount-only",
+		Amounts:  map[string]uint64{escrow.Address: total[escrow.Address] - 1},
+	})
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state after partial payment")
+
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "fee-and-escrow",
+		Amounts:  total,
+	})
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "state after fee and escrow")
+}
@@ -51,6 +51,7 @@ func (c *cleaner) deleteExpiredItems() {
 			internalDelete(key)
 		}
 	}
+	statusChanged()
 	globalData.Unlock()
 }
 
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// queue names of reservoir items
+const (
+	QueueFreeIssue  = "freeIssue"
+	QueuePaidIssue  = "paidIssue"
+	QueueTransfer   = "transfer"
+	QueueGrant      = "grant"
+	QueueSwap       = "swap"
+	QueueRedemption = "redemption"
+	QueueAmendment  = "amendment"
+)
+
+// IsQueue - check for a valid queue name
+func IsQueue(queue string) bool {
+	switch queue {
+	case QueueFreeIssue, QueuePaidIssue, QueueTransfer, QueueGrant, QueueSwap, QueueRedemption, QueueAmendment:
+		return true
+	default:
+		return false
+	}
+}
+
+// Item - summary of the records on one pay id
+type Item struct {
+	Queue     string                                 `json:"queue"`
+	State     string                                 `json:"state"`
+	PayId     pay.PayId                              `json:"payId"`
+	TxIds     []merkle.Digest                        `json:"txIds"`
+	Payments  []transactionrecord.PaymentAlternative `json:"payments,omitempty"`
+	ExpiresAt *time.Time                             `json:"expiresAt,omitempty"`
+	Size      int                                    `json:"size"`
+}
+
+// ItemFilter - select the items returned by Inspect
+//
+// blank fields match everything, items are in pay id order starting
+// after Start
+type ItemFilter struct {
+	Queue string
+	State TransactionState
+	Start *pay.PayId
+	Count int
+}
+
+// Inspect - list the pending and verified items that match a filter
+func Inspect(filter ItemFilter) ([]Item, error) {
+	if filter.Count <= 0 {
+		return nil, fault.InvalidCount
+	}
+	if "" != filter.Queue && !IsQueue(filter.Queue) {
+		return nil, fault.InvalidItem
+	}
+	switch filter.State {
+	case StateUnknown, StatePending, StateVerified:
+	default:
+		return nil, fault.InvalidItem
+	}
+
+	globalData.RLock()
+	defer globalData.RUnlock()
+
+	items := make([]Item, 0, len(globalData.pendingIndex)+len(globalData.verifiedIndex))
+
+	add := func(queue string, state TransactionState, payId pay.PayId, txs []*transactionData, payments []transactionrecord.PaymentAlternative, expiresAt *time.Time) {
+		if "" != filter.Queue && queue != filter.Queue {
+			return
+		}
+		if StateUnknown != filter.State && state != filter.State {
+			return
+		}
+		if nil != filter.Start && bytes.Compare(payId[:], filter.Start[:]) <= 0 {
+			return
+		}
+
+		item := Item{
+			Queue:     queue,
+			State:     state.String(),
+			PayId:     payId,
+			TxIds:     make([]merkle.Digest, len(txs)),
+			Payments:  payments,
+			ExpiresAt: expiresAt,
+		}
+		for i, tx := range txs {
+			item.TxIds[i] = tx.txId
+			item.Size += len(tx.packed)
+		}
+		items = append(items, item)
+	}
+
+	// pending
+
+	for payId, entry := range globalData.pendingFreeIssues {
+		expiresAt := entry.expiresAt
+		add(QueueFreeIssue, StatePending, payId, entry.txs, nil, &expiresAt)
+	}
+	for payId, entry := range globalData.pendingPaidIssues {
+		expiresAt := entry.expiresAt
+		add(QueuePaidIssue, StatePending, payId, entry.txs, entry.payments, &expiresAt)
+	}
+	for payId, entry := range globalData.pendingTransactions {
+		expiresAt := entry.expiresAt
+		add(queueOf(entry.tx), StatePending, payId, []*transactionData{entry.tx}, entry.payments, &expiresAt)
+	}
+
+	// verified
+
+	for payId, entry := range globalData.verifiedFreeIssues {
+		add(QueueFreeIssue, StateVerified, payId, entry.txs, nil, nil)
+	}
+	for payId, entry := range globalData.verifiedPaidIssues {
+		add(QueuePaidIssue, StateVerified, payId, entry.txs, entry.payments, nil)
+	}
+	for payId, tx := range globalData.verifiedTransactions {
+		add(queueOf(tx), StateVerified, payId, []*transactionData{tx}, nil, nil)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return bytes.Compare(items[i].PayId[:], items[j].PayId[:]) < 0
+	})
+	if len(items) > filter.Count {
+		items = items[:filter.Count]
+	}
+	return items, nil
+}
+
+// the queue of a single transaction
+func queueOf(tx *transactionData) string {
+	switch tx.transaction.(type) {
+	case *transactionrecord.ShareGrant:
+		return QueueGrant
+	case *transactionrecord.ShareSwap, *transactionrecord.AtomicSwap:
+		return QueueSwap
+	case *transactionrecord.ShareRedemption:
+		return QueueRedemption
+	case *transactionrecord.AssetAmendment:
+		return QueueAmendment
+	default:
+		return QueueTransfer
+	}
+}
+
+// Evict - remove a pending item, verified items are left to be
+// confirmed
+func Evict(payId pay.PayId) error {
+	globalData.Lock()
+	defer globalData.Unlock()
+
+	_, okT := globalData.pendingTransactions[payId]
+	_, okF := globalData.pendingFreeIssues[payId]
+	_, okP := globalData.pendingPaidIssues[payId]
+	if !okT && !okF && !okP {
+		return fault.PayIdNotFound
+	}
+
+	internalDelete(payId)
+	statusChanged()
+
+	return nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// a verified paid issue block and a transfer held pending by its
+// escrow
+func setupInspect(t *testing.T) (reservoir.Reservoir, pay.PayId) {
+	rsvr := setupSelection(t)
+
+	storeSelectionIssues(t, rsvr, &owner, privateKey, 5, 6)
+
+	escrow := &transactionrecord.Payment{
+		Currency: currency.Bitcoin,
+		Address:  "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn",
+		Amount:   10000,
+	}
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, escrow))
+	if nil != err {
+		t.Fatalf("store transfer error: %s", err)
+	}
+	return rsvr, info.Id
+}
+
+func TestInspectWhenInvalidFilter(t *testing.T) {
+	_, err := reservoir.Inspect(reservoir.ItemFilter{})
+	assert.Equal(t, fault.InvalidCount, err, "zero count")
+
+	_, err = reservoir.Inspect(reservoir.ItemFilter{Queue: "unknown", Count: 10})
+	assert.Equal(t, fault.InvalidItem, err, "unknown queue")
+
+	_, err = reservoir.Inspect(reservoir.ItemFilter{State: reservoir.StateConfirmed, Count: 10})
+	assert.Equal(t, fault.InvalidItem, err, "confirmed state")
+}
+
+func TestInspect(t *testing.T) {
+	_, transferPayId := setupInspect(t)
+	defer teardownSelection()
+
+	items, err := reservoir.Inspect(reservoir.ItemFilter{Count: 10})
+	assert.Nil(t, err, "inspect all")
+	assert.Equal(t, 2, len(items), "all items")
+	assert.True(t, bytes.Compare(items[0].PayId[:], items[1].PayId[:]) < 0, "pay id order")
+
+	items, err = reservoir.Inspect(reservoir.ItemFilter{State: reservoir.StatePending, Count: 10})
+	assert.Nil(t, err, "inspect pending")
+	assert.Equal(t, 1, len(items), "pending items")
+	transfer := items[0]
+	assert.Equal(t, reservoir.QueueTransfer, transfer.Queue, "pending queue")
+	assert.Equal(t, "Pending", transfer.State, "pending state")
+	assert.Equal(t, transferPayId, transfer.PayId, "pending pay id")
+	assert.Equal(t, 1, len(transfer.TxIds), "pending tx ids")
+	assert.NotEqual(t, 0, len(transfer.Payments), "pending payments")
+	assert.NotNil(t, transfer.ExpiresAt, "pending expiry")
+	assert.True(t, transfer.Size > 0, "pending size")
+
+	items, err = reservoir.Inspect(reservoir.ItemFilter{Queue: reservoir.QueuePaidIssue, Count: 10})
+	assert.Nil(t, err, "inspect paid issues")
+	assert.Equal(t, 1, len(items), "paid issue items")
+	issues := items[0]
+	assert.Equal(t, "Verified", issues.State, "verified state")
+	assert.Equal(t, 2, len(issues.TxIds), "verified tx ids")
+	assert.Nil(t, issues.ExpiresAt, "verified expiry")
+
+	items, err = reservoir.Inspect(reservoir.ItemFilter{Queue: reservoir.QueueSwap, Count: 10})
+	assert.Nil(t, err, "inspect swaps")
+	assert.Equal(t, 0, len(items), "swap items")
+
+	// paging
+	items, err = reservoir.Inspect(reservoir.ItemFilter{Count: 1})
+	assert.Nil(t, err, "inspect first page")
+	assert.Equal(t, 1, len(items), "first page")
+	first := items[0].PayId
+	items, err = reservoir.Inspect(reservoir.ItemFilter{Start: &first, Count: 1})
+	assert.Nil(t, err, "inspect second page")
+	assert.Equal(t, 1, len(items), "second page")
+	assert.NotEqual(t, first, items[0].PayId, "second page pay id")
+}
+
+func TestEvict(t *testing.T) {
+	rsvr, transferPayId := setupInspect(t)
+	defer teardownSelection()
+
+	items, err := reservoir.Inspect(reservoir.ItemFilter{State: reservoir.StateVerified, Count: 10})
+	assert.Nil(t, err, "inspect verified")
+	assert.Equal(t, 1, len(items), "verified items")
+	verifiedPayId := items[0].PayId
+
+	// verified items are left for the next block
+	err = reservoir.Evict(verifiedPayId)
+	assert.Equal(t, fault.PayIdNotFound, err, "evict verified")
+
+	err = reservoir.Evict(transferPayId)
+	assert.Nil(t, err, "evict pending")
+
+	err = reservoir.Evict(transferPayId)
+	assert.Equal(t, fault.PayIdNotFound, err, "evict twice")
+
+	items, err = reservoir.Inspect(reservoir.ItemFilter{Count: 10})
+	assert.Nil(t, err, "inspect after evict")
+	assert.Equal(t, 1, len(items), "items after evict")
+	assert.Equal(t, verifiedPayId, items[0].PayId, "remaining item")
+
+	// the link is free to be transferred again
+	_, _, err = rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "transfer after evict")
+}
@@ -222,6 +222,7 @@ func storeIssues(issues []*transactionrecord.BitmarkIssue, assetHandle storage.H
 			txId:        txId,
 			transaction: issues[i],
 			packed:      separated[i],
+			received:    time.Now(),
 		}
 	}
 
@@ -248,6 +249,8 @@ func storeIssues(issues []*transactionrecord.BitmarkIssue, assetHandle storage.H
 			globalData.verifiedPaidIssues[payId] = entry
 			//delete(globalData.pendingPaidIssues, payId) // not created
 			delete(globalData.orphanPayments, payId)
+			statusChanged()
+			journalIssues(issues, result.Packed)
 			return result, false, nil
 		}
 	}
@@ -257,6 +260,17 @@ func storeIssues(issues []*transactionrecord.BitmarkIssue, assetHandle storage.H
 		return nil, false, fault.BufferCapacityLimit
 	}
 
+	// as for selection a first block is always allowed, so an issue
+	// block larger than the limit can still be submitted
+	if freeIssueAllowed && globalData.accountLimit > 0 {
+		if issuer := issuerOf(txs); nil != issuer {
+			n := globalData.pendingFreeIssuers[*issuer]
+			if n > 0 && n+len(txs) > globalData.accountLimit {
+				return nil, false, fault.TooManyPendingFreeIssues
+			}
+		}
+	}
+
 	// create index entries
 	for _, txId := range txIds {
 		globalData.pendingIndex[txId] = payId
@@ -276,12 +290,17 @@ func storeIssues(issues []*transactionrecord.BitmarkIssue, assetHandle storage.H
 		}
 
 		globalData.pendingFreeCount += len(txs)
+		if issuer := issuerOf(txs); nil != issuer {
+			globalData.pendingFreeIssuers[*issuer] += len(txs)
+		}
 
 	} else {
 		globalData.pendingPaidIssues[payId] = entry
 		globalData.pendingPaidCount += len(txs)
 	}
+	statusChanged()
 
+	journalIssues(issues, result.Packed)
 	return result, false, nil
 }
 
@@ -381,11 +400,29 @@ func verifyIssueByNonce(payId pay.PayId, nonce []byte) bool {
 
 		// remove the pending data
 		globalData.pendingFreeCount -= len(entry.txs)
+		removePendingFreeIssuer(entry.txs)
 		delete(globalData.pendingFreeIssues, payId)
+		statusChanged()
 
 		// add to verified
 		globalData.verifiedFreeIssues[payId] = entry
+		journalProof(payId, nonce)
 	}
 
 	return ok
 }
+
+// remove an issue block from its issuer's pending free count
+// Lock must be held before calling this
+func removePendingFreeIssuer(txs []*transactionData) {
+	issuer := issuerOf(txs)
+	if nil == issuer {
+		return
+	}
+	n := globalData.pendingFreeIssuers[*issuer] - len(txs)
+	if n > 0 {
+		globalData.pendingFreeIssuers[*issuer] = n
+	} else {
+		delete(globalData.pendingFreeIssuers, *issuer)
+	}
+}
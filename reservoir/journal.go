@@ -0,0 +1,400 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/mode"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// the journal file
+const journalFile = "reservoir.journal"
+
+// how often the journal is rewritten from the current reservoir
+const journalCompactInterval = 10 * time.Minute
+
+// the BOF tag to check journal version
+// exact match is required
+var journalBOFData = []byte("bitmark-journal v1.0")
+
+// the journal is an append-only record of every change to the
+// reservoir so that a crash does not lose pending transactions
+//
+// records use the same tagged format as the cache file:
+//
+//	taggedTransaction  packed record(s) inserted into the reservoir
+//	taggedProof        pay id ⧺ nonce of an accepted free issue proof
+//	taggedPayment      pay id ⧺ JSON payment detail
+//	taggedRemove       pay id of records that left the reservoir
+//
+// compaction replaces the journal with the minimal records that
+// recreate the current reservoir
+//
+// every record is appended while the globalData lock is held so the
+// journal order is the order the reservoir changed
+//
+// appends are not synced, the journal survives a crash of the node
+// process but an operating system crash or power loss can lose the
+// most recent records
+type journalType struct {
+	sync.Mutex
+
+	filename string
+	file     *os.File
+
+	// records appended since the last compaction
+	appended int
+
+	// payments that may be needed to recreate verified records
+	payments map[pay.PayId]*PaymentDetail
+}
+
+var journal journalType
+
+// setup the journal, it is not written until opened by LoadFromFile
+func initialiseJournal(filename string) {
+	journal.Lock()
+	journal.filename = filename
+	journal.file = nil
+	journal.appended = 0
+	journal.payments = make(map[pay.PayId]*PaymentDetail)
+	journal.Unlock()
+}
+
+// close the journal and remove it if the reservoir was saved, the
+// cache file then holds everything
+func finaliseJournal(saved bool) {
+	journal.Lock()
+	defer journal.Unlock()
+
+	if nil == journal.file {
+		return
+	}
+	journal.file.Close()
+	journal.file = nil
+
+	if saved {
+		err := os.Remove(journal.filename)
+		if nil != err && !os.IsNotExist(err) {
+			globalData.log.Errorf("remove journal: %s  error: %s", journal.filename, err)
+		}
+	}
+}
+
+// journal a single inserted transaction
+func journalTransaction(packed []byte) {
+	appendJournal(taggedTransaction, packed)
+}
+
+// journal an inserted issue block preceded by any of its assets that
+// are not yet confirmed
+func journalIssues(issues []*transactionrecord.BitmarkIssue, packed []byte) {
+	seen := make(map[transactionrecord.AssetIdentifier]struct{})
+	for _, issue := range issues {
+		if _, ok := seen[issue.AssetId]; ok {
+			continue
+		}
+		seen[issue.AssetId] = struct{}{}
+		if packedAsset := asset.Get(issue.AssetId); nil != packedAsset {
+			appendJournal(taggedTransaction, packedAsset)
+		}
+	}
+	appendJournal(taggedTransaction, packed)
+}
+
+// journal an accepted free issue proof
+func journalProof(payId pay.PayId, nonce []byte) {
+	appendJournal(taggedProof, append(payId[:], nonce...))
+}
+
+// journal a payment, whether or not it matched any records
+func journalPayment(payId pay.PayId, detail *PaymentDetail) {
+	data, err := json.Marshal(detail)
+	if nil != err {
+		globalData.log.Errorf("journal payment: %s  error: %s", payId, err)
+		return
+	}
+
+	journal.Lock()
+	journal.payments[payId] = detail
+	journal.Unlock()
+
+	appendJournal(taggedPayment, append(payId[:], data...))
+}
+
+// journal the removal of all records of a pay id
+func journalRemove(payId pay.PayId) {
+	journal.Lock()
+	delete(journal.payments, payId)
+	journal.Unlock()
+
+	appendJournal(taggedRemove, payId[:])
+}
+
+// append a record if the journal is open
+func appendJournal(tag tagType, data []byte) {
+	journal.Lock()
+	defer journal.Unlock()
+
+	if nil == journal.file {
+		return
+	}
+
+	err := writeRecord(journal.file, tag, data)
+	if nil != err {
+		globalData.log.Errorf("journal append error: %s", err)
+		return
+	}
+	journal.appended += 1
+}
+
+// compactJournal - replace the journal with the current reservoir
+//
+// the new journal is written to a temporary file and renamed so a
+// crash during compaction leaves the old journal intact
+func compactJournal() error {
+	globalData.RLock()
+	defer globalData.RUnlock()
+
+	journal.Lock()
+	defer journal.Unlock()
+
+	log := globalData.log
+
+	tempName := journal.filename + ".new"
+	f, err := os.OpenFile(tempName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if nil != err {
+		return err
+	}
+
+	err = writeJournalSnapshot(f)
+	if nil == err {
+		err = f.Sync()
+	}
+	f.Close()
+	if nil != err {
+		os.Remove(tempName)
+		return err
+	}
+
+	err = os.Rename(tempName, journal.filename)
+	if nil != err {
+		os.Remove(tempName)
+		return err
+	}
+
+	if nil != journal.file {
+		journal.file.Close()
+	}
+	journal.file, err = os.OpenFile(journal.filename, os.O_APPEND|os.O_WRONLY, 0600)
+	if nil != err {
+		journal.file = nil
+		return err
+	}
+	journal.appended = 0
+
+	log.Debugf("journal compacted: %s", journal.filename)
+	return nil
+}
+
+// write the records that recreate the current reservoir
+// globalData and journal locks must be held before calling this
+func writeJournalSnapshot(f *os.File) error {
+	err := writeRecord(f, taggedBOF, journalBOFData)
+	if nil != err {
+		return err
+	}
+
+	err = writeSnapshot(f)
+	if nil != err {
+		return err
+	}
+
+	// payments that verified records, drop any that are no longer
+	// needed
+	payments := make(map[pay.PayId]*PaymentDetail)
+	for payId := range globalData.verifiedTransactions {
+		if detail, ok := journal.payments[payId]; ok {
+			payments[payId] = detail
+		}
+	}
+	for payId := range globalData.verifiedPaidIssues {
+		if detail, ok := journal.payments[payId]; ok {
+			payments[payId] = detail
+		}
+	}
+	for payId, detail := range globalData.orphanPayments {
+		payments[payId] = detail
+	}
+	journal.payments = payments
+
+	for payId, detail := range payments {
+		data, err := json.Marshal(detail)
+		if nil != err {
+			return err
+		}
+		err = writeRecord(f, taggedPayment, append(payId[:], data...))
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// replay a journal left by a node that did not shut down cleanly
+//
+// a partly written final record is the expected result of a crash so
+// it ends the replay without error
+func replayJournal(handles Handles) error {
+	log := globalData.log
+
+	f, err := os.Open(journal.filename)
+	if nil != err {
+		return err
+	}
+	defer f.Close()
+
+	tag, packed, err := readRecord(f)
+	if nil != err {
+		return err
+	}
+	if taggedBOF != tag {
+		return fmt.Errorf("journal expected BOF: %d but read: %d", taggedBOF, tag)
+	}
+	if !bytes.Equal(journalBOFData, packed) {
+		return fmt.Errorf("journal expected BOF: %q but read: %q", journalBOFData, packed)
+	}
+
+	log.Infof("replay journal: %s", journal.filename)
+
+	count := 0
+replay_loop:
+	for {
+		tag, packed, err := readRecord(f)
+		if io.EOF == err {
+			break replay_loop
+		}
+		if nil != err {
+			log.Warnf("journal ends with incomplete record: %s", err)
+			break replay_loop
+		}
+		count += 1
+
+		switch tag {
+
+		case taggedTransaction:
+			unpacked, _, err := packed.Unpack(mode.IsTesting())
+			if nil != err {
+				log.Errorf("unable to unpack journal transaction: %s", err)
+				continue replay_loop
+			}
+
+			restorer, err := NewTransactionRestorer(unpacked, packed, handles)
+			if nil != err {
+				log.Errorf("create transaction restorer with error: %s", err)
+				continue replay_loop
+			}
+
+			err = restorer.Restore()
+			if nil != err {
+				log.Errorf("restore %s with error: %s", restorer, err)
+				continue replay_loop
+			}
+
+		case taggedProof:
+			var payId pay.PayId
+			pn := len(payId)
+			if len(packed) <= pn {
+				log.Errorf("unable to unpack proof: record too short: %d  expected > %d", len(packed), pn)
+				continue replay_loop
+			}
+			copy(payId[:], packed[:pn])
+			tryProof(payId, packed[pn:])
+
+		case taggedPayment:
+			var payId pay.PayId
+			pn := len(payId)
+			if len(packed) <= pn {
+				log.Errorf("unable to unpack payment: record too short: %d  expected > %d", len(packed), pn)
+				continue replay_loop
+			}
+			copy(payId[:], packed[:pn])
+			detail := &PaymentDetail{}
+			err := json.Unmarshal(packed[pn:], detail)
+			if nil != err {
+				log.Errorf("unable to unpack payment: %s", err)
+				continue replay_loop
+			}
+			SetTransferVerified(payId, detail)
+
+		case taggedRemove:
+			var payId pay.PayId
+			if len(packed) != len(payId) {
+				log.Errorf("unable to unpack remove: record length: %d  expected: %d", len(packed), len(payId))
+				continue replay_loop
+			}
+			copy(payId[:], packed)
+			globalData.Lock()
+			internalDelete(payId)
+			globalData.Unlock()
+
+		default:
+			msg := fmt.Errorf("abort, journal has invalid tag: 0x%02x", tag)
+			log.Error(msg.Error())
+			return msg
+		}
+	}
+
+	log.Infof("journal replay completed: %d records", count)
+	return nil
+}
+
+// background process to periodically compact the journal
+type compactor struct {
+	log *logger.L
+}
+
+func (c *compactor) Run(args interface{}, shutdown <-chan struct{}) {
+
+	c.log = logger.New("journal")
+
+	ticker := time.NewTicker(journalCompactInterval)
+	for {
+		select {
+		case <-ticker.C:
+			c.compact()
+		case <-shutdown:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// only rewrite an open journal that has changed
+func (c *compactor) compact() {
+	journal.Lock()
+	skip := nil == journal.file || 0 == journal.appended
+	journal.Unlock()
+	if skip {
+		return
+	}
+
+	err := compactJournal()
+	if nil != err {
+		c.log.Errorf("compact error: %s", err)
+	}
+}
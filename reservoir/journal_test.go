@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+)
+
+var (
+	journalName = path.Join(testingDirName, "reservoir.journal")
+	cacheName   = path.Join(testingDirName, "reservoir.cache")
+)
+
+func journalHandles() reservoir.Handles {
+	return reservoir.Handles{
+		Assets:            storage.Pool.Assets,
+		Transactions:      storage.Pool.Transactions,
+		OwnerTxIndex:      storage.Pool.OwnerTxIndex,
+		OwnerData:         storage.Pool.OwnerData,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+	}
+}
+
+// start the reservoir and restore it as on node start
+func restartReservoir(t *testing.T) reservoir.Reservoir {
+	err := reservoir.Initialise(testingDirName, journalHandles(), false)
+	assert.Nil(t, err, "reservoir initialise")
+
+	err = reservoir.LoadFromFile(journalHandles())
+	if nil != err && !os.IsNotExist(err) {
+		t.Fatalf("load error: %s", err)
+	}
+	return reservoir.Get()
+}
+
+// stop the reservoir leaving the journal behind as a crash would,
+// including a partly written record
+func crashReservoir(t *testing.T) {
+	data, err := ioutil.ReadFile(journalName)
+	if nil != err {
+		t.Fatalf("read journal error: %s", err)
+	}
+
+	_ = reservoir.Finalise()
+
+	_ = os.Remove(cacheName)
+	data = append(data, 0x02, 0x00, 0x40, 0x01)
+	err = ioutil.WriteFile(journalName, data, 0600)
+	if nil != err {
+		t.Fatalf("write journal error: %s", err)
+	}
+}
+
+// pay the first payment alternative of a pending transfer
+func payTransfer(info *reservoir.TransferInfo) {
+	amounts := make(map[string]uint64)
+	for _, p := range info.Payments[0] {
+		amounts[p.Address] += p.Amount
+	}
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: info.Payments[0][0].Currency,
+		TxID:     "journal-payment",
+		Amounts:  amounts,
+	})
+}
+
+func TestJournalReplayAfterCrash(t *testing.T) {
+	setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	_ = asset.Initialise()
+	defer asset.Finalise()
+
+	_ = reservoir.Finalise()
+	rsvr := restartReservoir(t)
+
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+	payTransfer(info)
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "state before crash")
+
+	// the payment is replayed so the transfer stays verified
+	crashReservoir(t)
+	rsvr = restartReservoir(t)
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "state after crash")
+
+	// removal is journaled
+	reservoir.Disable()
+	reservoir.ClearSpend()
+	reservoir.DeleteByTxId(info.TxId)
+	reservoir.Rescan()
+	reservoir.Enable()
+
+	crashReservoir(t)
+	rsvr = restartReservoir(t)
+	assert.Equal(t, reservoir.StateUnknown, rsvr.TransactionStatus(info.TxId), "state after removal")
+}
+
+func TestJournalOrphanPaymentAfterCrash(t *testing.T) {
+	setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	_ = asset.Initialise()
+	defer asset.Finalise()
+
+	_ = reservoir.Finalise()
+	rsvr := restartReservoir(t)
+
+	// find the pay id and payments without keeping the transfer
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+	reservoir.Disable()
+	reservoir.ClearSpend()
+	reservoir.DeleteByTxId(info.TxId)
+	reservoir.Rescan()
+	reservoir.Enable()
+
+	// payment arrives before the transfer is resubmitted
+	payTransfer(info)
+	assert.Equal(t, reservoir.StateUnknown, rsvr.TransactionStatus(info.TxId), "state after payment")
+
+	crashReservoir(t)
+	rsvr = restartReservoir(t)
+
+	_, _, err = rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer after crash")
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "state after resubmit")
+}
+
+func TestJournalRemovedOnCleanShutdown(t *testing.T) {
+	setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	_ = asset.Initialise()
+	defer asset.Finalise()
+
+	_ = reservoir.Finalise()
+	rsvr := restartReservoir(t)
+
+	_, err := os.Stat(journalName)
+	assert.Nil(t, err, "journal not started")
+
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+
+	err = reservoir.Finalise()
+	assert.Nil(t, err, "finalise")
+
+	_, err = os.Stat(journalName)
+	assert.True(t, os.IsNotExist(err), "journal remains after clean shutdown")
+	_, err = os.Stat(cacheName)
+	assert.Nil(t, err, "cache not saved")
+
+	// restored from the cache
+	rsvr = restartReservoir(t)
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state after restart")
+}
@@ -21,12 +21,14 @@ import (
 
 type tagType byte
 
-// record types in cache file
+// record types in cache file and journal
 const (
 	taggedBOF         tagType = iota
 	taggedEOF         tagType = iota
 	taggedTransaction tagType = iota
 	taggedProof       tagType = iota
+	taggedPayment     tagType = iota // journal only
+	taggedRemove      tagType = iota // journal only
 )
 
 // the BOF tag to check file version
@@ -35,13 +37,40 @@ var bofData = []byte("bitmark-cache v1.0")
 
 // LoadFromFile - load transactions from file
 // called later when system is able to handle the tx and proofs
+//
+// a journal is only left behind when the node did not shut down
+// cleanly, it is then more recent than the cache file
 func LoadFromFile(handles Handles) error {
 	Disable()
 	defer Enable()
 
-	log := globalData.log
+	globalData.log.Info("starting…")
+
+	finaliseJournal(false)
 
-	log.Info("starting…")
+	var err error
+	if _, e := os.Stat(journal.filename); nil == e {
+		err = replayJournal(handles)
+	} else {
+		err = loadCache(handles)
+	}
+	if nil != err && !os.IsNotExist(err) {
+		return err
+	}
+
+	// start a new journal from the restored transactions
+	jerr := compactJournal()
+	if nil != jerr {
+		globalData.log.Errorf("start journal error: %s", jerr)
+		return jerr
+	}
+	return err
+}
+
+// restore transactions from the cache file
+func loadCache(handles Handles) error {
+
+	log := globalData.log
 
 	f, err := os.Open(globalData.filename)
 	if nil != err {
@@ -145,8 +174,27 @@ func saveToFile() error {
 		return err
 	}
 
+	err = writeSnapshot(f)
+	if nil != err {
+		return err
+	}
+
+	// end the file
+	err = writeRecord(f, taggedEOF, []byte("EOF"))
+	if nil != err {
+		return err
+	}
+
+	log.Info("save completed")
+	return nil
+}
+
+// write all assets and transactions in the reservoir
+// Lock must be held before calling this
+func writeSnapshot(f *os.File) error {
+
 	// all assets at start of file
-	err = backupAssets(f)
+	err := backupAssets(f)
 	if nil != err {
 		return err
 	}
@@ -201,13 +249,6 @@ func saveToFile() error {
 		}
 	}
 
-	// end the file
-	err = writeRecord(f, taggedEOF, []byte("EOF"))
-	if nil != err {
-		return err
-	}
-
-	log.Info("save completed")
 	return nil
 }
 
@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 
 	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/mode"
 	"github.com/bitmark-inc/bitmarkd/storage"
 	"github.com/bitmark-inc/bitmarkd/transactionrecord"
@@ -74,8 +75,9 @@ func getPayments(transferBlockNumber uint64, issueBlockNumber uint64, previousTr
 
 		i := previousTransfer.GetPayment().Currency.Index() // zero based index (panics if any problem)
 
-		// always keep this as a separate amount even if address is the same
-		// so it shows up separately in currency transaction
+		// listed separately even if the address is the same so it
+		// shows up separately in currency transaction, acceptablePayment
+		// sums the amounts for each address
 		payments[i] = append(payments[i], previousTransfer.GetPayment())
 
 		return []transactionrecord.PaymentAlternative{payments[i]}
@@ -84,6 +86,71 @@ func getPayments(transferBlockNumber uint64, issueBlockNumber uint64, previousTr
 	return payments
 }
 
+// restrict payments to the currency of a transfer's escrow and add the
+// escrow to that alternative so fees and escrow are paid together
+func addEscrow(payments []transactionrecord.PaymentAlternative, escrow *transactionrecord.Payment) ([]transactionrecord.PaymentAlternative, error) {
+	for _, p := range payments {
+		if 0 == len(p) || p[0].Currency != escrow.Currency {
+			continue
+		}
+		alternative := make(transactionrecord.PaymentAlternative, len(p), len(p)+1)
+		copy(alternative, p)
+
+		// listed separately even if the address is the same so it
+		// shows up separately in currency transaction, acceptablePayment
+		// sums the amounts for each address
+		alternative = append(alternative, escrow)
+
+		return []transactionrecord.PaymentAlternative{alternative}, nil
+	}
+	return nil, fault.EscrowCurrencyMismatch
+}
+
+// combine the payments of several records into a single alternative
+// for each currency, amounts to the same address are added together
+func mergePayments(payments []transactionrecord.PaymentAlternative, more []transactionrecord.PaymentAlternative) []transactionrecord.PaymentAlternative {
+
+	// copy so that the payments being merged are not changed
+	merged := make([]transactionrecord.PaymentAlternative, 0, len(payments)+len(more))
+	add := func(alternative transactionrecord.PaymentAlternative) {
+		if 0 == len(alternative) {
+			return
+		}
+		for i, m := range merged {
+			if m[0].Currency != alternative[0].Currency {
+				continue
+			}
+		next_payment:
+			for _, p := range alternative {
+				for _, item := range m {
+					if item.Address == p.Address {
+						item.Amount += p.Amount
+						continue next_payment
+					}
+				}
+				item := *p
+				merged[i] = append(merged[i], &item)
+				m = merged[i]
+			}
+			return
+		}
+		copied := make(transactionrecord.PaymentAlternative, len(alternative))
+		for i, p := range alternative {
+			item := *p
+			copied[i] = &item
+		}
+		merged = append(merged, copied)
+	}
+
+	for _, alternative := range payments {
+		add(alternative)
+	}
+	for _, alternative := range more {
+		add(alternative)
+	}
+	return merged
+}
+
 // get a payment record from a specific block given the blocks 8 byte big endian key
 func getPayment(blockNumberKey []byte, blockOwnerPaymentHandle storage.Handle) *PaymentSegment {
 	if nil == blockOwnerPaymentHandle {
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/blockheader"
+	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// a transfer of the test issue to a recipient with a sequence
+func sequencedTransfer(t *testing.T, recipient *account.Account, sequence uint64) *transactionrecord.BitmarkTransferUnratified {
+	transfer := &transactionrecord.BitmarkTransferUnratified{
+		Link:     assetTxID,
+		Sequence: sequence,
+		Owner:    recipient,
+	}
+	packed, err := transfer.Pack(&owner)
+	if fault.InvalidSignature != err {
+		t.Fatalf("transfer pack error: %s", err)
+	}
+	transfer.Signature = ed25519.Sign(privateKey, packed)
+	return transfer
+}
+
+func TestTransferReplacement(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	first, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 0))
+	assert.Nil(t, err, "store first transfer")
+	assert.Nil(t, first.Replaced, "first transfer replaced")
+
+	// a new recipient with a higher sequence
+	second, duplicate, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner, 1))
+	assert.Nil(t, err, "store replacement")
+	assert.False(t, duplicate, "replacement is a duplicate")
+	assert.Equal(t, &first.TxId, second.Replaced, "replaced tx id")
+	assert.Equal(t, reservoir.StateUnknown, rsvr.TransactionStatus(first.TxId), "first state")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(second.TxId), "replacement state")
+
+	pending, _ := reservoir.ReadCounters()
+	assert.Equal(t, 1, pending, "pending count")
+
+	// the replaced transfer cannot come back
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 0))
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "resubmit replaced")
+
+	// the sequence must increase
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 1))
+	assert.Equal(t, fault.SequenceNotIncreased, err, "same sequence")
+
+	// resubmitting the replacement is a duplicate
+	repeat, duplicate, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner, 1))
+	assert.Nil(t, err, "repeat replacement")
+	assert.True(t, duplicate, "repeat is not a duplicate")
+	assert.Nil(t, repeat.Replaced, "repeat replaced")
+
+	third, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 7))
+	assert.Nil(t, err, "store second replacement")
+	assert.Equal(t, &second.TxId, third.Replaced, "second replaced tx id")
+
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner, 6))
+	assert.Equal(t, fault.SequenceNotIncreased, err, "lower sequence")
+
+	// a payment for the replaced transfer is kept as an orphan
+	payTransfer(second)
+	assert.Equal(t, reservoir.StateUnknown, rsvr.TransactionStatus(second.TxId), "paid replaced state")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(third.TxId), "current state")
+}
+
+func TestTransferReplacementWithoutSequence(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	_, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 3))
+	assert.Nil(t, err, "store first transfer")
+
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner, 0))
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "zero sequence")
+
+	// countersigned transfers are never replacements
+	transfer := &transactionrecord.BitmarkTransferCountersigned{
+		Link:  assetTxID,
+		Owner: &owner2,
+	}
+	packed, err := transfer.Pack(&owner)
+	if fault.InvalidSignature != err {
+		t.Fatalf("transfer pack error: %s", err)
+	}
+	transfer.Signature = ed25519.Sign(privateKey, packed)
+	packed, err = transfer.Pack(&owner)
+	if fault.InvalidSignature != err {
+		t.Fatalf("transfer pack error: %s", err)
+	}
+	transfer.Countersignature = ed25519.Sign(privateKey2, packed)
+
+	_, _, err = rsvr.StoreTransfer(transfer)
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "countersigned")
+}
+
+func TestTransferReplacementBeforeBlockVersion(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	// a chain still at the previous block version
+	height, digest, _, timestamp := blockheader.Get()
+	blockheader.Set(height, digest, 5, timestamp)
+
+	_, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 3))
+	assert.Equal(t, fault.RecordNotAllowedInBlockVersion, err, "sequenced transfer")
+
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 0))
+	assert.Nil(t, err, "transfer without sequence")
+}
+
+func TestTransferReplacementWhenPaid(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	info, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 1))
+	assert.Nil(t, err, "store transfer")
+	payTransfer(info)
+	assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(info.TxId), "paid state")
+
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner, 2))
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "replace verified")
+}
+
+func TestTransferReplacementWhenPartlyPaid(t *testing.T) {
+	rsvr := setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	escrow := &transactionrecord.Payment{
+		Currency: currency.Litecoin,
+		Address:  escrowAddress,
+		Amount:   250000,
+	}
+
+	transfer := sequencedTransfer(t, &owner2, 1)
+	transfer.Escrow = escrow
+	transfer.Signature = nil
+	packed, err := transfer.Pack(&owner)
+	if fault.InvalidSignature != err {
+		t.Fatalf("transfer pack error: %s", err)
+	}
+	transfer.Signature = ed25519.Sign(privateKey, packed)
+
+	info, _, err := rsvr.StoreTransfer(transfer)
+	assert.Nil(t, err, "store transfer")
+
+	// fees without the escrow leave the transfer pending
+	alternative := info.Payments[0]
+	fees := make(map[string]uint64)
+	for _, p := range alternative[:len(alternative)-1] {
+		fees[p.Address] += p.Amount
+	}
+	reservoir.SetTransferVerified(info.Id, &reservoir.PaymentDetail{
+		Currency: currency.Litecoin,
+		TxID:     "fees-only",
+		Amounts:  fees,
+	})
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(info.TxId), "state after fees")
+
+	_, _, err = rsvr.StoreTransfer(sequencedTransfer(t, &owner, 2))
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "replace partly paid")
+}
+
+func TestTransferReplacementAfterCrash(t *testing.T) {
+	setupEscrow(t, false)
+	defer teardown()
+	defer reservoir.Finalise()
+
+	_ = asset.Initialise()
+	defer asset.Finalise()
+
+	_ = reservoir.Finalise()
+	rsvr := restartReservoir(t)
+
+	first, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner2, 0))
+	assert.Nil(t, err, "store first transfer")
+	second, _, err := rsvr.StoreTransfer(sequencedTransfer(t, &owner, 1))
+	assert.Nil(t, err, "store replacement")
+
+	crashReservoir(t)
+	rsvr = restartReservoir(t)
+	assert.Equal(t, reservoir.StateUnknown, rsvr.TransactionStatus(first.TxId), "first state after crash")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(second.TxId), "replacement state after crash")
+}
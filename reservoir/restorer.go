@@ -68,6 +68,39 @@ func NewTransactionRestorer(unpacked interface{}, packed interface{}, handles Ha
 			blockOwnerPayment: handles.BlockOwnerPayment,
 		}, nil
 
+	case *transactionrecord.ShareRedemption:
+
+		return &redemptionRestoreData{
+			unpacked:          t,
+			shareQuantity:     handles.ShareQuantity,
+			shares:            handles.Shares,
+			ownerData:         handles.OwnerData,
+			blockOwnerPayment: handles.BlockOwnerPayment,
+			transactions:      handles.Transactions,
+		}, nil
+
+	case *transactionrecord.AtomicSwap:
+
+		return &atomicSwapRestoreData{
+			unpacked:          t,
+			shareQuantity:     handles.ShareQuantity,
+			shares:            handles.Shares,
+			ownerTx:           handles.OwnerTxIndex,
+			ownerData:         handles.OwnerData,
+			blockOwnerPayment: handles.BlockOwnerPayment,
+			transactions:      handles.Transactions,
+		}, nil
+
+	case *transactionrecord.AssetAmendment:
+
+		return &amendmentRestoreData{
+			unpacked:          t,
+			assets:            handles.Assets,
+			assetVersions:     handles.AssetVersions,
+			blockOwnerPayment: handles.BlockOwnerPayment,
+			transactions:      handles.Transactions,
+		}, nil
+
 	default:
 		return nil, fmt.Errorf("unhandled restore tx type: %d", t)
 	}
@@ -186,3 +219,66 @@ func (s *swapRestoreData) Restore() error {
 	}
 	return err
 }
+
+type redemptionRestoreData struct {
+	unpacked          *transactionrecord.ShareRedemption
+	shareQuantity     storage.Handle
+	shares            storage.Handle
+	ownerData         storage.Handle
+	blockOwnerPayment storage.Handle
+	transactions      storage.Handle
+}
+
+func (r *redemptionRestoreData) String() string {
+	return "transactionrecord.ShareRedemption"
+}
+
+func (r *redemptionRestoreData) Restore() error {
+	_, _, err := storeRedemption(r.unpacked, r.shareQuantity, r.shares, r.ownerData, r.blockOwnerPayment, r.transactions)
+	if nil != err {
+		return fmt.Errorf("fail to restore redemption: %s", err)
+	}
+	return err
+}
+
+type atomicSwapRestoreData struct {
+	unpacked          *transactionrecord.AtomicSwap
+	shareQuantity     storage.Handle
+	shares            storage.Handle
+	ownerTx           storage.Handle
+	ownerData         storage.Handle
+	blockOwnerPayment storage.Handle
+	transactions      storage.Handle
+}
+
+func (a *atomicSwapRestoreData) String() string {
+	return "transactionrecord.AtomicSwap"
+}
+
+func (a *atomicSwapRestoreData) Restore() error {
+	_, _, err := storeAtomicSwap(a.unpacked, a.shareQuantity, a.shares, a.ownerTx, a.ownerData, a.blockOwnerPayment, a.transactions)
+	if nil != err {
+		return fmt.Errorf("fail to restore atomic swap: %s", err)
+	}
+	return err
+}
+
+type amendmentRestoreData struct {
+	unpacked          *transactionrecord.AssetAmendment
+	assets            storage.Handle
+	assetVersions     storage.Handle
+	blockOwnerPayment storage.Handle
+	transactions      storage.Handle
+}
+
+func (a *amendmentRestoreData) String() string {
+	return "transactionrecord.AssetAmendment"
+}
+
+func (a *amendmentRestoreData) Restore() error {
+	_, _, err := storeAmendment(a.unpacked, a.assets, a.assetVersions, a.blockOwnerPayment, a.transactions)
+	if nil != err {
+		return fmt.Errorf("fail to restore amendment: %s", err)
+	}
+	return err
+}
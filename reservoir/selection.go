@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// Selector - chooses the verified records to put in a new block
+type Selector interface {
+	FetchVerified(count int) ([]merkle.Digest, []byte, error)
+}
+
+// SelectionPolicy - how a selector orders and limits verified records
+//
+// the zero value selects in the fixed order of FetchVerified
+type SelectionPolicy struct {
+	PaidFirst    bool // paid issues and transactions before any free issues
+	OldestFirst  bool // earliest received first, instead of by record kind
+	AccountLimit int  // maximum issues from one issuer in a block, zero for no limit
+}
+
+// NewSelector - create the selector for a policy
+func NewSelector(policy SelectionPolicy) (Selector, error) {
+	if policy.AccountLimit < 0 {
+		return nil, fault.InvalidCount
+	}
+	if (SelectionPolicy{}) == policy {
+		return defaultSelector{}, nil
+	}
+	return &policySelector{policy: policy}, nil
+}
+
+// free issues, then paid issues, then transactions
+type defaultSelector struct{}
+
+func (defaultSelector) FetchVerified(count int) ([]merkle.Digest, []byte, error) {
+	return FetchVerified(count)
+}
+
+// order of record kinds when not selecting by age
+const (
+	kindFreeIssue = iota
+	kindPaidIssue
+	kindTransaction
+)
+
+// a group of records that must all go in the same block
+type candidate struct {
+	kind   int
+	payId  pay.PayId
+	issuer *issuerKey
+	txs    []*transactionData
+}
+
+// account key of an issuer
+type issuerKey [64]byte
+
+type policySelector struct {
+	policy SelectionPolicy
+}
+
+// FetchVerified - fetch verified records in policy order
+//
+// an issue block is never split, so the first block from an issuer is
+// always allowed even if it is larger than the account limit
+//
+// as in FetchVerified free and paid issues beyond their fetch maximum
+// are only added if space remains after all other records
+func (s *policySelector) FetchVerified(count int) ([]merkle.Digest, []byte, error) {
+	if count <= 0 {
+		return nil, nil, fault.InvalidCount
+	}
+	if count < minimumFetchTransactions {
+		return nil, nil, fault.InvalidCount
+	}
+
+	txIds := make([]merkle.Digest, 0, count)
+	txData := make([]byte, 0, 200*count) // some arbitrary start
+
+	globalData.RLock()
+	defer globalData.RUnlock()
+
+	if !globalData.enabled {
+		return txIds, txData, nil
+	}
+
+	candidates := s.candidates()
+
+	seenAsset := make(map[transactionrecord.AssetIdentifier]struct{})
+	issued := make(map[issuerKey]int)
+
+	// records stored for each issue kind, limited as in FetchVerified
+	stored := make(map[int]int)
+	maximum := map[int]int{
+		kindFreeIssue: maximumFetchFreeIssues,
+		kindPaidIssue: maximumFetchPaidIssues,
+	}
+
+	// add a candidate if it is within the limits and fits in count
+	add := func(c *candidate) {
+		if nil != c.issuer && s.policy.AccountLimit > 0 {
+			n := issued[*c.issuer]
+			if n > 0 && n+len(c.txs) > s.policy.AccountLimit {
+				return
+			}
+		}
+
+		// free issues carry any unconfirmed asset before them
+		assets := make([]transactionrecord.Packed, 0, 1)
+		if kindFreeIssue == c.kind {
+			pending := make(map[transactionrecord.AssetIdentifier]struct{})
+			for _, item := range c.txs {
+				tx := item.transaction.(*transactionrecord.BitmarkIssue)
+				if _, ok := seenAsset[tx.AssetId]; ok {
+					continue
+				}
+				if _, ok := pending[tx.AssetId]; ok {
+					continue
+				}
+				if storage.Pool.Assets.Has(tx.AssetId[:]) {
+					continue
+				}
+				packedAsset := asset.Get(tx.AssetId)
+				if nil == packedAsset {
+					globalData.log.Criticalf("missing asset: %v", tx.AssetId)
+					logger.Panicf("fetch verified missing asset: %v", tx.AssetId)
+				}
+				pending[tx.AssetId] = struct{}{}
+				assets = append(assets, packedAsset)
+			}
+		}
+
+		// paid issues need an asset that is confirmed or already in
+		// this block, otherwise the block would fail to store
+		if kindPaidIssue == c.kind {
+			for _, item := range c.txs {
+				tx := item.transaction.(*transactionrecord.BitmarkIssue)
+				if _, ok := seenAsset[tx.AssetId]; ok {
+					continue
+				}
+				if !storage.Pool.Assets.Has(tx.AssetId[:]) {
+					globalData.log.Criticalf("missing confirmed asset: %v", tx.AssetId)
+					return
+				}
+			}
+		}
+
+		if len(assets)+len(c.txs) > count {
+			return
+		}
+
+		for _, packedAsset := range assets {
+			txIds = append(txIds, merkle.NewDigest(packedAsset))
+			txData = append(txData, packedAsset...)
+		}
+		for _, item := range c.txs {
+			if kindFreeIssue == c.kind {
+				seenAsset[item.transaction.(*transactionrecord.BitmarkIssue).AssetId] = struct{}{}
+			}
+			txIds = append(txIds, item.txId)
+			txData = append(txData, item.packed...)
+		}
+		count -= len(assets) + len(c.txs)
+		stored[c.kind] += len(assets) + len(c.txs)
+
+		if nil != c.issuer {
+			issued[*c.issuer] += len(c.txs)
+		}
+	}
+
+	// issues over the kind limit only fill space left by transactions
+	deferred := make([]*candidate, 0)
+
+next_candidate:
+	for _, c := range candidates {
+		if count <= 0 {
+			break next_candidate
+		}
+		if limit, ok := maximum[c.kind]; ok && stored[c.kind] >= limit {
+			deferred = append(deferred, c)
+			continue next_candidate
+		}
+		add(c)
+	}
+
+next_deferred:
+	for _, c := range deferred {
+		if count <= 0 {
+			break next_deferred
+		}
+		add(c)
+	}
+
+	globalData.log.Infof("tx ids: %v", txIds)
+
+	return txIds, txData, nil
+}
+
+// all verified records in policy order
+// Lock must be held before calling this
+func (s *policySelector) candidates() []*candidate {
+	candidates := make([]*candidate, 0, len(globalData.verifiedFreeIssues)+len(globalData.verifiedPaidIssues)+len(globalData.verifiedTransactions))
+
+	for payId, item := range globalData.verifiedFreeIssues {
+		candidates = append(candidates, &candidate{
+			kind:   kindFreeIssue,
+			payId:  payId,
+			issuer: issuerOf(item.txs),
+			txs:    item.txs,
+		})
+	}
+	for payId, item := range globalData.verifiedPaidIssues {
+		candidates = append(candidates, &candidate{
+			kind:   kindPaidIssue,
+			payId:  payId,
+			issuer: issuerOf(item.txs),
+			txs:    item.txs,
+		})
+	}
+	for payId, item := range globalData.verifiedTransactions {
+		candidates = append(candidates, &candidate{
+			kind:  kindTransaction,
+			payId: payId,
+			txs:   []*transactionData{item},
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a := candidates[i]
+		b := candidates[j]
+
+		if s.policy.PaidFirst {
+			aFree := kindFreeIssue == a.kind
+			bFree := kindFreeIssue == b.kind
+			if aFree != bFree {
+				return bFree
+			}
+		}
+
+		if s.policy.OldestFirst {
+			aTime := a.txs[0].received
+			bTime := b.txs[0].received
+			if !aTime.Equal(bTime) {
+				return aTime.Before(bTime)
+			}
+		}
+
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return bytes.Compare(a.payId[:], b.payId[:]) < 0
+	})
+
+	return candidates
+}
+
+// the issuer of an issue block
+func issuerOf(txs []*transactionData) *issuerKey {
+	issue, ok := txs[0].transaction.(*transactionrecord.BitmarkIssue)
+	if !ok || nil == issue.Owner {
+		return nil
+	}
+	var key issuerKey
+	copy(key[:], issue.Owner.Bytes())
+	return &key
+}
@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/asset"
+	"github.com/bitmark-inc/bitmarkd/blockrecord"
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+const selectionCount = blockrecord.MaximumTransactions - 1
+
+// confirm the test asset and its first issue so that paid issues and
+// transfers are possible, all payments are auto verified
+func setupSelection(t *testing.T) reservoir.Reservoir {
+	setup(t, chain.Testing)
+
+	_ = asset.Initialise()
+
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Assets:            storage.Pool.Assets,
+		Transactions:      storage.Pool.Transactions,
+		OwnerTxIndex:      storage.Pool.OwnerTxIndex,
+		OwnerData:         storage.Pool.OwnerData,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+	}, true)
+	assert.Nil(t, err, "reservoir initialise")
+
+	blockNumberKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(blockNumberKey, 2)
+
+	packedAsset, err := assetData.Pack(&owner)
+	assert.Nil(t, err, "asset pack")
+	packedIssue, err := assetIssuance.Pack(&owner)
+	assert.Nil(t, err, "issue pack")
+
+	trx, _ := storage.NewDBTransaction()
+	payments, _ := currencyMap.Pack(true)
+	trx.Put(storage.Pool.BlockOwnerPayment, blockNumberKey, payments, []byte{})
+	trx.Put(storage.Pool.Assets, assetID[:], blockNumberKey, packedAsset)
+	trx.Put(storage.Pool.Transactions, assetTxID[:], blockNumberKey, packedIssue)
+	ownership.CreateAsset(trx, assetTxID, 2, assetID, &owner)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	return reservoir.Get()
+}
+
+func teardownSelection() {
+	_ = reservoir.Finalise()
+	asset.Finalise()
+	teardown()
+}
+
+// store a verified block of paid issues of the test asset, nonce 1 is
+// the confirmed issue
+func storeSelectionIssues(t *testing.T, rsvr reservoir.Reservoir, issuer *account.Account, key []byte, nonces ...uint64) []merkle.Digest {
+	issues := make([]*transactionrecord.BitmarkIssue, len(nonces))
+	for i, nonce := range nonces {
+		issue := &transactionrecord.BitmarkIssue{
+			AssetId: assetID,
+			Owner:   issuer,
+			Nonce:   nonce,
+		}
+		packed, err := issue.Pack(issuer)
+		if fault.InvalidSignature != err {
+			t.Fatalf("issue pack error: %s", err)
+		}
+		issue.Signature = ed25519.Sign(key, packed)
+		issues[i] = issue
+	}
+
+	info, _, err := rsvr.StoreIssues(issues)
+	if nil != err {
+		t.Fatalf("store issues error: %s", err)
+	}
+	for _, txId := range info.TxIds {
+		assert.Equal(t, reservoir.StateVerified, rsvr.TransactionStatus(txId), "issue state")
+	}
+	return info.TxIds
+}
+
+func TestNewSelectorWhenInvalidLimit(t *testing.T) {
+	_, err := reservoir.NewSelector(reservoir.SelectionPolicy{AccountLimit: -1})
+	assert.Equal(t, fault.InvalidCount, err, "wrong error")
+}
+
+func TestSelectorDefault(t *testing.T) {
+	rsvr := setupSelection(t)
+	defer teardownSelection()
+
+	issued := storeSelectionIssues(t, rsvr, &owner, privateKey, 5, 6)
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+
+	selector, err := reservoir.NewSelector(reservoir.SelectionPolicy{})
+	assert.Nil(t, err, "new selector")
+
+	txIds, data, err := selector.FetchVerified(selectionCount)
+	assert.Nil(t, err, "fetch verified")
+
+	expectedIds, expectedData, err := reservoir.FetchVerified(selectionCount)
+	assert.Nil(t, err, "reservoir fetch verified")
+
+	// transactions follow the issues
+	assert.Equal(t, append(issued, info.TxId), expectedIds, "wrong order")
+	assert.Equal(t, expectedIds, txIds, "wrong tx ids")
+	assert.Equal(t, expectedData, data, "wrong data")
+
+	_, _, err = selector.FetchVerified(1)
+	assert.Equal(t, fault.InvalidCount, err, "wrong error")
+}
+
+func TestSelectorOldestFirst(t *testing.T) {
+	rsvr := setupSelection(t)
+	defer teardownSelection()
+
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+	issued := storeSelectionIssues(t, rsvr, &owner, privateKey, 5, 6)
+
+	selector, err := reservoir.NewSelector(reservoir.SelectionPolicy{OldestFirst: true})
+	assert.Nil(t, err, "new selector")
+
+	txIds, _, err := selector.FetchVerified(selectionCount)
+	assert.Nil(t, err, "fetch verified")
+	assert.Equal(t, append([]merkle.Digest{info.TxId}, issued...), txIds, "wrong order")
+
+	// by kind paid issues come before transactions
+	selector, err = reservoir.NewSelector(reservoir.SelectionPolicy{PaidFirst: true})
+	assert.Nil(t, err, "new selector")
+
+	txIds, _, err = selector.FetchVerified(selectionCount)
+	assert.Nil(t, err, "fetch verified")
+	assert.Equal(t, append(issued, info.TxId), txIds, "wrong order")
+}
+
+func TestSelectorAccountLimit(t *testing.T) {
+	rsvr := setupSelection(t)
+	defer teardownSelection()
+
+	first := storeSelectionIssues(t, rsvr, &owner, privateKey, 5)
+	second := storeSelectionIssues(t, rsvr, &owner, privateKey, 6)
+	third := storeSelectionIssues(t, rsvr, &owner, privateKey, 7)
+
+	// a single block larger than the limit is not split
+	large := storeSelectionIssues(t, rsvr, &owner2, privateKey2, 10, 11, 12)
+
+	selector, err := reservoir.NewSelector(reservoir.SelectionPolicy{
+		OldestFirst:  true,
+		AccountLimit: 2,
+	})
+	assert.Nil(t, err, "new selector")
+
+	txIds, _, err := selector.FetchVerified(selectionCount)
+	assert.Nil(t, err, "fetch verified")
+
+	expected := append(append(first, second...), large...)
+	assert.Equal(t, expected, txIds, "wrong tx ids")
+	assert.NotContains(t, txIds, third[0], "issue over limit selected")
+}
+
+func TestSelectorFetchLimits(t *testing.T) {
+	rsvr := setupSelection(t)
+	defer teardownSelection()
+
+	// more paid issues than a fetch takes before the transactions
+	issued := make([]merkle.Digest, 0, 2100)
+	for nonce := uint64(5); nonce < 2105; nonce += reservoir.MaximumIssues {
+		nonces := make([]uint64, reservoir.MaximumIssues)
+		for i := range nonces {
+			nonces[i] = nonce + uint64(i)
+		}
+		issued = append(issued, storeSelectionIssues(t, rsvr, &owner, privateKey, nonces...)...)
+	}
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+
+	selector, err := reservoir.NewSelector(reservoir.SelectionPolicy{PaidFirst: true})
+	assert.Nil(t, err, "new selector")
+
+	txIds, _, err := selector.FetchVerified(selectionCount)
+	assert.Nil(t, err, "fetch verified")
+	assert.Equal(t, len(issued)+1, len(txIds), "wrong count")
+	assert.Equal(t, info.TxId, txIds[2000], "transaction not after the paid issue limit")
+	assert.ElementsMatch(t, issued, append(txIds[:2000:2000], txIds[2001:]...), "wrong issues")
+}
+
+// a free issue of a new cached asset
+func makeFreeIssue(t *testing.T, issuer *account.Account, key []byte, fingerprint string) *transactionrecord.BitmarkIssue {
+	newAsset := &transactionrecord.AssetData{
+		Name:        "free asset",
+		Fingerprint: fingerprint,
+		Metadata:    "",
+		Registrant:  issuer,
+	}
+	packed, err := newAsset.Pack(issuer)
+	if fault.InvalidSignature != err {
+		t.Fatalf("asset pack error: %s", err)
+	}
+	newAsset.Signature = ed25519.Sign(key, packed)
+	newAssetId, _, err := asset.Cache(newAsset, storage.Pool.Assets)
+	if nil != err {
+		t.Fatalf("asset cache error: %s", err)
+	}
+
+	issue := &transactionrecord.BitmarkIssue{
+		AssetId: *newAssetId,
+		Owner:   issuer,
+		Nonce:   0,
+	}
+	packed, err = issue.Pack(issuer)
+	if fault.InvalidSignature != err {
+		t.Fatalf("issue pack error: %s", err)
+	}
+	issue.Signature = ed25519.Sign(key, packed)
+	return issue
+}
+
+func TestStoreIssuesAccountLimit(t *testing.T) {
+	rsvr := setupSelection(t)
+	defer teardownSelection()
+
+	err := reservoir.SetAccountLimit(-1)
+	assert.Equal(t, fault.InvalidCount, err, "wrong error")
+
+	err = reservoir.SetAccountLimit(2)
+	assert.Nil(t, err, "set account limit")
+
+	// a first block larger than the limit is allowed
+	_, _, err = rsvr.StoreIssues([]*transactionrecord.BitmarkIssue{
+		makeFreeIssue(t, &owner, privateKey, "fingerprint-1"),
+		makeFreeIssue(t, &owner, privateKey, "fingerprint-2"),
+		makeFreeIssue(t, &owner, privateKey, "fingerprint-3"),
+	})
+	assert.Nil(t, err, "store first block")
+
+	_, _, err = rsvr.StoreIssues([]*transactionrecord.BitmarkIssue{
+		makeFreeIssue(t, &owner, privateKey, "fingerprint-4"),
+	})
+	assert.Equal(t, fault.TooManyPendingFreeIssues, err, "wrong error")
+
+	// other issuers are not affected
+	_, _, err = rsvr.StoreIssues([]*transactionrecord.BitmarkIssue{
+		makeFreeIssue(t, &owner2, privateKey2, "fingerprint-5"),
+		makeFreeIssue(t, &owner2, privateKey2, "fingerprint-6"),
+	})
+	assert.Nil(t, err, "store other issuer")
+
+	// without a limit the issuer may add more
+	err = reservoir.SetAccountLimit(0)
+	assert.Nil(t, err, "clear account limit")
+
+	_, _, err = rsvr.StoreIssues([]*transactionrecord.BitmarkIssue{
+		makeFreeIssue(t, &owner, privateKey, "fingerprint-4"),
+	})
+	assert.Nil(t, err, "store without limit")
+}
+
+func TestSelectorPaidIssueWithoutConfirmedAsset(t *testing.T) {
+	rsvr := setupSelection(t)
+	defer teardownSelection()
+
+	_ = storeSelectionIssues(t, rsvr, &owner, privateKey, 5, 6)
+	info, _, err := rsvr.StoreTransfer(escrowTransfer(t, nil))
+	assert.Nil(t, err, "store transfer")
+
+	// the asset is no longer confirmed, e.g. after a block was deleted
+	trx, _ := storage.NewDBTransaction()
+	trx.Delete(storage.Pool.Assets, assetID[:])
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	selector, err := reservoir.NewSelector(reservoir.SelectionPolicy{PaidFirst: true})
+	assert.Nil(t, err, "new selector")
+
+	txIds, _, err := selector.FetchVerified(selectionCount)
+	assert.Nil(t, err, "fetch verified")
+	assert.Equal(t, []merkle.Digest{info.TxId}, txIds, "paid issues selected")
+}
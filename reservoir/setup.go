@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/asset"
 	"github.com/bitmark-inc/bitmarkd/background"
+	"github.com/bitmark-inc/bitmarkd/blockheader"
 	"github.com/bitmark-inc/bitmarkd/blockrecord"
 	"github.com/bitmark-inc/bitmarkd/currency"
 	"github.com/bitmark-inc/bitmarkd/difficulty"
@@ -44,6 +46,7 @@ type transactionData struct {
 	txId        merkle.Digest                 // transaction id
 	transaction transactionrecord.Transaction // unpacked transaction
 	packed      transactionrecord.Packed      // transaction bytes
+	received    time.Time                     // when first stored, for selection by age
 }
 
 // key: pay id
@@ -51,6 +54,7 @@ type transactionPaymentData struct {
 	tx        *transactionData                       // record on this pay id
 	payId     pay.PayId                              // for payment matching
 	payments  []transactionrecord.PaymentAlternative // required payment
+	escrow    *transactionrecord.Payment             // escrow that must also be paid
 	expiresAt time.Time                              // only used in pending state
 }
 
@@ -87,6 +91,8 @@ type spendKey struct {
 // Handles - storage handles used when restore from cache file
 type Handles struct {
 	Assets            storage.Handle
+	AssetVersions     storage.Handle
+	AssetMetadata     storage.Handle
 	BlockOwnerPayment storage.Handle
 	Blocks            storage.Handle
 	Transactions      storage.Handle
@@ -94,6 +100,7 @@ type Handles struct {
 	OwnerData         storage.Handle
 	Shares            storage.Handle
 	ShareQuantity     storage.Handle
+	ShareHolders      storage.Handle
 }
 
 type globalDataType struct {
@@ -117,6 +124,9 @@ type globalDataType struct {
 	// Link -> TxId to check for double spend
 	inProgressLinks map[merkle.Digest]merkle.Digest
 
+	// asset id -> TxId of the single pending amendment
+	inProgressAssets map[transactionrecord.AssetIdentifier]merkle.Digest
+
 	// separate pending pools
 	pendingTransactions map[pay.PayId]*transactionPaymentData
 	pendingFreeIssues   map[pay.PayId]*issueFreeData
@@ -126,6 +136,10 @@ type globalDataType struct {
 	pendingFreeCount int
 	pendingPaidCount int
 
+	// pending free issues of each issuer, limited by accountLimit
+	pendingFreeIssuers map[issuerKey]int
+	accountLimit       int
+
 	// payments that are valid but have no pending record
 	// ***** FIX THIS: need to expire
 	orphanPayments map[pay.PayId]*PaymentDetail
@@ -139,6 +153,9 @@ type globalDataType struct {
 	// if true the assume all payments are successful and verify immediately
 	autoVerify bool
 
+	// closed and replaced whenever a transaction changes state
+	changed chan struct{}
+
 	// set once during initialise
 	initialised bool
 }
@@ -172,10 +189,26 @@ func (g *globalDataType) TransactionStatus(txID merkle.Digest) TransactionState
 	return transactionStatus(txID)
 }
 
+func (g *globalDataType) WaitTransactionStatus(txID merkle.Digest, current TransactionState, timeout time.Duration) TransactionState {
+	return waitTransactionStatus(txID, current, timeout)
+}
+
+func (g *globalDataType) PendingPayment(txID merkle.Digest) (pay.PayId, []transactionrecord.PaymentAlternative, bool) {
+	return pendingPayment(txID)
+}
+
 func (g *globalDataType) ShareBalance(owner *account.Account, startSharedID merkle.Digest, count int) ([]BalanceInfo, error) {
 	return shareBalance(owner, startSharedID, count, g.handles.ShareQuantity)
 }
 
+func (g *globalDataType) ShareHolders(shareId merkle.Digest, start *account.Account, count int) (*HoldersInfo, error) {
+	return shareHolders(shareId, start, count, g.handles.Shares, g.handles.ShareHolders)
+}
+
+func (g *globalDataType) ShareHoldings(owner *account.Account, startShareId merkle.Digest, count int) (*HoldingsInfo, error) {
+	return shareHoldings(owner, startShareId, count, g.handles.Shares, g.handles.ShareQuantity)
+}
+
 func (g *globalDataType) StoreGrant(grant *transactionrecord.ShareGrant) (*GrantInfo, bool, error) {
 	return storeGrant(
 		grant,
@@ -197,15 +230,55 @@ func (g *globalDataType) StoreSwap(swap *transactionrecord.ShareSwap) (*SwapInfo
 	)
 }
 
+func (g *globalDataType) StoreRedemption(redemption *transactionrecord.ShareRedemption) (*RedemptionInfo, bool, error) {
+	return storeRedemption(
+		redemption,
+		g.handles.ShareQuantity,
+		g.handles.Shares,
+		g.handles.OwnerData,
+		g.handles.BlockOwnerPayment,
+		g.handles.Transactions,
+	)
+}
+
+func (g *globalDataType) StoreAtomicSwap(swap *transactionrecord.AtomicSwap) (*AtomicSwapInfo, bool, error) {
+	return storeAtomicSwap(
+		swap,
+		g.handles.ShareQuantity,
+		g.handles.Shares,
+		g.handles.OwnerTxIndex,
+		g.handles.OwnerData,
+		g.handles.BlockOwnerPayment,
+		g.handles.Transactions,
+	)
+}
+
+func (g *globalDataType) StoreAmendment(amendment *transactionrecord.AssetAmendment) (*AmendmentInfo, bool, error) {
+	return storeAmendment(
+		amendment,
+		g.handles.Assets,
+		g.handles.AssetVersions,
+		g.handles.BlockOwnerPayment,
+		g.handles.Transactions,
+	)
+}
+
 // Reservoir - APIs
 type Reservoir interface {
 	StoreTransfer(transactionrecord.BitmarkTransfer) (*TransferInfo, bool, error)
 	StoreIssues(issues []*transactionrecord.BitmarkIssue) (*IssueInfo, bool, error)
 	TryProof(pay.PayId, []byte) TrackingStatus
 	TransactionStatus(merkle.Digest) TransactionState
+	WaitTransactionStatus(merkle.Digest, TransactionState, time.Duration) TransactionState
+	PendingPayment(merkle.Digest) (pay.PayId, []transactionrecord.PaymentAlternative, bool)
 	ShareBalance(*account.Account, merkle.Digest, int) ([]BalanceInfo, error)
+	ShareHolders(merkle.Digest, *account.Account, int) (*HoldersInfo, error)
+	ShareHoldings(*account.Account, merkle.Digest, int) (*HoldingsInfo, error)
 	StoreGrant(*transactionrecord.ShareGrant) (*GrantInfo, bool, error)
 	StoreSwap(swap *transactionrecord.ShareSwap) (*SwapInfo, bool, error)
+	StoreRedemption(*transactionrecord.ShareRedemption) (*RedemptionInfo, bool, error)
+	StoreAtomicSwap(*transactionrecord.AtomicSwap) (*AtomicSwapInfo, bool, error)
+	StoreAmendment(*transactionrecord.AssetAmendment) (*AmendmentInfo, bool, error)
 }
 
 // Get - return reservoir APIs
@@ -231,6 +304,8 @@ func Initialise(cacheDirectory string, handles Handles, autoVerify bool) error {
 	globalData.log.Info("starting…")
 
 	globalData.inProgressLinks = make(map[merkle.Digest]merkle.Digest)
+	globalData.inProgressAssets = make(map[transactionrecord.AssetIdentifier]merkle.Digest)
+	globalData.changed = make(chan struct{})
 
 	globalData.verifiedTransactions = make(map[pay.PayId]*transactionData)
 	globalData.verifiedFreeIssues = make(map[pay.PayId]*issueFreeData)
@@ -245,6 +320,9 @@ func Initialise(cacheDirectory string, handles Handles, autoVerify bool) error {
 	globalData.pendingFreeCount = 0
 	globalData.pendingPaidCount = 0
 
+	globalData.pendingFreeIssuers = make(map[issuerKey]int)
+	globalData.accountLimit = 0
+
 	globalData.orphanPayments = make(map[pay.PayId]*PaymentDetail)
 
 	globalData.spend = make(map[spendKey]uint64)
@@ -253,6 +331,8 @@ func Initialise(cacheDirectory string, handles Handles, autoVerify bool) error {
 
 	globalData.filename = path.Join(cacheDirectory, reservoirFile)
 
+	initialiseJournal(path.Join(cacheDirectory, journalFile))
+
 	globalData.handles = handles
 
 	// if true the assume all payments are successful
@@ -274,6 +354,7 @@ func Initialise(cacheDirectory string, handles Handles, autoVerify bool) error {
 	processes := background.Processes{
 		&rebroadcaster{},
 		&cleaner{},
+		&compactor{},
 	}
 
 	globalData.background = background.Start(processes, nil)
@@ -294,8 +375,9 @@ func Finalise() error {
 	// stop background
 	globalData.background.Stop()
 
-	// save data
-	saveToFile()
+	// save data, the journal is only kept if the save failed
+	err := saveToFile()
+	finaliseJournal(nil == err)
 
 	// finally...
 	globalData.initialised = false
@@ -364,6 +446,60 @@ func transactionStatus(txId merkle.Digest) TransactionState {
 	return StateUnknown
 }
 
+// waitTransactionStatus - block until the status of a transaction
+// differs from current or the timeout expires
+func waitTransactionStatus(txId merkle.Digest, current TransactionState, timeout time.Duration) TransactionState {
+	expired := time.After(timeout)
+	for {
+		// take the channel before checking so no change is missed
+		globalData.RLock()
+		changed := globalData.changed
+		globalData.RUnlock()
+
+		state := transactionStatus(txId)
+		if state != current {
+			return state
+		}
+
+		select {
+		case <-changed:
+		case <-expired:
+			return state
+		}
+	}
+}
+
+// pendingPayment - the payments that will verify a pending transaction
+//
+// free issues waiting for a proof have no payments
+func pendingPayment(txId merkle.Digest) (pay.PayId, []transactionrecord.PaymentAlternative, bool) {
+	globalData.RLock()
+	defer globalData.RUnlock()
+
+	payId, ok := globalData.pendingIndex[txId]
+	if !ok {
+		return pay.PayId{}, nil, false
+	}
+
+	if entry, ok := globalData.pendingTransactions[payId]; ok {
+		return payId, entry.payments, true
+	}
+	if entry, ok := globalData.pendingPaidIssues[payId]; ok {
+		return payId, entry.payments, true
+	}
+	return payId, nil, true
+}
+
+// wake any status waiters
+// Lock must be held before calling this
+func statusChanged() {
+	if nil == globalData.changed {
+		return
+	}
+	close(globalData.changed)
+	globalData.changed = make(chan struct{})
+}
+
 // move transaction(s) to verified cache
 func setVerified(payId pay.PayId, detail *PaymentDetail) bool {
 
@@ -376,7 +512,7 @@ func setVerified(payId pay.PayId, detail *PaymentDetail) bool {
 
 	// single transaction
 	if entry, ok := globalData.pendingTransactions[payId]; ok {
-		if !acceptablePayment(detail, entry.payments) {
+		if !acceptablePayment(detail, entry.payments) || !escrowPaid(detail, entry.escrow) {
 			globalData.log.Warnf("single transaction failed check for txid: %s  payid: %s", detail.TxID, payId)
 			return false
 		}
@@ -389,6 +525,7 @@ func setVerified(payId pay.PayId, detail *PaymentDetail) bool {
 
 		delete(globalData.pendingIndex, txId)
 		globalData.verifiedIndex[txId] = payId
+		statusChanged()
 
 		return true
 	}
@@ -411,6 +548,7 @@ func setVerified(payId pay.PayId, detail *PaymentDetail) bool {
 			delete(globalData.pendingIndex, txId)
 			globalData.verifiedIndex[txId] = payId
 		}
+		statusChanged()
 
 		return true
 	}
@@ -428,12 +566,20 @@ func acceptablePayment(detail *PaymentDetail, payments []transactionrecord.Payme
 
 next_currency:
 	for _, p := range payments {
-		acceptable := true
+
+		// an address can appear more than once, e.g. a fee and an
+		// escrow, so it must be paid the total of its amounts
+		required := make(map[string]uint64)
 		for _, item := range p {
 			if item.Currency != detail.Currency {
 				continue next_currency
 			}
-			if detail.Amounts[item.Address] < item.Amount {
+			required[item.Address] += item.Amount
+		}
+
+		acceptable := true
+		for address, amount := range required {
+			if detail.Amounts[address] < amount {
 				acceptable = false
 			}
 		}
@@ -444,11 +590,28 @@ next_currency:
 	return false
 }
 
+// check that a payment includes the escrow amount, an escrow is never
+// auto verified since it is a payment to the previous owner and not a
+// fee
+//
+// without auto verify the escrow is also part of the payments checked
+// by acceptablePayment, which adds it to any fee for the same address
+func escrowPaid(detail *PaymentDetail, escrow *transactionrecord.Payment) bool {
+	if nil == escrow {
+		return true
+	}
+	if nil == detail || detail.Currency != escrow.Currency {
+		return false
+	}
+	return detail.Amounts[escrow.Address] >= escrow.Amount
+}
+
 // SetTransferVerified - set verified if transaction found, otherwise preserv payment for later
 func SetTransferVerified(payId pay.PayId, detail *PaymentDetail) {
 	globalData.log.Infof("txid: %s  payid: %s", detail.TxID, payId)
 
 	globalData.Lock()
+	journalPayment(payId, detail)
 	if !setVerified(payId, detail) {
 		globalData.log.Debugf("orphan payment: txid: %s  payid: %s", detail.TxID, payId)
 		globalData.orphanPayments[payId] = detail
@@ -456,6 +619,36 @@ func SetTransferVerified(payId pay.PayId, detail *PaymentDetail) {
 	globalData.Unlock()
 }
 
+// the newer record types are kept out of the reservoir until the chain
+// has reached the block version that allows them, so they cannot be
+// put in a block that other nodes would reject
+func checkBlockVersion(transaction transactionrecord.Transaction) error {
+	if !transactionrecord.IsExtendedRecord(transaction) {
+		return nil
+	}
+	_, _, version, _ := blockheader.Get()
+	if !blockrecord.IsExtendedRecordsAllowedVersion(version) {
+		return fault.RecordNotAllowedInBlockVersion
+	}
+	return nil
+}
+
+// SetAccountLimit - maximum pending free issues from one issuer, zero
+// for no limit
+//
+// this is the same limit as SelectionPolicy.AccountLimit, so a single
+// issuer cannot fill the free issue buffer with issues that could
+// never be selected
+func SetAccountLimit(limit int) error {
+	if limit < 0 {
+		return fault.InvalidCount
+	}
+	globalData.Lock()
+	globalData.accountLimit = limit
+	globalData.Unlock()
+	return nil
+}
+
 // Disable - lock down to prevent proofer from getting data
 func Disable() {
 	globalData.Lock()
@@ -464,9 +657,13 @@ func Disable() {
 }
 
 // Enable - allow proofer to run again
+//
+// called when a block has been stored, so confirmed transactions have
+// left the reservoir
 func Enable() {
 	globalData.Lock()
 	globalData.enabled = true
+	statusChanged()
 	globalData.Unlock()
 }
 
@@ -590,6 +787,32 @@ func rescanItem(item *transactionData) {
 			globalData.spend[k] += tx.QuantityTwo
 		}
 
+	case *transactionrecord.ShareRedemption:
+		ownerData, err := CheckRedemptionBalance(nil, tx, storage.Pool.ShareQuantity, storage.Pool.Shares, storage.Pool.OwnerData)
+		if nil != err {
+			internalDeleteByTxId(txId)
+		} else {
+			k := makeSpendKey(tx.Owner, ownerData.IssueTxId())
+			globalData.spend[k] += tx.Quantity
+		}
+
+	case *transactionrecord.AtomicSwap:
+		_, err := CheckAtomicSwap(nil, tx, storage.Pool.ShareQuantity, storage.Pool.OwnerTxIndex, storage.Pool.OwnerData)
+		if nil != err {
+			internalDeleteByTxId(txId)
+		} else {
+			for _, leg := range tx.Shares {
+				k := makeSpendKey(leg.Owner, leg.ShareId)
+				globalData.spend[k] += leg.Quantity
+			}
+		}
+
+	case *transactionrecord.AssetAmendment:
+		_, err := asset.CheckAmendment(nil, tx, storage.Pool.Assets, storage.Pool.AssetVersions)
+		if nil != err {
+			internalDeleteByTxId(txId)
+		}
+
 	default:
 		// undefined data in the memory pool - so panic
 		globalData.log.Criticalf("reservoir rescan unhandled transaction: %v", tx)
@@ -643,6 +866,8 @@ func DeleteByLink(link merkle.Digest) {
 // Lock must be held before calling this
 func internalDelete(payId pay.PayId) {
 
+	journalRemove(payId)
+
 	// pending
 
 	if entry, ok := globalData.pendingTransactions[payId]; ok {
@@ -651,6 +876,14 @@ func internalDelete(payId pay.PayId) {
 			link := transfer.GetLink()
 			delete(globalData.inProgressLinks, link)
 		}
+		if swap, ok := entry.tx.transaction.(*transactionrecord.AtomicSwap); ok {
+			for _, leg := range swap.Bitmarks {
+				delete(globalData.inProgressLinks, leg.Link)
+			}
+		}
+		if amendment, ok := entry.tx.transaction.(*transactionrecord.AssetAmendment); ok {
+			delete(globalData.inProgressAssets, amendment.AssetId)
+		}
 		delete(globalData.pendingTransactions, payId)
 	}
 
@@ -659,6 +892,7 @@ func internalDelete(payId pay.PayId) {
 			delete(globalData.pendingIndex, tx.txId)
 		}
 		globalData.pendingFreeCount -= len(entry.txs)
+		removePendingFreeIssuer(entry.txs)
 		delete(globalData.pendingFreeIssues, payId)
 	}
 
@@ -678,6 +912,14 @@ func internalDelete(payId pay.PayId) {
 			link := transfer.GetLink()
 			delete(globalData.inProgressLinks, link)
 		}
+		if swap, ok := entry.transaction.(*transactionrecord.AtomicSwap); ok {
+			for _, leg := range swap.Bitmarks {
+				delete(globalData.inProgressLinks, leg.Link)
+			}
+		}
+		if amendment, ok := entry.transaction.(*transactionrecord.AssetAmendment); ok {
+			delete(globalData.inProgressAssets, amendment.AssetId)
+		}
 		delete(globalData.verifiedTransactions, payId)
 	}
 
@@ -102,6 +102,7 @@ func storeGrant(
 		txId:        txId,
 		transaction: grant,
 		packed:      packedGrant,
+		received:    time.Now(),
 	}
 
 	// already received the payment for the grant
@@ -114,9 +115,11 @@ func storeGrant(
 			delete(globalData.pendingTransactions, payId)
 			delete(globalData.pendingIndex, txId)
 			delete(globalData.orphanPayments, payId)
+			statusChanged()
 
 			globalData.spend[spendKey] += grant.Quantity
 			result.Remaining -= grant.Quantity
+			journalTransaction(result.Packed)
 			return result, false, nil
 		}
 	}
@@ -131,9 +134,11 @@ func storeGrant(
 
 	globalData.pendingTransactions[payId] = payment
 	globalData.pendingIndex[txId] = payId
+	statusChanged()
 	globalData.spend[spendKey] += grant.Quantity
 	result.Remaining -= grant.Quantity
 
+	journalTransaction(result.Packed)
 	return result, false, nil
 }
 
@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/storage"
+)
+
+// HolderInfo - balance of one account holding a share
+type HolderInfo struct {
+	Owner     *account.Account `json:"owner"`
+	Confirmed uint64           `json:"confirmed"`
+	Spend     uint64           `json:"spend"`
+	Available uint64           `json:"available"`
+}
+
+// HoldersInfo - a page of holders of a share
+//
+// Total is the confirmed balance of every holder, not just those in
+// this page, and always equals the Quantity created by the share
+type HoldersInfo struct {
+	ShareId   merkle.Digest    `json:"shareId"`
+	Quantity  uint64           `json:"quantity"`
+	Total     uint64           `json:"total"`
+	Holders   []HolderInfo     `json:"holders"`
+	NextStart *account.Account `json:"nextStart,omitempty"`
+}
+
+// HoldingInfo - balance of one share held by an account
+type HoldingInfo struct {
+	BalanceInfo
+	Quantity uint64 `json:"quantity"` // total created by the share
+}
+
+// HoldingsInfo - a page of the shares held by an account
+type HoldingsInfo struct {
+	Holdings  []HoldingInfo  `json:"holdings"`
+	NextStart *merkle.Digest `json:"nextStart,omitempty"`
+}
+
+// to end a cursor Map early
+var errEndOfRange = errors.New("end of range")
+
+// total quantity created for a share
+func shareQuantity(shareId merkle.Digest, shareHandle storage.Handle) (uint64, bool) {
+	value := shareHandle.Get(shareId[:])
+	if len(value) < 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(value[:8]), true
+}
+
+// pending spend of a share by an account
+func pendingSpend(owner *account.Account, shareId merkle.Digest) uint64 {
+	spendKey := makeSpendKey(owner, shareId)
+
+	globalData.RLock()
+	spend := globalData.spend[spendKey]
+	globalData.RUnlock()
+
+	return spend
+}
+
+// shareHolders - list the holders of a share starting from an account
+//
+// the whole share is scanned so that the total reconciles with the
+// quantity even when only one page is returned
+func shareHolders(shareId merkle.Digest, start *account.Account, count int, shareHandle storage.Handle, holdersHandle storage.Handle) (*HoldersInfo, error) {
+	if nil == shareHandle || nil == holdersHandle {
+		return nil, fault.NilPointer
+	}
+	if count <= 0 {
+		return nil, fault.InvalidCount
+	}
+
+	quantity, ok := shareQuantity(shareId, shareHandle)
+	if !ok {
+		return nil, fault.ShareNotFound
+	}
+
+	startKey := shareId[:]
+	if nil != start {
+		startKey = append(shareId[:], start.Bytes()...)
+	}
+
+	result := &HoldersInfo{
+		ShareId:  shareId,
+		Quantity: quantity,
+		Holders:  make([]HolderInfo, 0, count),
+	}
+
+	err := holdersHandle.NewFetchCursor().Seek(shareId[:]).Map(func(key []byte, value []byte) error {
+		if !bytes.HasPrefix(key, shareId[:]) {
+			return errEndOfRange
+		}
+		if len(value) < 8 {
+			return fault.InvalidBuffer
+		}
+		balance := binary.BigEndian.Uint64(value[:8])
+		result.Total += balance
+
+		if bytes.Compare(key, startKey) < 0 || nil != result.NextStart {
+			return nil
+		}
+
+		owner, err := account.AccountFromBytes(key[len(shareId):])
+		if nil != err {
+			return err
+		}
+		if len(result.Holders) >= count {
+			result.NextStart = owner
+			return nil
+		}
+
+		spend := pendingSpend(owner, shareId)
+		result.Holders = append(result.Holders, HolderInfo{
+			Owner:     owner,
+			Confirmed: balance,
+			Spend:     spend,
+			Available: balance - spend,
+		})
+		return nil
+	})
+	if nil != err && errEndOfRange != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// shareHoldings - list the shares held by an account starting from a share
+func shareHoldings(owner *account.Account, startShareId merkle.Digest, count int, shareHandle storage.Handle, quantityHandle storage.Handle) (*HoldingsInfo, error) {
+	if nil == shareHandle || nil == quantityHandle {
+		return nil, fault.NilPointer
+	}
+	if count <= 0 {
+		return nil, fault.InvalidCount
+	}
+
+	ownerBytes := owner.Bytes()
+
+	result := &HoldingsInfo{
+		Holdings: make([]HoldingInfo, 0, count),
+	}
+
+	err := quantityHandle.NewFetchCursor().Seek(append(ownerBytes, startShareId[:]...)).Map(func(key []byte, value []byte) error {
+		if len(key) != len(ownerBytes)+merkle.DigestLength || !bytes.HasPrefix(key, ownerBytes) {
+			return errEndOfRange
+		}
+		if len(value) < 8 {
+			return fault.InvalidBuffer
+		}
+
+		var shareId merkle.Digest
+		copy(shareId[:], key[len(ownerBytes):])
+
+		if len(result.Holdings) >= count {
+			result.NextStart = &shareId
+			return errEndOfRange
+		}
+
+		balance := binary.BigEndian.Uint64(value[:8])
+		quantity, _ := shareQuantity(shareId, shareHandle)
+		spend := pendingSpend(owner, shareId)
+
+		result.Holdings = append(result.Holdings, HoldingInfo{
+			BalanceInfo: BalanceInfo{
+				ShareId:   shareId,
+				Confirmed: balance,
+				Spend:     spend,
+				Available: balance - spend,
+			},
+			Quantity: quantity,
+		})
+		return nil
+	})
+	if nil != err && errEndOfRange != err {
+		return nil, err
+	}
+
+	return result, nil
+}
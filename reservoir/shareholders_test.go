@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+)
+
+// create a share in the database with the given balances
+func storeShareBalances(t *testing.T, shareId merkle.Digest, balances map[*account.Account]uint64) {
+	total := uint64(0)
+	for _, balance := range balances {
+		total += balance
+	}
+
+	trx, err := storage.NewDBTransaction()
+	if nil != err {
+		t.Fatalf("transaction error: %s", err)
+	}
+	shareData := make([]byte, 8, 8+merkle.DigestLength)
+	binary.BigEndian.PutUint64(shareData, total)
+	shareData = append(shareData, shareId[:]...)
+	trx.Put(storage.Pool.Shares, shareId[:], shareData, []byte{})
+
+	for owner, balance := range balances {
+		ownership.SetShareBalance(trx, owner, shareId, balance)
+	}
+	err = trx.Commit()
+	if nil != err {
+		t.Fatalf("commit error: %s", err)
+	}
+}
+
+func TestShareHoldersAndHoldings(t *testing.T) {
+	setup(t, chain.Testing)
+	defer teardown()
+
+	// another test may have left the reservoir running
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Shares:        storage.Pool.Shares,
+		ShareQuantity: storage.Pool.ShareQuantity,
+		ShareHolders:  storage.Pool.ShareHolders,
+	}, false)
+	assert.Nil(t, err, "reservoir initialise")
+	defer reservoir.Finalise()
+
+	rsvr := reservoir.Get()
+
+	seed, _ := account.NewBase58EncodedSeedV2(true)
+	p, _ := account.PrivateKeyFromBase58Seed(seed)
+	owner3 := p.Account()
+
+	shareOne := merkle.Digest{1, 2, 3}
+	shareTwo := merkle.Digest{4, 5, 6}
+	storeShareBalances(t, shareOne, map[*account.Account]uint64{
+		&owner:  60,
+		&owner2: 30,
+		owner3:  10,
+	})
+	storeShareBalances(t, shareTwo, map[*account.Account]uint64{
+		&owner: 7,
+	})
+
+	// page through the holders of share one
+	holders := []reservoir.HolderInfo{}
+	var start *account.Account
+	for i := 0; i < 3; i += 1 {
+		page, err := rsvr.ShareHolders(shareOne, start, 2)
+		assert.Nil(t, err, "holders")
+		assert.Equal(t, uint64(100), page.Quantity, "quantity")
+		assert.Equal(t, page.Quantity, page.Total, "total does not reconcile")
+		holders = append(holders, page.Holders...)
+		start = page.NextStart
+		if nil == start {
+			break
+		}
+	}
+	assert.Nil(t, start, "should be no further pages")
+	assert.Equal(t, 3, len(holders), "holder count")
+
+	sum := uint64(0)
+	for _, h := range holders {
+		sum += h.Confirmed
+		assert.Equal(t, h.Confirmed, h.Available, "available without pending spend")
+	}
+	assert.Equal(t, uint64(100), sum, "page balances do not reconcile")
+
+	// a zero balance removes the holder
+	trx, _ := storage.NewDBTransaction()
+	ownership.SetShareBalance(trx, owner3, shareOne, 0)
+	ownership.SetShareBalance(trx, &owner2, shareOne, 40)
+	_ = trx.Commit()
+
+	page, err := rsvr.ShareHolders(shareOne, nil, 10)
+	assert.Nil(t, err, "holders")
+	assert.Equal(t, 2, len(page.Holders), "holder count after zero balance")
+	assert.Equal(t, page.Quantity, page.Total, "total does not reconcile")
+
+	// holdings of the first owner cover both shares
+	holdings, err := rsvr.ShareHoldings(&owner, merkle.Digest{}, 1)
+	assert.Nil(t, err, "holdings")
+	assert.Equal(t, 1, len(holdings.Holdings), "first page")
+	assert.Equal(t, shareOne, holdings.Holdings[0].ShareId, "first share")
+	assert.Equal(t, uint64(60), holdings.Holdings[0].Confirmed, "first balance")
+	assert.Equal(t, uint64(100), holdings.Holdings[0].Quantity, "first quantity")
+	assert.NotNil(t, holdings.NextStart, "next start")
+
+	holdings, err = rsvr.ShareHoldings(&owner, *holdings.NextStart, 1)
+	assert.Nil(t, err, "holdings")
+	assert.Equal(t, 1, len(holdings.Holdings), "second page")
+	assert.Equal(t, shareTwo, holdings.Holdings[0].ShareId, "second share")
+	assert.Equal(t, uint64(7), holdings.Holdings[0].Quantity, "second quantity")
+	assert.Nil(t, holdings.NextStart, "no more holdings")
+
+	_, err = rsvr.ShareHolders(merkle.Digest{9}, nil, 10)
+	assert.Equal(t, fault.ShareNotFound, err, "missing share")
+}
+
+func TestRebuildShareHolders(t *testing.T) {
+	setup(t, chain.Testing)
+	defer teardown()
+
+	shareId := merkle.Digest{7, 7, 7}
+	key := append(owner.Bytes(), shareId[:]...)
+	storage.Pool.ShareQuantity.PutN(key, 25)
+	_ = storage.Pool.ShareQuantity.Commit()
+
+	trx, err := storage.NewDBTransaction()
+	assert.Nil(t, err, "transaction")
+	err = ownership.RebuildShareHolders(trx)
+	assert.Nil(t, err, "rebuild")
+	_ = trx.Commit()
+
+	balance, ok := storage.Pool.ShareHolders.GetN(append(shareId[:], owner.Bytes()...))
+	assert.True(t, ok, "holder index missing")
+	assert.Equal(t, uint64(25), balance, "holder balance")
+}
@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/bitmark-inc/bitmarkd/constants"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/logger"
+)
+
+// RedemptionInfo - result returned by store redemption
+type RedemptionInfo struct {
+	Id       pay.PayId
+	TxId     merkle.Digest
+	ShareId  merkle.Digest
+	Packed   []byte
+	Payments []transactionrecord.PaymentAlternative
+}
+
+// returned data from verifyRedemption
+type verifiedRedemptionInfo struct {
+	txId                merkle.Digest
+	packed              []byte
+	issueTxId           merkle.Digest
+	transferBlockNumber uint64
+	issueBlockNumber    uint64
+}
+
+// storeRedemption - validate and store a redemption request
+func storeRedemption(
+	redemption *transactionrecord.ShareRedemption,
+	shareQuantityHandle storage.Handle,
+	shareHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+	blockOwnerPaymentHandle storage.Handle,
+	transactionHandle storage.Handle,
+) (*RedemptionInfo, bool, error) {
+	if nil == shareQuantityHandle || nil == shareHandle || nil == ownerDataHandle || nil == blockOwnerPaymentHandle || nil == transactionHandle {
+		return nil, false, fault.NilPointer
+	}
+
+	err := checkBlockVersion(redemption)
+	if nil != err {
+		return nil, false, err
+	}
+
+	globalData.Lock()
+	defer globalData.Unlock()
+
+	verifyResult, duplicate, err := verifyRedemption(redemption, shareQuantityHandle, shareHandle, ownerDataHandle, transactionHandle)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// compute pay id
+	packedRedemption := verifyResult.packed
+	payId := pay.NewPayId([][]byte{packedRedemption})
+
+	txId := verifyResult.txId
+
+	payments := getPayments(verifyResult.transferBlockNumber, verifyResult.issueBlockNumber, nil, blockOwnerPaymentHandle)
+
+	result := &RedemptionInfo{
+		Id:       payId,
+		TxId:     txId,
+		ShareId:  verifyResult.issueTxId,
+		Packed:   packedRedemption,
+		Payments: payments,
+	}
+
+	// if already seen just return pay id and previous payments if present
+	entry, ok := globalData.pendingTransactions[payId]
+	if ok {
+		if nil != entry.payments {
+			result.Payments = entry.payments
+		} else {
+			// this would mean that reservoir data is corrupt
+			logger.Panicf("storeRedemption: failed to get current payment data for: %s  payid: %s", txId, payId)
+		}
+		return result, true, nil
+	}
+
+	// if duplicates were detected, but different duplicates were present
+	// then it is an error
+	if duplicate {
+		return nil, true, fault.TransactionAlreadyExists
+	}
+
+	// no other spend of the share may be pending
+	spendKey := makeSpendKey(redemption.Owner, verifyResult.issueTxId)
+	if 0 != globalData.spend[spendKey] {
+		return nil, false, fault.InsufficientShares
+	}
+
+	redemptionItem := &transactionData{
+		txId:        txId,
+		transaction: redemption,
+		packed:      packedRedemption,
+		received:    time.Now(),
+	}
+
+	// already received the payment for the redemption
+	// approve the redemption immediately if payment is ok
+	detail, ok := globalData.orphanPayments[payId]
+	if ok || globalData.autoVerify {
+		if acceptablePayment(detail, payments) {
+			globalData.verifiedTransactions[payId] = redemptionItem
+			globalData.verifiedIndex[txId] = payId
+			delete(globalData.pendingTransactions, payId)
+			delete(globalData.pendingIndex, txId)
+			delete(globalData.orphanPayments, payId)
+			statusChanged()
+
+			globalData.spend[spendKey] += redemption.Quantity
+			journalTransaction(result.Packed)
+			return result, false, nil
+		}
+	}
+
+	// waiting for the payment to come
+	payment := &transactionPaymentData{
+		payId:     payId,
+		tx:        redemptionItem,
+		payments:  payments,
+		expiresAt: time.Now().Add(constants.ReservoirTimeout),
+	}
+
+	globalData.pendingTransactions[payId] = payment
+	globalData.pendingIndex[txId] = payId
+	statusChanged()
+	globalData.spend[spendKey] += redemption.Quantity
+
+	journalTransaction(result.Packed)
+	return result, false, nil
+}
+
+// CheckRedemptionBalance - check that the link is a share and the owner
+// holds every unit of it, returns the share ownership data
+func CheckRedemptionBalance(
+	trx storage.Transaction,
+	redemption *transactionrecord.ShareRedemption,
+	shareQuantityHandle storage.Handle,
+	shareHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+) (ownership.OwnerData, error) {
+	if nil == shareQuantityHandle || nil == shareHandle || nil == ownerDataHandle {
+		return nil, fault.NilPointer
+	}
+
+	ownerData, err := ownership.GetOwnerData(trx, redemption.Link, ownerDataHandle)
+	if nil != err {
+		return nil, fault.DoubleTransferAttempt
+	}
+	if _, ok := ownerData.(*ownership.ShareOwnerData); !ok {
+		return nil, fault.CanOnlyRedeemShares
+	}
+
+	// the share id is the issue id of the bitmark
+	shareId := ownerData.IssueTxId()
+	oKey := append(redemption.Owner.Bytes(), shareId[:]...)
+
+	var total uint64
+	var shareTxId []byte
+	var balance uint64
+	if nil == trx {
+		total, shareTxId = shareHandle.GetNB(shareId[:])
+		balance, _ = shareQuantityHandle.GetN(oKey)
+	} else {
+		total, shareTxId = trx.GetNB(shareHandle, shareId[:])
+		balance, _ = trx.GetN(shareQuantityHandle, oKey)
+	}
+
+	if nil == shareTxId || !bytes.Equal(shareTxId, redemption.Link[:]) {
+		return nil, fault.ShareNotFound
+	}
+	if total != redemption.Quantity {
+		return nil, fault.ShareQuantityMismatch
+	}
+	if balance != total {
+		return nil, fault.InsufficientShares
+	}
+
+	return ownerData, nil
+}
+
+// verify that a redemption is ok
+func verifyRedemption(
+	redemption *transactionrecord.ShareRedemption,
+	shareQuantityHandle storage.Handle,
+	shareHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+	transactionHandle storage.Handle,
+) (*verifiedRedemptionInfo, bool, error) {
+
+	ownerData, err := CheckRedemptionBalance(nil, redemption, shareQuantityHandle, shareHandle, ownerDataHandle)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// pack redemption and check signature
+	packedRedemption, err := redemption.Pack(redemption.Owner)
+	if nil != err {
+		return nil, false, err
+	}
+
+	// transfer identifier and check for duplicate
+	txId := packedRedemption.MakeLink()
+
+	// check for double spend
+	_, okP := globalData.pendingIndex[txId]
+	_, okV := globalData.verifiedIndex[txId]
+
+	duplicate := false
+	if okP {
+		// if both then it is a possible duplicate
+		// (depends on later pay id check)
+		duplicate = true
+	}
+
+	// a single verified transfer fails the whole block
+	if okV {
+		return nil, false, fault.TransactionAlreadyExists
+	}
+	// a single confirmed transfer fails the whole block
+	if transactionHandle.Has(txId[:]) {
+		return nil, false, fault.TransactionAlreadyExists
+	}
+
+	result := &verifiedRedemptionInfo{
+		txId:                txId,
+		packed:              packedRedemption,
+		issueTxId:           ownerData.IssueTxId(),
+		transferBlockNumber: ownerData.TransferBlockNumber(),
+		issueBlockNumber:    ownerData.IssueBlockNumber(),
+	}
+	return result, duplicate, nil
+}
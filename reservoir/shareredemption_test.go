@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reservoir_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/ownership"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+)
+
+// sign a redemption by the given private key
+func signRedemption(t *testing.T, r *transactionrecord.ShareRedemption, key []byte) merkle.Digest {
+	packed, err := r.Pack(r.Owner)
+	if fault.InvalidSignature != err {
+		t.Fatalf("redemption pack error: %s", err)
+	}
+	r.Signature = ed25519.Sign(key, packed)
+	packed, err = r.Pack(r.Owner)
+	if nil != err {
+		t.Fatalf("signed redemption pack error: %s", err)
+	}
+	return packed.MakeLink()
+}
+
+func TestShareRedemption(t *testing.T) {
+	setup(t, chain.Testing)
+	defer teardown()
+
+	// another test may have left the reservoir running
+	_ = reservoir.Finalise()
+	err := reservoir.Initialise(testingDirName, reservoir.Handles{
+		Transactions:      storage.Pool.Transactions,
+		OwnerData:         storage.Pool.OwnerData,
+		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
+		Shares:            storage.Pool.Shares,
+		ShareQuantity:     storage.Pool.ShareQuantity,
+		ShareHolders:      storage.Pool.ShareHolders,
+	}, false)
+	assert.Nil(t, err, "reservoir initialise")
+	defer reservoir.Finalise()
+
+	rsvr := reservoir.Get()
+
+	issueTxId := merkle.Digest{1, 1, 1}
+	shareTxId := merkle.Digest{2, 2, 2}
+	shareId := issueTxId
+
+	// bitmark issued in block 1 and converted to a share in block 2
+	trx, _ := storage.NewDBTransaction()
+	payments, _ := currencyMap.Pack(true)
+	for bn := uint64(1); bn <= 2; bn += 1 {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, bn)
+		trx.Put(storage.Pool.BlockOwnerPayment, key, payments, []byte{})
+	}
+	ownership.CreateAsset(trx, issueTxId, 1, assetID, &owner)
+	ownership.Share(trx, issueTxId, shareTxId, 2, &owner, shareQuantity)
+
+	// every share moves to the second owner
+	ownership.SetShareBalance(trx, &owner, shareId, 0)
+	ownership.SetShareBalance(trx, &owner2, shareId, shareQuantity)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	// the converting owner no longer holds any shares
+	r := &transactionrecord.ShareRedemption{
+		Link:     shareTxId,
+		Quantity: shareQuantity,
+		Owner:    &owner,
+	}
+	signRedemption(t, r, privateKey)
+	_, _, err = rsvr.StoreRedemption(r)
+	assert.Equal(t, fault.InsufficientShares, err, "redeem without shares")
+
+	// the quantity must be the whole share
+	r = &transactionrecord.ShareRedemption{
+		Link:     shareTxId,
+		Quantity: shareQuantity - 1,
+		Owner:    &owner2,
+	}
+	signRedemption(t, r, privateKey2)
+	_, _, err = rsvr.StoreRedemption(r)
+	assert.Equal(t, fault.ShareQuantityMismatch, err, "partial redemption")
+
+	// only a share can be redeemed
+	r = &transactionrecord.ShareRedemption{
+		Link:     issueTxId,
+		Quantity: shareQuantity,
+		Owner:    &owner2,
+	}
+	signRedemption(t, r, privateKey2)
+	_, _, err = rsvr.StoreRedemption(r)
+	assert.Equal(t, fault.DoubleTransferAttempt, err, "redeem a converted issue")
+
+	r = &transactionrecord.ShareRedemption{
+		Link:     shareTxId,
+		Quantity: shareQuantity,
+		Owner:    &owner2,
+	}
+	redeemTxId := signRedemption(t, r, privateKey2)
+	info, duplicate, err := rsvr.StoreRedemption(r)
+	assert.Nil(t, err, "redeem")
+	assert.False(t, duplicate, "first redemption is a duplicate")
+	assert.Equal(t, redeemTxId, info.TxId, "tx id")
+	assert.Equal(t, shareId, info.ShareId, "share id")
+	assert.Equal(t, reservoir.StatePending, rsvr.TransactionStatus(redeemTxId), "state")
+
+	_, duplicate, err = rsvr.StoreRedemption(r)
+	assert.Nil(t, err, "repeat redemption")
+	assert.True(t, duplicate, "repeat redemption is not a duplicate")
+
+	// confirm the redemption
+	trx, _ = storage.NewDBTransaction()
+	ownership.Redeem(trx, shareTxId, redeemTxId, 3, &owner, &owner2)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	assert.False(t, storage.Pool.Shares.Has(shareId[:]), "share not burned")
+	_, ok := storage.Pool.ShareQuantity.GetN(append(owner2.Bytes(), shareId[:]...))
+	assert.False(t, ok, "balance not burned")
+	_, ok = storage.Pool.ShareHolders.GetN(append(shareId[:], owner2.Bytes()...))
+	assert.False(t, ok, "holder not burned")
+	assert.False(t, ownership.CurrentlyOwns(nil, &owner, shareTxId, storage.Pool.OwnerTxIndex), "share still owned")
+	assert.True(t, ownership.CurrentlyOwns(nil, &owner2, redeemTxId, storage.Pool.OwnerTxIndex), "bitmark not returned")
+
+	ownerData, err := ownership.GetOwnerData(nil, redeemTxId, storage.Pool.OwnerData)
+	assert.Nil(t, err, "owner data")
+	_, ok = ownerData.(*ownership.AssetOwnerData)
+	assert.True(t, ok, "redeemed bitmark is not an asset")
+	assert.Equal(t, issueTxId, ownerData.IssueTxId(), "issue id")
+
+	// roll back the redemption
+	trx, _ = storage.NewDBTransaction()
+	ownership.Unredeem(trx, redeemTxId, shareTxId, 2, &owner2, &owner, shareQuantity)
+	err = trx.Commit()
+	assert.Nil(t, err, "commit")
+
+	total, txId := storage.Pool.Shares.GetNB(shareId[:])
+	assert.Equal(t, uint64(shareQuantity), total, "share total")
+	assert.Equal(t, shareTxId[:], txId, "share tx id")
+	balance, _ := storage.Pool.ShareQuantity.GetN(append(owner2.Bytes(), shareId[:]...))
+	assert.Equal(t, uint64(shareQuantity), balance, "balance not restored")
+	assert.True(t, ownership.CurrentlyOwns(nil, &owner, shareTxId, storage.Pool.OwnerTxIndex), "share not restored")
+	assert.False(t, ownership.CurrentlyOwns(nil, &owner2, redeemTxId, storage.Pool.OwnerTxIndex), "redemption still owned")
+
+	ownerData, err = ownership.GetOwnerData(nil, shareTxId, storage.Pool.OwnerData)
+	assert.Nil(t, err, "owner data")
+	_, ok = ownerData.(*ownership.ShareOwnerData)
+	assert.True(t, ok, "restored bitmark is not a share")
+}
@@ -99,6 +99,7 @@ func storeSwap(swap *transactionrecord.ShareSwap, shareQuantityHandle storage.Ha
 		txId:        txId,
 		transaction: swap,
 		packed:      packedSwap,
+		received:    time.Now(),
 	}
 
 	// already received the payment for the swap
@@ -111,11 +112,13 @@ func storeSwap(swap *transactionrecord.ShareSwap, shareQuantityHandle storage.Ha
 			delete(globalData.pendingTransactions, payId)
 			delete(globalData.pendingIndex, txId)
 			delete(globalData.orphanPayments, payId)
+			statusChanged()
 
 			globalData.spend[spendKeyOne] += swap.QuantityOne
 			globalData.spend[spendKeyTwo] += swap.QuantityTwo
 			result.RemainingOne -= swap.QuantityOne
 			result.RemainingTwo -= swap.QuantityTwo
+			journalTransaction(result.Packed)
 			return result, false, nil
 		}
 	}
@@ -130,11 +133,13 @@ func storeSwap(swap *transactionrecord.ShareSwap, shareQuantityHandle storage.Ha
 
 	globalData.pendingTransactions[payId] = payment
 	globalData.pendingIndex[txId] = payId
+	statusChanged()
 	globalData.spend[spendKeyOne] += swap.QuantityOne
 	globalData.spend[spendKeyTwo] += swap.QuantityTwo
 	result.RemainingOne -= swap.QuantityOne
 	result.RemainingTwo -= swap.QuantityTwo
 
+	journalTransaction(result.Packed)
 	return result, false, nil
 }
 
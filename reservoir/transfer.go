@@ -27,6 +27,15 @@ type TransferInfo struct {
 	IssueTxId merkle.Digest
 	Packed    []byte
 	Payments  []transactionrecord.PaymentAlternative
+	Escrow    *EscrowInfo
+	Replaced  *merkle.Digest // pending transfer replaced by a higher sequence
+}
+
+// EscrowInfo - the escrow payment a transfer is waiting for
+type EscrowInfo struct {
+	Payment   *transactionrecord.Payment `json:"payment"`
+	Paid      bool                       `json:"paid"`
+	ExpiresAt *time.Time                 `json:"expiresAt,omitempty"`
 }
 
 // returned data from verifyTransfer
@@ -37,6 +46,7 @@ type verifiedTransferInfo struct {
 	issueTxId           merkle.Digest
 	transferBlockNumber uint64
 	issueBlockNumber    uint64
+	replaces            *transactionPaymentData
 }
 
 // storeTransfer - verify and store a transfer request
@@ -51,6 +61,11 @@ func storeTransfer(
 		return nil, false, fault.NilPointer
 	}
 
+	err := checkBlockVersion(transfer)
+	if nil != err {
+		return nil, false, err
+	}
+
 	globalData.Lock()
 	defer globalData.Unlock()
 
@@ -69,6 +84,15 @@ func storeTransfer(
 
 	payments := getPayments(verifyResult.transferBlockNumber, verifyResult.issueBlockNumber, previousTransfer, blockOwnerPaymentHandle)
 
+	// the transfer's own escrow must be paid along with the fees
+	escrow := transfer.GetPayment()
+	if nil != escrow {
+		payments, err = addEscrow(payments, escrow)
+		if nil != err {
+			return nil, false, err
+		}
+	}
+
 	result := &TransferInfo{
 		Id:        payId,
 		TxId:      txId,
@@ -86,6 +110,12 @@ func storeTransfer(
 			// this would mean that reservoir data is corrupt
 			logger.Panicf("storeTransfer: failed to get current payment data for: %s  payid: %s", txId, payId)
 		}
+		if nil != escrow {
+			result.Escrow = &EscrowInfo{
+				Payment:   escrow,
+				ExpiresAt: &entry.expiresAt,
+			}
+		}
 		return result, true, nil
 	}
 
@@ -95,23 +125,40 @@ func storeTransfer(
 		return nil, true, fault.TransactionAlreadyExists
 	}
 
+	// drop the pending transfer of the same link
+	if replaces := verifyResult.replaces; nil != replaces {
+		globalData.log.Infof("transfer: %s  replaces: %s", txId, replaces.tx.txId)
+		replaced := replaces.tx.txId
+		result.Replaced = &replaced
+		internalDelete(replaces.payId)
+	}
+
 	transferredItem := &transactionData{
 		txId:        txId,
 		transaction: transfer,
 		packed:      packedTransfer,
+		received:    time.Now(),
 	}
 
 	// already received the payment for the transfer
 	// approve the transfer immediately if payment is ok
 	detail, ok := globalData.orphanPayments[payId]
 	if ok || globalData.autoVerify {
-		if acceptablePayment(detail, payments) {
+		if acceptablePayment(detail, payments) && escrowPaid(detail, escrow) {
+			if nil != escrow {
+				result.Escrow = &EscrowInfo{
+					Payment: escrow,
+					Paid:    true,
+				}
+			}
 			globalData.verifiedTransactions[payId] = transferredItem
 			globalData.verifiedIndex[txId] = payId
 			globalData.inProgressLinks[transfer.GetLink()] = txId
 			delete(globalData.pendingTransactions, payId)
 			delete(globalData.pendingIndex, txId)
 			delete(globalData.orphanPayments, payId)
+			statusChanged()
+			journalTransaction(result.Packed)
 			return result, false, nil
 		}
 	}
@@ -121,6 +168,7 @@ func storeTransfer(
 		payId:     payId,
 		tx:        transferredItem,
 		payments:  payments,
+		escrow:    escrow,
 		expiresAt: time.Now().Add(constants.ReservoirTimeout),
 	}
 
@@ -130,8 +178,17 @@ func storeTransfer(
 
 	globalData.pendingTransactions[payId] = payment
 	globalData.pendingIndex[txId] = payId
+	statusChanged()
 	globalData.inProgressLinks[transfer.GetLink()] = txId
 
+	if nil != escrow {
+		result.Escrow = &EscrowInfo{
+			Payment:   escrow,
+			ExpiresAt: &payment.expiresAt,
+		}
+	}
+
+	journalTransaction(result.Packed)
 	return result, false, nil
 }
 
@@ -184,6 +241,29 @@ func verifyTransfer(transfer transactionrecord.BitmarkTransfer, transactionHandl
 			return nil, false, fault.LinkToInvalidOrUnconfirmedTransaction
 		}
 
+	case *transactionrecord.ShareRedemption:
+		// a redeemed bitmark can be transferred or shared again
+		switch transfer.(type) {
+		case *transactionrecord.BitmarkTransferUnratified, *transactionrecord.BitmarkTransferCountersigned, *transactionrecord.BitmarkShare:
+			currentOwner = tx.Owner
+		default:
+			return nil, false, fault.LinkToInvalidOrUnconfirmedTransaction
+		}
+
+	case *transactionrecord.AtomicSwap:
+		// a bitmark moved by a swap is linked through its leg id
+		swapTxId := transactionrecord.Packed(previousPacked).MakeLink()
+		leg, ok := tx.BitmarkLeg(swapTxId, transfer.GetLink())
+		if !ok {
+			return nil, false, fault.LinkToInvalidOrUnconfirmedTransaction
+		}
+		switch transfer.(type) {
+		case *transactionrecord.BitmarkTransferUnratified, *transactionrecord.BitmarkTransferCountersigned, *transactionrecord.BitmarkShare:
+			currentOwner = leg.Recipient
+		default:
+			return nil, false, fault.LinkToInvalidOrUnconfirmedTransaction
+		}
+
 	case *transactionrecord.OldBaseData:
 		// ensure link to correct transfer type
 		switch transfer.(type) {
@@ -236,9 +316,14 @@ func verifyTransfer(transfer transactionrecord.BitmarkTransfer, transactionHandl
 	_, okP := globalData.pendingIndex[txId]
 	_, okV := globalData.verifiedIndex[txId]
 
+	var replaces *transactionPaymentData
 	if okL && linkTxId != txId {
-		// not an exact match - must be a double transfer
-		return nil, false, fault.DoubleTransferAttempt
+		// not an exact match - must be a double transfer unless it
+		// replaces a pending transfer
+		replaces, err = replaceableTransfer(transfer, linkTxId)
+		if nil != err {
+			return nil, false, err
+		}
 	}
 
 	duplicate := false
@@ -260,20 +345,10 @@ func verifyTransfer(transfer transactionrecord.BitmarkTransfer, transactionHandl
 	// log.Infof("packed transfer: %x", packedTransfer)
 	// log.Infof("id: %v", txId)
 
-	// get count for current owner record
-	// to make sure that the record has not already been transferred
-	dKey := append(currentOwner.Bytes(), link[:]...)
-	// log.Infof("dKey: %x", dKey)
-	dCount := ownerTxHandle.Get(dKey)
-	if nil == dCount {
-		return nil, false, fault.DoubleTransferAttempt
-	}
-
-	// get ownership data
-	ownerData, err := ownership.GetOwnerData(nil, link, ownerDataHandle)
+	ownerData, err := CheckTransferOwner(nil, link, currentOwner, ownerTxHandle, ownerDataHandle)
 	if nil != err {
 		globalData.log.Errorf("owner data error: %s", err)
-		return nil, false, err //fault.DoubleTransferAttempt
+		return nil, false, err
 	}
 	// log.Debugf("ownerData: %x", ownerData)
 
@@ -284,6 +359,75 @@ func verifyTransfer(transfer transactionrecord.BitmarkTransfer, transactionHandl
 		issueTxId:           ownerData.IssueTxId(),
 		transferBlockNumber: ownerData.TransferBlockNumber(),
 		issueBlockNumber:    ownerData.IssueBlockNumber(),
+		replaces:            replaces,
 	}
 	return result, duplicate, nil
 }
+
+// find the pending transfer that a transfer can replace
+//
+// an unratified transfer replaces the unratified transfer of the same
+// link if it has a higher sequence and no payment has been seen for
+// it, both are signed by the current owner of the link so only that
+// owner can replace a transfer
+// ensure lock is held before calling
+func replaceableTransfer(transfer transactionrecord.BitmarkTransfer, pendingTxId merkle.Digest) (*transactionPaymentData, error) {
+	replacement, ok := transfer.(*transactionrecord.BitmarkTransferUnratified)
+	if !ok || 0 == replacement.Sequence {
+		return nil, fault.DoubleTransferAttempt
+	}
+
+	payId, ok := globalData.pendingIndex[pendingTxId]
+	if !ok {
+		return nil, fault.DoubleTransferAttempt
+	}
+	entry, ok := globalData.pendingTransactions[payId]
+	if !ok {
+		return nil, fault.DoubleTransferAttempt
+	}
+	pending, ok := entry.tx.transaction.(*transactionrecord.BitmarkTransferUnratified)
+	if !ok || pending.Link != replacement.Link {
+		return nil, fault.DoubleTransferAttempt
+	}
+
+	// a partial payment is held as an orphan until the rest arrives
+	if _, ok := globalData.orphanPayments[payId]; ok {
+		return nil, fault.DoubleTransferAttempt
+	}
+
+	if replacement.Sequence <= pending.Sequence {
+		return nil, fault.SequenceNotIncreased
+	}
+	return entry, nil
+}
+
+// CheckTransferOwner - check that an account still owns the record at
+// link, i.e. it has not already been transferred, and return the
+// ownership data of the record
+func CheckTransferOwner(
+	trx storage.Transaction,
+	link merkle.Digest,
+	currentOwner *account.Account,
+	ownerTxHandle storage.Handle,
+	ownerDataHandle storage.Handle,
+) (ownership.OwnerData, error) {
+	if nil == ownerTxHandle || nil == ownerDataHandle {
+		return nil, fault.NilPointer
+	}
+
+	// get count for current owner record
+	// to make sure that the record has not already been transferred
+	dKey := append(currentOwner.Bytes(), link[:]...)
+	var dCount []byte
+	if nil == trx {
+		dCount = ownerTxHandle.Get(dKey)
+	} else {
+		dCount = trx.Get(ownerTxHandle, dKey)
+	}
+	if nil == dCount {
+		return nil, fault.DoubleTransferAttempt
+	}
+
+	// get ownership data
+	return ownership.GetOwnerData(trx, link, ownerDataHandle)
+}
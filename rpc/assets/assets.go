@@ -6,11 +6,16 @@
 package assets
 
 import (
+	"strings"
+
 	"golang.org/x/time/rate"
 
 	"github.com/bitmark-inc/bitmarkd/asset"
 	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/messagebus"
 	"github.com/bitmark-inc/bitmarkd/mode"
+	"github.com/bitmark-inc/bitmarkd/pay"
 	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/ratelimit"
 	"github.com/bitmark-inc/bitmarkd/storage"
@@ -23,8 +28,11 @@ type Assets struct {
 	Log            *logger.L
 	Limiter        *rate.Limiter
 	Pool           storage.Handle
+	Versions       storage.Handle
+	Index          storage.Handle
 	IsNormalMode   func(mode.Mode) bool
 	IsTestingChain func() bool
+	Rsvr           reservoir.Reservoir
 }
 
 const (
@@ -44,13 +52,16 @@ type RegisterReply struct {
 	Assets []Status `json:"assets"`
 }
 
-func New(log *logger.L, pools reservoir.Handles, isNormalMode func(mode.Mode) bool, isTestingChain func() bool) *Assets {
+func New(log *logger.L, pools reservoir.Handles, isNormalMode func(mode.Mode) bool, isTestingChain func() bool, rsvr reservoir.Reservoir) *Assets {
 	return &Assets{
 		Log:            log,
 		Limiter:        rate.NewLimiter(rateLimitAssets, rateBurstAssets),
 		Pool:           pools.Assets,
+		Versions:       pools.AssetVersions,
+		Index:          pools.AssetMetadata,
 		IsNormalMode:   isNormalMode,
 		IsTestingChain: isTestingChain,
+		Rsvr:           rsvr,
 	}
 }
 
@@ -61,6 +72,12 @@ func Register(assets []*transactionrecord.AssetData, pool storage.Handle) ([]Sta
 	// pack each transaction
 	packed := []byte{}
 	for i, argument := range assets {
+		// typed metadata must match its schema
+		err := asset.ValidateMetadata(argument.Metadata)
+		if nil != err {
+			return nil, nil, err
+		}
+
 		assetId, packedAsset, err := asset.Cache(argument, pool)
 		if nil != err {
 			return nil, nil, err
@@ -82,6 +99,7 @@ func Register(assets []*transactionrecord.AssetData, pool storage.Handle) ([]Sta
 // GetArguments - arguments for RPC request
 type GetArguments struct {
 	Fingerprints []string `json:"fingerprints"`
+	History      bool     `json:"history"`
 }
 
 // GetReply - results from get RPC request
@@ -90,11 +108,17 @@ type GetReply struct {
 }
 
 // Record - structure of asset records in the response
+//
+// data is always the original registration, the version and metadata
+// are from the latest confirmed amendment
 type Record struct {
-	Record    string      `json:"record"`
-	Confirmed bool        `json:"confirmed"`
-	AssetId   interface{} `json:"id,omitempty"`
-	Data      interface{} `json:"data"`
+	Record    string          `json:"record"`
+	Confirmed bool            `json:"confirmed"`
+	AssetId   interface{}     `json:"id,omitempty"`
+	Data      interface{}     `json:"data"`
+	Version   uint64          `json:"version,string"`
+	Metadata  string          `json:"metadata"`
+	History   []asset.Version `json:"history,omitempty"`
 }
 
 // Get - RPC to fetch asset data
@@ -120,7 +144,7 @@ loop:
 		assetId := transactionrecord.NewAssetIdentifier([]byte(fingerprint))
 
 		confirmed := true
-		_, packedAsset := assets.Pool.GetNB(assetId[:])
+		blockNumber, packedAsset := assets.Pool.GetNB(assetId[:])
 		if nil == packedAsset {
 
 			confirmed = false
@@ -135,12 +159,44 @@ loop:
 			continue loop
 		}
 
+		assetData, ok := assetTx.(*transactionrecord.AssetData)
+		if !ok {
+			continue loop
+		}
+
 		record, _ := transactionrecord.RecordName(assetTx)
 		a[i] = Record{
 			Record:    record,
 			Confirmed: confirmed,
 			AssetId:   assetId,
 			Data:      assetTx,
+			Version:   0,
+			Metadata:  assetData.Metadata,
+		}
+
+		// only a confirmed asset can be amended
+		if !confirmed {
+			continue loop
+		}
+
+		amendments, err := asset.Amendments(assetId, assets.Versions)
+		if nil != err {
+			return err
+		}
+		if n := len(amendments); n > 0 {
+			a[i].Version = amendments[n-1].Version
+			a[i].Metadata = amendments[n-1].Metadata
+		}
+
+		if arguments.History {
+			a[i].History = append([]asset.Version{
+				{
+					Version:     0,
+					BlockNumber: blockNumber,
+					TxId:        transactionrecord.Packed(packedAsset).MakeLink(),
+					Metadata:    assetData.Metadata,
+				},
+			}, amendments...)
 		}
 	}
 
@@ -148,3 +204,118 @@ loop:
 
 	return nil
 }
+
+// ---
+
+// AmendReply - results from an amendment RPC request
+type AmendReply struct {
+	TxId     merkle.Digest                                   `json:"txId"`
+	PayId    pay.PayId                                       `json:"payId"`
+	Payments map[string]transactionrecord.PaymentAlternative `json:"payments"`
+}
+
+// Amend - RPC to replace the metadata of a confirmed asset
+//
+// the asset id and any provenance still refer to the original
+// registration
+func (assets *Assets) Amend(arguments *transactionrecord.AssetAmendment, reply *AmendReply) error {
+
+	if err := ratelimit.Limit(assets.Limiter); nil != err {
+		return err
+	}
+
+	log := assets.Log
+
+	log.Infof("Assets.Amend: %+v", arguments)
+
+	if nil == arguments || nil == arguments.Registrant {
+		return fault.InvalidItem
+	}
+
+	if !assets.IsNormalMode(mode.Normal) {
+		return fault.NotAvailableDuringSynchronise
+	}
+
+	if arguments.Registrant.IsTesting() != assets.IsTestingChain() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	// typed metadata must match its schema
+	err := asset.ValidateMetadata(arguments.Metadata)
+	if nil != err {
+		return err
+	}
+
+	// save amendment/check for duplicate
+	stored, duplicate, err := assets.Rsvr.StoreAmendment(arguments)
+	if nil != err {
+		return err
+	}
+
+	log.Debugf("id: %v", stored.TxId)
+	reply.TxId = stored.TxId
+	reply.PayId = stored.Id
+	reply.Payments = make(map[string]transactionrecord.PaymentAlternative)
+
+	for _, payment := range stored.Payments {
+		c := payment[0].Currency.String()
+		reply.Payments[c] = payment
+	}
+
+	// announce transaction block to other peers
+	if !duplicate {
+		messagebus.Bus.Broadcast.Send("transfer", stored.Packed)
+	}
+
+	return nil
+}
+
+// ---
+
+// QueryArguments - arguments for RPC request
+type QueryArguments struct {
+	Key   string                             `json:"key"`
+	Value string                             `json:"value"`
+	Start *transactionrecord.AssetIdentifier `json:"start"`
+	Count int                                `json:"count"`
+}
+
+// QueryReply - results from query RPC request
+type QueryReply struct {
+	Assets    []transactionrecord.AssetIdentifier `json:"assets"`
+	NextStart *transactionrecord.AssetIdentifier  `json:"nextStart,omitempty"`
+}
+
+// Query - RPC to list the confirmed assets whose latest metadata
+// contains a key/value pair
+func (assets *Assets) Query(arguments *QueryArguments, reply *QueryReply) error {
+
+	if err := ratelimit.Limit(assets.Limiter); nil != err {
+		return err
+	}
+
+	if nil == arguments || arguments.Count <= 0 || arguments.Count > maximumAssets {
+		return fault.InvalidCount
+	}
+
+	if "" == arguments.Key || "" == arguments.Value ||
+		strings.ContainsRune(arguments.Key, 0) || strings.ContainsRune(arguments.Value, 0) {
+		return fault.InvalidItem
+	}
+
+	if !assets.IsNormalMode(mode.Normal) {
+		return fault.NotAvailableDuringSynchronise
+	}
+
+	assets.Log.Infof("Assets.Query: %+v", arguments)
+
+	assetIds, next, err := asset.QueryMetadata(arguments.Key, arguments.Value, arguments.Start, arguments.Count, assets.Index)
+	if nil != err {
+		return err
+	}
+
+	reply.Assets = assetIds
+	reply.NextStart = next
+
+	return nil
+}
@@ -13,9 +13,14 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/bitmark-inc/bitmarkd/account"
+	"github.com/bitmark-inc/bitmarkd/asset"
 	"github.com/bitmark-inc/bitmarkd/chain"
+	"github.com/bitmark-inc/bitmarkd/currency"
 	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/messagebus"
 	"github.com/bitmark-inc/bitmarkd/mode"
+	"github.com/bitmark-inc/bitmarkd/pay"
 	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/assets"
 	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
@@ -35,14 +40,17 @@ func TestAssetsGet(t *testing.T) {
 	defer ctl.Finish()
 
 	p := mocks.NewMockHandle(ctl)
+	v := mocks.NewMockHandle(ctl)
 
 	a := assets.New(
 		logger.New(fixtures.LogCategory),
 		reservoir.Handles{
-			Assets: p,
+			Assets:        p,
+			AssetVersions: v,
 		},
 		func(_ mode.Mode) bool { return true },
 		mode.IsTesting,
+		nil,
 	)
 
 	arg := assets.GetArguments{Fingerprints: []string{"fin1", "fin2"}}
@@ -68,10 +76,15 @@ func TestAssetsGet(t *testing.T) {
 
 	p.EXPECT().GetNB(bin1[:]).Return(uint64(1), packed).Times(1)
 	p.EXPECT().GetNB(bin2[:]).Return(uint64(1), packed).Times(1)
+	v.EXPECT().GetNB(asset.VersionKey(bin1, 1)).Return(uint64(0), nil).Times(1)
+	v.EXPECT().GetNB(asset.VersionKey(bin2, 1)).Return(uint64(0), nil).Times(1)
 
 	err := a.Get(&arg, &reply)
 	assert.Nil(t, err, "wrong get")
 	assert.Equal(t, 2, len(reply.Assets), "wrong asset count")
+	assert.Equal(t, uint64(0), reply.Assets[0].Version, "wrong version")
+	assert.Equal(t, ad.Metadata, reply.Assets[0].Metadata, "wrong metadata")
+	assert.Nil(t, reply.Assets[0].History, "wrong history")
 
 	assert.Equal(t, "AssetData", reply.Assets[0].Record, "wrong record")
 	assert.True(t, reply.Assets[0].Confirmed, "wrong confirmed")
@@ -82,6 +95,218 @@ func TestAssetsGet(t *testing.T) {
 	assert.Equal(t, ad.Metadata, d.Metadata, "wrong asset metadata")
 }
 
+func TestAssetsGetWithHistory(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	p := mocks.NewMockHandle(ctl)
+	v := mocks.NewMockHandle(ctl)
+
+	a := assets.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{
+			Assets:        p,
+			AssetVersions: v,
+		},
+		func(_ mode.Mode) bool { return true },
+		mode.IsTesting,
+		nil,
+	)
+
+	arg := assets.GetArguments{Fingerprints: []string{"fin1"}, History: true}
+	var reply assets.GetReply
+	bin1 := transactionrecord.NewAssetIdentifier([]byte("fin1"))
+	acc := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+	ad := transactionrecord.AssetData{
+		Name:        "test",
+		Fingerprint: "fin1",
+		Metadata:    "owner\x00test",
+		Registrant:  acc,
+	}
+	packed, _ := ad.Pack(acc)
+	ad.Signature = ed25519.Sign(fixtures.IssuerPrivateKey, packed)
+	packed, _ = ad.Pack(acc)
+
+	amendment := transactionrecord.AssetAmendment{
+		AssetId:    bin1,
+		Version:    1,
+		Metadata:   "owner\x00amended",
+		Registrant: acc,
+	}
+	packedAmendment, _ := amendment.Pack(acc)
+	amendment.Signature = ed25519.Sign(fixtures.IssuerPrivateKey, packedAmendment)
+	packedAmendment, _ = amendment.Pack(acc)
+
+	p.EXPECT().GetNB(bin1[:]).Return(uint64(1), packed).Times(1)
+	v.EXPECT().GetNB(asset.VersionKey(bin1, 1)).Return(uint64(5), packedAmendment).Times(1)
+	v.EXPECT().GetNB(asset.VersionKey(bin1, 2)).Return(uint64(0), nil).Times(1)
+
+	err := a.Get(&arg, &reply)
+	assert.Nil(t, err, "wrong get")
+	assert.Equal(t, 1, len(reply.Assets), "wrong asset count")
+
+	r := reply.Assets[0]
+	assert.True(t, r.Confirmed, "wrong confirmed")
+	assert.Equal(t, uint64(1), r.Version, "wrong version")
+	assert.Equal(t, amendment.Metadata, r.Metadata, "wrong latest metadata")
+
+	d := r.Data.(*transactionrecord.AssetData)
+	assert.Equal(t, ad.Metadata, d.Metadata, "original metadata was changed")
+
+	assert.Equal(t, 2, len(r.History), "wrong history count")
+	assert.Equal(t, uint64(0), r.History[0].Version, "wrong original version")
+	assert.Equal(t, uint64(1), r.History[0].BlockNumber, "wrong original block number")
+	assert.Equal(t, ad.Metadata, r.History[0].Metadata, "wrong original metadata")
+	assert.Equal(t, uint64(1), r.History[1].Version, "wrong amended version")
+	assert.Equal(t, uint64(5), r.History[1].BlockNumber, "wrong amended block number")
+	assert.Equal(t, amendment.Metadata, r.History[1].Metadata, "wrong amended metadata")
+	assert.Equal(t, transactionrecord.Packed(packedAmendment).MakeLink(), r.History[1].TxId, "wrong amended tx id")
+}
+
+func TestAssetsAmend(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	a := assets.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{},
+		func(_ mode.Mode) bool { return true },
+		mode.IsTesting,
+		r,
+	)
+
+	acc := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+	arg := transactionrecord.AssetAmendment{
+		AssetId:    transactionrecord.NewAssetIdentifier([]byte("fin1")),
+		Version:    1,
+		Metadata:   "owner\x00amended",
+		Registrant: acc,
+		Signature:  []byte{1, 2, 3, 4},
+	}
+
+	info := reservoir.AmendmentInfo{
+		Id:     pay.PayId{1, 2},
+		TxId:   merkle.Digest{1, 2, 3},
+		Packed: []byte{5, 6, 7, 8},
+		Payments: []transactionrecord.PaymentAlternative{
+			[]*transactionrecord.Payment{
+				{
+					Currency: currency.Litecoin,
+					Address:  fixtures.LitecoinAddress,
+					Amount:   100,
+				},
+			},
+		},
+	}
+
+	r.EXPECT().StoreAmendment(&arg).Return(&info, false, nil).Times(1)
+
+	messagebus.Bus.Broadcast.Release()
+	ch := messagebus.Bus.Broadcast.Chan(5)
+
+	var reply assets.AmendReply
+	err := a.Amend(&arg, &reply)
+	assert.Nil(t, err, "wrong amend")
+	assert.Equal(t, info.TxId, reply.TxId, "wrong tx id")
+	assert.Equal(t, info.Id, reply.PayId, "wrong pay id")
+	assert.Equal(t, info.Payments[0], reply.Payments[currency.Litecoin.String()], "wrong payment")
+
+	received := <-ch
+	assert.Equal(t, "transfer", received.Command, "wrong command")
+	assert.Equal(t, info.Packed, received.Parameters[0], "wrong packed")
+}
+
+func TestAssetsAmendWhenEmptyRegistrant(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	a := assets.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{},
+		func(_ mode.Mode) bool { return true },
+		mode.IsTesting,
+		r,
+	)
+
+	var reply assets.AmendReply
+	err := a.Amend(&transactionrecord.AssetAmendment{}, &reply)
+	assert.Equal(t, fault.InvalidItem, err, "wrong error")
+}
+
+func TestAssetsAmendWhenMetadataFailsSchema(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	_, err := asset.RegisterSchema([]byte(`{"name": "artwork", "required": ["artist"], "properties": {"artist": {"type": "string"}}}`))
+	assert.Nil(t, err, "wrong RegisterSchema")
+	defer asset.ClearSchemas()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	a := assets.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{},
+		func(_ mode.Mode) bool { return true },
+		mode.IsTesting,
+		r,
+	)
+
+	acc := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+	arg := transactionrecord.AssetAmendment{
+		AssetId:    transactionrecord.NewAssetIdentifier([]byte("fin1")),
+		Version:    1,
+		Metadata:   "_schema\x00artwork\x00year\x002020",
+		Registrant: acc,
+		Signature:  []byte{1, 2, 3, 4},
+	}
+
+	r.EXPECT().StoreAmendment(gomock.Any()).Times(0)
+
+	var reply assets.AmendReply
+	err = a.Amend(&arg, &reply)
+	assert.Equal(t, fault.MissingRequiredMetadata, err, "wrong error")
+}
+
 func TestAssetsGetWhenNotInNormal(t *testing.T) {
 	fixtures.SetupTestLogger()
 	defer fixtures.TeardownTestLogger()
@@ -101,6 +326,7 @@ func TestAssetsGetWhenNotInNormal(t *testing.T) {
 		},
 		func(_ mode.Mode) bool { return false },
 		mode.IsTesting,
+		nil,
 	)
 
 	var reply assets.GetReply
@@ -126,6 +352,7 @@ func TestAssetsGetWhenNilAsset(t *testing.T) {
 		},
 		func(_ mode.Mode) bool { return true },
 		mode.IsTesting,
+		nil,
 	)
 
 	arg := assets.GetArguments{Fingerprints: []string{"fin1"}}
@@ -157,6 +384,7 @@ func TestAssetsGetWhenUnpackError(t *testing.T) {
 		},
 		func(_ mode.Mode) bool { return true },
 		mode.IsTesting,
+		nil,
 	)
 
 	arg := assets.GetArguments{Fingerprints: []string{"fin1", "fin2"}}
@@ -211,3 +439,112 @@ func TestRegister(t *testing.T) {
 	assert.Equal(t, true, status[0].Duplicate, "wrong duplicate status")
 	assert.Equal(t, ad.AssetId(), *status[0].AssetId, "wrong asset ID")
 }
+
+func TestRegisterWhenMetadataFailsSchema(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	_, err := asset.RegisterSchema([]byte(`{"name": "artwork", "required": ["artist"], "properties": {"artist": {"type": "string"}}}`))
+	assert.Nil(t, err, "wrong RegisterSchema")
+	defer asset.ClearSchemas()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	p := mocks.NewMockHandle(ctl)
+
+	acc := &account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	tests := []struct {
+		metadata string
+		err      error
+	}{
+		{"_schema\x00artwork\x00year\x002020", fault.MissingRequiredMetadata},
+		{"_schema\x00sculpture\x00artist\x00me", fault.MetadataSchemaNotFound},
+	}
+
+	for i, item := range tests {
+		ad := transactionrecord.AssetData{
+			Name:        "test",
+			Fingerprint: "123456789",
+			Metadata:    item.metadata,
+			Registrant:  acc,
+		}
+
+		status, data, err := assets.Register([]*transactionrecord.AssetData{&ad}, p)
+		assert.Equal(t, item.err, err, "%d: wrong error", i)
+		assert.Nil(t, status, "%d: wrong status", i)
+		assert.Nil(t, data, "%d: wrong data", i)
+	}
+}
+
+func TestAssetsQueryWhenInvalidArguments(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	a := assets.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{
+			AssetMetadata: mocks.NewMockHandle(ctl),
+		},
+		func(_ mode.Mode) bool { return true },
+		mode.IsTesting,
+		nil,
+	)
+
+	tests := []struct {
+		arguments assets.QueryArguments
+		err       error
+	}{
+		{assets.QueryArguments{Key: "artist", Value: "me", Count: 0}, fault.InvalidCount},
+		{assets.QueryArguments{Key: "artist", Value: "me", Count: 101}, fault.InvalidCount},
+		{assets.QueryArguments{Key: "", Value: "me", Count: 10}, fault.InvalidItem},
+		{assets.QueryArguments{Key: "artist", Value: "", Count: 10}, fault.InvalidItem},
+		{assets.QueryArguments{Key: "art\x00ist", Value: "me", Count: 10}, fault.InvalidItem},
+	}
+
+	for i, item := range tests {
+		var reply assets.QueryReply
+		err := a.Query(&item.arguments, &reply)
+		assert.Equal(t, item.err, err, "%d: wrong error", i)
+	}
+}
+
+func TestAssetsQueryWhenNotInNormal(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	a := assets.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{
+			AssetMetadata: mocks.NewMockHandle(ctl),
+		},
+		func(_ mode.Mode) bool { return false },
+		mode.IsTesting,
+		nil,
+	)
+
+	var reply assets.QueryReply
+	err := a.Query(&assets.QueryArguments{Key: "artist", Value: "me", Count: 10}, &reply)
+	assert.Equal(t, fault.NotAvailableDuringSynchronise, err, "wrong error")
+}
@@ -49,6 +49,18 @@ type TransferReply struct {
 	BitmarkId merkle.Digest                                   `json:"bitmarkId"`
 	PayId     pay.PayId                                       `json:"payId"`
 	Payments  map[string]transactionrecord.PaymentAlternative `json:"payments"`
+	Escrow    *reservoir.EscrowInfo                           `json:"escrow,omitempty"`
+	Replaced  *merkle.Digest                                  `json:"replaced,omitempty"`
+}
+
+// TransferArguments - arguments for transfer RPC
+//
+// a transfer without a countersignature is unratified and may carry a
+// sequence so that it replaces an unpaid pending transfer of the same
+// bitmark
+type TransferArguments struct {
+	transactionrecord.BitmarkTransferCountersigned
+	Sequence uint64 `json:"sequence,string"`
 }
 
 func New(log *logger.L, pools reservoir.Handles, isNormalMode func(mode.Mode) bool, isTestingChain func() bool, rsvr reservoir.Reservoir) *Bitmark {
@@ -66,20 +78,21 @@ func New(log *logger.L, pools reservoir.Handles, isNormalMode func(mode.Mode) bo
 }
 
 // Transfer - transfer a bitmark
-func (bitmark *Bitmark) Transfer(arguments *transactionrecord.BitmarkTransferCountersigned, reply *TransferReply) error {
+func (bitmark *Bitmark) Transfer(arguments *TransferArguments, reply *TransferReply) error {
 	if err := ratelimit.Limit(bitmark.Limiter); nil != err {
 		return err
 	}
 
 	log := bitmark.Log
-	transfer := transactionrecord.BitmarkTransfer(arguments)
 
-	log.Infof("Bitmark.Transfer: %+v", transfer)
+	log.Infof("Bitmark.Transfer: %+v", arguments)
 
 	if nil == arguments || nil == arguments.Owner {
 		return fault.InvalidItem
 	}
 
+	transfer := transactionrecord.BitmarkTransfer(&arguments.BitmarkTransferCountersigned)
+
 	if !bitmark.IsNormalMode(mode.Normal) {
 		return fault.NotAvailableDuringSynchronise
 	}
@@ -93,9 +106,13 @@ func (bitmark *Bitmark) Transfer(arguments *transactionrecord.BitmarkTransferCou
 		transfer = &transactionrecord.BitmarkTransferUnratified{
 			Link:      arguments.Link,
 			Escrow:    arguments.Escrow,
+			Sequence:  arguments.Sequence,
 			Owner:     arguments.Owner,
 			Signature: arguments.Signature,
 		}
+	} else if 0 != arguments.Sequence {
+		// only unratified transfers can be replaced
+		return fault.InvalidItem
 	}
 
 	// save transfer/check for duplicate
@@ -122,6 +139,13 @@ func (bitmark *Bitmark) Transfer(arguments *transactionrecord.BitmarkTransferCou
 		reply.Payments[c] = payment
 	}
 
+	// an escrow transfer stays pending until the escrow is paid
+	reply.Escrow = stored.Escrow
+
+	// a replacement is broadcast like any new transfer so peers apply
+	// the same replacement
+	reply.Replaced = stored.Replaced
+
 	// announce transaction block to other peers
 	if !duplicate {
 		messagebus.Bus.Broadcast.Send("transfer", packedTransfer)
@@ -257,6 +281,26 @@ loop:
 			provenance = append(provenance, h)
 			id = tx.Link
 
+		case *transactionrecord.ShareRedemption:
+			if 0 == i {
+				h.IsOwner = ownership.CurrentlyOwns(nil, tx.Owner, id, bitmark.PoolOwnerTxIndex)
+			}
+
+			provenance = append(provenance, h)
+			id = tx.Link
+
+		case *transactionrecord.AtomicSwap:
+			leg, ok := tx.BitmarkLeg(transactionrecord.Packed(packed).MakeLink(), id)
+			if !ok {
+				break loop
+			}
+			if 0 == i {
+				h.IsOwner = ownership.CurrentlyOwns(nil, leg.Recipient, id, bitmark.PoolOwnerTxIndex)
+			}
+
+			provenance = append(provenance, h)
+			id = leg.Link
+
 		default:
 			break loop
 		}
@@ -419,6 +463,26 @@ loop:
 			provenance = append(provenance, h)
 			id = tx.Link
 
+		case *transactionrecord.ShareRedemption:
+			if 0 == i {
+				h.IsOwner = ownership.CurrentlyOwns(nil, tx.Owner, id, bitmark.PoolOwnerTxIndex)
+			}
+
+			provenance = append(provenance, h)
+			id = tx.Link
+
+		case *transactionrecord.AtomicSwap:
+			leg, ok := tx.BitmarkLeg(transactionrecord.Packed(packed).MakeLink(), id)
+			if !ok {
+				break loop
+			}
+			if 0 == i {
+				h.IsOwner = ownership.CurrentlyOwns(nil, leg.Recipient, id, bitmark.PoolOwnerTxIndex)
+			}
+
+			provenance = append(provenance, h)
+			id = leg.Link
+
 		default:
 			break loop
 		}
@@ -8,6 +8,7 @@ package bitmark_test
 import (
 	"crypto/ed25519"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,7 @@ import (
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/chain"
 	"github.com/bitmark-inc/bitmarkd/currency"
+	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
 	"github.com/bitmark-inc/bitmarkd/messagebus"
 	"github.com/bitmark-inc/bitmarkd/mode"
@@ -90,7 +92,7 @@ func TestBitmarkTransfer(t *testing.T) {
 	)
 
 	var reply bitmark.TransferReply
-	err := b.Transfer(&transfer, &reply)
+	err := b.Transfer(&bitmark.TransferArguments{BitmarkTransferCountersigned: transfer}, &reply)
 	assert.Nil(t, err, "wrong transfer")
 	assert.Equal(t, info.Id, reply.PayId, "wrong payID")
 	assert.Equal(t, info.TxId, reply.TxId, "wrong txID")
@@ -101,6 +103,207 @@ func TestBitmarkTransfer(t *testing.T) {
 	assert.Equal(t, "transfer", received.Command, "wrong message")
 }
 
+func TestBitmarkTransferWithEscrow(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	messagebus.Bus.Broadcast.Release()
+	bus := messagebus.Bus.Broadcast.Chan(5)
+	defer messagebus.Bus.Broadcast.Release()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	owner := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	escrow := &transactionrecord.Payment{
+		Currency: currency.Litecoin,
+		Address:  fixtures.LitecoinAddress,
+		Amount:   5000,
+	}
+
+	transfer := transactionrecord.BitmarkTransferCountersigned{
+		Link:             merkle.Digest{3, 4},
+		Escrow:           escrow,
+		Owner:            &owner,
+		Signature:        nil,
+		Countersignature: nil,
+	}
+
+	unratitifed := transactionrecord.BitmarkTransferUnratified{
+		Link:      merkle.Digest{3, 4},
+		Escrow:    escrow,
+		Owner:     &owner,
+		Signature: nil,
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	info := reservoir.TransferInfo{
+		Id:        pay.PayId{3, 4},
+		TxId:      merkle.Digest{3, 4, 5},
+		IssueTxId: merkle.Digest{3, 4},
+		Packed:    []byte{3, 4},
+		Payments: []transactionrecord.PaymentAlternative{
+			[]*transactionrecord.Payment{
+				{
+					Currency: currency.Litecoin,
+					Address:  fixtures.LitecoinAddress,
+					Amount:   100,
+				},
+				escrow,
+			},
+		},
+		Escrow: &reservoir.EscrowInfo{
+			Payment:   escrow,
+			Paid:      false,
+			ExpiresAt: &expiresAt,
+		},
+	}
+
+	r := mocks.NewMockReservoir(ctl)
+	r.EXPECT().StoreTransfer(&unratitifed).Return(&info, false, nil).Times(1)
+
+	b := bitmark.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{},
+		func(_ mode.Mode) bool { return true },
+		func() bool { return true },
+		r,
+	)
+
+	var reply bitmark.TransferReply
+	err := b.Transfer(&bitmark.TransferArguments{BitmarkTransferCountersigned: transfer}, &reply)
+	assert.Nil(t, err, "wrong transfer")
+	assert.Equal(t, info.Escrow, reply.Escrow, "wrong escrow")
+	assert.Equal(t, 2, len(reply.Payments[currency.Litecoin.String()]), "wrong litecoin payment count")
+
+	received := <-bus
+	assert.Equal(t, "transfer", received.Command, "wrong message")
+}
+
+func TestBitmarkTransferWithSequence(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	messagebus.Bus.Broadcast.Release()
+	bus := messagebus.Bus.Broadcast.Chan(5)
+	defer messagebus.Bus.Broadcast.Release()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	owner := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	arguments := bitmark.TransferArguments{
+		BitmarkTransferCountersigned: transactionrecord.BitmarkTransferCountersigned{
+			Link:  merkle.Digest{5, 6},
+			Owner: &owner,
+		},
+		Sequence: 2,
+	}
+
+	unratitifed := transactionrecord.BitmarkTransferUnratified{
+		Link:     merkle.Digest{5, 6},
+		Sequence: 2,
+		Owner:    &owner,
+	}
+
+	replaced := merkle.Digest{5, 6, 7}
+	info := reservoir.TransferInfo{
+		Id:        pay.PayId{5, 6},
+		TxId:      merkle.Digest{5, 6, 8},
+		IssueTxId: merkle.Digest{5, 6},
+		Packed:    []byte{5, 6},
+		Payments: []transactionrecord.PaymentAlternative{
+			[]*transactionrecord.Payment{
+				{
+					Currency: currency.Litecoin,
+					Address:  fixtures.LitecoinAddress,
+					Amount:   100,
+				},
+			},
+		},
+		Replaced: &replaced,
+	}
+
+	r := mocks.NewMockReservoir(ctl)
+	r.EXPECT().StoreTransfer(&unratitifed).Return(&info, false, nil).Times(1)
+
+	b := bitmark.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{},
+		func(_ mode.Mode) bool { return true },
+		func() bool { return true },
+		r,
+	)
+
+	var reply bitmark.TransferReply
+	err := b.Transfer(&arguments, &reply)
+	assert.Nil(t, err, "wrong transfer")
+	assert.Equal(t, &replaced, reply.Replaced, "wrong replaced")
+
+	// peers receive the replacement as a new transfer
+	received := <-bus
+	assert.Equal(t, "transfer", received.Command, "wrong message")
+	assert.Equal(t, info.Packed, received.Parameters[0], "wrong packed transfer")
+}
+
+func TestBitmarkTransferWhenCountersignedWithSequence(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	owner := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	arguments := bitmark.TransferArguments{
+		BitmarkTransferCountersigned: transactionrecord.BitmarkTransferCountersigned{
+			Link:             merkle.Digest{5, 6},
+			Owner:            &owner,
+			Signature:        []byte{1},
+			Countersignature: []byte{2},
+		},
+		Sequence: 2,
+	}
+
+	b := bitmark.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{},
+		func(_ mode.Mode) bool { return true },
+		func() bool { return true },
+		mocks.NewMockReservoir(ctl),
+	)
+
+	var reply bitmark.TransferReply
+	err := b.Transfer(&arguments, &reply)
+	assert.Equal(t, fault.InvalidItem, err, "wrong error")
+}
+
 func TestBitmarkProvenanceWhenBitmarkIssuance(t *testing.T) {
 	fixtures.SetupTestLogger()
 	defer fixtures.TeardownTestLogger()
@@ -104,6 +104,7 @@ type TransferReply struct {
 	TxId     merkle.Digest                                   `json:"txId"`
 	PayId    pay.PayId                                       `json:"payId"`
 	Payments map[string]transactionrecord.PaymentAlternative `json:"payments"`
+	Escrow   *reservoir.EscrowInfo                           `json:"escrow,omitempty"`
 }
 
 // Transfer - transfer the ownership of a block to new account and/or
@@ -147,6 +148,9 @@ func (bitmark *BlockOwner) Transfer(transfer *transactionrecord.BlockOwnerTransf
 		reply.Payments[c] = payment
 	}
 
+	// an escrow transfer stays pending until the escrow is paid
+	reply.Escrow = stored.Escrow
+
 	// announce transaction block to other peers
 	if !duplicate {
 		messagebus.Bus.Broadcast.Send("transfer", packedTransfer)
@@ -61,6 +61,8 @@ type Handler interface {
 	RPC(http.ResponseWriter, *http.Request)
 	Details(http.ResponseWriter, *http.Request)
 	Connections(http.ResponseWriter, *http.Request)
+	Reservoir(http.ResponseWriter, *http.Request)
+	Evict(http.ResponseWriter, *http.Request)
 	Root(http.ResponseWriter, *http.Request)
 	SetAllow(allow map[string][]*net.IPNet)
 }
@@ -368,6 +370,9 @@ func sendReply(w http.ResponseWriter, data interface{}) {
 func sendNotFound(w http.ResponseWriter) {
 	sendError(w, "not found", http.StatusNotFound)
 }
+func sendBadRequest(w http.ResponseWriter, message string) {
+	sendError(w, message, http.StatusBadRequest)
+}
 func sendMethodNotAllowed(w http.ResponseWriter) {
 	sendError(w, "method not allowed", http.StatusMethodNotAllowed)
 }
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/pay"
+	"github.com/bitmark-inc/bitmarkd/reservoir"
+)
+
+// GET to list the pending and verified items in the reservoir
+// (restricted to the reservoir allow list)
+//
+// query parameters:
+//
+//	queue=<name>                     [freeIssue, paidIssue, transfer, grant, swap, redemption, amendment  default: all]
+//	state=<pending|verified>         [default: both]
+//	start=<pay-id-hex>               [only items after this pay id]
+//	count=<int>                      [1..100  default: 10]
+func (h *handler) Reservoir(w http.ResponseWriter, r *http.Request) {
+	if http.MethodGet != r.Method {
+		sendMethodNotAllowed(w)
+		return
+	}
+	if !h.isAllowed("reservoir", r) {
+		h.log.Warnf("Deny access: %q", r.RemoteAddr)
+		sendForbidden(w)
+		return
+	}
+
+	if connectionCountHTTPS.Increment() > h.maximumConnections {
+		connectionCountHTTPS.Decrement()
+		sendTooManyRequests(w)
+		return
+	}
+	defer connectionCountHTTPS.Decrement()
+
+	r.ParseForm()
+
+	filter := reservoir.ItemFilter{
+		Queue: r.Form.Get("queue"),
+		Count: defaultCount,
+	}
+
+	if "" != filter.Queue && !reservoir.IsQueue(filter.Queue) {
+		sendBadRequest(w, "invalid queue")
+		return
+	}
+
+	switch r.Form.Get("state") {
+	case "":
+	case "pending":
+		filter.State = reservoir.StatePending
+	case "verified":
+		filter.State = reservoir.StateVerified
+	default:
+		sendBadRequest(w, "invalid state")
+		return
+	}
+
+	if s := r.Form.Get("start"); "" != s {
+		var start pay.PayId
+		err := start.UnmarshalText([]byte(s))
+		if nil != err {
+			sendBadRequest(w, fault.NotAPayId.Error())
+			return
+		}
+		filter.Start = &start
+	}
+
+	if s := r.Form.Get("count"); "" != s {
+		n, err := strconv.Atoi(s)
+		if nil != err || n < 1 || n > maximumCount {
+			sendBadRequest(w, fault.InvalidCount.Error())
+			return
+		}
+		filter.Count = n
+	}
+
+	items, err := reservoir.Inspect(filter)
+	if nil != err {
+		sendBadRequest(w, err.Error())
+		return
+	}
+
+	sendReply(w, items)
+}
+
+// POST to remove a pending item from the reservoir
+// (restricted to the evict allow list)
+//
+// form parameters:
+//
+//	pay_id=<pay-id-hex>
+func (h *handler) Evict(w http.ResponseWriter, r *http.Request) {
+	if http.MethodPost != r.Method {
+		sendMethodNotAllowed(w)
+		return
+	}
+	if !h.isAllowed("evict", r) {
+		h.log.Warnf("Deny access: %q", r.RemoteAddr)
+		sendForbidden(w)
+		return
+	}
+
+	if connectionCountHTTPS.Increment() > h.maximumConnections {
+		connectionCountHTTPS.Decrement()
+		sendTooManyRequests(w)
+		return
+	}
+	defer connectionCountHTTPS.Decrement()
+
+	r.ParseForm()
+
+	var payId pay.PayId
+	err := payId.UnmarshalText([]byte(r.Form.Get("pay_id")))
+	if nil != err {
+		sendBadRequest(w, fault.NotAPayId.Error())
+		return
+	}
+
+	err = reservoir.Evict(payId)
+	if fault.PayIdNotFound == err {
+		sendNotFound(w)
+		return
+	}
+	if nil != err {
+		sendInternalServerError(w)
+		return
+	}
+
+	h.log.Warnf("evicted pay id: %s  by: %q", payId, r.RemoteAddr)
+
+	type reply struct {
+		PayId pay.PayId `json:"payId"`
+	}
+	sendReply(w, reply{PayId: payId})
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package handler_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
+	"github.com/bitmark-inc/bitmarkd/rpc/handler"
+	"github.com/bitmark-inc/logger"
+)
+
+func newAllowedHandler(api string) handler.Handler {
+	h := handler.New(
+		logger.New(fixtures.LogCategory),
+		rpc.NewServer(),
+		time.Now(),
+		"1.0",
+		uint64(5),
+	)
+
+	allow := make(map[string][]*net.IPNet)
+	_, ipNet, _ := net.ParseCIDR("192.0.2.1/32")
+	allow[api] = []*net.IPNet{ipNet}
+	h.SetAllow(allow)
+
+	return h
+}
+
+func TestReservoirWhenWrongHTTPMethod(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	h := newAllowedHandler("reservoir")
+
+	req := httptest.NewRequest("POST", "http://not.exist", nil)
+	w := httptest.NewRecorder()
+	h.Reservoir(w, req)
+
+	resp := w.Result()
+	var j eResp
+	_ = json.NewDecoder(resp.Body).Decode(&j)
+	assert.Equal(t, notAllowed, j.Error, "wrong method")
+}
+
+func TestReservoirWhenNotAllow(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	// allowed to evict is not allowed to list
+	h := newAllowedHandler("evict")
+
+	req := httptest.NewRequest("GET", "http://test.com", nil)
+	w := httptest.NewRecorder()
+	h.Reservoir(w, req)
+
+	resp := w.Result()
+	var j eResp
+	_ = json.NewDecoder(resp.Body).Decode(&j)
+	assert.Equal(t, "forbidden", j.Error, "wrong not allow")
+}
+
+func TestReservoirWhenInvalidFilter(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	h := newAllowedHandler("reservoir")
+
+	tests := []struct {
+		params url.Values
+		err    string
+	}{
+		{url.Values{"queue": {"unknown"}}, "invalid queue"},
+		{url.Values{"state": {"confirmed"}}, "invalid state"},
+		{url.Values{"start": {"1234"}}, "not a pay id"},
+		{url.Values{"count": {"0"}}, "invalid count"},
+		{url.Values{"count": {"101"}}, "invalid count"},
+	}
+
+	for i, item := range tests {
+		req := httptest.NewRequest("GET", "http://test.com?"+item.params.Encode(), nil)
+		w := httptest.NewRecorder()
+		h.Reservoir(w, req)
+
+		resp := w.Result()
+		var j eResp
+		_ = json.NewDecoder(resp.Body).Decode(&j)
+		assert.Equal(t, http.StatusBadRequest, j.Code, "%d: wrong code", i)
+		assert.Equal(t, item.err, j.Error, "%d: wrong error", i)
+	}
+}
+
+func TestEvictWhenWrongHTTPMethod(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	h := newAllowedHandler("evict")
+
+	req := httptest.NewRequest("GET", "http://not.exist", nil)
+	w := httptest.NewRecorder()
+	h.Evict(w, req)
+
+	resp := w.Result()
+	var j eResp
+	_ = json.NewDecoder(resp.Body).Decode(&j)
+	assert.Equal(t, notAllowed, j.Error, "wrong method")
+}
+
+func TestEvictWhenNotAllow(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	// allowed to list is not allowed to evict
+	h := newAllowedHandler("reservoir")
+
+	req := httptest.NewRequest("POST", "http://test.com", nil)
+	w := httptest.NewRecorder()
+	h.Evict(w, req)
+
+	resp := w.Result()
+	var j eResp
+	_ = json.NewDecoder(resp.Body).Decode(&j)
+	assert.Equal(t, "forbidden", j.Error, "wrong not allow")
+}
+
+func TestEvictWhenInvalidPayId(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	h := newAllowedHandler("evict")
+
+	form := url.Values{"pay_id": {"not-hex"}}
+	req := httptest.NewRequest("POST", "http://test.com", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.Evict(w, req)
+
+	resp := w.Result()
+	var j eResp
+	_ = json.NewDecoder(resp.Body).Decode(&j)
+	assert.Equal(t, http.StatusBadRequest, j.Code, "wrong code")
+	assert.Equal(t, "not a pay id", j.Error, "wrong error")
+}
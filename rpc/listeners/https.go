@@ -122,6 +122,8 @@ func NewHTTPS(
 	h.mux.HandleFunc("/bitmarkd/details", hdlr.Details)
 	h.mux.HandleFunc("/bitmarkd/connections", hdlr.Connections)
 	h.mux.HandleFunc("/bitmarkd/peers", hdlr.Peers)
+	h.mux.HandleFunc("/bitmarkd/reservoir", hdlr.Reservoir)
+	h.mux.HandleFunc("/bitmarkd/reservoir/evict", hdlr.Evict)
 	h.mux.HandleFunc("/", hdlr.Root)
 
 	return &h, nil
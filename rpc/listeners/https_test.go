@@ -44,6 +44,14 @@ func (h testHandler) Peers(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("Peers"))
 }
 
+func (h testHandler) Reservoir(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("Reservoir"))
+}
+
+func (h testHandler) Evict(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("Evict"))
+}
+
 func (h testHandler) Root(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("Root"))
 }
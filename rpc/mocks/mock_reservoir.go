@@ -11,6 +11,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -104,6 +105,36 @@ func (mr *MockReservoirMockRecorder) TransactionStatus(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionStatus", reflect.TypeOf((*MockReservoir)(nil).TransactionStatus), arg0)
 }
 
+// WaitTransactionStatus mocks base method
+func (m *MockReservoir) WaitTransactionStatus(arg0 merkle.Digest, arg1 reservoir.TransactionState, arg2 time.Duration) reservoir.TransactionState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitTransactionStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(reservoir.TransactionState)
+	return ret0
+}
+
+// WaitTransactionStatus indicates an expected call of WaitTransactionStatus
+func (mr *MockReservoirMockRecorder) WaitTransactionStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitTransactionStatus", reflect.TypeOf((*MockReservoir)(nil).WaitTransactionStatus), arg0, arg1, arg2)
+}
+
+// PendingPayment mocks base method
+func (m *MockReservoir) PendingPayment(arg0 merkle.Digest) (pay.PayId, []transactionrecord.PaymentAlternative, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingPayment", arg0)
+	ret0, _ := ret[0].(pay.PayId)
+	ret1, _ := ret[1].([]transactionrecord.PaymentAlternative)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
+}
+
+// PendingPayment indicates an expected call of PendingPayment
+func (mr *MockReservoirMockRecorder) PendingPayment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingPayment", reflect.TypeOf((*MockReservoir)(nil).PendingPayment), arg0)
+}
+
 // ShareBalance mocks base method
 func (m *MockReservoir) ShareBalance(arg0 *account.Account, arg1 merkle.Digest, arg2 int) ([]reservoir.BalanceInfo, error) {
 	m.ctrl.T.Helper()
@@ -119,6 +150,36 @@ func (mr *MockReservoirMockRecorder) ShareBalance(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareBalance", reflect.TypeOf((*MockReservoir)(nil).ShareBalance), arg0, arg1, arg2)
 }
 
+// ShareHolders mocks base method
+func (m *MockReservoir) ShareHolders(arg0 merkle.Digest, arg1 *account.Account, arg2 int) (*reservoir.HoldersInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareHolders", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*reservoir.HoldersInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShareHolders indicates an expected call of ShareHolders
+func (mr *MockReservoirMockRecorder) ShareHolders(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareHolders", reflect.TypeOf((*MockReservoir)(nil).ShareHolders), arg0, arg1, arg2)
+}
+
+// ShareHoldings mocks base method
+func (m *MockReservoir) ShareHoldings(arg0 *account.Account, arg1 merkle.Digest, arg2 int) (*reservoir.HoldingsInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareHoldings", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*reservoir.HoldingsInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShareHoldings indicates an expected call of ShareHoldings
+func (mr *MockReservoirMockRecorder) ShareHoldings(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareHoldings", reflect.TypeOf((*MockReservoir)(nil).ShareHoldings), arg0, arg1, arg2)
+}
+
 // StoreGrant mocks base method
 func (m *MockReservoir) StoreGrant(arg0 *transactionrecord.ShareGrant) (*reservoir.GrantInfo, bool, error) {
 	m.ctrl.T.Helper()
@@ -135,6 +196,22 @@ func (mr *MockReservoirMockRecorder) StoreGrant(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreGrant", reflect.TypeOf((*MockReservoir)(nil).StoreGrant), arg0)
 }
 
+// StoreRedemption mocks base method
+func (m *MockReservoir) StoreRedemption(arg0 *transactionrecord.ShareRedemption) (*reservoir.RedemptionInfo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreRedemption", arg0)
+	ret0, _ := ret[0].(*reservoir.RedemptionInfo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StoreRedemption indicates an expected call of StoreRedemption
+func (mr *MockReservoirMockRecorder) StoreRedemption(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRedemption", reflect.TypeOf((*MockReservoir)(nil).StoreRedemption), arg0)
+}
+
 // StoreSwap mocks base method
 func (m *MockReservoir) StoreSwap(swap *transactionrecord.ShareSwap) (*reservoir.SwapInfo, bool, error) {
 	m.ctrl.T.Helper()
@@ -150,3 +227,35 @@ func (mr *MockReservoirMockRecorder) StoreSwap(swap interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreSwap", reflect.TypeOf((*MockReservoir)(nil).StoreSwap), swap)
 }
+
+// StoreAtomicSwap mocks base method
+func (m *MockReservoir) StoreAtomicSwap(arg0 *transactionrecord.AtomicSwap) (*reservoir.AtomicSwapInfo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAtomicSwap", arg0)
+	ret0, _ := ret[0].(*reservoir.AtomicSwapInfo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StoreAtomicSwap indicates an expected call of StoreAtomicSwap
+func (mr *MockReservoirMockRecorder) StoreAtomicSwap(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAtomicSwap", reflect.TypeOf((*MockReservoir)(nil).StoreAtomicSwap), arg0)
+}
+
+// StoreAmendment mocks base method
+func (m *MockReservoir) StoreAmendment(arg0 *transactionrecord.AssetAmendment) (*reservoir.AmendmentInfo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAmendment", arg0)
+	ret0, _ := ret[0].(*reservoir.AmendmentInfo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StoreAmendment indicates an expected call of StoreAmendment
+func (mr *MockReservoirMockRecorder) StoreAmendment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAmendment", reflect.TypeOf((*MockReservoir)(nil).StoreAmendment), arg0)
+}
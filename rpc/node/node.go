@@ -44,7 +44,10 @@ type Node struct {
 }
 
 // limit for count
-const maximumNodeList = 100
+const (
+	maximumNodeList         = 100
+	maximumProofSubmissions = 100
+)
 
 // ---
 
@@ -164,6 +167,30 @@ func (node *Node) Info(_ *InfoArguments, reply *InfoReply) error {
 	return nil
 }
 
+// ProofSubmissionsArguments - number of recent submissions to return
+type ProofSubmissionsArguments struct {
+	Count int `json:"count"`
+}
+
+// ProofSubmissionsReply - submission results since start and the most
+// recent submissions, newest first
+type ProofSubmissionsReply struct {
+	Counts  map[string]uint64        `json:"counts"`
+	Records []proof.SubmissionRecord `json:"records"`
+}
+
+// ProofSubmissions - return the reasons recorderd submissions were
+// accepted or rejected
+func (node *Node) ProofSubmissions(arguments *ProofSubmissionsArguments, reply *ProofSubmissionsReply) error {
+
+	if err := ratelimit.LimitN(node.Limiter, arguments.Count, maximumProofSubmissions); nil != err {
+		return err
+	}
+
+	reply.Counts, reply.Records = proof.Submissions(arguments.Count)
+	return nil
+}
+
 // BlockDumpArguments - the block to be dumped
 type BlockDumpArguments struct {
 	Height uint64 `json:"height,string"`
@@ -16,6 +16,7 @@ import (
 	"github.com/bitmark-inc/bitmarkd/announce/rpc"
 	"github.com/bitmark-inc/bitmarkd/chain"
 	"github.com/bitmark-inc/bitmarkd/counter"
+	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/mode"
 	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
@@ -110,3 +111,35 @@ func TestNodeInfo(t *testing.T) {
 	assert.Equal(t, n.Version, reply.Version, "wrong version")
 	assert.Equal(t, "", reply.PublicKey, "wrong empty public key")
 }
+
+func TestNodeProofSubmissions(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	a := mocks.NewMockAnnounce(ctl)
+	b := mocks.NewMockHandle(ctl)
+	c := counter.Counter(0)
+
+	n := node.New(
+		logger.New(fixtures.LogCategory),
+		reservoir.Handles{
+			Blocks: b,
+		},
+		time.Now(),
+		"100",
+		&c,
+		a,
+	)
+
+	var reply node.ProofSubmissionsReply
+	err := n.ProofSubmissions(&node.ProofSubmissionsArguments{Count: 10}, &reply)
+	assert.Nil(t, err, "wrong ProofSubmissions")
+	assert.Equal(t, 0, len(reply.Counts), "wrong counts")
+	assert.Equal(t, 0, len(reply.Records), "wrong records")
+
+	err = n.ProofSubmissions(&node.ProofSubmissionsArguments{Count: 101}, &reply)
+	assert.Equal(t, fault.InvalidCount, err, "wrong error")
+}
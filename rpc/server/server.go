@@ -32,6 +32,8 @@ func Create(log *logger.L, version string, rpcCount *counter.Counter) *rpc.Serve
 	start := time.Now().UTC()
 	pools := reservoir.Handles{
 		Assets:            storage.Pool.Assets,
+		AssetVersions:     storage.Pool.AssetVersions,
+		AssetMetadata:     storage.Pool.AssetMetadata,
 		BlockOwnerPayment: storage.Pool.BlockOwnerPayment,
 		Blocks:            storage.Pool.Blocks,
 		Transactions:      storage.Pool.Transactions,
@@ -39,16 +41,17 @@ func Create(log *logger.L, version string, rpcCount *counter.Counter) *rpc.Serve
 		OwnerData:         storage.Pool.OwnerData,
 		Shares:            storage.Pool.Shares,
 		ShareQuantity:     storage.Pool.ShareQuantity,
+		ShareHolders:      storage.Pool.ShareHolders,
 	}
 
 	server := rpc.NewServer()
 
-	_ = server.Register(assets.New(log, pools, mode.Is, mode.IsTesting))
+	_ = server.Register(assets.New(log, pools, mode.Is, mode.IsTesting, reservoir.Get()))
 	_ = server.Register(bitmark.New(log, pools, mode.Is, mode.IsTesting, reservoir.Get()))
 	_ = server.Register(bitmarks.New(log, pools, mode.Is, reservoir.Get()))
 	_ = server.Register(owner.New(log, pools, ownership.Get()))
 	_ = server.Register(node.New(log, pools, start, version, rpcCount, announce.Get()))
-	_ = server.Register(transaction.New(log, start, reservoir.Get()))
+	_ = server.Register(transaction.New(log, pools, start, reservoir.Get()))
 	_ = server.Register(blockowner.New(log, pools, mode.Is, mode.IsTesting, reservoir.Get(), blockrecord.Get()))
 	_ = server.Register(share.New(log, mode.Is, reservoir.Get()))
 
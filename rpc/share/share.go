@@ -150,7 +150,7 @@ func (share *Share) Balance(arguments *BalanceArguments, reply *BalanceReply) er
 		return fault.WrongNetworkForPublicKey
 	}
 
-	result, err := share.Rsvr.ShareBalance(arguments.Owner, arguments.ShareId, arguments.Count)
+	result, err := share.Rsvr.ShareBalance(arguments.Owner, arguments.ShareId, count)
 	if nil != err {
 		return err
 	}
@@ -160,6 +160,118 @@ func (share *Share) Balance(arguments *BalanceArguments, reply *BalanceReply) er
 	return nil
 }
 
+// List the holders of a share
+// ---------------------------
+
+// HoldersArguments - arguments for RPC
+type HoldersArguments struct {
+	ShareId merkle.Digest    `json:"shareId"`
+	Start   *account.Account `json:"start"` // optional base58: first holder to return
+	Count   int              `json:"count"` // number of records
+}
+
+// HoldersReply - a page of holders and the totals for the share
+type HoldersReply struct {
+	reservoir.HoldersInfo
+}
+
+// Holders - list every account holding a share
+func (share *Share) Holders(arguments *HoldersArguments, reply *HoldersReply) error {
+
+	if err := ratelimit.Limit(share.Limiter); nil != err {
+		return err
+	}
+
+	log := share.Log
+
+	log.Infof("Share.Holders: %+v", arguments)
+
+	if nil == arguments {
+		return fault.InvalidItem
+	}
+
+	count := arguments.Count
+	if count <= 0 {
+		return fault.InvalidCount
+	}
+	if count > owner.MaximumBitmarksCount {
+		count = owner.MaximumBitmarksCount
+	}
+
+	if !share.IsNormalMode(mode.Normal) {
+		return fault.NotAvailableDuringSynchronise
+	}
+
+	if nil != arguments.Start && arguments.Start.IsTesting() != mode.IsTesting() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	result, err := share.Rsvr.ShareHolders(arguments.ShareId, arguments.Start, count)
+	if nil != err {
+		return err
+	}
+
+	reply.HoldersInfo = *result
+
+	return nil
+}
+
+// List the shares held by an account
+// ----------------------------------
+
+// HoldingsArguments - arguments for RPC
+type HoldingsArguments struct {
+	Owner   *account.Account `json:"owner"`   // base58
+	ShareId merkle.Digest    `json:"shareId"` // first share to return
+	Count   int              `json:"count"`   // number of records
+}
+
+// HoldingsReply - a page of shares held by an account
+type HoldingsReply struct {
+	reservoir.HoldingsInfo
+}
+
+// Holdings - list every share held by an account
+func (share *Share) Holdings(arguments *HoldingsArguments, reply *HoldingsReply) error {
+
+	if err := ratelimit.Limit(share.Limiter); nil != err {
+		return err
+	}
+
+	log := share.Log
+
+	log.Infof("Share.Holdings: %+v", arguments)
+
+	if nil == arguments || nil == arguments.Owner {
+		return fault.InvalidItem
+	}
+
+	count := arguments.Count
+	if count <= 0 {
+		return fault.InvalidCount
+	}
+	if count > owner.MaximumBitmarksCount {
+		count = owner.MaximumBitmarksCount
+	}
+
+	if !share.IsNormalMode(mode.Normal) {
+		return fault.NotAvailableDuringSynchronise
+	}
+
+	if arguments.Owner.IsTesting() != mode.IsTesting() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	result, err := share.Rsvr.ShareHoldings(arguments.Owner, arguments.ShareId, count)
+	if nil != err {
+		return err
+	}
+
+	reply.HoldingsInfo = *result
+
+	return nil
+}
+
 // Grant some shares
 // -----------------
 
@@ -305,3 +417,137 @@ func (share *Share) Swap(arguments *transactionrecord.ShareSwap, reply *SwapRepl
 
 	return nil
 }
+
+// Redeem all shares
+// -----------------
+
+// RedeemReply - result of redeeming every share of a bitmark
+type RedeemReply struct {
+	TxId     merkle.Digest                                   `json:"txId"`
+	ShareId  merkle.Digest                                   `json:"shareId"`
+	PayId    pay.PayId                                       `json:"payId"`
+	Payments map[string]transactionrecord.PaymentAlternative `json:"payments"`
+}
+
+// Redeem - burn every share of a bitmark held by one account and
+// return the bitmark to that account
+func (share *Share) Redeem(arguments *transactionrecord.ShareRedemption, reply *RedeemReply) error {
+
+	if err := ratelimit.Limit(share.Limiter); nil != err {
+		return err
+	}
+
+	log := share.Log
+
+	log.Infof("Share.Redeem: %+v", arguments)
+
+	if nil == arguments || nil == arguments.Owner {
+		return fault.InvalidItem
+	}
+
+	if arguments.Quantity < 1 {
+		return fault.ShareQuantityTooSmall
+	}
+
+	if !share.IsNormalMode(mode.Normal) {
+		return fault.NotAvailableDuringSynchronise
+	}
+
+	if arguments.Owner.IsTesting() != mode.IsTesting() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	// save redemption/check for duplicate
+	stored, duplicate, err := share.Rsvr.StoreRedemption(arguments)
+	if nil != err {
+		return err
+	}
+
+	payId := stored.Id
+	txId := stored.TxId
+	packed := stored.Packed
+
+	log.Debugf("id: %v", txId)
+	reply.TxId = txId
+	reply.ShareId = stored.ShareId
+	reply.PayId = payId
+	reply.Payments = make(map[string]transactionrecord.PaymentAlternative)
+
+	for _, payment := range stored.Payments {
+		c := payment[0].Currency.String()
+		reply.Payments[c] = payment
+	}
+
+	// announce transaction block to other peers
+	if !duplicate {
+		messagebus.Bus.Broadcast.Send("transfer", packed)
+	}
+
+	return nil
+}
+
+// Atomic swap of bitmarks and shares
+// ----------------------------------
+
+// AtomicSwapReply - result of an atomic swap
+type AtomicSwapReply struct {
+	TxId     merkle.Digest                                   `json:"txId"`
+	PayId    pay.PayId                                       `json:"payId"`
+	Payments map[string]transactionrecord.PaymentAlternative `json:"payments"`
+}
+
+// AtomicSwap - exchange bitmarks and shares between two accounts, all
+// legs are applied together or not at all
+func (share *Share) AtomicSwap(arguments *transactionrecord.AtomicSwap, reply *AtomicSwapReply) error {
+
+	if err := ratelimit.Limit(share.Limiter); nil != err {
+		return err
+	}
+
+	log := share.Log
+
+	log.Infof("Share.AtomicSwap: %+v", arguments)
+
+	if nil == arguments || nil == arguments.OwnerOne || nil == arguments.OwnerTwo {
+		return fault.InvalidItem
+	}
+
+	if !share.IsNormalMode(mode.Normal) {
+		return fault.NotAvailableDuringSynchronise
+	}
+
+	if arguments.OwnerOne.IsTesting() != mode.IsTesting() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	if arguments.OwnerTwo.IsTesting() != mode.IsTesting() {
+		return fault.WrongNetworkForPublicKey
+	}
+
+	// save swap/check for duplicate
+	stored, duplicate, err := share.Rsvr.StoreAtomicSwap(arguments)
+	if nil != err {
+		return err
+	}
+
+	payId := stored.Id
+	txId := stored.TxId
+	packed := stored.Packed
+
+	log.Debugf("id: %v", txId)
+	reply.TxId = txId
+	reply.PayId = payId
+	reply.Payments = make(map[string]transactionrecord.PaymentAlternative)
+
+	for _, payment := range stored.Payments {
+		c := payment[0].Currency.String()
+		reply.Payments[c] = payment
+	}
+
+	// announce transaction block to other peers
+	if !duplicate {
+		messagebus.Bus.Broadcast.Send("transfer", packed)
+	}
+
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
 	"github.com/bitmark-inc/bitmarkd/rpc/mocks"
+	"github.com/bitmark-inc/bitmarkd/rpc/owner"
 	"github.com/bitmark-inc/bitmarkd/rpc/share"
 	"github.com/bitmark-inc/bitmarkd/transactionrecord"
 	"github.com/bitmark-inc/logger"
@@ -152,7 +153,7 @@ func TestShareBalance(t *testing.T) {
 		Available: 900,
 	}
 
-	r.EXPECT().ShareBalance(arg.Owner, arg.ShareId, arg.Count).Return([]reservoir.BalanceInfo{info}, nil).Times(1)
+	r.EXPECT().ShareBalance(arg.Owner, arg.ShareId, owner.MaximumBitmarksCount).Return([]reservoir.BalanceInfo{info}, nil).Times(1)
 
 	var reply share.BalanceReply
 	err := s.Balance(&arg, &reply)
@@ -290,6 +291,146 @@ func TestShareBalanceWhenSmallCount(t *testing.T) {
 	assert.Equal(t, fault.InvalidCount, err, "wrong error")
 }
 
+func TestShareHolders(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		r,
+	)
+
+	acc := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	arg := share.HoldersArguments{
+		ShareId: merkle.Digest{5, 3, 1},
+		Count:   10,
+	}
+
+	info := reservoir.HoldersInfo{
+		ShareId:  arg.ShareId,
+		Quantity: 100,
+		Total:    100,
+		Holders: []reservoir.HolderInfo{
+			{Owner: &acc, Confirmed: 100, Available: 100},
+		},
+	}
+
+	r.EXPECT().ShareHolders(arg.ShareId, arg.Start, arg.Count).Return(&info, nil).Times(1)
+
+	var reply share.HoldersReply
+	err := s.Holders(&arg, &reply)
+	assert.Nil(t, err, "wrong Holders")
+	assert.Equal(t, info, reply.HoldersInfo, "wrong holders")
+}
+
+func TestShareHoldersWhenSmallCount(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		r,
+	)
+
+	var reply share.HoldersReply
+	err := s.Holders(&share.HoldersArguments{}, &reply)
+	assert.Equal(t, fault.InvalidCount, err, "wrong error")
+}
+
+func TestShareHoldings(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		r,
+	)
+
+	acc := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	arg := share.HoldingsArguments{
+		Owner: &acc,
+		Count: 10,
+	}
+
+	next := merkle.Digest{9}
+	info := reservoir.HoldingsInfo{
+		Holdings: []reservoir.HoldingInfo{
+			{
+				BalanceInfo: reservoir.BalanceInfo{
+					ShareId:   merkle.Digest{5, 3, 1},
+					Confirmed: 5,
+					Available: 5,
+				},
+				Quantity: 100,
+			},
+		},
+		NextStart: &next,
+	}
+
+	r.EXPECT().ShareHoldings(arg.Owner, arg.ShareId, arg.Count).Return(&info, nil).Times(1)
+
+	var reply share.HoldingsReply
+	err := s.Holdings(&arg, &reply)
+	assert.Nil(t, err, "wrong Holdings")
+	assert.Equal(t, info, reply.HoldingsInfo, "wrong holdings")
+}
+
+func TestShareHoldingsWhenInvalidOwner(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		r,
+	)
+
+	var reply share.HoldingsReply
+	err := s.Holdings(&share.HoldingsArguments{Count: 10}, &reply)
+	assert.Equal(t, fault.InvalidItem, err, "wrong error")
+}
+
 func TestShareGrant(t *testing.T) {
 	fixtures.SetupTestLogger()
 	defer fixtures.TeardownTestLogger()
@@ -1099,3 +1240,192 @@ func TestShareSwapWhenStoreSwapError(t *testing.T) {
 	assert.NotNil(t, err, "wrong Swap")
 	assert.Equal(t, "fake", err.Error(), "wrong error")
 }
+
+func TestShareRedeem(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		r,
+	)
+
+	acc := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	arg := transactionrecord.ShareRedemption{
+		Link:      merkle.Digest{1, 2, 3},
+		Quantity:  100,
+		Owner:     &acc,
+		Signature: nil,
+	}
+	packed, _ := arg.Pack(&acc)
+	arg.Signature = ed25519.Sign(fixtures.IssuerPrivateKey, packed)
+
+	info := reservoir.RedemptionInfo{
+		Id:      pay.PayId{1, 2, 3, 4},
+		TxId:    merkle.Digest{5, 6, 7, 8},
+		ShareId: merkle.Digest{9, 10},
+		Packed:  []byte{4},
+		Payments: []transactionrecord.PaymentAlternative{
+			[]*transactionrecord.Payment{
+				{
+					Currency: currency.Litecoin,
+					Address:  fixtures.LitecoinAddress,
+					Amount:   299,
+				},
+			},
+		},
+	}
+
+	r.EXPECT().StoreRedemption(&arg).Return(&info, false, nil).Times(1)
+
+	messagebus.Bus.Broadcast.Release()
+	bus := messagebus.Bus.Broadcast.Chan(5)
+	defer messagebus.Bus.Broadcast.Release()
+
+	var reply share.RedeemReply
+	err := s.Redeem(&arg, &reply)
+	received := <-bus
+	assert.Equal(t, "transfer", received.Command, "wrong command")
+	assert.Nil(t, err, "wrong Redeem")
+	assert.Equal(t, info.TxId, reply.TxId, "wrong tx ID")
+	assert.Equal(t, info.ShareId, reply.ShareId, "wrong share ID")
+	assert.Equal(t, info.Id, reply.PayId, "wrong payment ID")
+	assert.Equal(t, *info.Payments[0][0], *reply.Payments[info.Payments[0][0].Currency.String()][0], "wrong payments")
+}
+
+func TestShareRedeemWhenEmptyArgumentsOwner(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		nil,
+	)
+
+	var reply share.RedeemReply
+	err := s.Redeem(&transactionrecord.ShareRedemption{Quantity: 100}, &reply)
+	assert.NotNil(t, err, "wrong Redeem")
+	assert.Equal(t, fault.InvalidItem, err, "wrong error")
+}
+
+func TestShareAtomicSwap(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	mode.Initialise(chain.Testing)
+	defer mode.Finalise()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		r,
+	)
+
+	acc1 := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.IssuerPublicKey,
+		},
+	}
+
+	acc2 := account.Account{
+		AccountInterface: &account.ED25519Account{
+			Test:      true,
+			PublicKey: fixtures.ReceiverPublicKey,
+		},
+	}
+
+	arg := transactionrecord.AtomicSwap{
+		Bitmarks: []transactionrecord.SwapBitmarkLeg{
+			{
+				Link:      merkle.Digest{1, 2, 3},
+				Owner:     &acc1,
+				Recipient: &acc2,
+			},
+		},
+		Shares: []transactionrecord.SwapShareLeg{
+			{
+				ShareId:   merkle.Digest{4, 5, 6},
+				Quantity:  10,
+				Owner:     &acc2,
+				Recipient: &acc1,
+			},
+		},
+		OwnerOne:         &acc1,
+		OwnerTwo:         &acc2,
+		BeforeBlock:      300,
+		Signature:        nil,
+		Countersignature: nil,
+	}
+	packed, _ := arg.Pack(&acc1)
+	arg.Signature = ed25519.Sign(fixtures.IssuerPrivateKey, packed)
+	packed, _ = arg.Pack(&acc1)
+	arg.Countersignature = ed25519.Sign(fixtures.ReceiverPrivateKey, packed)
+
+	info := reservoir.AtomicSwapInfo{
+		Id:     pay.PayId{1, 2, 3, 4},
+		TxId:   merkle.Digest{5, 6, 7, 8},
+		Packed: []byte{5},
+		Payments: []transactionrecord.PaymentAlternative{
+			[]*transactionrecord.Payment{
+				{
+					Currency: currency.Litecoin,
+					Address:  fixtures.LitecoinAddress,
+					Amount:   300,
+				},
+			},
+		},
+	}
+
+	r.EXPECT().StoreAtomicSwap(&arg).Return(&info, false, nil).Times(1)
+
+	messagebus.Bus.Broadcast.Release()
+	bus := messagebus.Bus.Broadcast.Chan(5)
+	defer messagebus.Bus.Broadcast.Release()
+
+	var reply share.AtomicSwapReply
+	err := s.AtomicSwap(&arg, &reply)
+	received := <-bus
+	assert.Equal(t, "transfer", received.Command, "wrong command")
+	assert.Nil(t, err, "wrong AtomicSwap")
+	assert.Equal(t, info.TxId, reply.TxId, "wrong tx ID")
+	assert.Equal(t, info.Id, reply.PayId, "wrong payment ID")
+	assert.Equal(t, *info.Payments[0][0], *reply.Payments[info.Payments[0][0].Currency.String()][0], "wrong payments")
+}
+
+func TestShareAtomicSwapWhenEmptyArgumentsOwner(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	s := share.New(
+		logger.New(fixtures.LogCategory),
+		func(_ mode.Mode) bool { return true },
+		nil,
+	)
+
+	var reply share.AtomicSwapReply
+	err := s.AtomicSwap(&transactionrecord.AtomicSwap{}, &reply)
+	assert.NotNil(t, err, "wrong AtomicSwap")
+	assert.Equal(t, fault.InvalidItem, err, "wrong error")
+}
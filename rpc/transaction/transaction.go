@@ -12,22 +12,29 @@ import (
 
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/pay"
 	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/ratelimit"
+	"github.com/bitmark-inc/bitmarkd/storage"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
 	"github.com/bitmark-inc/logger"
 )
 
 const (
 	rateLimitTransaction = 200
 	rateBurstTransaction = 100
+
+	defaultWatchTimeout = 30 * time.Second
+	maximumWatchTimeout = 60 * time.Second
 )
 
 // Transaction - an RPC entry for transaction related functions
 type Transaction struct {
-	Log     *logger.L
-	Limiter *rate.Limiter
-	Start   time.Time
-	Rsvr    reservoir.Reservoir
+	Log              *logger.L
+	Limiter          *rate.Limiter
+	Start            time.Time
+	Rsvr             reservoir.Reservoir
+	PoolTransactions storage.Handle
 }
 
 // Arguments - arguments for status RPC request
@@ -40,12 +47,28 @@ type StatusReply struct {
 	Status string `json:"status"`
 }
 
-func New(log *logger.L, start time.Time, rsvr reservoir.Reservoir) *Transaction {
+// WatchArguments - arguments for watch RPC request
+type WatchArguments struct {
+	TxId    merkle.Digest `json:"txId"`
+	Status  string        `json:"status"`  // last status seen by the caller
+	Timeout uint64        `json:"timeout"` // seconds, zero for the default
+}
+
+// WatchReply - results from watch RPC
+type WatchReply struct {
+	Status      string                                          `json:"status"`
+	PayId       *pay.PayId                                      `json:"payId,omitempty"`              // only when pending
+	Payments    map[string]transactionrecord.PaymentAlternative `json:"payments,omitempty"`           // only when pending and not a free issue
+	BlockNumber uint64                                          `json:"blockNumber,string,omitempty"` // only when confirmed
+}
+
+func New(log *logger.L, pools reservoir.Handles, start time.Time, rsvr reservoir.Reservoir) *Transaction {
 	return &Transaction{
-		Log:     log,
-		Limiter: rate.NewLimiter(rateLimitTransaction, rateBurstTransaction),
-		Start:   start,
-		Rsvr:    rsvr,
+		Log:              log,
+		Limiter:          rate.NewLimiter(rateLimitTransaction, rateBurstTransaction),
+		Start:            start,
+		Rsvr:             rsvr,
+		PoolTransactions: pools.Transactions,
 	}
 }
 
@@ -62,3 +85,73 @@ func (t *Transaction) Status(arguments *Arguments, reply *StatusReply) error {
 	reply.Status = t.Rsvr.TransactionStatus(arguments.TxId).String()
 	return nil
 }
+
+// Watch - wait for the status of a transaction to change
+//
+// the reply is sent as soon as the status differs from the one given
+// or when the timeout expires, whichever is first; so a client can
+// follow a transaction without repeatedly polling Status
+func (t *Transaction) Watch(arguments *WatchArguments, reply *WatchReply) error {
+	if err := ratelimit.Limit(t.Limiter); nil != err {
+		return err
+	}
+
+	if t.Rsvr == nil {
+		return fault.MissingReservoir
+	}
+
+	current, err := parseState(arguments.Status)
+	if nil != err {
+		return err
+	}
+
+	timeout := time.Duration(arguments.Timeout) * time.Second
+	if 0 == timeout {
+		timeout = defaultWatchTimeout
+	} else if timeout > maximumWatchTimeout {
+		timeout = maximumWatchTimeout
+	}
+
+	state := t.Rsvr.WaitTransactionStatus(arguments.TxId, current, timeout)
+
+	reply.Status = state.String()
+
+	switch state {
+	case reservoir.StatePending:
+		payId, payments, ok := t.Rsvr.PendingPayment(arguments.TxId)
+		if ok {
+			reply.PayId = &payId
+		}
+		if 0 != len(payments) {
+			reply.Payments = make(map[string]transactionrecord.PaymentAlternative)
+			for _, payment := range payments {
+				c := payment[0].Currency.String()
+				reply.Payments[c] = payment
+			}
+		}
+
+	case reservoir.StateConfirmed:
+		if nil != t.PoolTransactions {
+			reply.BlockNumber, _ = t.PoolTransactions.GetNB(arguments.TxId[:])
+		}
+	}
+	return nil
+}
+
+// convert a status string back to a state, empty is Unknown
+func parseState(status string) (reservoir.TransactionState, error) {
+	if "" == status {
+		return reservoir.StateUnknown, nil
+	}
+	for _, state := range []reservoir.TransactionState{
+		reservoir.StateUnknown,
+		reservoir.StatePending,
+		reservoir.StateVerified,
+		reservoir.StateConfirmed,
+	} {
+		if state.String() == status {
+			return state, nil
+		}
+	}
+	return reservoir.StateUnknown, fault.InvalidItem
+}
@@ -12,12 +12,15 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/bitmark-inc/bitmarkd/currency"
 	"github.com/bitmark-inc/bitmarkd/fault"
 	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/pay"
 	"github.com/bitmark-inc/bitmarkd/reservoir"
 	"github.com/bitmark-inc/bitmarkd/rpc/fixtures"
 	"github.com/bitmark-inc/bitmarkd/rpc/mocks"
 	"github.com/bitmark-inc/bitmarkd/rpc/transaction"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
 	"github.com/bitmark-inc/logger"
 )
 
@@ -32,7 +35,7 @@ func TestTransactionStatus(t *testing.T) {
 
 	now := time.Now()
 
-	tr := transaction.New(logger.New(fixtures.LogCategory), now, r)
+	tr := transaction.New(logger.New(fixtures.LogCategory), reservoir.Handles{}, now, r)
 
 	arg := transaction.Arguments{TxId: merkle.Digest{1, 2, 3, 4}}
 
@@ -53,7 +56,7 @@ func TestTransactionStatusWhenReservoirEmpty(t *testing.T) {
 
 	now := time.Now()
 
-	tr := transaction.New(logger.New(fixtures.LogCategory), now, nil)
+	tr := transaction.New(logger.New(fixtures.LogCategory), reservoir.Handles{}, now, nil)
 
 	arg := transaction.Arguments{TxId: merkle.Digest{1, 2, 3, 4}}
 
@@ -62,3 +65,88 @@ func TestTransactionStatusWhenReservoirEmpty(t *testing.T) {
 	assert.NotNil(t, err, "wrong Status")
 	assert.Equal(t, fault.MissingReservoir, err, "wrong error message")
 }
+
+func TestTransactionWatch(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+	trx := mocks.NewMockHandle(ctl)
+
+	tr := transaction.New(logger.New(fixtures.LogCategory), reservoir.Handles{Transactions: trx}, time.Now(), r)
+
+	arg := transaction.WatchArguments{
+		TxId:    merkle.Digest{1, 2, 3, 4},
+		Status:  reservoir.StateVerified.String(),
+		Timeout: 3600,
+	}
+
+	r.EXPECT().WaitTransactionStatus(arg.TxId, reservoir.StateVerified, 60*time.Second).Return(reservoir.StateConfirmed).Times(1)
+	trx.EXPECT().GetNB(arg.TxId[:]).Return(uint64(1234), []byte{}).Times(1)
+
+	var reply transaction.WatchReply
+	err := tr.Watch(&arg, &reply)
+	assert.Nil(t, err, "wrong Watch")
+	assert.Equal(t, reservoir.StateConfirmed.String(), reply.Status, "wrong status")
+	assert.Equal(t, uint64(1234), reply.BlockNumber, "wrong block number")
+}
+
+func TestTransactionWatchPending(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	tr := transaction.New(logger.New(fixtures.LogCategory), reservoir.Handles{}, time.Now(), r)
+
+	arg := transaction.WatchArguments{TxId: merkle.Digest{1, 2, 3, 4}}
+
+	payId := pay.PayId{5, 6, 7, 8}
+	payments := []transactionrecord.PaymentAlternative{
+		{
+			&transactionrecord.Payment{
+				Currency: currency.Litecoin,
+				Address:  "mmCKZS7toE69QgXNs1JZcjW6LFj8LfUbz6",
+				Amount:   100,
+			},
+		},
+	}
+
+	r.EXPECT().WaitTransactionStatus(arg.TxId, reservoir.StateUnknown, 30*time.Second).Return(reservoir.StatePending).Times(1)
+	r.EXPECT().PendingPayment(arg.TxId).Return(payId, payments, true).Times(1)
+
+	var reply transaction.WatchReply
+	err := tr.Watch(&arg, &reply)
+	assert.Nil(t, err, "wrong Watch")
+	assert.Equal(t, reservoir.StatePending.String(), reply.Status, "wrong status")
+	assert.Equal(t, payId, *reply.PayId, "wrong pay id")
+	assert.Equal(t, payments[0], reply.Payments[currency.Litecoin.String()], "wrong payments")
+	assert.Equal(t, uint64(0), reply.BlockNumber, "wrong block number")
+}
+
+func TestTransactionWatchWithInvalidStatus(t *testing.T) {
+	fixtures.SetupTestLogger()
+	defer fixtures.TeardownTestLogger()
+
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	r := mocks.NewMockReservoir(ctl)
+
+	tr := transaction.New(logger.New(fixtures.LogCategory), reservoir.Handles{}, time.Now(), r)
+
+	arg := transaction.WatchArguments{
+		TxId:   merkle.Digest{1, 2, 3, 4},
+		Status: "Finished",
+	}
+
+	var reply transaction.WatchReply
+	err := tr.Watch(&arg, &reply)
+	assert.Equal(t, fault.InvalidItem, err, "wrong error")
+}
@@ -46,6 +46,11 @@
 //
 //   A ⧺ asset id         - confirmed asset identifier
 //                          data: BN ⧺ packed asset data
+//   V ⧺ asset id ⧺ count - confirmed metadata amendments (count ≡ version, starting at 1)
+//                          data: BN ⧺ packed amendment data
+//   M ⧺ key ⧺ 00 ⧺ value ⧺ 00 ⧺ asset id
+//                        - index of the latest metadata key/value pairs of each asset
+//                          data: BN
 //
 //
 // Ownership:
@@ -68,6 +73,8 @@
 //                          data: value ⧺ txId
 //   Q ⧺ owner ⧺ txId     - current balance quantity of shares (ShareId) for each owner (deleted if value becomes zero)
 //                          data: value
+//   S ⧺ txId ⧺ owner     - reverse of Q to list the holders of each share (deleted if value becomes zero)
+//                          data: value
 //
 // Testing:
 //   Z ⧺ key              - testing data
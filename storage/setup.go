@@ -27,6 +27,8 @@ type pools struct {
 	BlockOwnerPayment Handle `prefix:"H" pool:"PoolHandle"`
 	BlockOwnerTxIndex Handle `prefix:"I" pool:"PoolHandle"`
 	Assets            Handle `prefix:"A" pool:"PoolNB"`
+	AssetVersions     Handle `prefix:"V" pool:"PoolNB"`
+	AssetMetadata     Handle `prefix:"M" pool:"PoolHandle"`
 	Transactions      Handle `prefix:"T" pool:"PoolNB"`
 	OwnerNextCount    Handle `prefix:"N" pool:"PoolHandle"`
 	OwnerList         Handle `prefix:"L" pool:"PoolHandle"`
@@ -34,6 +36,7 @@ type pools struct {
 	OwnerData         Handle `prefix:"O" pool:"PoolHandle"`
 	Shares            Handle `prefix:"F" pool:"PoolHandle"`
 	ShareQuantity     Handle `prefix:"Q" pool:"PoolHandle"`
+	ShareHolders      Handle `prefix:"S" pool:"PoolHandle"`
 	TestData          Handle `prefix:"Z" pool:"PoolHandle"`
 }
 
@@ -44,10 +47,18 @@ var Pool pools
 var (
 	versionKey    = []byte{0x00, 'V', 'E', 'R', 'S', 'I', 'O', 'N'}
 	needMigration = false
+	storedVersion = 0 // version on file before migration
 )
 
+// BitmarksDB versions - each version adds a migration
 const (
-	currentBitmarksDBVersion = 0x1
+	BlockHeaderHashVersion = 0x1 // index of block header hashes
+	ShareHoldersVersion    = 0x2 // index of share holders
+	MetadataIndexVersion   = 0x3 // index of asset metadata
+)
+
+const (
+	currentBitmarksDBVersion = MetadataIndexVersion
 	bitmarksDBName           = "bitmarks"
 )
 
@@ -218,6 +229,7 @@ func validateBitmarksDBVersion(bitmarksDBVersion int, readOnly bool) error {
 
 	if 0 < bitmarksDBVersion && bitmarksDBVersion < currentBitmarksDBVersion {
 		needMigration = true
+		storedVersion = bitmarksDBVersion
 	} else if 0 == bitmarksDBVersion {
 		// database was empty so tag as current version
 		err := putVersion(poolData.bitmarksDB, currentBitmarksDBVersion)
@@ -309,6 +321,26 @@ func IsMigrationNeed() bool {
 	return needMigration
 }
 
+// IsMigrationNeedFor - check if the migration added by a version has
+// to be applied, i.e. the database on file is older than that version
+func IsMigrationNeedFor(version int) bool {
+	return needMigration && storedVersion < version
+}
+
+// MigrationCompleted - tag the bitmarks database as the current version
+// once all migrations have been applied
+func MigrationCompleted() error {
+	poolData.Lock()
+	defer poolData.Unlock()
+
+	err := putVersion(poolData.bitmarksDB, currentBitmarksDBVersion)
+	if nil != err {
+		return err
+	}
+	needMigration = false
+	return nil
+}
+
 func NewDBTransaction() (Transaction, error) {
 	err := poolData.trx.Begin()
 	if nil != err {
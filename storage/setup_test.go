@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// only migrations added after the version on file are needed
+func TestIsMigrationNeedFor(t *testing.T) {
+	defer func() {
+		needMigration = false
+		storedVersion = 0
+	}()
+
+	err := validateBitmarksDBVersion(ShareHoldersVersion, false)
+	assert.Nil(t, err, "validate version")
+	assert.True(t, IsMigrationNeed(), "migration not needed")
+
+	assert.False(t, IsMigrationNeedFor(BlockHeaderHashVersion), "block header hash migration")
+	assert.False(t, IsMigrationNeedFor(ShareHoldersVersion), "share holder migration")
+	assert.True(t, IsMigrationNeedFor(MetadataIndexVersion), "metadata index migration")
+
+	err = MigrationCompleted()
+	assert.Nil(t, err, "migration completed")
+	assert.False(t, IsMigrationNeedFor(MetadataIndexVersion), "migration after completion")
+}
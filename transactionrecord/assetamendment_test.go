@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package transactionrecord_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/bitmarkd/util"
+)
+
+// test the packing/unpacking of asset amendment record
+//
+// ensures that pack->unpack returns the same original value
+func TestPackAssetAmendment(t *testing.T) {
+
+	registrantAccount := makeAccount(registrant.publicKey)
+
+	r := transactionrecord.AssetAmendment{
+		AssetId:    transactionrecord.NewAssetIdentifier([]byte("0123456789abcdef")),
+		Version:    1,
+		Metadata:   "description\u0000amended",
+		Registrant: registrantAccount,
+	}
+
+	expected := []byte{
+		0x0d, 0x40, 0x59, 0xd0, 0x61, 0x55, 0xd2, 0x5d,
+		0xff, 0xdb, 0x98, 0x27, 0x29, 0xde, 0x8d, 0xce,
+		0x9d, 0x78, 0x55, 0xca, 0x09, 0x4d, 0x8b, 0xab,
+		0x81, 0x24, 0xb3, 0x47, 0xc4, 0x06, 0x68, 0x47,
+		0x70, 0x56, 0xb3, 0xc2, 0x7c, 0xcb, 0x7d, 0x71,
+		0xb5, 0x40, 0x43, 0xd2, 0x07, 0xcc, 0xd1, 0x87,
+		0x64, 0x2b, 0xf9, 0xc8, 0x46, 0x6f, 0x9a, 0x8d,
+		0x0d, 0xbe, 0xfb, 0x4c, 0x41, 0x63, 0x3a, 0x7e,
+		0x39, 0xef, 0x01, 0x13, 0x64, 0x65, 0x73, 0x63,
+		0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x00,
+		0x61, 0x6d, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x21,
+		0x13, 0x7a, 0x81, 0x92, 0x56, 0x5e, 0x6c, 0xa2,
+		0x35, 0x80, 0xe1, 0x81, 0x59, 0xef, 0x30, 0x73,
+		0xf6, 0xe2, 0xfb, 0x8e, 0x7e, 0x9d, 0x31, 0x49,
+		0x7e, 0x79, 0xd7, 0x73, 0x1b, 0xa3, 0x74, 0x11,
+		0x01,
+	}
+
+	expectedTxId := merkle.Digest{
+		0xca, 0xed, 0x4e, 0xbe, 0x9b, 0x68, 0x04, 0xf5,
+		0x20, 0x49, 0x2f, 0x99, 0x05, 0x56, 0xfc, 0x4f,
+		0x8f, 0x43, 0x7a, 0x4c, 0xa6, 0x87, 0x60, 0x55,
+		0x16, 0x82, 0x32, 0x33, 0x5c, 0xf2, 0x8f, 0xf8,
+	}
+
+	// manually sign the record and attach signature to "expected"
+	signature := ed25519.Sign(registrant.privateKey, expected)
+	r.Signature = signature
+	l := util.ToVarint64(uint64(len(signature)))
+	expected = append(expected, l...)
+	expected = append(expected, signature...)
+
+	// test the packer
+	packed, err := r.Pack(registrantAccount)
+	if nil != err {
+		t.Errorf("pack error: %s", err)
+	}
+
+	// if either of above fail we will have the message _without_ a signature
+	if !bytes.Equal(packed, expected) {
+		t.Errorf("pack record: %x  expected: %x", packed, expected)
+		t.Errorf("*** GENERATED Packed:\n%s", util.FormatBytes("expected", packed))
+		t.Fatal("fatal error")
+	}
+
+	t.Logf("Packed length: %d bytes", len(packed))
+
+	// check txId
+	txId := packed.MakeLink()
+
+	if txId != expectedTxId {
+		t.Errorf("pack txId: %#v  expected: %x", txId, expectedTxId)
+		t.Errorf("*** GENERATED txId:\n%s", util.FormatBytes("expectedTxId", txId[:]))
+		t.Fatal("fatal error")
+	}
+
+	// test the unpacker
+	unpacked, n, err := packed.Unpack(true)
+	if nil != err {
+		t.Fatalf("unpack error: %s", err)
+	}
+	if len(packed) != n {
+		t.Errorf("did not unpack all data: only used: %d of: %d bytes", n, len(packed))
+	}
+
+	amendment, ok := unpacked.(*transactionrecord.AssetAmendment)
+	if !ok {
+		t.Fatalf("did not unpack to AssetAmendment")
+	}
+
+	// display a JSON version for information
+	item := struct {
+		TxId           merkle.Digest
+		AssetAmendment *transactionrecord.AssetAmendment
+	}{
+		txId,
+		amendment,
+	}
+	b, err := json.MarshalIndent(item, "", "  ")
+	if nil != err {
+		t.Fatalf("json error: %s", err)
+	}
+
+	t.Logf("Asset Amendment: JSON: %s", b)
+
+	// check that structure is preserved through Pack/Unpack
+	// note reg is a pointer here
+	if !reflect.DeepEqual(r, *amendment) {
+		t.Fatalf("different, original: %v  recovered: %v", r, *amendment)
+	}
+}
+
+// test the packing of asset amendment record
+//
+// ensures that the version and metadata are checked
+func TestPackAssetAmendmentInvalid(t *testing.T) {
+
+	registrantAccount := makeAccount(registrant.publicKey)
+
+	r := transactionrecord.AssetAmendment{
+		AssetId:    transactionrecord.NewAssetIdentifier([]byte("0123456789abcdef")),
+		Version:    0,
+		Metadata:   "description\u0000amended",
+		Registrant: registrantAccount,
+	}
+
+	_, err := r.Pack(registrantAccount)
+	if fault.InvalidAssetVersion != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+
+	r.Version = 2
+	r.Metadata = "description"
+	_, err = r.Pack(registrantAccount)
+	if fault.MetadataIsNotMap != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+
+	r.Metadata = ""
+	_, err = r.Pack(makeAccount(registrant.publicKey))
+	if fault.InvalidOwnerOrRegistrant != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+
+	_, err = r.Pack(registrantAccount)
+	if fault.InvalidSignature != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+}
@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package transactionrecord_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/bitmarkd/util"
+)
+
+// test the packing/unpacking of atomic swap record
+//
+// ensures that pack->unpack returns the same original value
+func TestPackAtomicSwap(t *testing.T) {
+
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+	ownerTwoAccount := makeAccount(ownerTwo.publicKey)
+
+	var link merkle.Digest
+	err := merkleDigestFromLE("79a67be2b3d313bd490363fb0d27901c46ed53d3f7b21f60d48bc42439b06084", &link)
+	if nil != err {
+		t.Fatalf("hex to link error: %s", err)
+	}
+
+	var shareId merkle.Digest
+	err = merkleDigestFromLE("630c041cd1f586bcb9097e816189185c1e0379f67bbfc2f0626724f542047873", &shareId)
+	if nil != err {
+		t.Fatalf("hex to shareId error: %s", err)
+	}
+
+	r := transactionrecord.AtomicSwap{
+		Bitmarks: []transactionrecord.SwapBitmarkLeg{
+			{
+				Link:      link,
+				Owner:     ownerOneAccount,
+				Recipient: ownerTwoAccount,
+			},
+		},
+		Shares: []transactionrecord.SwapShareLeg{
+			{
+				ShareId:   shareId,
+				Quantity:  215,
+				Owner:     ownerTwoAccount,
+				Recipient: ownerOneAccount,
+			},
+		},
+		OwnerOne:    ownerOneAccount,
+		OwnerTwo:    ownerTwoAccount,
+		BeforeBlock: 300,
+	}
+
+	expected := []byte{
+		0x0c, 0x01, 0x20, 0x79, 0xa6, 0x7b, 0xe2, 0xb3,
+		0xd3, 0x13, 0xbd, 0x49, 0x03, 0x63, 0xfb, 0x0d,
+		0x27, 0x90, 0x1c, 0x46, 0xed, 0x53, 0xd3, 0xf7,
+		0xb2, 0x1f, 0x60, 0xd4, 0x8b, 0xc4, 0x24, 0x39,
+		0xb0, 0x60, 0x84, 0x21, 0x13, 0x27, 0x64, 0x0e,
+		0x4a, 0xab, 0x92, 0xd8, 0x7b, 0x4a, 0x6a, 0x2f,
+		0x30, 0xb8, 0x81, 0xf4, 0x49, 0x29, 0xf8, 0x66,
+		0x04, 0x3a, 0x84, 0x1c, 0x38, 0x14, 0xb1, 0x66,
+		0xb8, 0x89, 0x44, 0xb0, 0x92, 0x21, 0x13, 0xa1,
+		0x36, 0x32, 0xd5, 0x42, 0x5a, 0xed, 0x3a, 0x6b,
+		0x62, 0xe2, 0xbb, 0x6d, 0xe4, 0xc9, 0x59, 0x48,
+		0x41, 0xc1, 0x5b, 0x70, 0x15, 0x69, 0xec, 0x99,
+		0x99, 0xdc, 0x20, 0x1c, 0x35, 0xf7, 0xb3, 0x01,
+		0x20, 0x63, 0x0c, 0x04, 0x1c, 0xd1, 0xf5, 0x86,
+		0xbc, 0xb9, 0x09, 0x7e, 0x81, 0x61, 0x89, 0x18,
+		0x5c, 0x1e, 0x03, 0x79, 0xf6, 0x7b, 0xbf, 0xc2,
+		0xf0, 0x62, 0x67, 0x24, 0xf5, 0x42, 0x04, 0x78,
+		0x73, 0xd7, 0x01, 0x21, 0x13, 0xa1, 0x36, 0x32,
+		0xd5, 0x42, 0x5a, 0xed, 0x3a, 0x6b, 0x62, 0xe2,
+		0xbb, 0x6d, 0xe4, 0xc9, 0x59, 0x48, 0x41, 0xc1,
+		0x5b, 0x70, 0x15, 0x69, 0xec, 0x99, 0x99, 0xdc,
+		0x20, 0x1c, 0x35, 0xf7, 0xb3, 0x21, 0x13, 0x27,
+		0x64, 0x0e, 0x4a, 0xab, 0x92, 0xd8, 0x7b, 0x4a,
+		0x6a, 0x2f, 0x30, 0xb8, 0x81, 0xf4, 0x49, 0x29,
+		0xf8, 0x66, 0x04, 0x3a, 0x84, 0x1c, 0x38, 0x14,
+		0xb1, 0x66, 0xb8, 0x89, 0x44, 0xb0, 0x92, 0x21,
+		0x13, 0x27, 0x64, 0x0e, 0x4a, 0xab, 0x92, 0xd8,
+		0x7b, 0x4a, 0x6a, 0x2f, 0x30, 0xb8, 0x81, 0xf4,
+		0x49, 0x29, 0xf8, 0x66, 0x04, 0x3a, 0x84, 0x1c,
+		0x38, 0x14, 0xb1, 0x66, 0xb8, 0x89, 0x44, 0xb0,
+		0x92, 0x21, 0x13, 0xa1, 0x36, 0x32, 0xd5, 0x42,
+		0x5a, 0xed, 0x3a, 0x6b, 0x62, 0xe2, 0xbb, 0x6d,
+		0xe4, 0xc9, 0x59, 0x48, 0x41, 0xc1, 0x5b, 0x70,
+		0x15, 0x69, 0xec, 0x99, 0x99, 0xdc, 0x20, 0x1c,
+		0x35, 0xf7, 0xb3, 0xac, 0x02,
+	}
+
+	expectedTxId := merkle.Digest{
+		0xa9, 0xac, 0xef, 0x5a, 0x7c, 0xd3, 0x91, 0xc2,
+		0x4f, 0xb4, 0x2e, 0xec, 0x37, 0xed, 0x8e, 0xa4,
+		0xc3, 0x97, 0xa8, 0x0d, 0x7e, 0x1f, 0x0e, 0x88,
+		0x6d, 0x40, 0x93, 0x85, 0x59, 0x17, 0xfa, 0x4d,
+	}
+
+	// manually sign the record and attach signature to "expected"
+	signature := ed25519.Sign(ownerOne.privateKey, expected)
+	r.Signature = signature
+	l := util.ToVarint64(uint64(len(signature)))
+	expected = append(expected, l...)
+	expected = append(expected, signature...)
+
+	// manually countersign the record and attach countersignature to "expected"
+	signature = ed25519.Sign(ownerTwo.privateKey, expected)
+	r.Countersignature = signature
+	l = util.ToVarint64(uint64(len(signature)))
+	expected = append(expected, l...)
+	expected = append(expected, signature...)
+
+	// test the packer
+	packed, err := r.Pack(ownerOneAccount)
+	if nil != err {
+		t.Errorf("pack error: %s", err)
+	}
+
+	// if either of above fail we will have the message _without_ a signature
+	if !bytes.Equal(packed, expected) {
+		t.Errorf("pack record: %x  expected: %x", packed, expected)
+		t.Errorf("*** GENERATED Packed:\n%s", util.FormatBytes("expected", packed))
+		t.Fatal("fatal error")
+	}
+
+	t.Logf("Packed length: %d bytes", len(packed))
+
+	// check txId
+	txId := packed.MakeLink()
+
+	if txId != expectedTxId {
+		t.Errorf("pack txId: %#v  expected: %x", txId, expectedTxId)
+		t.Errorf("*** GENERATED txId:\n%s", util.FormatBytes("expectedTxId", txId[:]))
+		t.Fatal("fatal error")
+	}
+
+	// test the unpacker
+	unpacked, n, err := packed.Unpack(true)
+	if nil != err {
+		t.Fatalf("unpack error: %s", err)
+	}
+	if len(packed) != n {
+		t.Errorf("did not unpack all data: only used: %d of: %d bytes", n, len(packed))
+	}
+
+	swap, ok := unpacked.(*transactionrecord.AtomicSwap)
+	if !ok {
+		t.Fatalf("did not unpack to AtomicSwap")
+	}
+
+	// display a JSON version for information
+	item := struct {
+		TxId       merkle.Digest
+		AtomicSwap *transactionrecord.AtomicSwap
+	}{
+		txId,
+		swap,
+	}
+	b, err := json.MarshalIndent(item, "", "  ")
+	if nil != err {
+		t.Fatalf("json error: %s", err)
+	}
+
+	t.Logf("Atomic Swap: JSON: %s", b)
+
+	// check that structure is preserved through Pack/Unpack
+	// note reg is a pointer here
+	if !reflect.DeepEqual(r, *swap) {
+		t.Fatalf("different, original: %v  recovered: %v", r, *swap)
+	}
+
+	// the bitmark leg is found from its leg id
+	leg, ok := swap.BitmarkLeg(txId, transactionrecord.SwapLegTxId(txId, 0))
+	if !ok {
+		t.Fatal("bitmark leg not found")
+	}
+	if leg.Link != link {
+		t.Errorf("leg link: %v  expected: %v", leg.Link, link)
+	}
+	_, ok = swap.BitmarkLeg(txId, txId)
+	if ok {
+		t.Error("swap id found as a bitmark leg")
+	}
+}
+
+// test the packing of atomic swap record
+//
+// ensures that both accounts must give something
+func TestPackAtomicSwapOneWay(t *testing.T) {
+
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+	ownerTwoAccount := makeAccount(ownerTwo.publicKey)
+
+	var shareId merkle.Digest
+	err := merkleDigestFromLE("630c041cd1f586bcb9097e816189185c1e0379f67bbfc2f0626724f542047873", &shareId)
+	if nil != err {
+		t.Fatalf("hex to shareId error: %s", err)
+	}
+
+	r := transactionrecord.AtomicSwap{
+		Shares: []transactionrecord.SwapShareLeg{
+			{
+				ShareId:   shareId,
+				Quantity:  215,
+				Owner:     ownerOneAccount,
+				Recipient: ownerTwoAccount,
+			},
+		},
+		OwnerOne:    ownerOneAccount,
+		OwnerTwo:    ownerTwoAccount,
+		BeforeBlock: 300,
+	}
+
+	_, err = r.Pack(ownerOneAccount)
+	if fault.SwapMustBeTwoWay != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+
+	r.Shares = nil
+	_, err = r.Pack(ownerOneAccount)
+	if fault.SwapLegCountOutOfRange != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+}
+
+// test the packing of atomic swap record
+//
+// ensures that a bitmark can only be moved once and only between the
+// two accounts of the swap
+func TestPackAtomicSwapInvalidLegs(t *testing.T) {
+
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+	ownerTwoAccount := makeAccount(ownerTwo.publicKey)
+	issuerAccount := makeAccount(issuer.publicKey)
+
+	var link merkle.Digest
+	err := merkleDigestFromLE("79a67be2b3d313bd490363fb0d27901c46ed53d3f7b21f60d48bc42439b06084", &link)
+	if nil != err {
+		t.Fatalf("hex to link error: %s", err)
+	}
+
+	r := transactionrecord.AtomicSwap{
+		Bitmarks: []transactionrecord.SwapBitmarkLeg{
+			{
+				Link:      link,
+				Owner:     ownerOneAccount,
+				Recipient: ownerTwoAccount,
+			},
+			{
+				Link:      link,
+				Owner:     ownerTwoAccount,
+				Recipient: ownerOneAccount,
+			},
+		},
+		OwnerOne:    ownerOneAccount,
+		OwnerTwo:    ownerTwoAccount,
+		BeforeBlock: 300,
+	}
+
+	_, err = r.Pack(ownerOneAccount)
+	if fault.SwapLegIsDuplicated != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+
+	r.Bitmarks[1].Link[0] ^= 0xff
+	r.Bitmarks[1].Recipient = issuerAccount
+	_, err = r.Pack(ownerOneAccount)
+	if fault.InvalidOwnerOrRegistrant != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+
+	r.Bitmarks[1].Recipient = ownerOneAccount
+	_, err = r.Pack(ownerOneAccount)
+	if fault.InvalidSignature != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+}
@@ -6,12 +6,14 @@
 package transactionrecord
 
 import (
+	"bytes"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/bitmark-inc/bitmarkd/account"
 	"github.com/bitmark-inc/bitmarkd/currency"
 	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
 	"github.com/bitmark-inc/bitmarkd/util"
 )
 
@@ -136,7 +138,12 @@ func (assetData *AssetData) check(testnet bool) error {
 		return fault.FingerprintTooLong
 	}
 
-	if utf8.RuneCountInString(assetData.Metadata) > maxMetadataLength {
+	return checkMetadata(assetData.Metadata)
+}
+
+// check the length of asset metadata and that it is a valid map
+func checkMetadata(metadata string) error {
+	if utf8.RuneCountInString(metadata) > maxMetadataLength {
 		return fault.MetadataTooLong
 	}
 
@@ -144,8 +151,8 @@ func (assetData *AssetData) check(testnet bool) error {
 	// i.e.  key1 <NUL> value1 <NUL> key2 <NUL> value2 <NUL> … keyN <NUL> valueN
 	// Notes: 1: no NUL after last value
 	//        2: no empty key or value is allowed
-	if 0 != len(assetData.Metadata) {
-		splitMetadata := strings.Split(assetData.Metadata, "\u0000")
+	if 0 != len(metadata) {
+		splitMetadata := strings.Split(metadata, "\u0000")
 		if 1 == len(splitMetadata)%2 {
 			return fault.MetadataIsNotMap
 		}
@@ -158,6 +165,58 @@ func (assetData *AssetData) check(testnet bool) error {
 	return nil
 }
 
+// Pack - AssetAmendment
+//
+// Pack Varint64(tag) followed by fields in order as struct above with
+// signature last
+//
+// NOTE: returns the "unsigned" message on signature failure - for
+//       debugging/testing
+func (amendment *AssetAmendment) Pack(address *account.Account) (Packed, error) {
+	if nil == address || address.IsZero() ||
+		address != amendment.Registrant {
+		return nil, fault.InvalidOwnerOrRegistrant
+	}
+
+	err := amendment.check(address.IsTesting())
+	if nil != err {
+		return nil, err
+	}
+
+	// concatenate bytes
+	message := createPacked(AssetAmendmentTag)
+	message.appendBytes(amendment.AssetId[:])
+	message.appendUint64(amendment.Version)
+	message.appendString(amendment.Metadata)
+	message.appendAccount(amendment.Registrant)
+
+	// signature
+	err = amendment.Registrant.CheckSignature(message, amendment.Signature)
+	if nil != err {
+		return message, err
+	}
+	// Signature Last
+	return *message.appendBytes(amendment.Signature), nil
+}
+
+func (amendment *AssetAmendment) check(testnet bool) error {
+	if len(amendment.Signature) > maxSignatureLength {
+		return fault.SignatureTooLong
+	}
+
+	// prevent nil or zero account
+	if nil == amendment.Registrant || amendment.Registrant.IsZero() {
+		return fault.InvalidOwnerOrRegistrant
+	}
+
+	// version zero is the registration itself
+	if amendment.Version < 1 {
+		return fault.InvalidAssetVersion
+	}
+
+	return checkMetadata(amendment.Metadata)
+}
+
 // Pack - BitmarkIssue
 //
 // Pack Varint64(tag) followed by fields in order as struct above with
@@ -222,13 +281,24 @@ func (transfer *BitmarkTransferUnratified) Pack(address *account.Account) (Packe
 
 	testnet := address.IsTesting()
 
+	// a sequence needs its own tag so that nodes that do not know of
+	// it reject the record instead of misreading it, a zero sequence
+	// keeps the original packing and transaction id
+	tag := BitmarkTransferUnratifiedTag
+	if 0 != transfer.Sequence {
+		tag = BitmarkTransferSequencedTag
+	}
+
 	// concatenate bytes
-	message := createPacked(BitmarkTransferUnratifiedTag)
+	message := createPacked(tag)
 	message.appendBytes(transfer.Link[:])
 	_, err = message.appendEscrow(transfer.Escrow, testnet)
 	if nil != err {
 		return nil, err
 	}
+	if 0 != transfer.Sequence {
+		message.appendUint64(transfer.Sequence)
+	}
 	message.appendAccount(transfer.Owner)
 
 	// signature
@@ -631,9 +701,205 @@ func (swap *ShareSwap) check(testnet bool) error {
 	return nil
 }
 
+// Pack - ShareRedemption
+//
+// Pack Varint64(tag) followed by fields in order as struct above with
+// signature last
+//
+// NOTE: returns the "unsigned" message on signature failure - for
+//       debugging/testing
+// NOTE: in this case address _MUST_ point to the record.Owner
+func (redemption *ShareRedemption) Pack(address *account.Account) (Packed, error) {
+	if nil == address || address.IsZero() ||
+		address != redemption.Owner {
+		return nil, fault.InvalidOwnerOrRegistrant
+	}
+
+	err := redemption.check(address.IsTesting())
+	if nil != err {
+		return nil, err
+	}
+
+	// concatenate bytes
+	message := createPacked(ShareRedemptionTag)
+	message.appendBytes(redemption.Link[:])
+	message.appendUint64(redemption.Quantity)
+	message.appendAccount(redemption.Owner)
+
+	// signature
+	err = redemption.Owner.CheckSignature(message, redemption.Signature)
+	if nil != err {
+		return message, err
+	}
+	// Signature Last
+	return *message.appendBytes(redemption.Signature), nil
+}
+
+func (redemption *ShareRedemption) check(testnet bool) error {
+	if len(redemption.Signature) > maxSignatureLength {
+		return fault.SignatureTooLong
+	}
+
+	// prevent nil or zero account
+	if nil == redemption.Owner || redemption.Owner.IsZero() {
+		return fault.InvalidOwnerOrRegistrant
+	}
+
+	// ensure minimum share quantity
+	if redemption.Quantity < 1 {
+		return fault.ShareQuantityTooSmall
+	}
+	return nil
+}
+
+// Pack - AtomicSwap
+//
+// Pack Varint64(tag) followed by fields in order as struct above with
+// signature last, each list of legs is preceded by its count
+//
+// NOTE: returns the "unsigned" message on signature failure - for
+//       debugging/testing
+// NOTE: in this case address _MUST_ point to the record.OwnerOne
+func (swap *AtomicSwap) Pack(address *account.Account) (Packed, error) {
+	if nil == address || address.IsZero() ||
+		address != swap.OwnerOne {
+		return nil, fault.InvalidOwnerOrRegistrant
+	}
+
+	err := swap.check(address.IsTesting())
+	if nil != err {
+		return nil, err
+	}
+
+	// concatenate bytes
+	message := createPacked(AtomicSwapTag)
+	message.appendUint64(uint64(len(swap.Bitmarks)))
+	for _, leg := range swap.Bitmarks {
+		message.appendBytes(leg.Link[:])
+		message.appendAccount(leg.Owner)
+		message.appendAccount(leg.Recipient)
+	}
+	message.appendUint64(uint64(len(swap.Shares)))
+	for _, leg := range swap.Shares {
+		message.appendBytes(leg.ShareId[:])
+		message.appendUint64(leg.Quantity)
+		message.appendAccount(leg.Owner)
+		message.appendAccount(leg.Recipient)
+	}
+	message.appendAccount(swap.OwnerOne)
+	message.appendAccount(swap.OwnerTwo)
+	message.appendUint64(swap.BeforeBlock)
+
+	// signature
+	err = swap.OwnerOne.CheckSignature(message, swap.Signature)
+	if nil != err {
+		return message, err
+	}
+	message.appendBytes(swap.Signature)
+
+	err = swap.OwnerTwo.CheckSignature(message, swap.Countersignature)
+	if nil != err {
+		return message, err
+	}
+
+	// Countersignature Last
+	return *message.appendBytes(swap.Countersignature), nil
+}
+
+func (swap *AtomicSwap) check(testnet bool) error {
+	if len(swap.Signature) > maxSignatureLength {
+		return fault.SignatureTooLong
+	}
+
+	if len(swap.Countersignature) > maxSignatureLength {
+		return fault.SignatureTooLong
+	}
+
+	// prevent nil or zero account
+	if nil == swap.OwnerOne || nil == swap.OwnerTwo ||
+		swap.OwnerOne.IsZero() || swap.OwnerTwo.IsZero() ||
+		sameAccount(swap.OwnerOne, swap.OwnerTwo) {
+		return fault.InvalidOwnerOrRegistrant
+	}
+
+	legs := len(swap.Bitmarks) + len(swap.Shares)
+	if 0 == legs || legs > maxSwapLegs {
+		return fault.SwapLegCountOutOfRange
+	}
+
+	// each account must give something in return
+	oneGives := false
+	twoGives := false
+
+	links := make(map[merkle.Digest]struct{})
+	for _, leg := range swap.Bitmarks {
+		fromOne, err := swap.legFromOne(leg.Owner, leg.Recipient)
+		if nil != err {
+			return err
+		}
+		oneGives = oneGives || fromOne
+		twoGives = twoGives || !fromOne
+
+		if _, ok := links[leg.Link]; ok {
+			return fault.SwapLegIsDuplicated
+		}
+		links[leg.Link] = struct{}{}
+	}
+
+	type shareKey struct {
+		fromOne bool
+		shareId merkle.Digest
+	}
+	shares := make(map[shareKey]struct{})
+	for _, leg := range swap.Shares {
+		fromOne, err := swap.legFromOne(leg.Owner, leg.Recipient)
+		if nil != err {
+			return err
+		}
+		oneGives = oneGives || fromOne
+		twoGives = twoGives || !fromOne
+
+		// ensure minimum share quantity
+		if leg.Quantity < 1 {
+			return fault.ShareQuantityTooSmall
+		}
+
+		k := shareKey{fromOne: fromOne, shareId: leg.ShareId}
+		if _, ok := shares[k]; ok {
+			return fault.SwapLegIsDuplicated
+		}
+		shares[k] = struct{}{}
+	}
+
+	if !oneGives || !twoGives {
+		return fault.SwapMustBeTwoWay
+	}
+	return nil
+}
+
+// determine the direction of a leg, the leg must move from one account
+// of the swap to the other
+func (swap *AtomicSwap) legFromOne(owner *account.Account, recipient *account.Account) (bool, error) {
+	if nil == owner || nil == recipient {
+		return false, fault.InvalidOwnerOrRegistrant
+	}
+	if sameAccount(owner, swap.OwnerOne) && sameAccount(recipient, swap.OwnerTwo) {
+		return true, nil
+	}
+	if sameAccount(owner, swap.OwnerTwo) && sameAccount(recipient, swap.OwnerOne) {
+		return false, nil
+	}
+	return false, fault.InvalidOwnerOrRegistrant
+}
+
 // internal routines below here
 // ----------------------------
 
+// compare two accounts by value
+func sameAccount(a *account.Account, b *account.Account) bool {
+	return bytes.Equal(a.Bytes(), b.Bytes())
+}
+
 // CheckPayments - check all currency addresses for correct network and validity
 func CheckPayments(version uint64, testnet bool, payments currency.Map) error {
 	// validate version
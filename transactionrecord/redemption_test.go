@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: ISC
+// Copyright (c) 2014-2020 Bitmark Inc.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package transactionrecord_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/bitmark-inc/bitmarkd/fault"
+	"github.com/bitmark-inc/bitmarkd/merkle"
+	"github.com/bitmark-inc/bitmarkd/transactionrecord"
+	"github.com/bitmark-inc/bitmarkd/util"
+)
+
+// test the packing/unpacking of Share redemption record
+//
+// ensures that pack->unpack returns the same original value
+func TestPackShareRedemption(t *testing.T) {
+
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+
+	var link merkle.Digest
+	err := merkleDigestFromLE("630c041cd1f586bcb9097e816189185c1e0379f67bbfc2f0626724f542047873", &link)
+	if nil != err {
+		t.Fatalf("hex to link error: %s", err)
+	}
+
+	r := transactionrecord.ShareRedemption{
+		Link:     link,
+		Quantity: 100,
+		Owner:    ownerOneAccount,
+	}
+
+	expected := []byte{
+		0x0b, 0x20, 0x63, 0x0c, 0x04, 0x1c, 0xd1, 0xf5,
+		0x86, 0xbc, 0xb9, 0x09, 0x7e, 0x81, 0x61, 0x89,
+		0x18, 0x5c, 0x1e, 0x03, 0x79, 0xf6, 0x7b, 0xbf,
+		0xc2, 0xf0, 0x62, 0x67, 0x24, 0xf5, 0x42, 0x04,
+		0x78, 0x73, 0x64, 0x21, 0x13, 0x27, 0x64, 0x0e,
+		0x4a, 0xab, 0x92, 0xd8, 0x7b, 0x4a, 0x6a, 0x2f,
+		0x30, 0xb8, 0x81, 0xf4, 0x49, 0x29, 0xf8, 0x66,
+		0x04, 0x3a, 0x84, 0x1c, 0x38, 0x14, 0xb1, 0x66,
+		0xb8, 0x89, 0x44, 0xb0, 0x92,
+	}
+
+	expectedTxId := merkle.Digest{
+		0xc6, 0x6c, 0x67, 0xc8, 0x98, 0xda, 0x71, 0xa4,
+		0x8a, 0x53, 0xdf, 0x61, 0xcf, 0x68, 0x10, 0xf7,
+		0x8d, 0x44, 0x2e, 0x8c, 0x84, 0x4e, 0x23, 0x6c,
+		0x9a, 0x4e, 0xf9, 0x50, 0x09, 0xf3, 0x49, 0x7b,
+	}
+
+	// manually sign the record and attach signature to "expected"
+	signature := ed25519.Sign(ownerOne.privateKey, expected)
+	r.Signature = signature
+	l := util.ToVarint64(uint64(len(signature)))
+	expected = append(expected, l...)
+	expected = append(expected, signature...)
+
+	// test the packer
+	packed, err := r.Pack(ownerOneAccount)
+	if nil != err {
+		t.Errorf("pack error: %s", err)
+	}
+
+	// if either of above fail we will have the message _without_ a signature
+	if !bytes.Equal(packed, expected) {
+		t.Errorf("pack record: %x  expected: %x", packed, expected)
+		t.Errorf("*** GENERATED Packed:\n%s", util.FormatBytes("expected", packed))
+		t.Fatal("fatal error")
+	}
+
+	t.Logf("Packed length: %d bytes", len(packed))
+
+	// check txId
+	txId := packed.MakeLink()
+
+	if txId != expectedTxId {
+		t.Errorf("pack txId: %#v  expected: %x", txId, expectedTxId)
+		t.Errorf("*** GENERATED txId:\n%s", util.FormatBytes("expectedTxId", txId[:]))
+		t.Fatal("fatal error")
+	}
+
+	// test the unpacker
+	unpacked, n, err := packed.Unpack(true)
+	if nil != err {
+		t.Fatalf("unpack error: %s", err)
+	}
+	if len(packed) != n {
+		t.Errorf("did not unpack all data: only used: %d of: %d bytes", n, len(packed))
+	}
+
+	redemption, ok := unpacked.(*transactionrecord.ShareRedemption)
+	if !ok {
+		t.Fatalf("did not unpack to ShareRedemption")
+	}
+
+	// display a JSON version for information
+	item := struct {
+		TxId            merkle.Digest
+		ShareRedemption *transactionrecord.ShareRedemption
+	}{
+		txId,
+		redemption,
+	}
+	b, err := json.MarshalIndent(item, "", "  ")
+	if nil != err {
+		t.Fatalf("json error: %s", err)
+	}
+
+	t.Logf("Share Redemption: JSON: %s", b)
+
+	// check that structure is preserved through Pack/Unpack
+	// note reg is a pointer here
+	if !reflect.DeepEqual(r, *redemption) {
+		t.Fatalf("different, original: %v  recovered: %v", r, *redemption)
+	}
+}
+
+// test the packing/unpacking of Share redemption record
+//
+// ensures that only the owner can sign
+func TestPackShareRedemptionWrongOwner(t *testing.T) {
+
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+	ownerTwoAccount := makeAccount(ownerTwo.publicKey)
+
+	r := transactionrecord.ShareRedemption{
+		Link:     merkle.Digest{1, 2, 3},
+		Quantity: 100,
+		Owner:    ownerOneAccount,
+	}
+
+	_, err := r.Pack(ownerTwoAccount)
+	if fault.InvalidOwnerOrRegistrant != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+}
+
+// test the packing/unpacking of Share redemption record
+//
+// ensures that quantity cannot be zero
+func TestPackShareRedemptionValueNotZero(t *testing.T) {
+
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+
+	r := transactionrecord.ShareRedemption{
+		Link:     merkle.Digest{1, 2, 3},
+		Quantity: 0,
+		Owner:    ownerOneAccount,
+	}
+
+	_, err := r.Pack(ownerOneAccount)
+	if fault.ShareQuantityTooSmall != err {
+		t.Fatalf("unexpected pack error: %s", err)
+	}
+}
@@ -35,6 +35,10 @@ const (
 	BitmarkShareTag                 = TagType(iota) // convert bitmark to a quantity of shares
 	ShareGrantTag                   = TagType(iota) // grant some value to another account
 	ShareSwapTag                    = TagType(iota) // atomically swap shares between accounts
+	ShareRedemptionTag              = TagType(iota) // convert every share back to a bitmark
+	AtomicSwapTag                   = TagType(iota) // atomically exchange bitmarks and shares between accounts
+	AssetAmendmentTag               = TagType(iota) // new version of an asset's metadata
+	BitmarkTransferSequencedTag     = TagType(iota) // single signed transfer with a replacement sequence
 
 	// this item must be last
 	InvalidTag = TagType(iota)
@@ -55,6 +59,7 @@ const (
 	minFingerprintLength = 1
 	maxFingerprintLength = 1024
 	maxSignatureLength   = 1024
+	maxSwapLegs          = 16
 )
 
 // OldBaseData - the unpacked Proofer Data structure (OBSOLETE)
@@ -111,10 +116,11 @@ type BitmarkTransfer interface {
 
 // BitmarkTransferUnratified - the unpacked BitmarkTransfer structure
 type BitmarkTransferUnratified struct {
-	Link      merkle.Digest     `json:"link"`      // previous record
-	Escrow    *Payment          `json:"escrow"`    // optional escrow payment address
-	Owner     *account.Account  `json:"owner"`     // base58: the "destination" owner
-	Signature account.Signature `json:"signature"` // hex: corresponds to owner in linked record
+	Link      merkle.Digest     `json:"link"`                      // previous record
+	Escrow    *Payment          `json:"escrow"`                    // optional escrow payment address
+	Sequence  uint64            `json:"sequence,string,omitempty"` // optional: a higher value replaces an unpaid pending transfer
+	Owner     *account.Account  `json:"owner"`                     // base58: the "destination" owner
+	Signature account.Signature `json:"signature"`                 // hex: corresponds to owner in linked record
 }
 
 // BitmarkTransferCountersigned - the unpacked Countersigned BitmarkTransfer structure
@@ -180,6 +186,52 @@ type ShareSwap struct {
 	Countersignature account.Signature `json:"countersignature"`   // hex: corresponds to owner in this record
 }
 
+// ShareRedemption - burn every share of a bitmark held by a single
+// account and return the bitmark to that account
+type ShareRedemption struct {
+	Link      merkle.Digest     `json:"link"`            // share record
+	Quantity  uint64            `json:"quantity,string"` // must equal the quantity created by the share
+	Owner     *account.Account  `json:"owner"`           // base58: holder of every share
+	Signature account.Signature `json:"signature"`       // hex: corresponds to owner in this record
+}
+
+// AtomicSwap - exchange bitmarks and shares between two accounts,
+// either every leg is applied or none are
+type AtomicSwap struct {
+	Bitmarks         []SwapBitmarkLeg  `json:"bitmarks"`           // bitmarks to transfer
+	Shares           []SwapShareLeg    `json:"shares"`             // shares to grant
+	OwnerOne         *account.Account  `json:"ownerOne"`           // base58
+	OwnerTwo         *account.Account  `json:"ownerTwo"`           // base58
+	BeforeBlock      uint64            `json:"beforeBlock,string"` // expires when chain height > before block
+	Signature        account.Signature `json:"signature"`          // hex: corresponds to owner one
+	Countersignature account.Signature `json:"countersignature"`   // hex: corresponds to owner two
+}
+
+// SwapBitmarkLeg - transfer of a bitmark between the accounts of an atomic swap
+type SwapBitmarkLeg struct {
+	Link      merkle.Digest    `json:"link"`      // previous record
+	Owner     *account.Account `json:"owner"`     // base58: current owner
+	Recipient *account.Account `json:"recipient"` // base58: new owner
+}
+
+// SwapShareLeg - grant of shares between the accounts of an atomic swap
+type SwapShareLeg struct {
+	ShareId   merkle.Digest    `json:"shareId"`         // share = issue id
+	Quantity  uint64           `json:"quantity,string"` // shares to transfer > 0
+	Owner     *account.Account `json:"owner"`           // base58
+	Recipient *account.Account `json:"recipient"`       // base58
+}
+
+// AssetAmendment - a new version of the metadata of a registered
+// asset, the asset id and fingerprint never change
+type AssetAmendment struct {
+	AssetId    AssetIdentifier   `json:"assetId"`        // asset being amended
+	Version    uint64            `json:"version,string"` // previous version + 1, registration is version 0
+	Metadata   string            `json:"metadata"`       // utf-8: replaces the previous metadata
+	Registrant *account.Account  `json:"registrant"`     // base58: registrant of the asset
+	Signature  account.Signature `json:"signature"`      // hex: corresponds to registrant
+}
+
 // Type - returns the record type code
 func (record Packed) Type() TagType {
 	recordType, n := util.FromVarint64(record)
@@ -222,11 +274,37 @@ func RecordName(record interface{}) (string, bool) {
 	case *ShareSwap, ShareSwap:
 		return "ShareSwap", true
 
+	case *ShareRedemption, ShareRedemption:
+		return "ShareRedemption", true
+
+	case *AtomicSwap, AtomicSwap:
+		return "AtomicSwap", true
+
+	case *AssetAmendment, AssetAmendment:
+		return "AssetAmendment", true
+
 	default:
 		return "*unknown*", false
 	}
 }
 
+// IsExtendedRecord - true for the record types that were added after
+// the original set, these are only valid in blocks of a version that
+// allows them
+func IsExtendedRecord(record Transaction) bool {
+	switch tx := record.(type) {
+	case *ShareRedemption, *AtomicSwap, *AssetAmendment:
+		return true
+
+	case *BitmarkTransferUnratified:
+		// packed with BitmarkTransferSequencedTag
+		return 0 != tx.Sequence
+
+	default:
+		return false
+	}
+}
+
 // AssetId - compute an asset id
 func (assetData *AssetData) AssetId() AssetIdentifier {
 	return NewAssetIdentifier([]byte(assetData.Fingerprint))
@@ -237,6 +315,23 @@ func (record Packed) MakeLink() merkle.Digest {
 	return merkle.NewDigest(record)
 }
 
+// SwapLegTxId - the id under which the bitmark moved by a leg of an
+// atomic swap is recorded, a later transfer of that bitmark links to
+// this id rather than to the swap itself
+func SwapLegTxId(swapTxId merkle.Digest, leg int) merkle.Digest {
+	return merkle.NewDigest(append(swapTxId[:], byte(leg)))
+}
+
+// BitmarkLeg - find the bitmark leg of a swap from its leg id
+func (swap *AtomicSwap) BitmarkLeg(swapTxId merkle.Digest, legTxId merkle.Digest) (*SwapBitmarkLeg, bool) {
+	for i := range swap.Bitmarks {
+		if legTxId == SwapLegTxId(swapTxId, i) {
+			return &swap.Bitmarks[i], true
+		}
+	}
+	return nil, false
+}
+
 // MarshalText - convert a packed to its hex JSON form
 func (record Packed) MarshalText() ([]byte, error) {
 	size := hex.EncodedLen(len(record))
@@ -499,3 +499,183 @@ func TestPackBitmarkTransferWithZeroAccount(t *testing.T) {
 		t.Fatalf("unexpected pack error: %s", err)
 	}
 }
+
+// test the packing/unpacking of Bitmark transfer record
+//
+// transfer from issue with a replacement sequence
+// ensures that pack->unpack returns the same original value
+func TestPackBitmarkTransferSequence(t *testing.T) {
+
+	issuerAccount := makeAccount(issuer.publicKey)
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+
+	var link merkle.Digest
+	err := merkleDigestFromLE("79a67be2b3d313bd490363fb0d27901c46ed53d3f7b21f60d48bc42439b06084", &link)
+	if nil != err {
+		t.Fatalf("hex to link error: %s", err)
+	}
+
+	r := transactionrecord.BitmarkTransferUnratified{
+		Link:     link,
+		Sequence: 5,
+		Owner:    ownerOneAccount,
+	}
+
+	// as transfer one with its own tag and the sequence after the escrow
+	expected := []byte{
+		0x0e, 0x20, 0x79, 0xa6, 0x7b, 0xe2, 0xb3, 0xd3,
+		0x13, 0xbd, 0x49, 0x03, 0x63, 0xfb, 0x0d, 0x27,
+		0x90, 0x1c, 0x46, 0xed, 0x53, 0xd3, 0xf7, 0xb2,
+		0x1f, 0x60, 0xd4, 0x8b, 0xc4, 0x24, 0x39, 0xb0,
+		0x60, 0x84, 0x00, 0x05, 0x21, 0x13, 0x27, 0x64,
+		0x0e, 0x4a, 0xab, 0x92, 0xd8, 0x7b, 0x4a, 0x6a,
+		0x2f, 0x30, 0xb8, 0x81, 0xf4, 0x49, 0x29, 0xf8,
+		0x66, 0x04, 0x3a, 0x84, 0x1c, 0x38, 0x14, 0xb1,
+		0x66, 0xb8, 0x89, 0x44, 0xb0, 0x92,
+	}
+
+	expectedTxId := merkle.Digest{
+		0xc0, 0xba, 0x24, 0xaf, 0x15, 0x07, 0xf2, 0x7f,
+		0xc0, 0xac, 0x95, 0x05, 0x7f, 0x16, 0xb7, 0xd6,
+		0x48, 0x3c, 0x96, 0xda, 0x9e, 0xc1, 0xb9, 0x5e,
+		0xf7, 0xbe, 0x07, 0x47, 0x25, 0xf9, 0x25, 0x2d,
+	}
+
+	// manually sign the record and attach signature to "expected"
+	signature := ed25519.Sign(issuer.privateKey, expected)
+	r.Signature = signature
+	l := util.ToVarint64(uint64(len(signature)))
+	expected = append(expected, l...)
+	expected = append(expected, signature...)
+
+	// test the packer
+	packed, err := r.Pack(issuerAccount)
+	if nil != err {
+		if nil != packed {
+			t.Errorf("partial packed:\n%s", util.FormatBytes("expected", packed))
+		}
+		t.Errorf("pack error: %s", err)
+	}
+
+	// if either of above fail we will have the message _without_ a signature
+	if !bytes.Equal(packed, expected) {
+		t.Errorf("pack record: %x  expected: %x", packed, expected)
+		t.Errorf("*** GENERATED Packed:\n%s", util.FormatBytes("expected", packed))
+		t.Fatal("fatal error")
+	}
+
+	// check txId
+	txId := packed.MakeLink()
+
+	if txId != expectedTxId {
+		t.Errorf("pack txId: %#v  expected: %x", txId, expectedTxId)
+		t.Errorf("*** GENERATED txId:\n%s", util.FormatBytes("expectedTxId", txId[:]))
+		t.Fatal("fatal error")
+	}
+
+	// test the unpacker
+	unpacked, n, err := packed.Unpack(true)
+	if nil != err {
+		t.Fatalf("unpack error: %s", err)
+	}
+	if len(packed) != n {
+		t.Errorf("did not unpack all data: only used: %d of: %d bytes", n, len(packed))
+	}
+
+	bmt, ok := unpacked.(*transactionrecord.BitmarkTransferUnratified)
+	if !ok {
+		t.Fatalf("did not unpack to BitmarkTransfer")
+	}
+
+	// check that structure is preserved through Pack/Unpack
+	// note reg is a pointer here
+	if !reflect.DeepEqual(r, *bmt) {
+		t.Fatalf("different, original: %v  recovered: %v", r, *bmt)
+	}
+	checkPackedData(t, "transfer sequence", packed)
+
+	// a zero sequence has only the packing without the sequence tag
+	zero := append(transactionrecord.Packed{}, packed...)
+	zero[35] = 0x00
+	_, _, err = zero.Unpack(true)
+	if fault.NotTransactionPack != err {
+		t.Fatalf("unpack zero sequence error: %v  expected: %s", err, fault.NotTransactionPack)
+	}
+
+	// the original transfer tag has no sequence
+	unratified := append(transactionrecord.Packed{}, packed...)
+	unratified[0] = 0x04
+	unratified[34] = 0x02
+	_, _, err = unratified.Unpack(true)
+	if fault.NotTransactionPack != err {
+		t.Fatalf("unpack unratified with sequence error: %v  expected: %s", err, fault.NotTransactionPack)
+	}
+}
+
+// test that an escrow and a sequence pack together
+func TestPackBitmarkTransferEscrowSequence(t *testing.T) {
+
+	issuerAccount := makeAccount(issuer.publicKey)
+	ownerOneAccount := makeAccount(ownerOne.publicKey)
+
+	var link merkle.Digest
+	err := merkleDigestFromLE("79a67be2b3d313bd490363fb0d27901c46ed53d3f7b21f60d48bc42439b06084", &link)
+	if nil != err {
+		t.Fatalf("hex to link error: %s", err)
+	}
+
+	r := transactionrecord.BitmarkTransferUnratified{
+		Link: link,
+		Escrow: &transactionrecord.Payment{
+			Currency: currency.Bitcoin,
+			Address:  "mnnemVbQECtikaGZPYux4dGHH3YZyCg4sq",
+			Amount:   250000,
+		},
+		Sequence: 300,
+		Owner:    ownerOneAccount,
+	}
+
+	packed, err := r.Pack(issuerAccount)
+	if fault.InvalidSignature != err {
+		t.Fatalf("pack error: %s", err)
+	}
+	r.Signature = ed25519.Sign(issuer.privateKey, packed)
+
+	packed, err = r.Pack(issuerAccount)
+	if nil != err {
+		t.Fatalf("pack error: %s", err)
+	}
+
+	unpacked, n, err := packed.Unpack(true)
+	if nil != err {
+		t.Fatalf("unpack error: %s", err)
+	}
+	if len(packed) != n {
+		t.Errorf("did not unpack all data: only used: %d of: %d bytes", n, len(packed))
+	}
+	if !reflect.DeepEqual(r, *unpacked.(*transactionrecord.BitmarkTransferUnratified)) {
+		t.Fatalf("different, original: %v  recovered: %v", r, unpacked)
+	}
+}
+
+// test which records need a block version that allows them
+func TestIsExtendedRecord(t *testing.T) {
+	records := []struct {
+		record   transactionrecord.Transaction
+		extended bool
+	}{
+		{&transactionrecord.BitmarkTransferUnratified{}, false},
+		{&transactionrecord.BitmarkTransferUnratified{Sequence: 1}, true},
+		{&transactionrecord.BitmarkTransferCountersigned{}, false},
+		{&transactionrecord.ShareGrant{}, false},
+		{&transactionrecord.ShareRedemption{}, true},
+		{&transactionrecord.AtomicSwap{}, true},
+		{&transactionrecord.AssetAmendment{}, true},
+	}
+
+	for i, item := range records {
+		if transactionrecord.IsExtendedRecord(item.record) != item.extended {
+			t.Errorf("%d: %T extended: %t", i, item.record, !item.extended)
+		}
+	}
+}
@@ -237,7 +237,7 @@ unpack_switch:
 		}
 		return r, n, nil
 
-	case BitmarkTransferUnratifiedTag:
+	case BitmarkTransferUnratifiedTag, BitmarkTransferSequencedTag:
 
 		// link
 		linkLength, linkOffset := util.ClippedVarint64(record[n:], 1, 8192)
@@ -258,6 +258,18 @@ unpack_switch:
 			return nil, 0, err
 		}
 
+		// sequence, only a sequenced transfer has one and it is
+		// non-zero so each record has a single packed form
+		sequence := uint64(0)
+		if BitmarkTransferSequencedTag == TagType(recordType) {
+			sequenceLength := 0
+			sequence, sequenceLength = util.FromVarint64(record[n:])
+			if 0 == sequenceLength || 0 == sequence {
+				break unpack_switch
+			}
+			n += sequenceLength
+		}
+
 		// owner public key
 		ownerLength, ownerOffset := util.ClippedVarint64(record[n:], 1, 8192)
 		if 0 == ownerOffset {
@@ -286,6 +298,7 @@ unpack_switch:
 		r := &BitmarkTransferUnratified{
 			Link:      link,
 			Escrow:    escrow,
+			Sequence:  sequence,
 			Owner:     owner,
 			Signature: signature,
 		}
@@ -786,11 +799,359 @@ unpack_switch:
 		}
 		return r, n, nil
 
+	case ShareRedemptionTag:
+
+		// link
+		linkLength, linkOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == linkOffset {
+			break unpack_switch
+		}
+		n += linkOffset
+		var link merkle.Digest
+		err := merkle.DigestFromBytes(&link, record[n:n+linkLength])
+		if nil != err {
+			return nil, 0, err
+		}
+		n += linkLength
+
+		// number of shares to burn
+		quantity, quantityLength := util.FromVarint64(record[n:])
+		if 0 == quantityLength {
+			break unpack_switch
+		}
+		n += quantityLength
+
+		// owner public key
+		ownerLength, ownerOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == ownerOffset {
+			break unpack_switch
+		}
+		n += ownerOffset
+		owner, err := account.AccountFromBytes(record[n : n+ownerLength])
+		if nil != err {
+			return nil, 0, err
+		}
+		if owner.IsTesting() != testnet {
+			return nil, 0, fault.WrongNetworkForPublicKey
+		}
+		n += ownerLength
+
+		// signature
+		signatureLength, signatureOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == signatureOffset {
+			break unpack_switch
+		}
+		signature := make(account.Signature, signatureLength)
+		n += signatureOffset
+		copy(signature, record[n:n+signatureLength])
+		n += signatureLength
+
+		r := &ShareRedemption{
+			Link:      link,
+			Quantity:  quantity,
+			Owner:     owner,
+			Signature: signature,
+		}
+		err = r.check(testnet)
+		if nil != err {
+			return nil, 0, err
+		}
+		return r, n, nil
+
+	case AssetAmendmentTag:
+
+		// asset id
+		assetIdentifierLength, assetIdentifierOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == assetIdentifierOffset {
+			break unpack_switch
+		}
+		n += assetIdentifierOffset
+		var assetId AssetIdentifier
+		err := AssetIdentifierFromBytes(&assetId, record[n:n+assetIdentifierLength])
+		if nil != err {
+			return nil, 0, err
+		}
+		n += assetIdentifierLength
+
+		// metadata version
+		version, versionLength := util.FromVarint64(record[n:])
+		if 0 == versionLength {
+			break unpack_switch
+		}
+		n += versionLength
+
+		// metadata (can be zero length)
+		metadataLength, metadataOffset := util.ClippedVarint64(record[n:], 0, 8192) // Note: zero is valid here
+		if 0 == metadataOffset {
+			break unpack_switch
+		}
+		metadata := make([]byte, metadataLength)
+		n += metadataOffset
+		copy(metadata, record[n:n+metadataLength])
+		n += metadataLength
+
+		// registrant public key
+		registrantLength, registrantOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == registrantOffset {
+			break unpack_switch
+		}
+		n += registrantOffset
+		registrant, err := account.AccountFromBytes(record[n : n+registrantLength])
+		if nil != err {
+			return nil, 0, err
+		}
+		if registrant.IsTesting() != testnet {
+			return nil, 0, fault.WrongNetworkForPublicKey
+		}
+		n += registrantLength
+
+		// signature
+		signatureLength, signatureOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == signatureOffset {
+			break unpack_switch
+		}
+		signature := make(account.Signature, signatureLength)
+		n += signatureOffset
+		copy(signature, record[n:n+signatureLength])
+		n += signatureLength
+
+		r := &AssetAmendment{
+			AssetId:    assetId,
+			Version:    version,
+			Metadata:   string(metadata),
+			Registrant: registrant,
+			Signature:  signature,
+		}
+		err = r.check(testnet)
+		if nil != err {
+			return nil, 0, err
+		}
+		return r, n, nil
+
+	case AtomicSwapTag:
+
+		// bitmarks to transfer
+		bitmarkCount, bitmarkCountLength := util.FromVarint64(record[n:])
+		if 0 == bitmarkCountLength {
+			break unpack_switch
+		}
+		n += bitmarkCountLength
+		if bitmarkCount > maxSwapLegs {
+			return nil, 0, fault.SwapLegCountOutOfRange
+		}
+		bitmarks := make([]SwapBitmarkLeg, bitmarkCount)
+		for i := range bitmarks {
+			leg, legLength, err := unpackSwapBitmarkLeg(record[n:], testnet)
+			if nil != err {
+				return nil, 0, err
+			}
+			bitmarks[i] = *leg
+			n += legLength
+		}
+
+		// shares to grant
+		shareCount, shareCountLength := util.FromVarint64(record[n:])
+		if 0 == shareCountLength {
+			break unpack_switch
+		}
+		n += shareCountLength
+		if shareCount > maxSwapLegs {
+			return nil, 0, fault.SwapLegCountOutOfRange
+		}
+		shares := make([]SwapShareLeg, shareCount)
+		for i := range shares {
+			leg, legLength, err := unpackSwapShareLeg(record[n:], testnet)
+			if nil != err {
+				return nil, 0, err
+			}
+			shares[i] = *leg
+			n += legLength
+		}
+
+		// owner one public key
+		ownerOneLength, ownerOneOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == ownerOneOffset {
+			break unpack_switch
+		}
+		n += ownerOneOffset
+		ownerOne, err := account.AccountFromBytes(record[n : n+ownerOneLength])
+		if nil != err {
+			return nil, 0, err
+		}
+		if ownerOne.IsTesting() != testnet {
+			return nil, 0, fault.WrongNetworkForPublicKey
+		}
+		n += ownerOneLength
+
+		// owner two public key
+		ownerTwoLength, ownerTwoOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == ownerTwoOffset {
+			break unpack_switch
+		}
+		n += ownerTwoOffset
+		ownerTwo, err := account.AccountFromBytes(record[n : n+ownerTwoLength])
+		if nil != err {
+			return nil, 0, err
+		}
+		if ownerTwo.IsTesting() != testnet {
+			return nil, 0, fault.WrongNetworkForPublicKey
+		}
+		n += ownerTwoLength
+
+		// time limit
+		beforeBlock, beforeBlockLength := util.FromVarint64(record[n:])
+		if 0 == beforeBlockLength {
+			break unpack_switch
+		}
+		n += beforeBlockLength
+
+		// signature
+		signatureLength, signatureOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == signatureOffset {
+			break unpack_switch
+		}
+		signature := make(account.Signature, signatureLength)
+		n += signatureOffset
+		copy(signature, record[n:n+signatureLength])
+		n += signatureLength
+
+		// countersignature
+		countersignatureLength, countersignatureOffset := util.ClippedVarint64(record[n:], 1, 8192)
+		if 0 == countersignatureOffset {
+			break unpack_switch
+		}
+		countersignature := make(account.Signature, countersignatureLength)
+		n += countersignatureOffset
+		copy(countersignature, record[n:n+countersignatureLength])
+		n += countersignatureLength
+
+		r := &AtomicSwap{
+			Bitmarks:         bitmarks,
+			Shares:           shares,
+			OwnerOne:         ownerOne,
+			OwnerTwo:         ownerTwo,
+			BeforeBlock:      beforeBlock,
+			Signature:        signature,
+			Countersignature: countersignature,
+		}
+		err = r.check(testnet)
+		if nil != err {
+			return nil, 0, err
+		}
+		return r, n, nil
+
 	default: // also NullTag
 	}
 	return nil, 0, fault.NotTransactionPack
 }
 
+// unpack one bitmark leg of a swap, returns the number of bytes used
+func unpackSwapBitmarkLeg(record []byte, testnet bool) (*SwapBitmarkLeg, int, error) {
+	n := 0
+
+	// link
+	linkLength, linkOffset := util.ClippedVarint64(record[n:], 1, 8192)
+	if 0 == linkOffset {
+		return nil, 0, fault.NotTransactionPack
+	}
+	n += linkOffset
+	var link merkle.Digest
+	err := merkle.DigestFromBytes(&link, record[n:n+linkLength])
+	if nil != err {
+		return nil, 0, err
+	}
+	n += linkLength
+
+	owner, recipient, accountsLength, err := unpackSwapLegAccounts(record[n:], testnet)
+	if nil != err {
+		return nil, 0, err
+	}
+	n += accountsLength
+
+	leg := &SwapBitmarkLeg{
+		Link:      link,
+		Owner:     owner,
+		Recipient: recipient,
+	}
+	return leg, n, nil
+}
+
+// unpack one share leg of a swap, returns the number of bytes used
+func unpackSwapShareLeg(record []byte, testnet bool) (*SwapShareLeg, int, error) {
+	n := 0
+
+	// share id
+	shareIdLength, shareIdOffset := util.ClippedVarint64(record[n:], 1, 8192)
+	if 0 == shareIdOffset {
+		return nil, 0, fault.NotTransactionPack
+	}
+	n += shareIdOffset
+	var shareId merkle.Digest
+	err := merkle.DigestFromBytes(&shareId, record[n:n+shareIdLength])
+	if nil != err {
+		return nil, 0, err
+	}
+	n += shareIdLength
+
+	// number of shares to transfer
+	quantity, quantityLength := util.FromVarint64(record[n:])
+	if 0 == quantityLength {
+		return nil, 0, fault.NotTransactionPack
+	}
+	n += quantityLength
+
+	owner, recipient, accountsLength, err := unpackSwapLegAccounts(record[n:], testnet)
+	if nil != err {
+		return nil, 0, err
+	}
+	n += accountsLength
+
+	leg := &SwapShareLeg{
+		ShareId:   shareId,
+		Quantity:  quantity,
+		Owner:     owner,
+		Recipient: recipient,
+	}
+	return leg, n, nil
+}
+
+// unpack the owner and recipient of a swap leg
+func unpackSwapLegAccounts(record []byte, testnet bool) (*account.Account, *account.Account, int, error) {
+	n := 0
+
+	// owner public key
+	ownerLength, ownerOffset := util.ClippedVarint64(record[n:], 1, 8192)
+	if 0 == ownerOffset {
+		return nil, nil, 0, fault.NotTransactionPack
+	}
+	n += ownerOffset
+	owner, err := account.AccountFromBytes(record[n : n+ownerLength])
+	if nil != err {
+		return nil, nil, 0, err
+	}
+	if owner.IsTesting() != testnet {
+		return nil, nil, 0, fault.WrongNetworkForPublicKey
+	}
+	n += ownerLength
+
+	// recipient public key
+	recipientLength, recipientOffset := util.ClippedVarint64(record[n:], 1, 8192)
+	if 0 == recipientOffset {
+		return nil, nil, 0, fault.NotTransactionPack
+	}
+	n += recipientOffset
+	recipient, err := account.AccountFromBytes(record[n : n+recipientLength])
+	if nil != err {
+		return nil, nil, 0, err
+	}
+	if recipient.IsTesting() != testnet {
+		return nil, nil, 0, fault.WrongNetworkForPublicKey
+	}
+	n += recipientLength
+
+	return owner, recipient, n, nil
+}
+
 func unpackEscrow(record []byte, n int) (*Payment, int, error) {
 
 	// optional escrow payment